@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/CaptainPineapple/go-mcprotocol/mcp"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	tagValueDesc = prometheus.NewDesc("mcp_tag_value",
+		"Value of tag. bool is 1 or 0 and scale of tag is applied.", []string{"tag", "unit"}, nil)
+	deviceValueDesc = prometheus.NewDesc("mcp_device_value",
+		"Value of device. word device is unsigned 16bit and bit device is 1 or 0.", []string{"device"}, nil)
+	scrapeSuccessDesc = prometheus.NewDesc("mcp_scrape_success",
+		"1 if all targets are read from PLC, otherwise 0.", nil, nil)
+	scrapeDurationDesc = prometheus.NewDesc("mcp_scrape_duration_seconds",
+		"Duration of reading targets from PLC.", nil, nil)
+)
+
+// DeviceRange is device range exported by mcp_device_value.
+type DeviceRange struct {
+	DeviceName string
+	Offset     int64
+	NumPoints  int64
+	Bits       bool
+}
+
+// ParseDeviceRange parses device range like D100:4. its number of points is 1 when it is omitted.
+func ParseDeviceRange(s string, bits bool) (DeviceRange, error) {
+	address, points, ok := strings.Cut(s, ":")
+	deviceName, offset, err := mcp.ParseDevice(address)
+	if err != nil {
+		return DeviceRange{}, err
+	}
+	r := DeviceRange{DeviceName: deviceName, Offset: offset, NumPoints: 1, Bits: bits}
+	if ok {
+		if r.NumPoints, err = strconv.ParseInt(points, 10, 64); err != nil || r.NumPoints <= 0 {
+			return DeviceRange{}, errors.New("invalid number of points: " + s)
+		}
+	}
+	return r, nil
+}
+
+// Exporter reads tags and device ranges from PLC and exports them as gauges.
+// without interval, targets are read on each scrape. with interval, they are read in background and the last values are exported.
+type Exporter struct {
+	client  mcp.Client
+	tags    *mcp.TagDB
+	devices []DeviceRange
+	order   mcp.WordOrder
+	timeout time.Duration
+
+	mu     sync.Mutex
+	cached []prometheus.Metric
+}
+
+// NewExporter returns Exporter that reads tags of db and devices by client. db may be nil.
+func NewExporter(client mcp.Client, db *mcp.TagDB, devices []DeviceRange, order mcp.WordOrder, timeout time.Duration) *Exporter {
+	return &Exporter{client: client, tags: db, devices: devices, order: order, timeout: timeout}
+}
+
+// Describe implements prometheus.Collector.
+func (e *Exporter) Describe(ch chan<- *prometheus.Desc) {
+	ch <- tagValueDesc
+	ch <- deviceValueDesc
+	ch <- scrapeSuccessDesc
+	ch <- scrapeDurationDesc
+}
+
+// Collect implements prometheus.Collector.
+func (e *Exporter) Collect(ch chan<- prometheus.Metric) {
+	e.mu.Lock()
+	metrics := e.cached
+	e.mu.Unlock()
+	if metrics == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
+		defer cancel()
+		metrics = e.scrape(ctx)
+	}
+	for _, m := range metrics {
+		ch <- m
+	}
+}
+
+// Run reads targets at interval and caches them for Collect until ctx is done.
+func (e *Exporter) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		scrapeCtx, cancel := context.WithTimeout(ctx, e.timeout)
+		metrics := e.scrape(scrapeCtx)
+		cancel()
+		e.mu.Lock()
+		e.cached = metrics
+		e.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// scrape reads all targets by one batch and returns their metrics.
+func (e *Exporter) scrape(ctx context.Context) []prometheus.Metric {
+	start := time.Now()
+	var tags []mcp.Tag
+	var requests []mcp.Request
+	if e.tags != nil {
+		for _, tag := range e.tags.Tags() {
+			// string tags are not exported because gauge is number
+			if r, err := e.tags.Request(tag.Name); err == nil && !strings.HasPrefix(tag.Type, "string") {
+				tags = append(tags, tag)
+				requests = append(requests, r)
+			}
+		}
+	}
+	for _, d := range e.devices {
+		r := mcp.Request{Op: mcp.OpRead, DeviceName: d.DeviceName, Offset: d.Offset, NumPoints: d.NumPoints}
+		if d.Bits {
+			r.Op = mcp.OpBitRead
+		}
+		requests = append(requests, r)
+	}
+
+	results, err := e.client.BatchContext(ctx, requests)
+	success := 1.0
+	if err != nil {
+		success = 0
+	}
+
+	var metrics []prometheus.Metric
+	for i, result := range results {
+		if result.Err != nil {
+			continue
+		}
+		if i < len(tags) {
+			value, err := e.tags.Decode(tags[i].Name, result.Payload, e.order)
+			if err != nil {
+				success = 0
+				continue
+			}
+			metrics = append(metrics, prometheus.MustNewConstMetric(tagValueDesc, prometheus.GaugeValue, gaugeValue(value), tags[i].Name, tags[i].Unit))
+			continue
+		}
+		d := e.devices[i-len(tags)]
+		for j, v := range deviceValues(d, result.Payload) {
+			metrics = append(metrics, prometheus.MustNewConstMetric(deviceValueDesc, prometheus.GaugeValue, v, mcp.FormatDevice(d.DeviceName, d.Offset+int64(j))))
+		}
+	}
+	return append(metrics,
+		prometheus.MustNewConstMetric(scrapeSuccessDesc, prometheus.GaugeValue, success),
+		prometheus.MustNewConstMetric(scrapeDurationDesc, prometheus.GaugeValue, time.Since(start).Seconds()))
+}
+
+// gaugeValue converts value decoded by TagDB to gauge value.
+func gaugeValue(value any) float64 {
+	switch v := value.(type) {
+	case bool:
+		if v {
+			return 1
+		}
+		return 0
+	case float64:
+		return v
+	}
+	return 0
+}
+
+// deviceValues decodes payload of device range to values of each point.
+func deviceValues(d DeviceRange, payload []byte) []float64 {
+	values := make([]float64, 0, d.NumPoints)
+	if d.Bits {
+		for _, b := range mcp.DecodeBits(payload, d.NumPoints) {
+			values = append(values, gaugeValue(b))
+		}
+		return values
+	}
+	for i := 0; i+1 < len(payload); i += 2 {
+		values = append(values, float64(binary.LittleEndian.Uint16(payload[i:])))
+	}
+	return values
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CaptainPineapple/go-mcprotocol/mcp"
+	"github.com/CaptainPineapple/go-mcprotocol/mcp/mcptest"
+	"github.com/google/go-cmp/cmp"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// newTestClient returns client connected to mcptest.Server that has 1000, 1001 in D100-D101, ON, OFF in X1E-X1F
+// and ON in M10.
+func newTestClient(t *testing.T) mcp.Client {
+	t.Helper()
+	plc, err := mcptest.NewServer()
+	if err != nil {
+		t.Fatalf("failed to start test server: %v", err)
+	}
+	t.Cleanup(func() { plc.Close() })
+	if err := plc.SetWords("D100", 1000, 1001); err != nil {
+		t.Fatalf("unexpected set err: %v", err)
+	}
+	if err := plc.SetBits("X1E", true, false); err != nil {
+		t.Fatalf("unexpected set err: %v", err)
+	}
+	if err := plc.SetBits("M10", true); err != nil {
+		t.Fatalf("unexpected set err: %v", err)
+	}
+	client, err := mcp.New3EClient(plc.Host(), plc.Port(), mcp.NewLocalStation(), true)
+	if err != nil {
+		t.Fatalf("unexpected connect err: %v", err)
+	}
+	t.Cleanup(client.ShutDown)
+	return client
+}
+
+func TestExporter(t *testing.T) {
+	db, err := mcp.LoadTags(strings.NewReader(`[
+		{"name": "FurnaceTemp", "device": "D100", "type": "int16", "scale": 0.5, "unit": "degC"},
+		{"name": "Running", "device": "M10", "type": "bool"},
+		{"name": "Recipe", "device": "D200", "type": "string:4"}
+	]`))
+	if err != nil {
+		t.Fatalf("unexpected load err: %v", err)
+	}
+	words, _ := ParseDeviceRange("D100:2", false)
+	bits, _ := ParseDeviceRange("X1E:2", true)
+	exporter := NewExporter(newTestClient(t), db, []DeviceRange{words, bits}, mcp.LowWordFirst, time.Second)
+
+	expected := `
+# HELP mcp_tag_value Value of tag. bool is 1 or 0 and scale of tag is applied.
+# TYPE mcp_tag_value gauge
+mcp_tag_value{tag="FurnaceTemp",unit="degC"} 500
+mcp_tag_value{tag="Running",unit=""} 1
+# HELP mcp_device_value Value of device. word device is unsigned 16bit and bit device is 1 or 0.
+# TYPE mcp_device_value gauge
+mcp_device_value{device="D100"} 1000
+mcp_device_value{device="D101"} 1001
+mcp_device_value{device="X1E"} 1
+mcp_device_value{device="X1F"} 0
+# HELP mcp_scrape_success 1 if all targets are read from PLC, otherwise 0.
+# TYPE mcp_scrape_success gauge
+mcp_scrape_success 1
+`
+	if err := testutil.CollectAndCompare(exporter, strings.NewReader(expected), "mcp_tag_value", "mcp_device_value", "mcp_scrape_success"); err != nil {
+		t.Fatalf("unexpected metrics: %v", err)
+	}
+
+	// failed range is not exported and scrape is not success. extended device is not supported by the PLC.
+	failed, err := ParseDeviceRange(`J1\W0:2`, false)
+	if err != nil {
+		t.Fatalf("unexpected parse err: %v", err)
+	}
+	exporter = NewExporter(newTestClient(t), nil, []DeviceRange{words, failed}, mcp.LowWordFirst, time.Second)
+	expected = `
+# HELP mcp_device_value Value of device. word device is unsigned 16bit and bit device is 1 or 0.
+# TYPE mcp_device_value gauge
+mcp_device_value{device="D100"} 1000
+mcp_device_value{device="D101"} 1001
+# HELP mcp_scrape_success 1 if all targets are read from PLC, otherwise 0.
+# TYPE mcp_scrape_success gauge
+mcp_scrape_success 0
+`
+	if err := testutil.CollectAndCompare(exporter, strings.NewReader(expected), "mcp_device_value", "mcp_scrape_success"); err != nil {
+		t.Fatalf("unexpected metrics: %v", err)
+	}
+}
+
+func TestParseDeviceRange(t *testing.T) {
+	r, err := ParseDeviceRange("W1A:3", false)
+	if err != nil {
+		t.Fatalf("unexpected parse err: %v", err)
+	}
+	if diff := cmp.Diff(r, DeviceRange{DeviceName: "W", Offset: 0x1A, NumPoints: 3}); diff != "" {
+		t.Errorf("device range differs: (-got +want)\n%s", diff)
+	}
+
+	for _, input := range []string{"D1A:1", "D100:0", "D100:x"} {
+		if _, err := ParseDeviceRange(input, false); err == nil {
+			t.Errorf("expected error: input is %v", input)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+// mcp_exporter exports tags and devices of PLC as Prometheus gauges, like modbus_exporter.
+//
+//	mcp_exporter -host 192.168.0.10 -port 5000 -tags tags.yaml -words D100:4 -bits M0:8
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/CaptainPineapple/go-mcprotocol/mcp"
+	"github.com/CaptainPineapple/go-mcprotocol/mcp/mcpprom"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// listFlag is flag that can be given multiple times or separated by comma.
+type listFlag []string
+
+func (f *listFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *listFlag) Set(s string) error {
+	*f = append(*f, strings.Split(s, ",")...)
+	return nil
+}
+
+func main() {
+	var words, bits listFlag
+	host := flag.String("host", "", "PLC hostname")
+	port := flag.Int("port", 5000, "MC protocol port number of PLC")
+	frame4E := flag.Bool("4e", false, "use 4E frame")
+	listen := flag.String("listen", ":9769", "address to serve /metrics")
+	tagFile := flag.String("tags", "", "JSON or YAML tag file. all numeric and bool tags are exported")
+	flag.Var(&words, "words", "word device range like D100:4. can be given multiple times")
+	flag.Var(&bits, "bits", "bit device range like M0:8. can be given multiple times")
+	interval := flag.Duration("interval", 0, "read PLC at this interval in background. 0 reads PLC on each scrape")
+	timeout := flag.Duration("timeout", 5*time.Second, "timeout of reading PLC")
+	highWordFirst := flag.Bool("high-word-first", false, "multi-word values store upper word first")
+	flag.Parse()
+
+	if err := run(*host, *port, *frame4E, *listen, *tagFile, words, bits, *interval, *timeout, *highWordFirst); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(host string, port int, frame4E bool, listen, tagFile string, words, bits []string, interval, timeout time.Duration, highWordFirst bool) error {
+	if host == "" {
+		return fmt.Errorf("-host is required")
+	}
+
+	var db *mcp.TagDB
+	if tagFile != "" {
+		var err error
+		if db, err = mcp.LoadTagFile(tagFile); err != nil {
+			return err
+		}
+	}
+	var ranges []DeviceRange
+	for _, list := range []struct {
+		values []string
+		bits   bool
+	}{{words, false}, {bits, true}} {
+		for _, s := range list.values {
+			r, err := ParseDeviceRange(s, list.bits)
+			if err != nil {
+				return err
+			}
+			ranges = append(ranges, r)
+		}
+	}
+	if db == nil && len(ranges) == 0 {
+		return fmt.Errorf("-tags, -words or -bits is required")
+	}
+
+	order := mcp.LowWordFirst
+	if highWordFirst {
+		order = mcp.HighWordFirst
+	}
+	collector := mcpprom.NewCollector()
+	opts := []mcp.Option{mcp.WithWordOrder(order), mcp.WithAutoReconnect(),
+		mcp.WithMiddleware(collector.Middleware()), mcp.WithConnStateHandler(collector.ConnStateHandler())}
+	if frame4E {
+		opts = append(opts, mcp.WithFrame4E())
+	}
+	client, err := mcp.New3EClient(host, port, mcp.NewLocalStation(), true, opts...)
+	if err != nil {
+		return err
+	}
+	defer client.ShutDown()
+
+	exporter := NewExporter(client, db, ranges, order, timeout)
+	if interval > 0 {
+		go exporter.Run(context.Background(), interval)
+	}
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(exporter, collector)
+
+	http.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	log.Printf("listening on %v", listen)
+	return http.ListenAndServe(listen, nil)
+}
@@ -0,0 +1,216 @@
+// mcpcli reads and writes devices of PLC from terminal.
+//
+//	mcpcli -host 192.168.0.10 -port 5000 read D100 4
+//	mcpcli -host 192.168.0.10 -port 5000 write D100 1 2 3
+//	mcpcli -host 192.168.0.10 -port 5000 bitread M0 8
+//	mcpcli -host 192.168.0.10 -port 5000 bitwrite M0 1 0 1
+//	mcpcli -host 192.168.0.10 -port 5000 health
+//	mcpcli -host 192.168.0.10 -port 5000 monitor D100:4 M0:8
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/CaptainPineapple/go-mcprotocol/mcp"
+)
+
+const usage = `Usage: mcpcli [OPTIONS] COMMAND [ARGS]
+
+Commands:
+  read DEVICE [POINTS]        read word devices like D100
+  write DEVICE VALUE...       write word values. 0x prefix is hexadecimal
+  bitread DEVICE [POINTS]     read bit devices like M0
+  bitwrite DEVICE VALUE...    write bit values of 1 or 0
+  health                      loopback test of PLC
+  monitor [-interval D] [-n N] RANGE...
+                              live table of ranges like D100:4 or M0:8. changed values are highlighted
+
+Options:
+`
+
+// command is subcommand of mcpcli.
+type command struct {
+	// minimum number of args
+	minArgs int
+	// interactive command runs until interrupted instead of timeout
+	interactive bool
+	run         func(ctx context.Context, client mcp.Client, args []string, w io.Writer) error
+}
+
+var commands = map[string]command{
+	"read":     {minArgs: 1, run: readCommand},
+	"write":    {minArgs: 2, run: writeCommand},
+	"bitread":  {minArgs: 1, run: bitReadCommand},
+	"bitwrite": {minArgs: 2, run: bitWriteCommand},
+	"health":   {minArgs: 0, run: healthCommand},
+	"monitor":  {minArgs: 1, run: monitorCommand, interactive: true},
+}
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		fmt.Fprintln(os.Stderr, "mcpcli:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("mcpcli", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		flags.PrintDefaults()
+	}
+	host := flags.String("host", "", "PLC hostname")
+	port := flags.Int("port", 5000, "MC protocol port number of PLC")
+	frame := flags.String("frame", "3e", "frame version, 3e or 4e")
+	timeout := flags.Duration("timeout", 5*time.Second, "timeout of a command")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *host == "" {
+		flags.Usage()
+		return errors.New("-host is required")
+	}
+	cmd, ok := commands[flags.Arg(0)]
+	if !ok || flags.NArg()-1 < cmd.minArgs {
+		flags.Usage()
+		return errors.New("unknown command or missing arguments: " + strings.Join(flags.Args(), " "))
+	}
+
+	var opts []mcp.Option
+	switch strings.ToLower(*frame) {
+	case "3e":
+	case "4e":
+		opts = append(opts, mcp.WithFrame4E())
+	default:
+		return errors.New("unsupported frame: " + *frame)
+	}
+	client, err := mcp.New3EClient(*host, *port, mcp.NewLocalStation(), true, opts...)
+	if err != nil {
+		return err
+	}
+	defer client.ShutDown()
+
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if cmd.interactive {
+		ctx, cancel = signal.NotifyContext(context.Background(), os.Interrupt)
+	} else {
+		ctx, cancel = context.WithTimeout(context.Background(), *timeout)
+	}
+	defer cancel()
+	return cmd.run(ctx, client, flags.Args()[1:], stdout)
+}
+
+// parseRange parses device address and optional number of points.
+func parseRange(args []string) (string, int64, int64, error) {
+	deviceName, offset, err := mcp.ParseDevice(args[0])
+	if err != nil {
+		return "", 0, 0, err
+	}
+	points := int64(1)
+	if len(args) > 1 {
+		if points, err = strconv.ParseInt(args[1], 10, 64); err != nil || points <= 0 {
+			return "", 0, 0, errors.New("invalid number of points: " + args[1])
+		}
+	}
+	return deviceName, offset, points, nil
+}
+
+func readCommand(ctx context.Context, client mcp.Client, args []string, w io.Writer) error {
+	deviceName, offset, points, err := parseRange(args)
+	if err != nil {
+		return err
+	}
+	payload, err := client.ReadContext(ctx, deviceName, offset, points)
+	if err != nil {
+		return err
+	}
+	for i := 0; i+1 < len(payload); i += 2 {
+		v := binary.LittleEndian.Uint16(payload[i:])
+		fmt.Fprintf(w, "%s\t%d\t0x%04X\n", mcp.FormatDevice(deviceName, offset+int64(i/2)), int16(v), v)
+	}
+	return nil
+}
+
+func writeCommand(ctx context.Context, client mcp.Client, args []string, w io.Writer) error {
+	deviceName, offset, err := mcp.ParseDevice(args[0])
+	if err != nil {
+		return err
+	}
+	data := make([]byte, 0, len(args[1:])*2)
+	for _, s := range args[1:] {
+		// both signed like -1 and unsigned like 0xFFFF are allowed
+		v, err := strconv.ParseInt(s, 0, 32)
+		if err != nil || v < -0x8000 || v > 0xFFFF {
+			return errors.New("invalid word value: " + s)
+		}
+		data = binary.LittleEndian.AppendUint16(data, uint16(v))
+	}
+	if _, err := client.WriteContext(ctx, deviceName, offset, int64(len(args[1:])), data); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "wrote %d points to %s\n", len(args[1:]), mcp.FormatDevice(deviceName, offset))
+	return nil
+}
+
+func bitReadCommand(ctx context.Context, client mcp.Client, args []string, w io.Writer) error {
+	deviceName, offset, points, err := parseRange(args)
+	if err != nil {
+		return err
+	}
+	payload, err := client.BitReadContext(ctx, deviceName, offset, points)
+	if err != nil {
+		return err
+	}
+	for i, v := range mcp.DecodeBits(payload, points) {
+		value := 0
+		if v {
+			value = 1
+		}
+		fmt.Fprintf(w, "%s\t%d\n", mcp.FormatDevice(deviceName, offset+int64(i)), value)
+	}
+	return nil
+}
+
+func bitWriteCommand(ctx context.Context, client mcp.Client, args []string, w io.Writer) error {
+	deviceName, offset, err := mcp.ParseDevice(args[0])
+	if err != nil {
+		return err
+	}
+	values := make([]bool, len(args[1:]))
+	for i, s := range args[1:] {
+		switch strings.ToLower(s) {
+		case "1", "on", "true":
+			values[i] = true
+		case "0", "off", "false":
+		default:
+			return errors.New("invalid bit value: " + s)
+		}
+	}
+	if _, err := client.BitWriteContext(ctx, deviceName, offset, int64(len(values)), mcp.EncodeBits(values)); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "wrote %d points to %s\n", len(values), mcp.FormatDevice(deviceName, offset))
+	return nil
+}
+
+func healthCommand(ctx context.Context, client mcp.Client, args []string, w io.Writer) error {
+	start := time.Now()
+	if err := client.HealthCheckContext(ctx); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "ok (%v)\n", time.Since(start).Round(time.Millisecond))
+	return nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/CaptainPineapple/go-mcprotocol/mcp/mcptest"
+	"github.com/google/go-cmp/cmp"
+)
+
+// newTestPLC starts mcptest.Server and returns flags to connect to it.
+func newTestPLC(t *testing.T) []string {
+	t.Helper()
+	plc, err := mcptest.NewServer()
+	if err != nil {
+		t.Fatalf("failed to start test server: %v", err)
+	}
+	t.Cleanup(func() { plc.Close() })
+	return []string{"-host", plc.Host(), "-port", strconv.Itoa(plc.Port())}
+}
+
+func TestRun(t *testing.T) {
+	plc := newTestPLC(t)
+
+	cases := []struct {
+		args     []string
+		expected string
+	}{
+		{args: []string{"write", "D100", "1", "-1", "0x1234"}, expected: "wrote 3 points to D100\n"},
+		{args: []string{"read", "D100", "3"}, expected: "D100\t1\t0x0001\nD101\t-1\t0xFFFF\nD102\t4660\t0x1234\n"},
+		{args: []string{"bitwrite", "X1E", "1", "off", "on"}, expected: "wrote 3 points to X1E\n"},
+		{args: []string{"bitread", "X1E", "3"}, expected: "X1E\t1\nX1F\t0\nX20\t1\n"},
+	}
+	for _, v := range cases {
+		var stdout bytes.Buffer
+		if err := run(append(plc, v.args...), &stdout, io.Discard); err != nil {
+			t.Fatalf("%v: unexpected run err: %v", v.args, err)
+		}
+		if diff := cmp.Diff(stdout.String(), v.expected); diff != "" {
+			t.Errorf("%v: output differs: (-got +want)\n%s", v.args, diff)
+		}
+	}
+
+	var stdout bytes.Buffer
+	if err := run(append(plc, "health"), &stdout, io.Discard); err != nil {
+		t.Fatalf("unexpected health err: %v", err)
+	}
+	if !strings.HasPrefix(stdout.String(), "ok") {
+		t.Errorf("expected ok but actual is %v", stdout.String())
+	}
+}
+
+func TestRun_InvalidArgs(t *testing.T) {
+	plc := newTestPLC(t)
+
+	cases := [][]string{
+		{"read", "D100"},
+		append(plc, "unknown"),
+		append(plc, "read"),
+		append(plc, "read", "D1A"),
+		append(plc, "read", "D100", "0"),
+		append(plc, "write", "D100", "0x10000"),
+		append(plc, "bitwrite", "M0", "2"),
+		append(append([]string{"-frame", "1e"}, plc...), "health"),
+	}
+	for _, args := range cases {
+		if err := run(args, io.Discard, io.Discard); err == nil {
+			t.Errorf("expected error: args is %v", args)
+		}
+	}
+}
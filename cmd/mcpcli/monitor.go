@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/CaptainPineapple/go-mcprotocol/mcp"
+)
+
+// bitDevices is device names that monitor reads in bit units. the others are read in word units.
+var bitDevices = map[string]bool{
+	"X": true, "Y": true, "M": true, "L": true, "F": true, "V": true, "B": true,
+	"SM": true, "SB": true, "DX": true, "DY": true,
+	"TS": true, "TC": true, "STS": true, "STC": true, "CS": true, "CC": true,
+}
+
+// ANSI escape sequences of terminal
+const (
+	clearScreen = "\x1b[H\x1b[2J"
+	highlight   = "\x1b[7m"
+	reset       = "\x1b[0m"
+)
+
+// monitorRow is state of one device point on the monitor.
+type monitorRow struct {
+	address string
+	value   string
+	// scan number when value changed last
+	changed int
+}
+
+// monitor is table of device ranges that is redrawn on each scan.
+type monitor struct {
+	ranges []mcp.Request
+	rows   [][]monitorRow
+	errs   []error
+	scan   int
+}
+
+func newMonitor(ranges []mcp.Request) *monitor {
+	m := &monitor{ranges: ranges, rows: make([][]monitorRow, len(ranges)), errs: make([]error, len(ranges))}
+	for i, r := range ranges {
+		m.rows[i] = make([]monitorRow, r.NumPoints)
+		for j := range m.rows[i] {
+			m.rows[i][j] = monitorRow{address: mcp.FormatDevice(r.DeviceName, r.Offset+int64(j)), value: "-", changed: -1}
+		}
+	}
+	return m
+}
+
+// update applies read result of range to the table.
+func (m *monitor) update(u mcp.Update) {
+	m.errs[u.Index] = u.Err
+	if u.Err != nil {
+		return
+	}
+	rows := m.rows[u.Index]
+	var values []string
+	if u.Request.Op == mcp.OpBitRead {
+		for _, v := range mcp.DecodeBits(u.Payload, u.Request.NumPoints) {
+			values = append(values, map[bool]string{true: "ON", false: "OFF"}[v])
+		}
+	} else {
+		for i := 0; i+1 < len(u.Payload); i += 2 {
+			v := binary.LittleEndian.Uint16(u.Payload[i:])
+			values = append(values, fmt.Sprintf("%d\t0x%04X", int16(v), v))
+		}
+	}
+	for i := range rows {
+		if i < len(values) && rows[i].value != values[i] {
+			// the first value is not change
+			if rows[i].value != "-" {
+				rows[i].changed = m.scan
+			}
+			rows[i].value = values[i]
+		}
+	}
+}
+
+// render draws the table. values changed in the current scan are highlighted.
+func (m *monitor) render(w io.Writer, header string) {
+	var b strings.Builder
+	b.WriteString(clearScreen)
+	b.WriteString(header + "\n\n")
+	for i, rows := range m.rows {
+		if m.errs[i] != nil {
+			fmt.Fprintf(&b, "%s\terror: %v\n", rows[0].address, m.errs[i])
+			continue
+		}
+		for _, row := range rows {
+			if row.changed == m.scan {
+				fmt.Fprintf(&b, "%s\t%s%s%s\n", row.address, highlight, row.value, reset)
+			} else {
+				fmt.Fprintf(&b, "%s\t%s\n", row.address, row.value)
+			}
+		}
+	}
+	io.WriteString(w, b.String())
+}
+
+// parseMonitorRange parses device range like D100:4. bit devices like M and X are read in bit units.
+func parseMonitorRange(s string) (mcp.Request, error) {
+	address, points, ok := strings.Cut(s, ":")
+	deviceName, offset, err := mcp.ParseDevice(address)
+	if err != nil {
+		return mcp.Request{}, err
+	}
+	r := mcp.Request{Op: mcp.OpRead, DeviceName: deviceName, Offset: offset, NumPoints: 1}
+	if ok {
+		if r.NumPoints, err = strconv.ParseInt(points, 10, 64); err != nil || r.NumPoints <= 0 {
+			return mcp.Request{}, errors.New("invalid number of points: " + s)
+		}
+	}
+	if bitDevices[deviceName] {
+		r.Op = mcp.OpBitRead
+	}
+	return r, nil
+}
+
+// monitorCommand shows live table of device ranges until interrupted.
+func monitorCommand(ctx context.Context, client mcp.Client, args []string, w io.Writer) error {
+	flags := flag.NewFlagSet("monitor", flag.ContinueOnError)
+	interval := flags.Duration("interval", 500*time.Millisecond, "scan interval")
+	count := flags.Int("n", 0, "number of scans. 0 is until interrupted")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		return errors.New("monitor needs device ranges like D100:4")
+	}
+
+	ranges := make([]mcp.Request, flags.NArg())
+	for i, s := range flags.Args() {
+		r, err := parseMonitorRange(s)
+		if err != nil {
+			return err
+		}
+		ranges[i] = r
+	}
+
+	s, err := mcp.Subscribe(ctx, client, *interval, ranges)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	m := newMonitor(ranges)
+	for u := range s.C {
+		m.update(u)
+		if u.Index < len(ranges)-1 {
+			continue
+		}
+		m.render(w, fmt.Sprintf("mcpcli monitor  every %v  %s  (Ctrl+C to quit)", *interval, u.Time.Format("15:04:05.000")))
+		m.scan++
+		if *count > 0 && m.scan >= *count {
+			return nil
+		}
+	}
+	return nil
+}
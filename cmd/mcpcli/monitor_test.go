@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/CaptainPineapple/go-mcprotocol/mcp"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMonitor(t *testing.T) {
+	d100, _ := parseMonitorRange("D100:2")
+	m0, _ := parseMonitorRange("M0:2")
+	m := newMonitor([]mcp.Request{d100, m0})
+
+	var out bytes.Buffer
+	m.update(mcp.Update{Index: 0, Request: d100, Payload: []byte{0x01, 0x00, 0xFF, 0xFF}})
+	m.update(mcp.Update{Index: 1, Request: m0, Payload: []byte{0x10}})
+	m.render(&out, "header")
+	expected := clearScreen + "header\n\nD100\t1\t0x0001\nD101\t-1\t0xFFFF\nM0\tON\nM1\tOFF\n"
+	if diff := cmp.Diff(out.String(), expected); diff != "" {
+		t.Errorf("first scan differs: (-got +want)\n%s", diff)
+	}
+
+	// changed values are highlighted only in the scan they changed
+	for scan, payload := range [][]byte{{0x01, 0x00, 0x02, 0x00}, {0x01, 0x00, 0x02, 0x00}} {
+		m.scan++
+		out.Reset()
+		m.update(mcp.Update{Index: 0, Request: d100, Payload: payload})
+		m.update(mcp.Update{Index: 1, Request: m0, Err: errors.New("timeout")})
+		m.render(&out, "header")
+		d101 := "D101\t2\t0x0002\n"
+		if scan == 0 {
+			d101 = "D101\t" + highlight + "2\t0x0002" + reset + "\n"
+		}
+		expected := clearScreen + "header\n\nD100\t1\t0x0001\n" + d101 + "M0\terror: timeout\n"
+		if diff := cmp.Diff(out.String(), expected); diff != "" {
+			t.Errorf("scan %v differs: (-got +want)\n%s", scan+1, diff)
+		}
+	}
+}
+
+func TestRun_Monitor(t *testing.T) {
+	plc := newTestPLC(t)
+	if err := run(append(plc, "write", "D100", "7"), io.Discard, io.Discard); err != nil {
+		t.Fatalf("unexpected write err: %v", err)
+	}
+
+	var stdout bytes.Buffer
+	if err := run(append(plc, "monitor", "-interval", "10ms", "-n", "2", "D100:2", "X1F"), &stdout, io.Discard); err != nil {
+		t.Fatalf("unexpected monitor err: %v", err)
+	}
+	if n := strings.Count(stdout.String(), clearScreen); n != 2 {
+		t.Errorf("expected 2 scans but actual is %v", n)
+	}
+	if !strings.Contains(stdout.String(), "D100\t7\t0x0007\nD101\t0\t0x0000\nX1F\tOFF\n") {
+		t.Errorf("unexpected output: %q", stdout.String())
+	}
+}
@@ -0,0 +1,186 @@
+// mcpsim is PLC simulator for HMI development without hardware.
+// it answers 1E, 3E and 4E batch read/write and loopback requests by mcptest.Server,
+// and its device memory is seeded from file and persisted to it.
+//
+//	mcpsim -listen :5000 -api 127.0.0.1:8080 -memory memory.json
+//
+// device memory can be read and written by local API of mcphttp like
+//
+//	curl -d '{"words":[1234]}' 127.0.0.1:8080/devices/D/100
+//	curl 127.0.0.1:8080/memory
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"time"
+
+	"github.com/CaptainPineapple/go-mcprotocol/mcp"
+	"github.com/CaptainPineapple/go-mcprotocol/mcp/mcphttp"
+	"github.com/CaptainPineapple/go-mcprotocol/mcp/mcptest"
+)
+
+func main() {
+	listen := flag.String("listen", ":5000", "address of MC protocol")
+	api := flag.String("api", "127.0.0.1:8080", "address of local API. empty disables API")
+	memory := flag.String("memory", "", "JSON file of device memory. it is loaded on start and saved on change")
+	saveInterval := flag.Duration("save-interval", time.Second, "interval of saving changed device memory")
+	flag.Parse()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	if err := run(ctx, *listen, *api, *memory, *saveInterval, nil); err != nil {
+		fmt.Fprintln(os.Stderr, "mcpsim:", err)
+		os.Exit(1)
+	}
+}
+
+// run serves simulator until ctx is done. ready is called with the simulator when it is started.
+func run(ctx context.Context, listen, api, memory string, saveInterval time.Duration, ready func(*simulator)) error {
+	server, err := mcptest.Listen(listen)
+	if err != nil {
+		return err
+	}
+	defer server.Close()
+
+	sim := &simulator{server: server, path: memory}
+	if err := sim.load(); err != nil {
+		return err
+	}
+	log.Printf("mcpsim is listening on %v", server.Addr())
+
+	if api != "" {
+		lis, err := net.Listen("tcp", api)
+		if err != nil {
+			return err
+		}
+		// local API accesses the simulator by client, so it behaves as the same as real PLC
+		client, err := mcp.New3EClient(server.Host(), server.Port(), mcp.NewLocalStation(), true, mcp.WithAutoReconnect())
+		if err != nil {
+			return err
+		}
+		defer client.ShutDown()
+
+		mux := http.NewServeMux()
+		mux.Handle("/", mcphttp.NewHandler(client))
+		mux.HandleFunc("/memory", sim.serveMemory)
+		httpServer := &http.Server{Handler: mux}
+		go httpServer.Serve(lis)
+		defer httpServer.Close()
+		sim.apiAddr = lis.Addr().String()
+		log.Printf("local API is listening on %v", sim.apiAddr)
+	}
+
+	if ready != nil {
+		ready(sim)
+	}
+
+	ticker := time.NewTicker(saveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return sim.save()
+		case <-ticker.C:
+			if err := sim.save(); err != nil {
+				log.Printf("failed to save memory: %v", err)
+			}
+		}
+	}
+}
+
+// simulator persists device memory of server.
+type simulator struct {
+	server  *mcptest.Server
+	path    string
+	apiAddr string
+	// last saved memory
+	saved []byte
+}
+
+// load restores device memory from file. memory is empty when file does not exist yet.
+func (s *simulator) load() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var m mcptest.Memory
+	if err := json.Unmarshal(data, &m); err != nil {
+		return fmt.Errorf("invalid memory file %v: %w", s.path, err)
+	}
+	if err := s.server.Restore(m); err != nil {
+		return err
+	}
+	s.saved, _ = json.MarshalIndent(s.server.Snapshot(), "", "  ")
+	return nil
+}
+
+// save writes device memory to file when it is changed. file is replaced atomically not to be broken by crash.
+func (s *simulator) save() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(s.server.Snapshot(), "", "  ")
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(data, s.saved) {
+		return nil
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return err
+	}
+	s.saved = data
+	return nil
+}
+
+// serveMemory returns whole device memory by GET and replaces it by PUT.
+func (s *simulator) serveMemory(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(s.server.Snapshot())
+	case http.MethodPut:
+		var m mcptest.Memory
+		if err := json.NewDecoder(r.Body).Decode(&m); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := s.server.Restore(m); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CaptainPineapple/go-mcprotocol/mcp"
+	"github.com/CaptainPineapple/go-mcprotocol/mcp/mcptest"
+	"github.com/google/go-cmp/cmp"
+)
+
+// startTestSimulator runs simulator until the returned stop is called.
+func startTestSimulator(t *testing.T, memory string) (*simulator, func()) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	ready := make(chan *simulator, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- run(ctx, "127.0.0.1:0", "127.0.0.1:0", memory, 10*time.Millisecond, func(s *simulator) { ready <- s })
+	}()
+	select {
+	case sim := <-ready:
+		return sim, func() {
+			cancel()
+			if err := <-done; err != nil {
+				t.Fatalf("unexpected run err: %v", err)
+			}
+		}
+	case err := <-done:
+		cancel()
+		t.Fatalf("unexpected run err: %v", err)
+	}
+	return nil, nil
+}
+
+func TestSimulator(t *testing.T) {
+	memory := filepath.Join(t.TempDir(), "memory.json")
+	if err := os.WriteFile(memory, []byte(`{"words":{"D100":7},"bits":{"X1F":true}}`), 0o644); err != nil {
+		t.Fatalf("failed to write memory: %v", err)
+	}
+
+	sim, stop := startTestSimulator(t, memory)
+	client, err := mcp.New3EClient(sim.server.Host(), sim.server.Port(), mcp.NewLocalStation(), true)
+	if err != nil {
+		t.Fatalf("unexpected connect err: %v", err)
+	}
+	defer client.ShutDown()
+	v, err := client.ReadUint16("D", 100)
+	if err != nil || v != 7 {
+		t.Fatalf("expected 7 but actual is %v: %v", v, err)
+	}
+
+	// write by local API
+	resp, err := http.Post("http://"+sim.apiAddr+"/devices/D/101", "application/json", strings.NewReader(`{"words":[8]}`))
+	if err != nil {
+		t.Fatalf("unexpected http err: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected %v but actual is %v", http.StatusOK, resp.StatusCode)
+	}
+	stop()
+
+	data, err := os.ReadFile(memory)
+	if err != nil {
+		t.Fatalf("failed to read memory: %v", err)
+	}
+	var saved mcptest.Memory
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatalf("unexpected json err: %v", err)
+	}
+	expected := mcptest.Memory{Words: map[string]uint16{"D100": 7, "D101": 8}, Bits: map[string]bool{"X1F": true}}
+	if diff := cmp.Diff(saved, expected); diff != "" {
+		t.Fatalf("saved memory differs: (-got +want)\n%s", diff)
+	}
+
+	// memory persists across restarts
+	sim, stop = startTestSimulator(t, memory)
+	defer stop()
+	resp, err = http.Get("http://" + sim.apiAddr + "/memory")
+	if err != nil {
+		t.Fatalf("unexpected http err: %v", err)
+	}
+	defer resp.Body.Close()
+	var restored mcptest.Memory
+	if err := json.NewDecoder(resp.Body).Decode(&restored); err != nil {
+		t.Fatalf("unexpected json err: %v", err)
+	}
+	if diff := cmp.Diff(restored, expected); diff != "" {
+		t.Errorf("restored memory differs: (-got +want)\n%s", diff)
+	}
+}
@@ -0,0 +1,237 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// AlarmState is state of alarm rule.
+type AlarmState int
+
+const (
+	// AlarmNormal is state that value is within limits.
+	AlarmNormal AlarmState = iota
+	// AlarmHigh is state that value reached High limit.
+	AlarmHigh
+	// AlarmLow is state that value reached Low limit.
+	AlarmLow
+)
+
+func (s AlarmState) String() string {
+	switch s {
+	case AlarmNormal:
+		return "Normal"
+	case AlarmHigh:
+		return "High"
+	case AlarmLow:
+		return "Low"
+	}
+	return "Unknown"
+}
+
+// AlarmRule is limits of value of tag.
+// alarm is raised when value is High or more (Low or less) for OnDelay,
+// and it is cleared when value goes below High-Hysteresis (above Low+Hysteresis).
+// bool tag has value 1 for true and 0 for false.
+type AlarmRule struct {
+	// name of the rule. it must be unique
+	Name string
+	// name of tag in TagDB
+	Tag string
+	// limits of value in engineering unit. nil means no limit
+	High *float64
+	Low  *float64
+	// width of clearing band inside limits, so value around a limit does not chatter the alarm
+	Hysteresis float64
+	// time that value must stay over a limit before alarm is raised
+	OnDelay time.Duration
+}
+
+// AlarmEvent is state transition of alarm rule.
+type AlarmEvent struct {
+	Rule  string
+	Tag   string
+	State AlarmState
+	// value that caused the transition
+	Value float64
+	Time  time.Time
+}
+
+// AlarmEngine evaluates alarm rules on values of tags.
+type AlarmEngine struct {
+	rules []*alarmRuleState
+}
+
+type alarmRuleState struct {
+	AlarmRule
+	state AlarmState
+	// state waiting for on-delay and time since when value satisfies it
+	pending      AlarmState
+	pendingSince time.Time
+}
+
+// NewAlarmEngine returns AlarmEngine of rules. all rules are Normal at first.
+func NewAlarmEngine(rules []AlarmRule) (*AlarmEngine, error) {
+	e := &AlarmEngine{}
+	names := map[string]bool{}
+	for _, r := range rules {
+		switch {
+		case r.Name == "":
+			return nil, errors.New("alarm rule name is empty: " + r.Tag)
+		case names[r.Name]:
+			return nil, errors.New("alarm rule is duplicated: " + r.Name)
+		case r.High == nil && r.Low == nil:
+			return nil, errors.New("alarm rule " + r.Name + " has no limit")
+		case r.High != nil && r.Low != nil && *r.Low >= *r.High:
+			return nil, errors.New("alarm rule " + r.Name + " has low limit not below high limit")
+		case r.Hysteresis < 0 || r.OnDelay < 0:
+			return nil, errors.New("alarm rule " + r.Name + " has negative hysteresis or on-delay")
+		}
+		names[r.Name] = true
+		e.rules = append(e.rules, &alarmRuleState{AlarmRule: r})
+	}
+	return e, nil
+}
+
+// Evaluate evaluates rules of tag by value at t and returns their state transitions.
+// values must be given in time order.
+func (e *AlarmEngine) Evaluate(tag string, value float64, t time.Time) []AlarmEvent {
+	var events []AlarmEvent
+	for _, r := range e.rules {
+		if r.Tag != tag {
+			continue
+		}
+		if state, ok := r.evaluate(value, t); ok {
+			events = append(events, AlarmEvent{Rule: r.Name, Tag: tag, State: state, Value: value, Time: t})
+		}
+	}
+	return events
+}
+
+// State returns current state of rule of name.
+func (e *AlarmEngine) State(name string) (AlarmState, bool) {
+	for _, r := range e.rules {
+		if r.Name == name {
+			return r.state, true
+		}
+	}
+	return AlarmNormal, false
+}
+
+// evaluate updates state by value and returns new state when it changes.
+func (r *alarmRuleState) evaluate(value float64, t time.Time) (AlarmState, bool) {
+	target := AlarmNormal
+	switch {
+	case r.High != nil && value >= *r.High:
+		target = AlarmHigh
+	case r.Low != nil && value <= *r.Low:
+		target = AlarmLow
+	case r.state == AlarmHigh && value >= *r.High-r.Hysteresis:
+		target = AlarmHigh
+	case r.state == AlarmLow && value <= *r.Low+r.Hysteresis:
+		target = AlarmLow
+	}
+
+	if target == r.state {
+		r.pending = r.state
+		return r.state, false
+	}
+	// alarm is raised after on-delay, and cleared immediately
+	if target != AlarmNormal && r.OnDelay > 0 {
+		if r.pending != target {
+			r.pending, r.pendingSince = target, t
+			return r.state, false
+		}
+		if t.Sub(r.pendingSince) < r.OnDelay {
+			return r.state, false
+		}
+	}
+	r.state, r.pending = target, target
+	return target, true
+}
+
+// Alarms polls tags of alarm rules and sends their state transitions to C.
+type Alarms struct {
+	// C receives state transitions. it is closed when polling stops.
+	C <-chan AlarmEvent
+
+	sub *Subscription
+}
+
+// WatchAlarms polls tags of rules in db by client at interval and evaluates rules until ctx is done or Close is called.
+// on-delay is judged at each poll, so its resolution is interval. order is word order of multi-word values.
+// failed reads do not change states of alarms.
+func WatchAlarms(ctx context.Context, client Client, db *TagDB, order WordOrder, interval time.Duration, rules []AlarmRule) (*Alarms, error) {
+	engine, err := NewAlarmEngine(rules)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	var ranges []Request
+	for _, r := range rules {
+		if contains(names, r.Tag) {
+			continue
+		}
+		req, err := db.Request(r.Tag)
+		if err != nil {
+			return nil, errors.New("alarm rule " + r.Name + ": " + err.Error())
+		}
+		names = append(names, r.Tag)
+		ranges = append(ranges, req)
+	}
+
+	sub, err := Subscribe(ctx, client, interval, ranges)
+	if err != nil {
+		return nil, err
+	}
+	ch := make(chan AlarmEvent, len(rules))
+	go func() {
+		defer close(ch)
+		for u := range sub.C {
+			if u.Err != nil {
+				continue
+			}
+			v, err := db.Decode(names[u.Index], u.Payload, order)
+			if err != nil {
+				continue
+			}
+			var value float64
+			switch v := v.(type) {
+			case float64:
+				value = v
+			case bool:
+				if v {
+					value = 1
+				}
+			default:
+				continue
+			}
+			for _, e := range engine.Evaluate(names[u.Index], value, u.Time) {
+				select {
+				case ch <- e:
+				case <-ctx.Done():
+				}
+			}
+		}
+	}()
+	return &Alarms{C: ch, sub: sub}, nil
+}
+
+// Close stops polling and waits until C is closed.
+func (a *Alarms) Close() {
+	a.sub.Close()
+	for range a.C {
+		// drop transitions that are not received
+	}
+}
+
+func contains(values []string, v string) bool {
+	for _, x := range values {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+package mcp
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestAlarmEngine(t *testing.T) {
+	high, low := 100.0, 10.0
+	engine, err := NewAlarmEngine([]AlarmRule{
+		{Name: "TempHigh", Tag: "Temp", High: &high, Hysteresis: 5, OnDelay: 2 * time.Second},
+		{Name: "LevelLow", Tag: "Level", Low: &low},
+	})
+	if err != nil {
+		t.Fatalf("unexpected alarm err: %v", err)
+	}
+
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	at := func(sec int) time.Time { return start.Add(time.Duration(sec) * time.Second) }
+	var events []AlarmEvent
+	for _, v := range []struct {
+		tag   string
+		value float64
+		time  time.Time
+	}{
+		// spike shorter than on-delay
+		{"Temp", 101, at(0)},
+		{"Temp", 99, at(1)},
+		// over the limit for on-delay
+		{"Temp", 100, at(2)},
+		{"Temp", 102, at(3)},
+		{"Temp", 101, at(4)},
+		// within hysteresis
+		{"Temp", 96, at(5)},
+		{"Temp", 94, at(6)},
+		{"Level", 10, at(7)},
+		{"Level", 11, at(8)},
+	} {
+		events = append(events, engine.Evaluate(v.tag, v.value, v.time)...)
+	}
+
+	expected := []AlarmEvent{
+		{Rule: "TempHigh", Tag: "Temp", State: AlarmHigh, Value: 101, Time: at(4)},
+		{Rule: "TempHigh", Tag: "Temp", State: AlarmNormal, Value: 94, Time: at(6)},
+		{Rule: "LevelLow", Tag: "Level", State: AlarmLow, Value: 10, Time: at(7)},
+		{Rule: "LevelLow", Tag: "Level", State: AlarmNormal, Value: 11, Time: at(8)},
+	}
+	if diff := cmp.Diff(events, expected); diff != "" {
+		t.Errorf("events differs: (-got +want)\n%s", diff)
+	}
+	if state, ok := engine.State("TempHigh"); !ok || state != AlarmNormal {
+		t.Errorf("expected %v but actual is %v, %v", AlarmNormal, state, ok)
+	}
+
+	cases := [][]AlarmRule{
+		{{Name: "", Tag: "Temp", High: &high}},
+		{{Name: "A", Tag: "Temp"}},
+		{{Name: "A", Tag: "Temp", High: &low, Low: &high}},
+		{{Name: "A", Tag: "Temp", High: &high, Hysteresis: -1}},
+		{{Name: "A", Tag: "Temp", High: &high}, {Name: "A", Tag: "Level", Low: &low}},
+	}
+	for _, rules := range cases {
+		if _, err := NewAlarmEngine(rules); err == nil {
+			t.Errorf("expected error for rules %+v", rules)
+		}
+	}
+}
+
+func TestWatchAlarms(t *testing.T) {
+	client, _ := newTestMemoryClient(t)
+	db, err := NewTagDB([]Tag{
+		{Name: "Temp", Device: "D100", Scale: 0.1},
+		{Name: "Door", Device: "M10", Type: "bool"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected tag err: %v", err)
+	}
+	high := 50.0
+	open := 1.0
+	rules := []AlarmRule{
+		{Name: "TempHigh", Tag: "Temp", High: &high},
+		{Name: "DoorOpen", Tag: "Door", High: &open},
+	}
+	a, err := WatchAlarms(context.Background(), client, db, LowWordFirst, 10*time.Millisecond, rules)
+	if err != nil {
+		t.Fatalf("unexpected watch err: %v", err)
+	}
+	defer a.Close()
+
+	if err := db.Write(client, "Temp", 60); err != nil {
+		t.Fatalf("unexpected write err: %v", err)
+	}
+	if e := <-a.C; e.Rule != "TempHigh" || e.State != AlarmHigh || e.Value != 60 {
+		t.Fatalf("unexpected event: %+v", e)
+	}
+	if err := db.Write(client, "Door", true); err != nil {
+		t.Fatalf("unexpected write err: %v", err)
+	}
+	if e := <-a.C; e.Rule != "DoorOpen" || e.State != AlarmHigh {
+		t.Fatalf("unexpected event: %+v", e)
+	}
+
+	if _, err := WatchAlarms(context.Background(), client, db, LowWordFirst, time.Second, []AlarmRule{{Name: "A", Tag: "Unknown", High: &high}}); err == nil {
+		t.Fatalf("expected error for unknown tag")
+	}
+}
@@ -0,0 +1,64 @@
+package mcp
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Area is backup of device range written by ExportArea. bit devices have values of 0 or 1.
+type Area struct {
+	// device name like D and the first device number
+	Device string `json:"device"`
+	Offset int64  `json:"offset"`
+	// time when the area is read
+	Time   time.Time `json:"time"`
+	Values []uint16  `json:"values"`
+}
+
+// ExportArea reads numPoints devices from offset and writes them to w as JSON of Area with metadata.
+// the range may be larger than a request like D0-D8000 or ZR blocks, it is split into multiple requests.
+func ExportArea(ctx context.Context, client Client, w io.Writer, deviceName string, offset, numPoints int64) error {
+	s, err := client.SnapshotContext(ctx, deviceName, offset, numPoints)
+	if err != nil {
+		return err
+	}
+	return json.NewEncoder(w).Encode(Area{Device: deviceName, Offset: offset, Time: s.Time(), Values: s.Values()})
+}
+
+// ImportArea reads Area written by ExportArea from r and writes it back to the same devices.
+// the range is written by WriteBlocksContext or BitWriteBlocksContext, so it is split by the write limit of the client.
+// the restored area is returned.
+func ImportArea(ctx context.Context, client Client, r io.Reader) (*Area, error) {
+	var area Area
+	if err := json.NewDecoder(r).Decode(&area); err != nil {
+		return nil, err
+	}
+	if area.Device == "" || len(area.Values) == 0 {
+		return nil, errors.New("area has no device or values")
+	}
+
+	var err error
+	numPoints := int64(len(area.Values))
+	if IsBitDevice(area.Device) {
+		bools := make([]bool, len(area.Values))
+		for i, v := range area.Values {
+			bools[i] = v != 0
+		}
+		err = client.BitWriteBlocksContext(ctx, area.Device, area.Offset, numPoints, EncodeBits(bools))
+	} else {
+		writeData := make([]byte, 0, 2*len(area.Values))
+		for _, v := range area.Values {
+			writeData = binary.LittleEndian.AppendUint16(writeData, v)
+		}
+		err = client.WriteBlocksContext(ctx, area.Device, area.Offset, numPoints, writeData)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("restore %v: %w", FormatDevice(area.Device, area.Offset), err)
+	}
+	return &area, nil
+}
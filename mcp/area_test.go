@@ -0,0 +1,130 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestExportArea(t *testing.T) {
+	client, mem := newTestMemoryClient(t)
+	ctx := context.Background()
+
+	// 2000 words need multiple requests in both export and import
+	for i := int64(0); i < 2000; i++ {
+		mem.words[[2]int64{0xA8, i}] = uint16(i)
+	}
+	if err := client.WriteBools("M", 0, []bool{true, false, true}); err != nil {
+		t.Fatalf("unexpected mcp write err: %v", err)
+	}
+
+	var words, bits bytes.Buffer
+	if err := ExportArea(ctx, client, &words, "D", 0, 2000); err != nil {
+		t.Fatalf("unexpected export err: %v", err)
+	}
+	if err := ExportArea(ctx, client, &bits, "M", 0, 3); err != nil {
+		t.Fatalf("unexpected export err: %v", err)
+	}
+
+	for i := int64(0); i < 2000; i++ {
+		mem.words[[2]int64{0xA8, i}] = 0
+	}
+	if err := client.WriteBools("M", 0, []bool{false, true, false}); err != nil {
+		t.Fatalf("unexpected mcp write err: %v", err)
+	}
+
+	area, err := ImportArea(ctx, client, &words)
+	if err != nil {
+		t.Fatalf("unexpected import err: %v", err)
+	}
+	if area.Device != "D" || area.Offset != 0 || len(area.Values) != 2000 || area.Time.IsZero() {
+		t.Fatalf("unexpected area: %v %v %v %v", area.Device, area.Offset, len(area.Values), area.Time)
+	}
+	if _, err := ImportArea(ctx, client, &bits); err != nil {
+		t.Fatalf("unexpected import err: %v", err)
+	}
+
+	for _, i := range []int64{0, 959, 960, 1999} {
+		if got := mem.words[[2]int64{0xA8, i}]; got != uint16(i) {
+			t.Fatalf("expected %v but actual is %v", i, got)
+		}
+	}
+	got, err := client.ReadBools("M", 0, 3)
+	if err != nil {
+		t.Fatalf("unexpected mcp read err: %v", err)
+	}
+	if diff := cmp.Diff(got, []bool{true, false, true}); diff != "" {
+		t.Errorf("bits differs: (-got +want)\n%s", diff)
+	}
+
+	if _, err := ImportArea(ctx, client, bytes.NewBufferString(`{"device":"D"}`)); err == nil {
+		t.Fatalf("expected error of empty area")
+	}
+}
+
+func TestImportArea_Profile(t *testing.T) {
+	small := FX5U
+	small.MaxWritePoints, small.MaxBitWritePoints = 64, 10
+	var writes []int64
+	count := func(next Handler) Handler {
+		return func(ctx context.Context, req *Request) ([]byte, error) {
+			if req.Op == OpWrite || req.Op == OpBitWrite {
+				writes = append(writes, req.NumPoints)
+			}
+			return next(ctx, req)
+		}
+	}
+	client, mem := newTestMemoryClient(t, WithProfile(small), WithMiddleware(count))
+	ctx := context.Background()
+
+	// writes are split by limits of the profile instead of 960 words and 7168 bits
+	words := make([]uint16, 150)
+	for i := range words {
+		words[i] = uint16(i + 1)
+	}
+	bits := []uint16{1, 0, 1, 1, 0, 0, 1, 0, 1, 1, 0, 1}
+	for _, area := range []Area{{Device: "D", Values: words}, {Device: "M", Values: bits}} {
+		var buf bytes.Buffer
+		if err := json.NewEncoder(&buf).Encode(area); err != nil {
+			t.Fatalf("unexpected encode err: %v", err)
+		}
+		if _, err := ImportArea(ctx, client, &buf); err != nil {
+			t.Fatalf("unexpected import err: %v", err)
+		}
+	}
+	if diff := cmp.Diff(writes, []int64{64, 64, 22, 10, 2}); diff != "" {
+		t.Errorf("writes differs: (-got +want)\n%s", diff)
+	}
+	for _, i := range []int64{0, 63, 64, 149} {
+		if got := mem.words[[2]int64{0xA8, i}]; got != uint16(i+1) {
+			t.Fatalf("expected %v but actual is %v", i+1, got)
+		}
+	}
+	for i, v := range bits {
+		if got := mem.bits[[2]int64{0x90, int64(i)}]; got != (v != 0) {
+			t.Fatalf("M%v: expected %v but actual is %v", i, v != 0, got)
+		}
+	}
+}
+
+func TestImportArea_QualifiedBit(t *testing.T) {
+	var ops []Op
+	answer := func(next Handler) Handler {
+		return func(ctx context.Context, req *Request) ([]byte, error) {
+			ops = append(ops, req.Op)
+			return []byte{0xD0, 0x00, 0x00, 0xFF, 0xFF, 0x03, 0x00, 0x02, 0x00, 0x00, 0x00}, nil
+		}
+	}
+	client, _ := newTestMemoryClient(t, WithMiddleware(answer))
+
+	// values of qualified bit device are written in bit units
+	if _, err := ImportArea(context.Background(), client, bytes.NewBufferString(`{"device":"J1\\X","offset":0,"values":[1,0,1]}`)); err != nil {
+		t.Fatalf("unexpected import err: %v", err)
+	}
+	if diff := cmp.Diff(ops, []Op{OpBitWrite}); diff != "" {
+		t.Errorf("ops differs: (-got +want)\n%s", diff)
+	}
+}
@@ -0,0 +1,98 @@
+package mcp
+
+import (
+	"context"
+	"time"
+)
+
+// AuditEvent is record of a write request.
+type AuditEvent struct {
+	// time when the write request is sent
+	Time time.Time
+	// OpWrite or OpBitWrite
+	Op         Op
+	DeviceName string
+	Offset     int64
+	NumPoints  int64
+	// value before the write. it is nil unless WithAuditReadBack is set or read-back failed
+	OldValue []byte
+	// written value
+	NewValue []byte
+	// info given by WithAuditInfo to context of the request
+	Info any
+	// error of the write. it may be *EndCodeError
+	Err error
+}
+
+// AuditHook is called after every write request.
+type AuditHook func(ctx context.Context, e AuditEvent)
+
+type auditInfoKey struct{}
+
+// WithAuditInfo returns context that carries info to AuditEvent, like an operator or a reason of the write.
+func WithAuditInfo(ctx context.Context, info any) context.Context {
+	return context.WithValue(ctx, auditInfoKey{}, info)
+}
+
+// WithAuditHook calls hook for every write request to keep an audit trail.
+// write requests rejected by middleware are not sent, so they are not audited.
+func WithAuditHook(hook AuditHook) Option {
+	return func(o *options) {
+		o.auditHook = hook
+	}
+}
+
+// WithAuditReadBack reads devices before each write to record old value to AuditEvent.
+// it costs one more request per write.
+func WithAuditReadBack() Option {
+	return func(o *options) {
+		o.auditReadBack = true
+	}
+}
+
+// auditMiddleware calls audit hook for write requests. it wraps terminal handler.
+func (c *client3E) auditMiddleware(next Handler) Handler {
+	return func(ctx context.Context, req *Request) ([]byte, error) {
+		if req.Op != OpWrite && req.Op != OpBitWrite {
+			return next(ctx, req)
+		}
+
+		bit := req.Op == OpBitWrite
+		e := AuditEvent{
+			Op:         req.Op,
+			DeviceName: req.DeviceName,
+			Offset:     req.Offset,
+			NumPoints:  req.NumPoints,
+			NewValue:   req.WriteData,
+			Info:       ctx.Value(auditInfoKey{}),
+		}
+		if n := WriteDataLen(req.NumPoints, bit); int64(len(e.NewValue)) > n {
+			e.NewValue = e.NewValue[:n]
+		}
+		if c.opts.auditReadBack {
+			// old value is nil when read-back failed
+			e.OldValue, _ = c.readBackHelper(ctx, req, bit)
+		}
+
+		e.Time = time.Now()
+		resp, err := next(ctx, req)
+		_, e.Err = payloadHelper(resp, err)
+		c.opts.auditHook(ctx, e)
+		return resp, err
+	}
+}
+
+// readBackHelper reads devices of write request directly from PLC.
+func (c *client3E) readBackHelper(ctx context.Context, req *Request, bit bool) ([]byte, error) {
+	stn := c.stationHelper(ctx)
+	build, op := stn.AppendReadRequest, OpRead
+	if bit {
+		build, op = stn.AppendBitReadRequest, OpBitRead
+	}
+	frame, err := build(nil, req.DeviceName, req.Offset, req.NumPoints)
+	if err != nil {
+		return nil, err
+	}
+	read := &Request{Op: op, DeviceName: req.DeviceName, Offset: req.Offset, NumPoints: req.NumPoints, frame: frame, buffSize: 22 + 2*req.NumPoints}
+	return payloadHelper(c.terminalHandler(ctx, read))
+}
@@ -0,0 +1,43 @@
+package mcp
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestClient3E_AuditHook(t *testing.T) {
+	var events []AuditEvent
+	hook := func(ctx context.Context, e AuditEvent) {
+		events = append(events, e)
+	}
+
+	client, mem := newTestMemoryClient(t, WithAuditHook(hook), WithAuditReadBack())
+	mem.words[[2]int64{0xA8, 100}] = 0x1234
+
+	ctx := WithAuditInfo(context.Background(), "operator-1")
+	if _, err := client.WriteContext(ctx, "D", 100, 1, []byte{0x78, 0x56, 0xFF}); err != nil {
+		t.Fatalf("unexpected mcp write err: %v", err)
+	}
+	if _, err := client.Read("D", 100, 1); err != nil {
+		t.Fatalf("unexpected mcp read err: %v", err)
+	}
+	if _, err := client.BitWrite("M", 10, 1, []byte{0x10}); err != nil {
+		t.Fatalf("unexpected mcp write err: %v", err)
+	}
+
+	expected := []AuditEvent{
+		{Op: OpWrite, DeviceName: "D", Offset: 100, NumPoints: 1, OldValue: []byte{0x34, 0x12}, NewValue: []byte{0x78, 0x56}, Info: "operator-1"},
+		{Op: OpBitWrite, DeviceName: "M", Offset: 10, NumPoints: 1, OldValue: []byte{0x00}, NewValue: []byte{0x10}},
+	}
+	if diff := cmp.Diff(events, expected, cmpopts.IgnoreFields(AuditEvent{}, "Time")); diff != "" {
+		t.Errorf("audit events differs: (-got +want)\n%s", diff)
+	}
+	for _, e := range events {
+		if e.Time.IsZero() {
+			t.Errorf("time of audit event is not set: %v", e)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package mcp
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Backoff is policy of waiting between reconnect attempts.
+// n-th wait is Initial * Multiplier^(n-1) that is limited by Max, and randomized by ±Jitter ratio.
+type Backoff struct {
+	// wait before the first attempt
+	Initial time.Duration
+	// upper limit of wait
+	Max time.Duration
+	// factor of wait growth per attempt. less than 1 is treated as 1
+	Multiplier float64
+	// randomization ratio of wait from 0 to 1
+	Jitter float64
+	// max number of attempts. zero means unlimited
+	MaxAttempts int
+}
+
+// DefaultBackoff waits 1 second and tries once.
+var DefaultBackoff = Backoff{
+	Initial:     1 * time.Second,
+	Max:         30 * time.Second,
+	Multiplier:  2,
+	MaxAttempts: 1,
+}
+
+// delay returns wait before attempt. attempt starts from 0.
+func (b Backoff) delay(attempt int) time.Duration {
+	multiplier := math.Max(b.Multiplier, 1)
+	d := float64(b.Initial) * math.Pow(multiplier, float64(attempt))
+	if b.Max > 0 && d > float64(b.Max) {
+		d = float64(b.Max)
+	}
+	if b.Jitter > 0 {
+		d *= 1 + b.Jitter*(2*rand.Float64()-1)
+	}
+	return time.Duration(d)
+}
+
+// ReconnectUntil closes connection and connects to PLC again waiting by backoff policy between attempts.
+// it blocks until connection is established, ctx is done or max attempts of policy are exhausted.
+// the last connect error is returned when attempts are exhausted.
+func (c *client3E) ReconnectUntil(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.closeHelper()
+	return c.reconnectHelper(ctx, nil)
+}
+
+// reconnectHelper connects to PLC by backoff policy. cause is the error that broke the connection. c.mu must be held.
+func (c *client3E) reconnectHelper(ctx context.Context, cause error) error {
+	c.conn.Close()
+
+	b := c.opts.backoff
+	err := cause
+	for attempt := 0; b.MaxAttempts == 0 || attempt < b.MaxAttempts; attempt++ {
+		timer := time.NewTimer(b.delay(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+
+		c.emitHelper(Reconnecting, err)
+		if err = c.connectHelper(ctx); err == nil {
+			return nil
+		}
+	}
+	return err
+}
@@ -0,0 +1,88 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestBackoff_Delay(t *testing.T) {
+	b := Backoff{Initial: 100 * time.Millisecond, Max: time.Second, Multiplier: 2}
+	expected := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second}
+	for i, v := range expected {
+		if actual := b.delay(i); actual != v {
+			t.Errorf("attempt %v: expected %v but actual is %v", i, v, actual)
+		}
+	}
+
+	b.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if actual := b.delay(0); actual < 50*time.Millisecond || actual > 150*time.Millisecond {
+			t.Fatalf("expected %v-%v but actual is %v", 50*time.Millisecond, 150*time.Millisecond, actual)
+		}
+	}
+}
+
+func TestClient3E_ReconnectUntil(t *testing.T) {
+	host, port := newTestServer(t, func(req []byte) []byte { return nil })
+
+	attempts := 0
+	dialer := &net.Dialer{}
+	dial := func(ctx context.Context, network, address string) (net.Conn, error) {
+		attempts++
+		if attempts > 1 && attempts < 4 {
+			return nil, errors.New("plc is restarting")
+		}
+		return dialer.DialContext(ctx, network, address)
+	}
+
+	client, err := New3EClient(host, port, NewLocalStation(), false, WithDialContext(dial),
+		WithBackoff(Backoff{Initial: time.Millisecond, Max: 10 * time.Millisecond, Multiplier: 2}))
+	if err != nil {
+		t.Fatalf("unexpected connect err: %v", err)
+	}
+	defer client.ShutDown()
+
+	if err := client.ReconnectUntil(context.Background()); err != nil {
+		t.Fatalf("unexpected reconnect err: %v", err)
+	}
+	if attempts != 4 {
+		t.Fatalf("expected %v but actual is %v", 4, attempts)
+	}
+}
+
+func TestClient3E_ReconnectUntilExhausted(t *testing.T) {
+	host, port := newTestServer(t, func(req []byte) []byte { return nil })
+
+	attempts := 0
+	dialer := &net.Dialer{}
+	dial := func(ctx context.Context, network, address string) (net.Conn, error) {
+		attempts++
+		if attempts > 1 {
+			return nil, errors.New("plc is down")
+		}
+		return dialer.DialContext(ctx, network, address)
+	}
+
+	client, err := New3EClient(host, port, NewLocalStation(), false, WithDialContext(dial),
+		WithBackoff(Backoff{Initial: time.Millisecond, MaxAttempts: 3}))
+	if err != nil {
+		t.Fatalf("unexpected connect err: %v", err)
+	}
+
+	if err := client.Reconnect(); err == nil || err.Error() != "plc is down" {
+		t.Fatalf("expected %v but actual is %v", "plc is down", err)
+	}
+	if attempts != 4 {
+		t.Fatalf("expected %v but actual is %v", 4, attempts)
+	}
+
+	client.(*client3E).opts.backoff.MaxAttempts = 0
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := client.ReconnectUntil(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expected %v but actual is %v", context.DeadlineExceeded, err)
+	}
+}
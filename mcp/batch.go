@@ -0,0 +1,144 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Batch sends requests in order and collects their results.
+// with 4E frame (WithFrame4E), all requests are sent back-to-back before waiting responses.
+// requests are not split, so NumPoints must not exceed MAX_READ_POINTS or MAX_BIT_READ_POINTS.
+// results has the same order as requests. the returned error is the first error of results.
+func (c *client3E) Batch(requests []Request) ([]Result, error) {
+	return c.BatchContext(context.Background(), requests)
+}
+
+// BatchContext is Batch that is aborted when ctx is canceled or its deadline exceeded.
+func (c *client3E) BatchContext(ctx context.Context, requests []Request) ([]Result, error) {
+	results := make([]Result, len(requests))
+	reqs := make([]*Request, len(requests))
+	for i, r := range requests {
+		r := r
+		reqs[i] = &r
+		results[i].Err = c.buildBatchHelper(c.stationHelper(ctx), reqs[i])
+	}
+
+	if c.opts.frame4E {
+		if err := c.opts.breaker.allow(); err != nil {
+			return nil, err
+		}
+		results = c.pipelineBatchHelper(ctx, reqs, results)
+	} else {
+		for i, r := range reqs {
+			if results[i].Err == nil {
+				results[i].Payload, results[i].Err = payloadHelper(c.chainHelper(c.terminalHandler)(ctx, r))
+			}
+		}
+	}
+
+	for _, r := range results {
+		if r.Err != nil {
+			return results, r.Err
+		}
+	}
+	return results, nil
+}
+
+// pipelineBatchHelper sends requests by 4E frame in order without waiting responses.
+// each request goes through middleware chain concurrently, and the requests are sent in turn.
+func (c *client3E) pipelineBatchHelper(ctx context.Context, reqs []*Request, results []Result) []Result {
+	// turns[i] is closed when reqs[i] can be sent
+	turns := make([]chan struct{}, len(reqs)+1)
+	for i := range turns {
+		turns[i] = make(chan struct{})
+	}
+	close(turns[0])
+
+	// the first communication error of requests is recorded to circuit breaker
+	var ioErr error
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i, r := range reqs {
+		if results[i].Err != nil {
+			// request that failed to build is skipped in its turn
+			go func(i int) {
+				<-turns[i]
+				close(turns[i+1])
+			}(i)
+			continue
+		}
+		wg.Add(1)
+		go func(i int, r *Request) {
+			defer wg.Done()
+			var once sync.Once
+			next := func() {
+				once.Do(func() {
+					<-turns[i]
+					close(turns[i+1])
+				})
+			}
+			// middleware may return without calling terminal handler
+			defer next()
+
+			terminal := func(ctx context.Context, r *Request) ([]byte, error) {
+				<-turns[i]
+				unlock := c.writeLockHelper(ctx, r)
+				p, err := c.pipelineSendHelper(ctx, r.frame)
+				unlock()
+				next()
+				if err == nil {
+					var resp []byte
+					if resp, err = p.wait(ctx); err == nil {
+						c.heartbeat.touch()
+						return resp, nil
+					}
+				}
+				mu.Lock()
+				if ioErr == nil {
+					ioErr = err
+				}
+				mu.Unlock()
+				return nil, err
+			}
+			results[i].Payload, results[i].Err = payloadHelper(c.chainHelper(terminal)(ctx, r))
+		}(i, r)
+	}
+	wg.Wait()
+	c.opts.breaker.record(ctx, ioErr)
+	return results
+}
+
+// buildBatchHelper builds request frame of r to stn.
+func (c *client3E) buildBatchHelper(stn *station, r *Request) error {
+	var err error
+	switch r.Op {
+	case OpRead:
+		if r.NumPoints > MAX_READ_POINTS {
+			return fmt.Errorf("%w: %v is larger than %v", ErrInvalidPoints, r.NumPoints, MAX_READ_POINTS)
+		}
+		r.frame, err = stn.AppendReadRequest(nil, r.DeviceName, r.Offset, r.NumPoints)
+		r.buffSize = 22 + 2*r.NumPoints
+	case OpBitRead:
+		if r.NumPoints > MAX_BIT_READ_POINTS {
+			return fmt.Errorf("%w: %v is larger than %v", ErrInvalidPoints, r.NumPoints, MAX_BIT_READ_POINTS)
+		}
+		r.frame, err = stn.AppendBitReadRequest(nil, r.DeviceName, r.Offset, r.NumPoints)
+		r.buffSize = 22 + 2*r.NumPoints
+	case OpWrite:
+		if err := c.guardHelper(r.Op, r.DeviceName, r.Offset, r.NumPoints); err != nil {
+			return err
+		}
+		r.frame, err = stn.AppendWriteRequest(nil, r.DeviceName, r.Offset, r.NumPoints, c.padHelper(r.WriteData, WriteDataLen(r.NumPoints, false)))
+		r.buffSize = 22
+	case OpBitWrite:
+		if err := c.guardHelper(r.Op, r.DeviceName, r.Offset, r.NumPoints); err != nil {
+			return err
+		}
+		r.frame, err = stn.AppendBitWriteRequest(nil, r.DeviceName, r.Offset, r.NumPoints, c.padHelper(r.WriteData, WriteDataLen(r.NumPoints, true)))
+		r.buffSize = 22
+	default:
+		return fmt.Errorf("unknown request op: %v", r.Op)
+	}
+	return err
+}
@@ -0,0 +1,46 @@
+package mcp
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestClient3E_Batch(t *testing.T) {
+	for _, frame := range []struct {
+		name string
+		opts []Option
+	}{
+		{name: "3E"},
+		{name: "4E", opts: []Option{WithFrame4E()}},
+	} {
+		t.Run(frame.name, func(t *testing.T) {
+			client, mem := newTestMemoryClient(t, frame.opts...)
+			mem.words[[2]int64{0xA8, 100}] = 0x1234
+			mem.bits[[2]int64{0x90, 11}] = true
+
+			results, err := client.Batch([]Request{
+				{Op: OpWrite, DeviceName: "D", Offset: 200, NumPoints: 1, WriteData: []byte{0x78, 0x56}},
+				{Op: OpRead, DeviceName: "D", Offset: 100, NumPoints: 1},
+				{Op: OpRead, DeviceName: "D", Offset: 200, NumPoints: 1},
+				{Op: OpBitRead, DeviceName: "M", Offset: 10, NumPoints: 2},
+				{Op: OpRead, DeviceName: "D", Offset: 0, NumPoints: MAX_READ_POINTS + 1},
+			})
+			if !errors.Is(err, ErrInvalidPoints) {
+				t.Fatalf("expected %v but actual is %v", ErrInvalidPoints, err)
+			}
+
+			var payloads [][]byte
+			for _, r := range results[:4] {
+				if r.Err != nil {
+					t.Fatalf("unexpected result err: %v", r.Err)
+				}
+				payloads = append(payloads, r.Payload)
+			}
+			if diff := cmp.Diff(payloads, [][]byte{{}, {0x34, 0x12}, {0x78, 0x56}, {0x01}}); diff != "" {
+				t.Errorf("batch payloads differs: (-got +want)\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package mcp
+
+import (
+	"errors"
+	"fmt"
+)
+
+// EncodeBCD16 encodes decimal value from 0 to 9999 to 16bit BCD.
+func EncodeBCD16(value uint16) (uint16, error) {
+	if value > 9999 {
+		return 0, errors.New("value is out of 16bit BCD range: " + fmt.Sprint(value))
+	}
+	bcd, _ := EncodeBCD32(uint32(value))
+	return uint16(bcd), nil
+}
+
+// DecodeBCD16 decodes 16bit BCD to decimal value. digit larger than 9 is error.
+func DecodeBCD16(bcd uint16) (uint16, error) {
+	value, err := DecodeBCD32(uint32(bcd))
+	return uint16(value), err
+}
+
+// EncodeBCD32 encodes decimal value from 0 to 99999999 to 32bit BCD.
+func EncodeBCD32(value uint32) (uint32, error) {
+	if value > 99999999 {
+		return 0, errors.New("value is out of 32bit BCD range: " + fmt.Sprint(value))
+	}
+
+	var bcd uint32
+	for shift := uint(0); value > 0; shift += 4 {
+		bcd |= (value % 10) << shift
+		value /= 10
+	}
+	return bcd, nil
+}
+
+// DecodeBCD32 decodes 32bit BCD to decimal value. digit larger than 9 is error.
+func DecodeBCD32(bcd uint32) (uint32, error) {
+	var value uint32
+	for shift := 28; shift >= 0; shift -= 4 {
+		digit := (bcd >> uint(shift)) & 0xF
+		if digit > 9 {
+			return 0, errors.New("invalid BCD: " + fmt.Sprintf("%X", bcd))
+		}
+		value = value*10 + digit
+	}
+	return value, nil
+}
+
+// ReadBCD16 reads 1 word device stored as BCD.
+func (c *client3E) ReadBCD16(deviceName string, offset int64) (uint16, error) {
+	bcd, err := c.ReadUint16(deviceName, offset)
+	if err != nil {
+		return 0, err
+	}
+	return DecodeBCD16(bcd)
+}
+
+// WriteBCD16 writes value from 0 to 9999 to 1 word device as BCD.
+func (c *client3E) WriteBCD16(deviceName string, offset int64, value uint16) error {
+	bcd, err := EncodeBCD16(value)
+	if err != nil {
+		return err
+	}
+	return c.WriteUint16(deviceName, offset, bcd)
+}
+
+// ReadBCD32 reads 2 word devices stored as BCD.
+func (c *client3E) ReadBCD32(deviceName string, offset int64) (uint32, error) {
+	bcd, err := c.ReadUint32(deviceName, offset)
+	if err != nil {
+		return 0, err
+	}
+	return DecodeBCD32(bcd)
+}
+
+// WriteBCD32 writes value from 0 to 99999999 to 2 word devices as BCD.
+func (c *client3E) WriteBCD32(deviceName string, offset int64, value uint32) error {
+	bcd, err := EncodeBCD32(value)
+	if err != nil {
+		return err
+	}
+	return c.WriteUint32(deviceName, offset, bcd)
+}
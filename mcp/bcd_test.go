@@ -0,0 +1,69 @@
+package mcp
+
+import "testing"
+
+func TestBCD(t *testing.T) {
+	cases := []struct {
+		value uint32
+		bcd   uint32
+	}{
+		{value: 0, bcd: 0x0},
+		{value: 1234, bcd: 0x1234},
+		{value: 9999, bcd: 0x9999},
+		{value: 12345678, bcd: 0x12345678},
+		{value: 99999999, bcd: 0x99999999},
+	}
+
+	for _, v := range cases {
+		bcd, err := EncodeBCD32(v.value)
+		if err != nil || bcd != v.bcd {
+			t.Errorf("expected %X but actual is %X (%v)", v.bcd, bcd, err)
+		}
+		value, err := DecodeBCD32(v.bcd)
+		if err != nil || value != v.value {
+			t.Errorf("expected %v but actual is %v (%v)", v.value, value, err)
+		}
+	}
+
+	if bcd, err := EncodeBCD16(1234); err != nil || bcd != 0x1234 {
+		t.Errorf("expected %X but actual is %X (%v)", 0x1234, bcd, err)
+	}
+	if _, err := EncodeBCD16(10000); err == nil {
+		t.Errorf("expected error for out of range")
+	}
+	if _, err := EncodeBCD32(100000000); err == nil {
+		t.Errorf("expected error for out of range")
+	}
+	if _, err := DecodeBCD16(0x12A4); err == nil {
+		t.Errorf("expected error for invalid digit")
+	}
+}
+
+func TestClient3E_BCD(t *testing.T) {
+	client, mem := newTestMemoryClient(t)
+
+	if err := client.WriteBCD16("D", 100, 1234); err != nil {
+		t.Fatalf("unexpected write err: %v", err)
+	}
+	if err := client.WriteBCD32("D", 200, 12345678); err != nil {
+		t.Fatalf("unexpected write err: %v", err)
+	}
+
+	mem.mu.Lock()
+	expected := map[int64]uint16{100: 0x1234, 200: 0x5678, 201: 0x1234}
+	for offset, word := range expected {
+		if actual := mem.words[[2]int64{0xA8, offset}]; actual != word {
+			t.Errorf("D%v: expected %X but actual is %X", offset, word, actual)
+		}
+	}
+	mem.mu.Unlock()
+
+	v16, err := client.ReadBCD16("D", 100)
+	if err != nil || v16 != 1234 {
+		t.Fatalf("expected %v but actual is %v (%v)", 1234, v16, err)
+	}
+	v32, err := client.ReadBCD32("D", 200)
+	if err != nil || v32 != 12345678 {
+		t.Fatalf("expected %v but actual is %v (%v)", 12345678, v32, err)
+	}
+}
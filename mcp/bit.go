@@ -0,0 +1,57 @@
+package mcp
+
+import (
+	"context"
+	"time"
+)
+
+// SetBit turns 1 bit device like M100 ON by bit unit write.
+func (c *client3E) SetBit(deviceName string, offset int64) error {
+	return c.writeBitHelper(context.Background(), deviceName, offset, true)
+}
+
+// ResetBit turns 1 bit device OFF by bit unit write.
+func (c *client3E) ResetBit(deviceName string, offset int64) error {
+	return c.writeBitHelper(context.Background(), deviceName, offset, false)
+}
+
+// ToggleBit inverts 1 bit device and returns the new state. it is read-modify-write from PLC like WriteWordBits,
+// so other writes of the client like SetBit wait until the new state is written.
+func (c *client3E) ToggleBit(deviceName string, offset int64) (bool, error) {
+	ctx, unlock := c.rmwHelper()
+	defer unlock()
+
+	payload, err := c.BitReadContext(withoutCache(ctx), deviceName, offset, 1)
+	if err != nil {
+		return false, err
+	}
+	on := !DecodeBits(payload, 1)[0]
+	return on, c.writeBitHelper(ctx, deviceName, offset, on)
+}
+
+// PulseBit turns 1 bit device ON for duration and then OFF, like a push button of HMI.
+// when ctx is canceled during the pulse, the device is turned OFF at once and ctx.Err() is returned.
+func (c *client3E) PulseBit(ctx context.Context, deviceName string, offset int64, duration time.Duration) error {
+	if err := c.writeBitHelper(ctx, deviceName, offset, true); err != nil {
+		return err
+	}
+
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+	var canceled error
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+		canceled = ctx.Err()
+	}
+	// the device must not be left ON even if ctx is canceled
+	if err := c.writeBitHelper(context.WithoutCancel(ctx), deviceName, offset, false); err != nil {
+		return err
+	}
+	return canceled
+}
+
+func (c *client3E) writeBitHelper(ctx context.Context, deviceName string, offset int64, value bool) error {
+	_, err := c.BitWriteContext(ctx, deviceName, offset, 1, EncodeBits([]bool{value}))
+	return err
+}
@@ -0,0 +1,126 @@
+package mcp
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestClient3E_SetBit(t *testing.T) {
+	client, _ := newTestMemoryClient(t)
+
+	if err := client.SetBit("M", 101); err != nil {
+		t.Fatalf("unexpected mcp write err: %v", err)
+	}
+	on, err := client.ToggleBit("M", 100)
+	if err != nil {
+		t.Fatalf("unexpected mcp toggle err: %v", err)
+	}
+	if !on {
+		t.Fatalf("expected %v but actual is %v", true, on)
+	}
+	if err := client.ResetBit("M", 101); err != nil {
+		t.Fatalf("unexpected mcp write err: %v", err)
+	}
+
+	got, err := client.ReadBools("M", 100, 2)
+	if err != nil {
+		t.Fatalf("unexpected mcp read err: %v", err)
+	}
+	if diff := cmp.Diff(got, []bool{true, false}); diff != "" {
+		t.Errorf("bits differs: (-got +want)\n%s", diff)
+	}
+}
+
+func TestClient3E_PulseBit(t *testing.T) {
+	var mu sync.Mutex
+	var writes []string
+	record := func(next Handler) Handler {
+		return func(ctx context.Context, req *Request) ([]byte, error) {
+			if req.Op == OpBitWrite {
+				mu.Lock()
+				writes = append(writes, FormatDevice(req.DeviceName, req.Offset)+"="+map[byte]string{0x10: "ON", 0x00: "OFF"}[req.WriteData[0]])
+				mu.Unlock()
+			}
+			return next(ctx, req)
+		}
+	}
+	client, _ := newTestMemoryClient(t, WithMiddleware(record))
+
+	start := time.Now()
+	if err := client.PulseBit(context.Background(), "M", 100, 30*time.Millisecond); err != nil {
+		t.Fatalf("unexpected mcp pulse err: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Fatalf("expected pulse of %v but actual is %v", 30*time.Millisecond, elapsed)
+	}
+
+	// canceled pulse still turns the device OFF
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := client.PulseBit(ctx, "M", 101, time.Minute); err != context.DeadlineExceeded {
+		t.Fatalf("expected %v but actual is %v", context.DeadlineExceeded, err)
+	}
+
+	if diff := cmp.Diff(writes, []string{"M100=ON", "M100=OFF", "M101=ON", "M101=OFF"}); diff != "" {
+		t.Errorf("writes differs: (-got +want)\n%s", diff)
+	}
+}
+
+func TestClient3E_ToggleBitCache(t *testing.T) {
+	client, mem := newTestMemoryClient(t, WithReadCache(time.Minute))
+
+	// cached bit is stale after PLC program sets it
+	if _, err := client.ReadBools("M", 0, 1); err != nil {
+		t.Fatalf("unexpected mcp read err: %v", err)
+	}
+	mem.mu.Lock()
+	mem.bits[[2]int64{0x90, 0}] = true
+	mem.mu.Unlock()
+
+	on, err := client.ToggleBit("M", 0)
+	if err != nil {
+		t.Fatalf("unexpected mcp toggle err: %v", err)
+	}
+	if on {
+		t.Fatalf("expected %v but actual is %v", false, on)
+	}
+}
+
+func TestClient3E_ToggleBitRace(t *testing.T) {
+	// ResetBit is issued between the read and the write back
+	read := make(chan struct{})
+	pause := func(next Handler) Handler {
+		return func(ctx context.Context, req *Request) ([]byte, error) {
+			resp, err := next(ctx, req)
+			if req.Op == OpBitRead {
+				close(read)
+				time.Sleep(50 * time.Millisecond)
+			}
+			return resp, err
+		}
+	}
+	client, mem := newTestMemoryClient(t, WithMiddleware(pause))
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := client.ToggleBit("M", 0)
+		done <- err
+	}()
+	<-read
+	if err := client.ResetBit("M", 0); err != nil {
+		t.Fatalf("unexpected mcp write err: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected mcp toggle err: %v", err)
+	}
+	// ResetBit waits for the toggle, so it is not overwritten
+	mem.mu.Lock()
+	defer mem.mu.Unlock()
+	if mem.bits[[2]int64{0x90, 0}] {
+		t.Fatalf("expected %v but actual is %v", false, true)
+	}
+}
@@ -0,0 +1,72 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without sending request while circuit breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// breaker is circuit breaker that opens after consecutive failures of communication with PLC.
+// while it is open, requests fail fast. after cool-down, one request is tried and
+// the breaker is closed when it succeeds, otherwise opened again.
+type breaker struct {
+	mu        sync.Mutex
+	threshold int
+	coolDown  time.Duration
+	// number of consecutive failures
+	failures int
+	// time when the breaker opened. zero while it is closed
+	openedAt time.Time
+	// true while a trial request after cool-down is in flight
+	trial bool
+}
+
+func newBreaker(threshold int, coolDown time.Duration) *breaker {
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &breaker{threshold: threshold, coolDown: coolDown}
+}
+
+// allow returns ErrCircuitOpen when request must not be sent. nil breaker always allows.
+func (b *breaker) allow() error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.openedAt.IsZero() {
+		return nil
+	}
+	if b.trial || time.Since(b.openedAt) < b.coolDown {
+		return ErrCircuitOpen
+	}
+	b.trial = true
+	return nil
+}
+
+// record records result of request. errors caused by ctx are not counted as failure.
+func (b *breaker) record(ctx context.Context, err error) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trial = false
+	if err == nil {
+		b.failures = 0
+		b.openedAt = time.Time{}
+		return
+	}
+	if ctx.Err() != nil {
+		return
+	}
+	b.failures++
+	if b.failures >= b.threshold {
+		b.openedAt = time.Now()
+	}
+}
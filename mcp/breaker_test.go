@@ -0,0 +1,83 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestBreaker(t *testing.T) {
+	b := newBreaker(2, 20*time.Millisecond)
+	ctx := context.Background()
+	ioErr := errors.New("connection reset")
+
+	b.record(ctx, ioErr)
+	if err := b.allow(); err != nil {
+		t.Fatalf("expected closed breaker but actual is %v", err)
+	}
+	b.record(ctx, ioErr)
+	if err := b.allow(); err != ErrCircuitOpen {
+		t.Fatalf("expected %v but actual is %v", ErrCircuitOpen, err)
+	}
+
+	time.Sleep(25 * time.Millisecond)
+	if err := b.allow(); err != nil {
+		t.Fatalf("expected trial after cool-down but actual is %v", err)
+	}
+	if err := b.allow(); err != ErrCircuitOpen {
+		t.Fatalf("expected %v while trial is in flight but actual is %v", ErrCircuitOpen, err)
+	}
+	b.record(ctx, ioErr)
+	if err := b.allow(); err != ErrCircuitOpen {
+		t.Fatalf("expected %v after failed trial but actual is %v", ErrCircuitOpen, err)
+	}
+
+	time.Sleep(25 * time.Millisecond)
+	_ = b.allow()
+	b.record(ctx, nil)
+	if err := b.allow(); err != nil {
+		t.Fatalf("expected closed breaker after successful trial but actual is %v", err)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	b.record(canceled, context.Canceled)
+	b.record(canceled, context.Canceled)
+	if err := b.allow(); err != nil {
+		t.Fatalf("expected canceled requests are not counted but actual is %v", err)
+	}
+}
+
+func TestClient3E_CircuitBreaker(t *testing.T) {
+	// PLC that closes connection soon after accepting it
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	addr := listener.Addr().(*net.TCPAddr)
+
+	client, err := New3EClient(addr.IP.String(), addr.Port, NewLocalStation(), false, WithCircuitBreaker(1, time.Minute))
+	if err != nil {
+		t.Fatalf("unexpected connect err: %v", err)
+	}
+	defer client.ShutDown()
+
+	if _, err := client.Read("D", 100, 1); err == nil || err == ErrCircuitOpen {
+		t.Fatalf("expected error of broken connection but actual is %v", err)
+	}
+	if _, err := client.Read("D", 100, 1); err != ErrCircuitOpen {
+		t.Fatalf("expected %v but actual is %v", ErrCircuitOpen, err)
+	}
+}
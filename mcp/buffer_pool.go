@@ -0,0 +1,103 @@
+package mcp
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"sync"
+)
+
+// bufferPool reuses encode and receive buffers of requests.
+var bufferPool = sync.Pool{
+	New: func() any {
+		b := make([]byte, 0, 512)
+		return &b
+	},
+}
+
+// getBuffer returns empty buffer from pool. return it by putBuffer when the frame in it is no longer used.
+func getBuffer() *[]byte {
+	return bufferPool.Get().(*[]byte)
+}
+
+// putBuffer returns buffer to pool. too large buffer is dropped not to keep memory of rare large frames.
+func putBuffer(b *[]byte) {
+	if cap(*b) > MAX_FRAME_SIZE {
+		return
+	}
+	*b = (*b)[:0]
+	bufferPool.Put(b)
+}
+
+// ReadInto reads len(dst)/2 word devices into dst. len(dst) must be even.
+// unlike Read, request and response buffers are reused and nothing is allocated on success
+// when no middleware, logger, audit hook nor 4E frame is set.
+// devices more than MAX_READ_POINTS or limit of WithProfile are split into multiple requests.
+func (c *client3E) ReadInto(ctx context.Context, deviceName string, offset int64, dst []byte) error {
+	if len(dst) == 0 || len(dst)%2 != 0 {
+		return fmt.Errorf("%w: dst must have 2 byte per 1 device point but it is %v byte", ErrInvalidPoints, len(dst))
+	}
+	for len(dst) > 0 {
+		points := int64(len(dst) / 2)
+		if limit := c.readLimitHelper(OpRead); points > limit {
+			points = limit
+		}
+		if err := c.readIntoHelper(ctx, deviceName, offset, points, dst[:2*points]); err != nil {
+			return err
+		}
+		dst = dst[2*points:]
+		offset += points
+	}
+	return nil
+}
+
+func (c *client3E) readIntoHelper(ctx context.Context, deviceName string, offset, numPoints int64, dst []byte) error {
+	encode := getBuffer()
+	defer putBuffer(encode)
+	frame, err := c.stationHelper(ctx).AppendReadRequest((*encode)[:0], deviceName, offset, numPoints)
+	if err != nil {
+		return err
+	}
+	*encode = frame
+
+	if c.opts.frame4E || c.opts.logger != nil || c.opts.auditHook != nil || len(c.opts.middlewares) > 0 || c.opts.cache != nil || c.opts.codec != nil || c.opts.profile != nil {
+		req := &Request{Op: OpRead, DeviceName: deviceName, Offset: offset, NumPoints: numPoints}
+		payload, err := payloadHelper(c.readHelper(ctx, req, frame, numPoints))
+		if err != nil {
+			return err
+		}
+		return copyPayloadHelper(dst, payload)
+	}
+
+	// fast path without middleware chain
+	if err := c.opts.breaker.allow(); err != nil {
+		return err
+	}
+	recv := getBuffer()
+	defer putBuffer(recv)
+	resp, err := c.sendIntoHelper(ctx, frame, (*recv)[:cap(*recv)])
+	c.opts.breaker.record(ctx, err)
+	if err != nil {
+		return err
+	}
+	if cap(resp) > cap(*recv) {
+		// keep grown buffer
+		*recv = resp
+	}
+
+	if len(resp) < 11 || binary.LittleEndian.Uint16(resp[9:11]) != 0 {
+		// error information is copied from response, so the error does not refer pooled buffer
+		_, err := payloadHelper(resp, nil)
+		return err
+	}
+	return copyPayloadHelper(dst, resp[11:])
+}
+
+// copyPayloadHelper copies payload of numPoints devices to dst.
+func copyPayloadHelper(dst, payload []byte) error {
+	if len(payload) != len(dst) {
+		return fmt.Errorf("%w: expected %v byte payload but actual is %v byte", ErrTruncatedResponse, len(dst), len(payload))
+	}
+	copy(dst, payload)
+	return nil
+}
@@ -0,0 +1,118 @@
+package mcp
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net"
+	"testing"
+)
+
+func TestClient3E_ReadInto(t *testing.T) {
+	for _, opts := range [][]Option{nil, {WithFrame4E()}} {
+		client, mem := newTestMemoryClient(t, opts...)
+		for i := int64(0); i < 1000; i++ {
+			mem.words[[2]int64{0xA8, i}] = uint16(i)
+		}
+
+		// split into 2 requests
+		dst := make([]byte, 2*1000)
+		if err := client.ReadInto(context.Background(), "D", 0, dst); err != nil {
+			t.Fatalf("unexpected mcp read err: %v", err)
+		}
+		for i := 0; i < 1000; i++ {
+			if actual := uint16(dst[2*i]) | uint16(dst[2*i+1])<<8; actual != uint16(i) {
+				t.Fatalf("D%v: expected %v but actual is %v", i, i, actual)
+			}
+		}
+
+		if err := client.ReadInto(context.Background(), "D", 0, make([]byte, 3)); !errors.Is(err, ErrInvalidPoints) {
+			t.Fatalf("expected %v but actual is %v", ErrInvalidPoints, err)
+		}
+	}
+}
+
+func TestClient3E_ReadIntoEndCode(t *testing.T) {
+	resp, _ := hex.DecodeString("d00000ffff03000b0059c000ffff030001040000")
+	host, port := newTestServer(t, func(req []byte) []byte { return resp })
+	client, err := New3EClient(host, port, NewLocalStation(), true)
+	if err != nil {
+		t.Fatalf("unexpected connect err: %v", err)
+	}
+	defer client.ShutDown()
+
+	var endCodeErr *EndCodeError
+	if err := client.ReadInto(context.Background(), "D", 0, make([]byte, 2)); !errors.As(err, &endCodeErr) || endCodeErr.EndCode != 0xC059 {
+		t.Fatalf("expected end code %X but actual is %v", 0xC059, err)
+	}
+}
+
+// newBenchServer starts PLC that answers fixed response to read request of 1 device point without allocation.
+func newBenchServer(b *testing.B, resp []byte) (string, int) {
+	b.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		b.Fatalf("failed to listen: %v", err)
+	}
+	b.Cleanup(func() { listener.Close() })
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		req := make([]byte, 21)
+		for {
+			if _, err := io.ReadFull(conn, req); err != nil {
+				return
+			}
+			if _, err := conn.Write(resp); err != nil {
+				return
+			}
+		}
+	}()
+
+	addr := listener.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port
+}
+
+func BenchmarkClient3E_ReadInto(b *testing.B) {
+	resp, _ := hex.DecodeString("d00000ffff030004000000" + "3412")
+	host, port := newBenchServer(b, resp)
+	client, err := New3EClient(host, port, NewLocalStation(), true)
+	if err != nil {
+		b.Fatalf("unexpected connect err: %v", err)
+	}
+	defer client.ShutDown()
+
+	ctx := context.Background()
+	dst := make([]byte, 2)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := client.ReadInto(ctx, "D", 100, dst); err != nil {
+			b.Fatalf("unexpected mcp read err: %v", err)
+		}
+	}
+}
+
+func BenchmarkClient3E_Read(b *testing.B) {
+	resp, _ := hex.DecodeString("d00000ffff030004000000" + "3412")
+	host, port := newBenchServer(b, resp)
+	client, err := New3EClient(host, port, NewLocalStation(), true)
+	if err != nil {
+		b.Fatalf("unexpected connect err: %v", err)
+	}
+	defer client.ShutDown()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := client.Read("D", 100, 1); err != nil {
+			b.Fatalf("unexpected mcp read err: %v", err)
+		}
+	}
+}
@@ -1,114 +1,280 @@
 package mcp
 
 import (
-	"encoding/hex"
+	"bytes"
+	"context"
+	"encoding/binary"
 	"errors"
 	"fmt"
+	"io"
 	"net"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
 type Client interface {
 	Read(deviceName string, offset, numPoints int64) ([]byte, error)
+	ReadContext(ctx context.Context, deviceName string, offset, numPoints int64) ([]byte, error)
+	ReadRaw(deviceName string, offset, numPoints int64) ([]byte, error)
+	ReadInto(ctx context.Context, deviceName string, offset int64, dst []byte) error
 	BitRead(deviceName string, offset, numPoints int64) ([]byte, error)
+	BitReadContext(ctx context.Context, deviceName string, offset, numPoints int64) ([]byte, error)
+	BitReadRaw(deviceName string, offset, numPoints int64) ([]byte, error)
 	Write(deviceName string, offset, numPoints int64, writeData []byte) ([]byte, error)
+	WriteContext(ctx context.Context, deviceName string, offset, numPoints int64, writeData []byte) ([]byte, error)
+	WriteRaw(deviceName string, offset, numPoints int64, writeData []byte) ([]byte, error)
 	BitWrite(deviceName string, offset, numPoints int64, writeData []byte) ([]byte, error)
+	BitWriteContext(ctx context.Context, deviceName string, offset, numPoints int64, writeData []byte) ([]byte, error)
+	BitWriteRaw(deviceName string, offset, numPoints int64, writeData []byte) ([]byte, error)
+	WriteBlocksContext(ctx context.Context, deviceName string, offset, numPoints int64, writeData []byte) error
+	BitWriteBlocksContext(ctx context.Context, deviceName string, offset, numPoints int64, writeData []byte) error
+	ReadUint16(deviceName string, offset int64) (uint16, error)
+	ReadInt16(deviceName string, offset int64) (int16, error)
+	ReadUint32(deviceName string, offset int64) (uint32, error)
+	ReadInt32(deviceName string, offset int64) (int32, error)
+	WriteUint16(deviceName string, offset int64, value uint16) error
+	WriteInt16(deviceName string, offset int64, value int16) error
+	WriteUint32(deviceName string, offset int64, value uint32) error
+	WriteInt32(deviceName string, offset int64, value int32) error
+	ReadFloat32(deviceName string, offset int64) (float32, error)
+	WriteFloat32(deviceName string, offset int64, value float32) error
+	ReadFloat64(deviceName string, offset int64) (float64, error)
+	WriteFloat64(deviceName string, offset int64, value float64) error
+	ReadBCD16(deviceName string, offset int64) (uint16, error)
+	WriteBCD16(deviceName string, offset int64, value uint16) error
+	ReadBCD32(deviceName string, offset int64) (uint32, error)
+	WriteBCD32(deviceName string, offset int64, value uint32) error
+	WriteWordBits(deviceName string, offset int64, mask, value uint16) error
+	Fill(deviceName string, offset, numPoints int64, value uint16) error
+	SetBit(deviceName string, offset int64) error
+	ResetBit(deviceName string, offset int64) error
+	ToggleBit(deviceName string, offset int64) (bool, error)
+	PulseBit(ctx context.Context, deviceName string, offset int64, duration time.Duration) error
+	ReadString(deviceName string, offset, length int64) (string, error)
+	ReadBools(deviceName string, offset, numPoints int64) ([]bool, error)
+	WriteBools(deviceName string, offset int64, values []bool) error
+	ReadStruct(v interface{}) error
+	WriteStruct(v interface{}) error
+	WriteString(deviceName string, offset, length int64, value string) error
+	ReadSharedMemory(cpuNum, offset, numPoints int64) ([]byte, error)
+	WriteSharedMemory(cpuNum, offset, numPoints int64, writeData []byte) ([]byte, error)
+	WriteRandom(ctx context.Context, requests []Request) error
+	Batch(requests []Request) ([]Result, error)
+	BatchContext(ctx context.Context, requests []Request) ([]Result, error)
+	Prepare(req Request) (*PreparedRequest, error)
+	Execute(command, subCommand uint16, data []byte) ([]byte, error)
+	ExecuteContext(ctx context.Context, command, subCommand uint16, data []byte) ([]byte, error)
+	Snapshot(deviceName string, offset, numPoints int64) (*Snapshot, error)
+	SnapshotContext(ctx context.Context, deviceName string, offset, numPoints int64) (*Snapshot, error)
 	HealthCheck() error
+	HealthCheckContext(ctx context.Context) error
 	ShutDown()
 	Reconnect() error
+	ReconnectUntil(ctx context.Context) error
+	Healthy() bool
 	Connect() error
+	ConnectContext(ctx context.Context) error
 }
 
 // client3E is 3E frame mcp client
 type client3E struct {
-	// PLC address
-	tcpAddr string //*net.TCPAddr
+	// PLC addresses like "192.168.0.1:5000". addresses after the first are failover endpoints
+	addrs []string
+	// index of addrs that is connected
+	active int
 	// PLC station
 	stn *station
 	// Connection Handle to PLC
-	conn *net.TCPConn
+	conn net.Conn
+	// client options
+	opts options
+	// mu serializes requests and reconnection on conn
+	mu sync.Mutex
+	// rmw is held by read-modify-writes like WriteWordBits and ToggleBit, and held for reading while other writes are sent
+	rmw sync.RWMutex
+	// heartbeat state
+	heartbeat heartbeat
+	// serial number of the last 4E frame request
+	serial uint32
+	// demultiplexer of 4E frame responses. it is set only when 4E frame is used
+	mux *demux
+	// number of timed out requests whose responses may arrive later
+	stale int
+	// tracking state of redundant CPU
+	redundancy redundancy
 }
 
-func New3EClient(host string, port int, stn *station, keep_alive bool) (Client, error) {
+// New3EClientWithConn returns client that communicates with PLC over conn that is already connected.
+// conn can be any transport like net.Pipe for testing. Reconnect is not supported by this client
+// because the client does not know how to connect to PLC.
+func New3EClientWithConn(conn net.Conn, stn *station, opts ...Option) Client {
+	newClient := &client3E{stn: stn, opts: newOptions(opts)}
+	if newClient.opts.slmp {
+		newClient.stn = stn.slmpHelper()
+	}
+	newClient.setConnHelper(conn)
+	if newClient.opts.autoDetect {
+		newClient.detectHelper()
+	}
+	newClient.startHeartbeat()
+	return newClient
+}
+
+func New3EClient(host string, port int, stn *station, keep_alive bool, opts ...Option) (Client, error) {
 	//tcpAddr, err := net.ResolveTCPAddr("tcp", fmt.Sprintf("%v:%v", host, port))
 	// if err != nil {
 	// 	return nil, err
 	// }
-	newClient := client3E{tcpAddr: fmt.Sprintf("%v:%v", host, port), stn: stn}
+	newClient := &client3E{stn: stn, opts: newOptions(opts)}
+	if newClient.opts.slmp {
+		newClient.stn = stn.slmpHelper()
+	}
+	newClient.addrs = append([]string{fmt.Sprintf("%v:%v", host, port)}, newClient.opts.failoverAddrs...)
+	newClient.opts.keepAlive = keep_alive
 	err := newClient.Connect()
 	if err != nil {
 		return nil, err
 	}
-	//newClient.conn.SetKeepAlive(keep_alive)
+	if newClient.opts.autoDetect {
+		newClient.detectHelper()
+	}
+	newClient.startHeartbeat()
+	newClient.startRedundancy()
 
-	return &newClient, nil
+	return newClient, nil
 }
 
 // MELSECコミュニケーションプロトコル p180
 // 11.4折返しテスト
 func (c *client3E) HealthCheck() error {
-	requestStr := c.stn.BuildHealthCheckRequest()
+	return c.HealthCheckContext(context.Background())
+}
+
+// HealthCheckContext is HealthCheck that is aborted when ctx is canceled or its deadline exceeded.
+func (c *client3E) HealthCheckContext(ctx context.Context) error {
+	frame := c.stn.AppendHealthCheckRequest(nil)
 
-	// binary protocol
-	payload, err := hex.DecodeString(requestStr)
+	// 折返しデータ数[2byte] + 折返しデータ[n byte] is returned as payload
+	expected := frame[len(frame)-2-len(healthCheckData):]
+
+	payload, err := payloadHelper(c.requestHelper(ctx, &Request{Op: OpHealthCheck}, frame, 22+int64(len(expected))))
 	if err != nil {
 		return err
 	}
 
-	// Send message
-	if _, err = c.conn.Write(payload); err != nil {
-		return err
+	if !bytes.Equal(payload, expected) {
+		return errors.New("plc connect test is fail: return data is [" + fmt.Sprintf("%X", payload) + "]")
 	}
 
-	// Receive message
-	readBuff := make([]byte, 30)
-	readLen, err := c.conn.Read(readBuff)
-	if err != nil {
-		return err
-	}
+	return nil
+}
 
-	resp := readBuff[:readLen]
+func (c *client3E) Connect() error {
+	return c.ConnectContext(context.Background())
+}
 
-	if readLen != 18 {
-		return errors.New("plc connect test is fail: return length is [" + fmt.Sprintf("%X", resp) + "]")
-	}
+// ConnectContext is Connect that is aborted when ctx is canceled or its deadline exceeded.
+// When failover endpoints are given, they are tried in order from the active endpoint.
+func (c *client3E) ConnectContext(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.connectHelper(ctx)
+}
 
-	// decodeString is 折返しデータ数ヘッダ[1byte]
-	if "0500" != fmt.Sprintf("%X", resp[11:13]) {
-		return errors.New("plc connect test is fail: return header is [" + fmt.Sprintf("%X", resp[11:13]) + "]")
+// connectHelper connects to the endpoints from the active one. c.mu must be held.
+func (c *client3E) connectHelper(ctx context.Context) error {
+	if len(c.addrs) == 0 {
+		return errors.New("client has no plc address to connect")
 	}
 
-	//  折返しデータ[5byte]=ABCDE
-	if "4142434445" != fmt.Sprintf("%X", resp[13:18]) {
-		return errors.New("plc connect test is fail: return body is [" + fmt.Sprintf("%X", resp[13:18]) + "]")
+	var err error
+	for i := 0; i < len(c.addrs); i++ {
+		index := (c.active + i) % len(c.addrs)
+		if err = c.dialHelper(ctx, c.addrs[index]); err == nil {
+			c.active = index
+			if c.opts.onConnect != nil {
+				c.opts.onConnect(c.addrs[index])
+			}
+			c.emitHelper(Connected, nil)
+			return nil
+		}
+		if ctx.Err() != nil {
+			break
+		}
 	}
-
-	return nil
+	return err
 }
 
-func (c *client3E) Connect() error {
-	dialer := net.Dialer{Timeout: 3 * time.Second}
-	conn, err := dialer.Dial("tcp", c.tcpAddr)
+// dialHelper connects to addr and replaces connection.
+func (c *client3E) dialHelper(ctx context.Context, addr string) error {
+	dial := c.opts.dialContext
+	if dial == nil {
+		dialer := net.Dialer{Timeout: 3 * time.Second}
+		if c.opts.localAddr != nil {
+			dialer.LocalAddr = c.opts.localAddr
+		}
+		dial = dialer.DialContext
+	}
+	conn, err := dial(ctx, "tcp", addr)
 	if err != nil {
 		return err
 	}
 
-	c.conn, _ = conn.(*net.TCPConn)
+	// connection of custom dial like userspace VPN may not be tcp connection, and socket options are not applied to it
+	if tcpConn, ok := conn.(*net.TCPConn); ok {
+		if err := c.opts.applySocketOptions(tcpConn); err != nil {
+			conn.Close()
+			return err
+		}
+	}
+
+	c.setConnHelper(conn)
 	return nil
 }
 
+// setConnHelper replaces connection. responses of 4E frame are read by demux.
+func (c *client3E) setConnHelper(conn net.Conn) {
+	if c.opts.recorder != nil {
+		conn = &recordConn{Conn: conn, recorder: c.opts.recorder}
+	}
+	c.conn = conn
+	c.stale = 0
+	atomic.StoreInt32(&c.heartbeat.unhealthy, 0)
+	if c.opts.frame4E {
+		c.mux = newDemux(conn, c.dumpHelper)
+	}
+}
+
+// Reconnect closes connection and connects to PLC again by backoff policy.
+// default policy waits 1 second and tries once. see WithBackoff.
 func (c *client3E) Reconnect() error {
-	c.ShutDown()
-	time.Sleep(1 * time.Second)
-	return c.Connect()
+	return c.ReconnectUntil(context.Background())
 }
 
 // Read is send read as word command to remote plc by mc protocol
 // deviceName is device code name like 'D' register.
 // offset is device offset addr.
 // numPoints is number of read device points.
+// Read returns payload of response. If PLC returns abnormal end code, *EndCodeError is returned.
+// numPoints larger than MAX_READ_POINTS or limit of WithProfile is split into multiple requests and their payloads are joined.
 func (c *client3E) Read(deviceName string, offset, numPoints int64) ([]byte, error) {
-	return c.readHelper(c.stn.BuildReadRequest(deviceName, offset, numPoints), numPoints)
+	return c.ReadContext(context.Background(), deviceName, offset, numPoints)
+}
+
+// ReadContext is Read that is aborted when ctx is canceled or its deadline exceeded.
+func (c *client3E) ReadContext(ctx context.Context, deviceName string, offset, numPoints int64) ([]byte, error) {
+	return c.readChunksHelper(ctx, OpRead, deviceName, offset, numPoints, c.readLimitHelper(OpRead), c.stationHelper(ctx).AppendReadRequest)
+}
+
+// ReadRaw is Read that returns raw response including header.
+// it has no context, so it always accesses the station of the client regardless of WithTargetStation.
+func (c *client3E) ReadRaw(deviceName string, offset, numPoints int64) ([]byte, error) {
+	frame, err := c.stn.AppendReadRequest(nil, deviceName, offset, numPoints)
+	if err != nil {
+		return nil, err
+	}
+	return c.readHelper(context.Background(), &Request{Op: OpRead, DeviceName: deviceName, Offset: offset, NumPoints: numPoints}, frame, numPoints)
 }
 
 // BitRead is send read as bit command to remote plc by mc protocol
@@ -116,30 +282,65 @@ func (c *client3E) Read(deviceName string, offset, numPoints int64) ([]byte, err
 // offset is device offset addr.
 // numPoints is number of read device points.
 // results of payload of BitRead will return []byte contains 0, 1, 16 or 17(hex encoded 00, 01, 10, 11)
+// numPoints larger than MAX_BIT_READ_POINTS or limit of WithProfile is split into multiple requests and their payloads are joined.
 func (c *client3E) BitRead(deviceName string, offset, numPoints int64) ([]byte, error) {
-	return c.readHelper(c.stn.BuildBitReadRequest(deviceName, offset, numPoints), numPoints)
+	return c.BitReadContext(context.Background(), deviceName, offset, numPoints)
+}
+
+// BitReadContext is BitRead that is aborted when ctx is canceled or its deadline exceeded.
+func (c *client3E) BitReadContext(ctx context.Context, deviceName string, offset, numPoints int64) ([]byte, error) {
+	return c.readChunksHelper(ctx, OpBitRead, deviceName, offset, numPoints, c.readLimitHelper(OpBitRead), c.stationHelper(ctx).AppendBitReadRequest)
 }
 
-func (c *client3E) readHelper(requestStr string, numPoints int64) ([]byte, error) {
-	// TODO binary protocol
-	payload, err := hex.DecodeString(requestStr)
+// BitReadRaw is BitRead that returns raw response including header.
+// it has no context, so it always accesses the station of the client regardless of WithTargetStation.
+func (c *client3E) BitReadRaw(deviceName string, offset, numPoints int64) ([]byte, error) {
+	frame, err := c.stn.AppendBitReadRequest(nil, deviceName, offset, numPoints)
 	if err != nil {
 		return nil, err
 	}
+	return c.readHelper(context.Background(), &Request{Op: OpBitRead, DeviceName: deviceName, Offset: offset, NumPoints: numPoints}, frame, numPoints)
+}
 
-	// Send message
-	if _, err = c.conn.Write(payload); err != nil {
-		return nil, err
-	}
+// readChunksHelper reads numPoints devices by requests of at most maxPoints devices and joins their payloads.
+// maxPoints of bit unit must be even because payload of bit unit has 2 points per 1 byte.
+func (c *client3E) readChunksHelper(ctx context.Context, op Op, deviceName string, offset, numPoints, maxPoints int64, build func([]byte, string, int64, int64) ([]byte, error)) ([]byte, error) {
+	encode := getBuffer()
+	defer putBuffer(encode)
 
-	// Receive message
-	readBuff := make([]byte, 22+2*numPoints) // 22 is response header size. [sub header + network num + unit i/o num + unit station num + response length + response code]
-	readLen, err := c.conn.Read(readBuff)
-	if err != nil {
-		return nil, err
+	var payload []byte
+	for {
+		points := numPoints
+		if points > maxPoints {
+			points = maxPoints
+		}
+
+		frame, err := build((*encode)[:0], deviceName, offset, points)
+		if err != nil {
+			return nil, err
+		}
+		*encode = frame
+		req := &Request{Op: op, DeviceName: deviceName, Offset: offset, NumPoints: points}
+		chunk, err := payloadHelper(c.readHelper(ctx, req, frame, points))
+		if err != nil {
+			return nil, err
+		}
+		if payload == nil && points == numPoints {
+			// not split
+			return chunk, nil
+		}
+		payload = append(payload, chunk...)
+
+		offset += points
+		numPoints -= points
+		if numPoints <= 0 {
+			return payload, nil
+		}
 	}
+}
 
-	return readBuff[:readLen], nil
+func (c *client3E) readHelper(ctx context.Context, req *Request, frame []byte, numPoints int64) ([]byte, error) {
+	return c.requestHelper(ctx, req, frame, 22+2*numPoints) // 22 is response header size. [sub header + network num + unit i/o num + unit station num + response length + response code]
 }
 
 // Write is send write command to remote plc by mc protocol
@@ -148,35 +349,347 @@ func (c *client3E) readHelper(requestStr string, numPoints int64) ([]byte, error
 // writeData is data to write.
 // numPoints is number of write device points.
 // writeData is the data to be written. If writeData is larger than 2*numPoints bytes,
-// data larger than 2*numPoints bytes is ignored.
+// data larger than 2*numPoints bytes is ignored. If writeData is shorter, ErrShortWriteData is returned
+// unless WithZeroPadding is set.
 func (c *client3E) Write(deviceName string, offset, numPoints int64, writeData []byte) ([]byte, error) {
-	return c.writeHelper(c.stn.BuildWriteRequest(deviceName, offset, numPoints, writeData))
+	return c.WriteContext(context.Background(), deviceName, offset, numPoints, writeData)
+}
+
+// WriteContext is Write that is aborted when ctx is canceled or its deadline exceeded.
+func (c *client3E) WriteContext(ctx context.Context, deviceName string, offset, numPoints int64, writeData []byte) ([]byte, error) {
+	if err := c.guardHelper(OpWrite, deviceName, offset, numPoints); err != nil {
+		return nil, err
+	}
+	encode := getBuffer()
+	defer putBuffer(encode)
+	frame, err := c.stationHelper(ctx).AppendWriteRequest((*encode)[:0], deviceName, offset, numPoints, c.padHelper(writeData, WriteDataLen(numPoints, false)))
+	if err != nil {
+		return nil, err
+	}
+	*encode = frame
+	return payloadHelper(c.writeHelper(ctx, &Request{Op: OpWrite, DeviceName: deviceName, Offset: offset, NumPoints: numPoints, WriteData: writeData}, frame))
 }
 
+// WriteRaw is Write that returns raw response including header.
+// it has no context, so it always accesses the station of the client regardless of WithTargetStation.
+func (c *client3E) WriteRaw(deviceName string, offset, numPoints int64, writeData []byte) ([]byte, error) {
+	if err := c.guardHelper(OpWrite, deviceName, offset, numPoints); err != nil {
+		return nil, err
+	}
+	frame, err := c.stn.AppendWriteRequest(nil, deviceName, offset, numPoints, c.padHelper(writeData, WriteDataLen(numPoints, false)))
+	if err != nil {
+		return nil, err
+	}
+	return c.writeHelper(context.Background(), &Request{Op: OpWrite, DeviceName: deviceName, Offset: offset, NumPoints: numPoints, WriteData: writeData}, frame)
+}
+
+// BitWrite is send write as bit command to remote plc by mc protocol
+// writeData has 2 device points per 1 byte. upper 4bit is first point and 1 means ON.
+// WriteBools builds writeData from []bool.
 func (c *client3E) BitWrite(deviceName string, offset, numPoints int64, writeData []byte) ([]byte, error) {
-	return c.writeHelper(c.stn.BuildBitWriteRequest(deviceName, offset, numPoints, writeData))
+	return c.BitWriteContext(context.Background(), deviceName, offset, numPoints, writeData)
+}
+
+// BitWriteContext is BitWrite that is aborted when ctx is canceled or its deadline exceeded.
+func (c *client3E) BitWriteContext(ctx context.Context, deviceName string, offset, numPoints int64, writeData []byte) ([]byte, error) {
+	if err := c.guardHelper(OpBitWrite, deviceName, offset, numPoints); err != nil {
+		return nil, err
+	}
+	encode := getBuffer()
+	defer putBuffer(encode)
+	frame, err := c.stationHelper(ctx).AppendBitWriteRequest((*encode)[:0], deviceName, offset, numPoints, c.padHelper(writeData, WriteDataLen(numPoints, true)))
+	if err != nil {
+		return nil, err
+	}
+	*encode = frame
+	return payloadHelper(c.writeHelper(ctx, &Request{Op: OpBitWrite, DeviceName: deviceName, Offset: offset, NumPoints: numPoints, WriteData: writeData}, frame))
+}
+
+// BitWriteRaw is BitWrite that returns raw response including header.
+// it has no context, so it always accesses the station of the client regardless of WithTargetStation.
+func (c *client3E) BitWriteRaw(deviceName string, offset, numPoints int64, writeData []byte) ([]byte, error) {
+	if err := c.guardHelper(OpBitWrite, deviceName, offset, numPoints); err != nil {
+		return nil, err
+	}
+	frame, err := c.stn.AppendBitWriteRequest(nil, deviceName, offset, numPoints, c.padHelper(writeData, WriteDataLen(numPoints, true)))
+	if err != nil {
+		return nil, err
+	}
+	return c.writeHelper(context.Background(), &Request{Op: OpBitWrite, DeviceName: deviceName, Offset: offset, NumPoints: numPoints, WriteData: writeData}, frame)
+}
+
+// padHelper pads writeData with zero up to writeLen bytes when WithZeroPadding is set.
+func (c *client3E) padHelper(writeData []byte, writeLen int64) []byte {
+	if !c.opts.zeroPadding || int64(len(writeData)) >= writeLen {
+		return writeData
+	}
+	padded := make([]byte, writeLen)
+	copy(padded, writeData)
+	return padded
+}
+
+func (c *client3E) writeHelper(ctx context.Context, req *Request, frame []byte) ([]byte, error) {
+	return c.requestHelper(ctx, req, frame, 22) // 22 is response header size. [sub header + network num + unit i/o num + unit station num + response length + response code]
+}
+
+// requestHelper sends request frame through middleware chain and receives response into buffer of buffSize.
+// req describes the request for middleware.
+func (c *client3E) requestHelper(ctx context.Context, req *Request, frame []byte, buffSize int64) ([]byte, error) {
+	req.frame, req.buffSize = frame, buffSize
+	return c.chainHelper(c.terminalHandler)(ctx, req)
+}
+
+// terminalHandler is the innermost handler of middleware chain that sends request to PLC.
+func (c *client3E) terminalHandler(ctx context.Context, req *Request) ([]byte, error) {
+	if err := c.opts.breaker.allow(); err != nil {
+		return nil, err
+	}
+	frame := req.frame
+	if c.opts.codec != nil {
+		frame = c.opts.codec.EncodeRequest(*req)
+	}
+	defer c.writeLockHelper(ctx, req)()
+	resp, err := c.sendHelper(ctx, frame, req.buffSize)
+	c.opts.breaker.record(ctx, err)
+	return resp, err
+}
+
+// writeLockHelper holds c.rmw for reading while write or command req is sent, so it is not sent between the read and
+// the write of a read-modify-write. requests of the read-modify-write itself do not wait. it returns function to unlock.
+func (c *client3E) writeLockHelper(ctx context.Context, req *Request) func() {
+	if req.Op == OpRead || req.Op == OpBitRead || req.Op == OpHealthCheck || ctx.Value(rmwKey{}) != nil {
+		return func() {}
+	}
+	c.rmw.RLock()
+	return c.rmw.RUnlock
+}
+
+// sendHelper sends request by 3E or 4E frame and receives response.
+func (c *client3E) sendHelper(ctx context.Context, payload []byte, buffSize int64) ([]byte, error) {
+	if c.opts.frame4E {
+		return c.pipelineHelper(ctx, payload)
+	}
+	return c.sendIntoHelper(ctx, payload, make([]byte, buffSize))
+}
+
+// sendIntoHelper sends request by 3E frame and receives response into readBuff.
+// returned response is a slice of readBuff unless the response is larger than readBuff.
+func (c *client3E) sendIntoHelper(ctx context.Context, payload, readBuff []byte) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	resp, err := c.exchangeHelper(ctx, payload, readBuff)
+	if err != nil && ctx.Err() == nil && !errors.Is(err, ErrStrayFrame) && c.recoverHelper(ctx, err) && c.opts.autoReconnect {
+		// retry once on the new connection
+		resp, err = c.exchangeHelper(ctx, payload, readBuff)
+	}
+	if err != nil {
+		return nil, err
+	}
+	c.heartbeat.touch()
+	return resp, nil
+}
+
+// exchangeHelper sends request and receives a response frame into readBuff.
+// readBuff is grown when the response is larger than it.
+func (c *client3E) exchangeHelper(ctx context.Context, request, readBuff []byte) ([]byte, error) {
+	if c.opts.limiter != nil {
+		if err := c.opts.limiter.wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.stale > 0 {
+		// late responses would be taken as response of this request
+		if err := c.drainHelper(ctx); err != nil {
+			return nil, c.lostHelper(err)
+		}
+	}
+
+	var resp []byte
+	var sent bool
+	err := c.withContext(ctx, func() error {
+		// Send message
+		c.dumpHelper("send", request)
+		if _, err := c.conn.Write(request); err != nil {
+			return err
+		}
+		sent = true
+
+		// Receive message
+		var err error
+		resp, err = c.readFrameHelper(readBuff, request)
+		return err
+	})
+	if err != nil {
+		if sent {
+			// response may arrive later
+			c.stale++
+		}
+		return nil, c.lostHelper(err)
+	}
+	c.dumpHelper("recv", resp)
+	if c.opts.codec != nil {
+		// request of codec is not 3E frame
+		return resp, nil
+	}
+	if err := checkResponseHelper(request, resp); err != nil {
+		// response of this request is still to come
+		c.stale++
+		return nil, err
+	}
+	return resp, nil
+}
+
+// readResponseHelper reads exactly one 3E response frame from r.
+// TCP may split a frame into several reads, so the 9 byte header is read first and then the rest of its length field.
+func readResponseHelper(r io.Reader, buff []byte) ([]byte, error) {
+	if len(buff) < 9 {
+		buff = make([]byte, 9)
+	}
+	// header is [sub header + network num + pc num + unit i/o num + unit station num + response length]
+	if _, err := io.ReadFull(r, buff[:9]); err != nil {
+		return nil, err
+	}
+	if buff[0] != 0xD0 || buff[1] != 0x00 {
+		return nil, fmt.Errorf("unexpected sub header of 3E frame response: %02X%02X", buff[0], buff[1])
+	}
+	size := 9 + int(binary.LittleEndian.Uint16(buff[7:9]))
+	if len(buff) < size {
+		grown := make([]byte, size)
+		copy(grown, buff[:9])
+		buff = grown
+	}
+	if _, err := io.ReadFull(r, buff[9:size]); err != nil {
+		return nil, err
+	}
+	return buff[:size], nil
+}
+
+// lostHelper closes connection and marks client unhealthy when err means PLC closed the connection.
+// following requests fail fast by ErrConnectionClosed until client connects again.
+func (c *client3E) lostHelper(err error) error {
+	err = connClosedError(err)
+	if errors.Is(err, ErrConnectionClosed) {
+		c.conn.Close()
+		atomic.StoreInt32(&c.heartbeat.unhealthy, 1)
+	}
+	return err
+}
+
+// recoverHelper connects again after I/O to the connection failed. it returns true when connection is established.
+// with failover endpoints, next request is sent to next endpoint. otherwise reconnects only when auto reconnect is enabled.
+func (c *client3E) recoverHelper(ctx context.Context, cause error) bool {
+	c.emitHelper(Disconnected, cause)
+	switch {
+	case len(c.addrs) > 1:
+		c.conn.Close()
+		c.active = (c.active + 1) % len(c.addrs)
+		c.emitHelper(Reconnecting, cause)
+		return c.connectHelper(ctx) == nil
+	case len(c.addrs) == 1 && c.opts.autoReconnect:
+		return c.reconnectHelper(ctx, cause) == nil
+	}
+	return false
 }
 
-func (c *client3E) writeHelper(requestStr string) ([]byte, error) {
-	payload, err := hex.DecodeString(requestStr)
+// payloadHelper parses raw response and returns its payload.
+// If end code of response is not normal completion, *EndCodeError is returned.
+func payloadHelper(resp []byte, err error) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
-	// Send message
-	if _, err = c.conn.Write(payload); err != nil {
+
+	response, err := NewStrictParser().Do(resp)
+	if err != nil {
 		return nil, err
 	}
 
-	// Receive message
-	readBuff := make([]byte, 22) // 22 is response header size. [sub header + network num + unit i/o num + unit station num + response length + response code]
+	if response.EndCode != 0 {
+		return nil, &EndCodeError{EndCode: response.EndCode, Response: response}
+	}
+
+	return response.Payload, nil
+}
+
+// withContext runs fn with deadline of ctx set to the connection.
+// When ctx is canceled while fn is running, the blocked conn.Read/Write is unblocked and ctx.Err() is returned.
+func (c *client3E) withContext(ctx context.Context, fn func() error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 
-	readLen, err := c.conn.Read(readBuff)
+	deadline, hasDeadline := ctx.Deadline() // zero value means no deadline
+	if err := c.conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+
+	if ctx.Done() == nil {
+		// never canceled
+		return fn()
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		select {
+		case <-ctx.Done():
+			// unblock conn.Read/Write immediately
+			_ = c.conn.SetDeadline(time.Unix(1, 0))
+		case <-done:
+		}
+	}()
+
+	err := fn()
+	close(done)
+	<-stopped
+
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	// connection deadline may expire slightly before ctx does
+	if netErr, ok := err.(net.Error); ok && netErr.Timeout() && hasDeadline && !time.Now().Before(deadline) {
+		return context.DeadlineExceeded
+	}
+	return err
+}
+
+// ReadSharedMemory is send read command of multi-CPU shared memory (U3E0\G) to remote plc by mc protocol
+// cpuNum is multi-CPU number from 1 to 4.
+// offset is G device offset addr.
+// numPoints is number of read device points.
+func (c *client3E) ReadSharedMemory(cpuNum, offset, numPoints int64) ([]byte, error) {
+	deviceName, err := SharedMemoryDevice(cpuNum)
 	if err != nil {
 		return nil, err
 	}
-	return readBuff[:readLen], nil
+	return c.Read(deviceName, offset, numPoints)
+}
+
+// WriteSharedMemory is send write command of multi-CPU shared memory (U3E0\G) to remote plc by mc protocol
+// cpuNum is multi-CPU number from 1 to 4.
+// offset is G device offset addr.
+// numPoints is number of write device points.
+func (c *client3E) WriteSharedMemory(cpuNum, offset, numPoints int64, writeData []byte) ([]byte, error) {
+	deviceName, err := SharedMemoryDevice(cpuNum)
+	if err != nil {
+		return nil, err
+	}
+	return c.Write(deviceName, offset, numPoints, writeData)
 }
 
 func (c *client3E) ShutDown() {
+	c.stopHeartbeat()
+	c.stopRedundancy()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closeHelper()
+}
+
+// closeHelper closes connection. c.mu must be held.
+func (c *client3E) closeHelper() {
 	c.conn.Close()
+	c.emitHelper(Disconnected, nil)
 }
@@ -1,11 +1,21 @@
 package mcp
 
 import (
+	"context"
+	"encoding/binary"
 	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/google/go-cmp/cmp"
+	"io"
+	"net"
 	"os"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 var (
@@ -22,6 +32,227 @@ func init() {
 	}
 }
 
+// newTestServer starts local tcp server that works as PLC.
+// handler receives each request frame and returns response frame. nil response means PLC does not answer.
+func newTestServer(t *testing.T, handler func(req []byte) []byte) (string, int) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveTestConn(conn, handler)
+		}
+	}()
+
+	addr := listener.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port
+}
+
+// serveTestConn answers request frames received from conn by handler until conn is closed.
+// handler always handles 3E frame. 4E frame request is converted to 3E frame and its response is converted back.
+func serveTestConn(conn net.Conn, handler func(req []byte) []byte) {
+	defer conn.Close()
+	for {
+		req, serial, is4E, err := readTestRequest(conn)
+		if err != nil {
+			return
+		}
+		resp := handler(req)
+		if resp == nil {
+			continue
+		}
+		if is4E {
+			resp = append([]byte{0xD4, 0x00, byte(serial), byte(serial >> 8), 0x00, 0x00}, resp[2:]...)
+		}
+		if _, err := conn.Write(resp); err != nil {
+			return
+		}
+	}
+}
+
+// readTestRequest reads a request frame from conn and returns it as 3E frame.
+func readTestRequest(conn net.Conn) ([]byte, uint16, bool, error) {
+	// 3E request header is 9 byte and its last 2 byte is length of the rest.
+	header := make([]byte, 9)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return nil, 0, false, err
+	}
+	var serial uint16
+	is4E := header[0] == 0x54
+	if is4E {
+		// 4E request header has serial number[2byte] and 0000[2byte] more
+		rest := make([]byte, 4)
+		if _, err := io.ReadFull(conn, rest); err != nil {
+			return nil, 0, false, err
+		}
+		serial = binary.LittleEndian.Uint16(header[2:4])
+		header = append([]byte{0x50, 0x00}, append(header[6:], rest...)...)
+	}
+	body := make([]byte, binary.LittleEndian.Uint16(header[7:9]))
+	if _, err := io.ReadFull(conn, body); err != nil {
+		return nil, 0, false, err
+	}
+	return append(header, body...), serial, is4E, nil
+}
+
+// testMemory is device memory of test PLC that handles 3E batch read and write requests of Q series subcommands
+// and loopback test.
+// words and bits are keyed by device code and device number.
+type testMemory struct {
+	mu    sync.Mutex
+	words map[[2]int64]uint16
+	bits  map[[2]int64]bool
+}
+
+func newTestMemory() *testMemory {
+	return &testMemory{words: map[[2]int64]uint16{}, bits: map[[2]int64]bool{}}
+}
+
+func (m *testMemory) handle(req []byte) []byte {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	command := binary.LittleEndian.Uint16(req[11:13])
+	subCommand := binary.LittleEndian.Uint16(req[13:15])
+	offset := int64(req[15]) | int64(req[16])<<8 | int64(req[17])<<16
+	code := int64(req[18])
+	numPoints := int64(binary.LittleEndian.Uint16(req[19:21]))
+	data := req[21:]
+
+	var payload []byte
+	switch {
+	case command == 0x0619:
+		// loopback test returns received data as it is
+		payload = req[15:]
+	case command == 0x0401 && subCommand == 0x0000:
+		for i := int64(0); i < numPoints; i++ {
+			word := m.words[[2]int64{code, offset + i}]
+			payload = append(payload, byte(word), byte(word>>8))
+		}
+	case command == 0x0401 && subCommand == 0x0001:
+		payload = make([]byte, (numPoints+1)/2)
+		for i := int64(0); i < numPoints; i++ {
+			if m.bits[[2]int64{code, offset + i}] {
+				payload[i/2] |= 0x10 >> (4 * (i % 2))
+			}
+		}
+	case command == 0x1401 && subCommand == 0x0000:
+		for i := int64(0); i < numPoints; i++ {
+			m.words[[2]int64{code, offset + i}] = binary.LittleEndian.Uint16(data[2*i:])
+		}
+	case command == 0x1401 && subCommand == 0x0001:
+		for i := int64(0); i < numPoints; i++ {
+			m.bits[[2]int64{code, offset + i}] = data[i/2]&(0x10>>(4*(i%2))) != 0
+		}
+	case command == 0x1402 && subCommand == 0x0000:
+		// random write in word units has no double word access points
+		for i := 0; i < int(req[15]); i++ {
+			d := req[17+6*i:]
+			m.words[[2]int64{int64(d[3]), int64(d[0]) | int64(d[1])<<8 | int64(d[2])<<16}] = binary.LittleEndian.Uint16(d[4:])
+		}
+	case command == 0x1402 && subCommand == 0x0001:
+		for i := 0; i < int(req[15]); i++ {
+			d := req[16+5*i:]
+			m.bits[[2]int64{int64(d[3]), int64(d[0]) | int64(d[1])<<8 | int64(d[2])<<16}] = d[4] == 0x01
+		}
+	default:
+		// command is not supported
+		return append([]byte{0xD0, 0x00, req[2], req[3], req[4], req[5], req[6], 0x0B, 0x00, 0x59, 0xC0}, append(req[2:7], req[11:15]...)...)
+	}
+
+	resp := []byte{0xD0, 0x00, req[2], req[3], req[4], req[5], req[6], 0, 0, 0x00, 0x00}
+	binary.LittleEndian.PutUint16(resp[7:9], uint16(2+len(payload)))
+	return append(resp, payload...)
+}
+
+// newTestMemoryClient returns client connected to test PLC that has device memory.
+func newTestMemoryClient(t *testing.T, opts ...Option) (Client, *testMemory) {
+	t.Helper()
+
+	mem := newTestMemory()
+	host, port := newTestServer(t, mem.handle)
+	client, err := New3EClient(host, port, NewLocalStation(), true, opts...)
+	if err != nil {
+		t.Fatalf("unexpected connect err: %v", err)
+	}
+	t.Cleanup(client.ShutDown)
+	return client, mem
+}
+
+func TestClient3E_ReadContext(t *testing.T) {
+	// PLC that never answers
+	host, port := newTestServer(t, func(req []byte) []byte { return nil })
+
+	client, err := New3EClient(host, port, NewLocalStation(), true)
+	if err != nil {
+		t.Fatalf("unexpected connect err: %v", err)
+	}
+	defer client.ShutDown()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := client.ReadContext(ctx, "D", 100, 1); err != context.DeadlineExceeded {
+		t.Fatalf("expected %v but actual is %v", context.DeadlineExceeded, err)
+	}
+
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel2)
+	if err := client.HealthCheckContext(ctx2); err != context.Canceled {
+		t.Fatalf("expected %v but actual is %v", context.Canceled, err)
+	}
+}
+
+func TestClient3E_ReadPayload(t *testing.T) {
+	host, port := newTestServer(t, func(req []byte) []byte {
+		if req[15] == 0xF4 { // offset 500 is not exists
+			resp, _ := hex.DecodeString(strings.ReplaceAll("d000 00 ff ff03 00 0b00 59c0 00 ff ff03 00 0104 0000", " ", ""))
+			return resp
+		}
+		resp, _ := hex.DecodeString(strings.ReplaceAll("d000 00 ff ff03 00 0400 0000 3412", " ", ""))
+		return resp
+	})
+
+	client, err := New3EClient(host, port, NewLocalStation(), true)
+	if err != nil {
+		t.Fatalf("unexpected connect err: %v", err)
+	}
+	defer client.ShutDown()
+
+	payload, err := client.Read("D", 100, 1)
+	if err != nil {
+		t.Fatalf("unexpected mcp read err: %v", err)
+	}
+	if hex.EncodeToString(payload) != "3412" {
+		t.Fatalf("expected %v but actual is %v", "3412", hex.EncodeToString(payload))
+	}
+
+	raw, err := client.ReadRaw("D", 100, 1)
+	if err != nil {
+		t.Fatalf("unexpected mcp read err: %v", err)
+	}
+	if hex.EncodeToString(raw) != "d00000ffff0300040000003412" {
+		t.Fatalf("expected %v but actual is %v", "d00000ffff0300040000003412", hex.EncodeToString(raw))
+	}
+
+	_, err = client.Read("D", 500, 1)
+	endCodeErr, ok := err.(*EndCodeError)
+	if !ok {
+		t.Fatalf("expected *EndCodeError but actual is %v", err)
+	}
+	if endCodeErr.EndCode != 0xC059 {
+		t.Fatalf("expected %X but actual is %X", 0xC059, endCodeErr.EndCode)
+	}
+}
+
 func TestClient3E_Read(t *testing.T) {
 	// running only when there is and plc that can be accepted mc protocol
 	if testPLCHost == "" {
@@ -43,11 +274,11 @@ func TestClient3E_Read(t *testing.T) {
 		t.Fatalf("unexpected mcp read err: %v", err)
 	}
 
-	if len(resp1) != 13 {
-		t.Fatalf("expected %v but actual is %v", 13, len(resp1))
+	if len(resp1) != 2 {
+		t.Fatalf("expected %v but actual is %v", 2, len(resp1))
 	}
-	if hex.EncodeToString(resp1) != strings.ReplaceAll("d000 00 ff ff03 0004 0000 0000 00", " ", "") {
-		t.Fatalf("expected %v but actual is %v", "d00000ffff0300040000000000", hex.EncodeToString(resp1))
+	if hex.EncodeToString(resp1) != "0000" {
+		t.Fatalf("expected %v but actual is %v", "0000", hex.EncodeToString(resp1))
 	}
 
 	// 3 device
@@ -56,12 +287,12 @@ func TestClient3E_Read(t *testing.T) {
 		t.Fatalf("unexpected mcp read err: %v", err)
 	}
 
-	if len(resp2) != 21 {
-		t.Fatalf("expected %v but actual is %v", 21, len(resp2))
+	if len(resp2) != 10 {
+		t.Fatalf("expected %v but actual is %v", 10, len(resp2))
 	}
 
-	if hex.EncodeToString(resp2) != strings.ReplaceAll("d000 00 ff ff03 000c 0000 0000 000000000000000000", " ", "") {
-		t.Fatalf("expected %v but actual is %v", "d00000ffff03000c00000000000000000000000000", hex.EncodeToString(resp2))
+	if hex.EncodeToString(resp2) != "00000000000000000000" {
+		t.Fatalf("expected %v but actual is %v", "00000000000000000000", hex.EncodeToString(resp2))
 	}
 
 }
@@ -87,11 +318,11 @@ func TestClient3E_BitRead(t *testing.T) {
 		t.Fatalf("unexpected mcp read err: %v", err)
 	}
 
-	if len(resp1) != 12 {
-		t.Fatalf("expected %v but actual is %v", 12, len(resp1))
+	if len(resp1) != 1 {
+		t.Fatalf("expected %v but actual is %v", 1, len(resp1))
 	}
-	if hex.EncodeToString(resp1) != strings.ReplaceAll("d000 00 ff ff03 0003 0000 0000", " ", "") {
-		t.Fatalf("expected %v but actual is %v", "d00000ffff03000300000000", hex.EncodeToString(resp1))
+	if hex.EncodeToString(resp1) != "00" {
+		t.Fatalf("expected %v but actual is %v", "00", hex.EncodeToString(resp1))
 	}
 
 	// 3 device
@@ -100,12 +331,12 @@ func TestClient3E_BitRead(t *testing.T) {
 		t.Fatalf("unexpected mcp read err: %v", err)
 	}
 
-	if len(resp2) != 14 {
-		t.Fatalf("expected %v but actual is %v", 14, len(resp2))
+	if len(resp2) != 3 {
+		t.Fatalf("expected %v but actual is %v", 3, len(resp2))
 	}
 
-	if hex.EncodeToString(resp2) != strings.ReplaceAll("d000 00 ff ff03 0005 0000 0000 0000", " ", "") {
-		t.Fatalf("expected %v but actual is %v", "d00000ffff030005000000000000", hex.EncodeToString(resp2))
+	if hex.EncodeToString(resp2) != "000000" {
+		t.Fatalf("expected %v but actual is %v", "000000", hex.EncodeToString(resp2))
 	}
 
 	// numpoints 5 and 6 will return same responce length
@@ -114,12 +345,12 @@ func TestClient3E_BitRead(t *testing.T) {
 		t.Fatalf("unexpected mcp read err: %v", err)
 	}
 
-	if len(resp2) != 14 {
-		t.Fatalf("expected %v but actual is %v", 14, len(resp3))
+	if len(resp3) != 3 {
+		t.Fatalf("expected %v but actual is %v", 3, len(resp3))
 	}
 
-	if hex.EncodeToString(resp2) != strings.ReplaceAll("d000 00 ff ff03 0005 0000 0000 0000", " ", "") {
-		t.Fatalf("expected %v but actual is %v", "d00000ffff030005000000000000", hex.EncodeToString(resp3))
+	if hex.EncodeToString(resp3) != "000000" {
+		t.Fatalf("expected %v but actual is %v", "000000", hex.EncodeToString(resp3))
 	}
 }
 
@@ -163,3 +394,511 @@ func TestClient3E_Ping(t *testing.T) {
 		t.Fatalf("unexpected error occured %v", err)
 	}
 }
+
+func TestClient3E_KeepAlive(t *testing.T) {
+	host, port := newTestServer(t, func(req []byte) []byte { return nil })
+
+	client, err := New3EClient(host, port, NewLocalStation(), true, WithKeepAlivePeriod(30*time.Second))
+	if err != nil {
+		t.Fatalf("unexpected connect err: %v", err)
+	}
+	defer client.ShutDown()
+
+	opts := client.(*client3E).opts
+	if !opts.keepAlive || opts.keepAlivePeriod != 30*time.Second {
+		t.Fatalf("expected keep-alive of %v but actual is %v %v", 30*time.Second, opts.keepAlive, opts.keepAlivePeriod)
+	}
+}
+
+func TestClient3E_SocketOptions(t *testing.T) {
+	host, port := newTestServer(t, func(req []byte) []byte { return nil })
+
+	local := &net.TCPAddr{IP: net.ParseIP("127.0.0.1")}
+	client, err := New3EClient(host, port, NewLocalStation(), false,
+		WithNoDelay(false), WithLinger(0), WithReadBuffer(8192), WithWriteBuffer(8192), WithLocalAddr(local))
+	if err != nil {
+		t.Fatalf("unexpected connect err: %v", err)
+	}
+	defer client.ShutDown()
+
+	addr := client.(*client3E).conn.LocalAddr().(*net.TCPAddr)
+	if !addr.IP.Equal(local.IP) {
+		t.Fatalf("expected %v but actual is %v", local.IP, addr.IP)
+	}
+}
+
+func TestClient3E_DialContext(t *testing.T) {
+	host, port := newTestServer(t, func(req []byte) []byte { return nil })
+
+	var dialed string
+	dialer := &net.Dialer{Timeout: time.Second}
+	client, err := New3EClient(host, port, NewLocalStation(), false, WithDialContext(func(ctx context.Context, network, address string) (net.Conn, error) {
+		dialed = address
+		return dialer.DialContext(ctx, network, address)
+	}))
+	if err != nil {
+		t.Fatalf("unexpected connect err: %v", err)
+	}
+	defer client.ShutDown()
+
+	if expected := fmt.Sprintf("%v:%v", host, port); dialed != expected {
+		t.Fatalf("expected %v but actual is %v", expected, dialed)
+	}
+
+	if _, err := New3EClient(host, port, NewLocalStation(), false, WithDialContext(func(ctx context.Context, network, address string) (net.Conn, error) {
+		return nil, errors.New("dial is refused")
+	})); err == nil {
+		t.Fatalf("expected dial error but actual is nil")
+	}
+}
+
+func TestClient3E_DialContextPipe(t *testing.T) {
+	// dial of userspace VPN returns connection that is not tcp connection
+	mem := newTestMemory()
+	dial := func(ctx context.Context, network, address string) (net.Conn, error) {
+		clientConn, plcConn := net.Pipe()
+		go serveTestConn(plcConn, mem.handle)
+		return clientConn, nil
+	}
+	client, err := New3EClient("127.0.0.1", 5000, NewLocalStation(), false, WithDialContext(dial), WithNoDelay(true))
+	if err != nil {
+		t.Fatalf("unexpected connect err: %v", err)
+	}
+	defer client.ShutDown()
+
+	if err := client.WriteUint16("D", 100, 0x1234); err != nil {
+		t.Fatalf("unexpected mcp write err: %v", err)
+	}
+	if got := mem.words[[2]int64{0xA8, 100}]; got != 0x1234 {
+		t.Fatalf("expected %X but actual is %X", 0x1234, got)
+	}
+}
+
+func TestClient3E_WithConn(t *testing.T) {
+	mem := newTestMemory()
+	clientConn, plcConn := net.Pipe()
+	go serveTestConn(plcConn, mem.handle)
+
+	client := New3EClientWithConn(clientConn, NewLocalStation())
+	defer client.ShutDown()
+
+	if err := client.WriteUint16("D", 100, 0x1234); err != nil {
+		t.Fatalf("unexpected mcp write err: %v", err)
+	}
+	value, err := client.ReadUint16("D", 100)
+	if err != nil {
+		t.Fatalf("unexpected mcp read err: %v", err)
+	}
+	if value != 0x1234 {
+		t.Fatalf("expected %X but actual is %X", 0x1234, value)
+	}
+
+	if err := client.Connect(); err == nil {
+		t.Fatalf("expected connect error but actual is nil")
+	}
+}
+
+func TestClient3E_Failover(t *testing.T) {
+	// PLC that closes connection soon after accepting it
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	brokenAddr := listener.Addr().(*net.TCPAddr)
+
+	mem := newTestMemory()
+	host, port := newTestServer(t, mem.handle)
+	standbyAddr := fmt.Sprintf("%v:%v", host, port)
+
+	var connected []string
+	client, err := New3EClient(brokenAddr.IP.String(), brokenAddr.Port, NewLocalStation(), false,
+		WithFailover(standbyAddr), WithOnConnect(func(addr string) { connected = append(connected, addr) }))
+	if err != nil {
+		t.Fatalf("unexpected connect err: %v", err)
+	}
+	defer client.ShutDown()
+
+	if _, err := client.ReadUint16("D", 100); err == nil {
+		t.Fatalf("expected error of broken connection but actual is nil")
+	}
+	if _, err := client.ReadUint16("D", 100); err != nil {
+		t.Fatalf("unexpected mcp read err after failover: %v", err)
+	}
+
+	expected := []string{brokenAddr.String(), standbyAddr}
+	if diff := cmp.Diff(connected, expected); diff != "" {
+		t.Errorf("connected endpoints differs: (-got +want)\n%s", diff)
+	}
+}
+
+func TestClient3E_AutoReconnect(t *testing.T) {
+	mem := newTestMemory()
+	mem.words[[2]int64{0xA8, 100}] = 0x1234
+
+	// PLC that drops the first connection without answering
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		for accepted := 0; ; accepted++ {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			if accepted == 0 {
+				conn.Close()
+				continue
+			}
+			go serveTestConn(conn, mem.handle)
+		}
+	}()
+	addr := listener.Addr().(*net.TCPAddr)
+
+	var events []ConnEvent
+	client, err := New3EClient(addr.IP.String(), addr.Port, NewLocalStation(), false,
+		WithAutoReconnect(), WithBackoff(Backoff{Initial: time.Millisecond, MaxAttempts: 3}),
+		WithConnStateHandler(func(e ConnEvent) { events = append(events, e) }))
+	if err != nil {
+		t.Fatalf("unexpected connect err: %v", err)
+	}
+
+	value, err := client.ReadUint16("D", 100)
+	if err != nil {
+		t.Fatalf("unexpected mcp read err: %v", err)
+	}
+	if value != 0x1234 {
+		t.Fatalf("expected %X but actual is %X", 0x1234, value)
+	}
+	client.ShutDown()
+
+	var states []ConnState
+	for _, e := range events {
+		states = append(states, e.State)
+		if e.Addr != addr.String() {
+			t.Errorf("expected %v but actual is %v", addr.String(), e.Addr)
+		}
+	}
+	if diff := cmp.Diff(states, []ConnState{Connected, Disconnected, Reconnecting, Connected, Disconnected}); diff != "" {
+		t.Errorf("connection states differs: (-got +want)\n%s", diff)
+	}
+	if !errors.Is(events[1].Err, ErrConnectionClosed) || !errors.Is(events[2].Err, ErrConnectionClosed) {
+		t.Errorf("expected %v but actual is %v, %v", ErrConnectionClosed, events[1].Err, events[2].Err)
+	}
+}
+
+func TestClient3E_ConnectionClosed(t *testing.T) {
+	// PLC that closes connection without answering
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			readTestRequest(conn)
+			conn.Close()
+		}
+	}()
+	addr := listener.Addr().(*net.TCPAddr)
+
+	client, err := New3EClient(addr.IP.String(), addr.Port, NewLocalStation(), false, WithBackoff(Backoff{Initial: time.Millisecond, MaxAttempts: 1}))
+	if err != nil {
+		t.Fatalf("unexpected connect err: %v", err)
+	}
+	defer client.ShutDown()
+
+	if _, err := client.Read("D", 100, 1); !errors.Is(err, ErrConnectionClosed) {
+		t.Fatalf("expected %v but actual is %v", ErrConnectionClosed, err)
+	}
+	if client.Healthy() {
+		t.Fatalf("expected unhealthy client after connection is closed")
+	}
+	// following requests fail fast
+	if _, err := client.Read("D", 100, 1); !errors.Is(err, ErrConnectionClosed) {
+		t.Fatalf("expected %v but actual is %v", ErrConnectionClosed, err)
+	}
+
+	if err := client.Reconnect(); err != nil {
+		t.Fatalf("unexpected reconnect err: %v", err)
+	}
+	if !client.Healthy() {
+		t.Fatalf("expected healthy client after reconnect")
+	}
+}
+
+func TestClient3E_Frame4E(t *testing.T) {
+	mem := newTestMemory()
+	mem.words[[2]int64{0xA8, 100}] = 0x1234
+	host, port := newTestServer(t, mem.handle)
+
+	client, err := New3EClient(host, port, NewLocalStation(), false, WithFrame4E())
+	if err != nil {
+		t.Fatalf("unexpected connect err: %v", err)
+	}
+	defer client.ShutDown()
+
+	value, err := client.ReadUint16("D", 100)
+	if err != nil {
+		t.Fatalf("unexpected mcp read err: %v", err)
+	}
+	if value != 0x1234 {
+		t.Fatalf("expected %X but actual is %X", 0x1234, value)
+	}
+	if err := client.HealthCheck(); err != nil {
+		t.Fatalf("unexpected health check err: %v", err)
+	}
+}
+
+func TestClient3E_Pipelining(t *testing.T) {
+	// PLC that answers 4E frame requests in reverse order of arrival
+	mem := newTestMemory()
+	for i := int64(0); i < 4; i++ {
+		mem.words[[2]int64{0xA8, i}] = uint16(i)
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		var resps [][]byte
+		for i := 0; i < 4; i++ {
+			req, serial, _, err := readTestRequest(conn)
+			if err != nil {
+				return
+			}
+			resps = append(resps, append([]byte{0xD4, 0x00, byte(serial), byte(serial >> 8), 0x00, 0x00}, mem.handle(req)[2:]...))
+		}
+		for i := len(resps) - 1; i >= 0; i-- {
+			conn.Write(resps[i])
+		}
+	}()
+	addr := listener.Addr().(*net.TCPAddr)
+
+	client, err := New3EClient(addr.IP.String(), addr.Port, NewLocalStation(), false, WithFrame4E())
+	if err != nil {
+		t.Fatalf("unexpected connect err: %v", err)
+	}
+	defer client.ShutDown()
+
+	var wg sync.WaitGroup
+	for i := int64(0); i < 4; i++ {
+		wg.Add(1)
+		go func(offset int64) {
+			defer wg.Done()
+			value, err := client.ReadUint16("D", offset)
+			if err != nil {
+				t.Errorf("unexpected mcp read err: %v", err)
+				return
+			}
+			if int64(value) != offset {
+				t.Errorf("expected %v but actual is %v", offset, value)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestClient3E_HealthCheckLoopback(t *testing.T) {
+	var respHex string
+	host, port := newTestServer(t, func(req []byte) []byte {
+		resp, _ := hex.DecodeString(strings.ReplaceAll(respHex, " ", ""))
+		return resp
+	})
+
+	client, err := New3EClient(host, port, NewLocalStation(), true)
+	if err != nil {
+		t.Fatalf("unexpected connect err: %v", err)
+	}
+	defer client.ShutDown()
+
+	respHex = "d000 00 ff ff03 00 0900 0000 0500 4142434445"
+	if err := client.HealthCheck(); err != nil {
+		t.Fatalf("unexpected health check err: %v", err)
+	}
+
+	respHex = "d000 00 ff ff03 00 0900 0000 0500 4142434446"
+	if err := client.HealthCheck(); err == nil {
+		t.Fatalf("expected error on wrong loopback data but actual is nil")
+	}
+
+	respHex = "d000 00 ff ff03 00 0b00 59c0 00 ff ff03 00 1906 0000"
+	if err := client.HealthCheck(); !errors.As(err, new(*EndCodeError)) {
+		t.Fatalf("expected *EndCodeError but actual is %v", err)
+	}
+}
+
+func TestClient3E_ReadChunks(t *testing.T) {
+	mem := newTestMemory()
+	var points []int64
+	host, port := newTestServer(t, func(req []byte) []byte {
+		mem.mu.Lock()
+		points = append(points, int64(binary.LittleEndian.Uint16(req[19:21])))
+		mem.mu.Unlock()
+		return mem.handle(req)
+	})
+	client, err := New3EClient(host, port, NewLocalStation(), true)
+	if err != nil {
+		t.Fatalf("unexpected connect err: %v", err)
+	}
+	defer client.ShutDown()
+
+	mem.words[[2]int64{0xA8, 0}] = 1
+	mem.words[[2]int64{0xA8, 960}] = 2
+	mem.words[[2]int64{0xA8, 1999}] = 3
+	mem.bits[[2]int64{0x90, 7168}] = true
+
+	payload, err := client.Read("D", 0, 2000)
+	if err != nil {
+		t.Fatalf("unexpected read err: %v", err)
+	}
+	if len(payload) != 4000 {
+		t.Fatalf("expected %v but actual is %v", 4000, len(payload))
+	}
+	if payload[0] != 1 || payload[2*960] != 2 || payload[2*1999] != 3 {
+		t.Fatalf("payload is not joined in order")
+	}
+
+	bits, err := client.BitRead("M", 0, 7170)
+	if err != nil {
+		t.Fatalf("unexpected read err: %v", err)
+	}
+	if len(bits) != 3585 {
+		t.Fatalf("expected %v but actual is %v", 3585, len(bits))
+	}
+	if bits[3584] != 0x10 {
+		t.Fatalf("expected %X but actual is %X", 0x10, bits[3584])
+	}
+
+	mem.mu.Lock()
+	defer mem.mu.Unlock()
+	if diff := cmp.Diff(points, []int64{960, 960, 80, 7168, 2}); diff != "" {
+		t.Errorf("request points differs: (-got +want)\n%s", diff)
+	}
+}
+
+func TestClient3E_WriteShortData(t *testing.T) {
+	client, _ := newTestMemoryClient(t)
+	if _, err := client.Write("D", 100, 2, []byte{0x01}); !errors.Is(err, ErrShortWriteData) {
+		t.Fatalf("expected %v but actual is %v", ErrShortWriteData, err)
+	}
+
+	padded, mem := newTestMemoryClient(t, WithZeroPadding())
+	mem.words[[2]int64{0xA8, 101}] = 0xFFFF
+	if _, err := padded.Write("D", 100, 2, []byte{0x01}); err != nil {
+		t.Fatalf("unexpected write err: %v", err)
+	}
+	mem.mu.Lock()
+	defer mem.mu.Unlock()
+	if mem.words[[2]int64{0xA8, 100}] != 0x0001 || mem.words[[2]int64{0xA8, 101}] != 0x0000 {
+		t.Fatalf("expected %X %X but actual is %X %X", 0x0001, 0x0000, mem.words[[2]int64{0xA8, 100}], mem.words[[2]int64{0xA8, 101}])
+	}
+}
+
+func TestClient3E_ReadValidation(t *testing.T) {
+	var requests int32
+	host, port := newTestServer(t, func(req []byte) []byte {
+		atomic.AddInt32(&requests, 1)
+		return nil
+	})
+	client, err := New3EClient(host, port, NewLocalStation(), true)
+	if err != nil {
+		t.Fatalf("unexpected connect err: %v", err)
+	}
+	defer client.ShutDown()
+
+	if _, err := client.Read("D", -1, 1); !errors.Is(err, ErrInvalidOffset) {
+		t.Fatalf("expected %v but actual is %v", ErrInvalidOffset, err)
+	}
+	if _, err := client.BitRead("M", 0, 0); !errors.Is(err, ErrInvalidPoints) {
+		t.Fatalf("expected %v but actual is %v", ErrInvalidPoints, err)
+	}
+	if _, err := client.Write("D", 0x1000000, 1, []byte{0, 0}); !errors.Is(err, ErrInvalidOffset) {
+		t.Fatalf("expected %v but actual is %v", ErrInvalidOffset, err)
+	}
+	if n := atomic.LoadInt32(&requests); n != 0 {
+		t.Fatalf("expected no request but actual is %v", n)
+	}
+}
+
+func TestClient3E_SplitResponse(t *testing.T) {
+	mem := newTestMemory()
+	mem.words[[2]int64{0xA8, 100}] = 0x1234
+	mem.words[[2]int64{0xA8, 101}] = 0x5678
+	clientConn, plcConn := net.Pipe()
+	go func() {
+		defer plcConn.Close()
+		for {
+			req, _, _, err := readTestRequest(plcConn)
+			if err != nil {
+				return
+			}
+			// send response frame in 3 byte chunks
+			resp := mem.handle(req)
+			for i := 0; i < len(resp); i += 3 {
+				end := i + 3
+				if end > len(resp) {
+					end = len(resp)
+				}
+				if _, err := plcConn.Write(resp[i:end]); err != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	client := New3EClientWithConn(clientConn, NewLocalStation())
+	defer client.ShutDown()
+
+	for i := 0; i < 2; i++ {
+		payload, err := client.Read("D", 100, 2)
+		if err != nil {
+			t.Fatalf("unexpected mcp read err: %v", err)
+		}
+		if expected := []byte{0x34, 0x12, 0x78, 0x56}; !cmp.Equal(payload, expected) {
+			t.Fatalf("expected %X but actual is %X", expected, payload)
+		}
+	}
+}
+
+func TestReadResponseHelper(t *testing.T) {
+	resp, _ := hex.DecodeString("d00000ffff03000600000034127856" + "d00000ffff0300040000000000")
+	r := strings.NewReader(string(resp))
+
+	// the first frame is larger than buffer and the second frame follows it in the same stream
+	first, err := readResponseHelper(r, make([]byte, 11))
+	if err != nil {
+		t.Fatalf("unexpected read err: %v", err)
+	}
+	if expected := resp[:15]; !cmp.Equal(first, expected) {
+		t.Fatalf("expected %X but actual is %X", expected, first)
+	}
+	second, err := readResponseHelper(r, make([]byte, 22))
+	if err != nil {
+		t.Fatalf("unexpected read err: %v", err)
+	}
+	if expected := resp[15:]; !cmp.Equal(second, expected) {
+		t.Fatalf("expected %X but actual is %X", expected, second)
+	}
+}
@@ -0,0 +1,210 @@
+package mcp
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ClockFormat is layout of clock data in SD210 and later. the clock is set to them when SM210 turns ON.
+type ClockFormat int
+
+const (
+	// ClockBCD is clock data of MELSEC-Q/L series. SD210 to SD213 are BCD of year (lower 2 digits) and month,
+	// day and hour, minute and second, and year (upper 2 digits) and day of week in upper and lower byte.
+	ClockBCD ClockFormat = iota
+
+	// ClockBinary is clock data of MELSEC iQ-R and iQ-F series. SD210 to SD216 are binary of year, month, day,
+	// hour, minute, second and day of week.
+	ClockBinary
+)
+
+const (
+	clockDataDevice   = "SD"
+	clockDataOffset   = 210
+	clockSetRequest   = "SM"
+	clockSetReqOffset = 210
+)
+
+// ReadClock reads clock of PLC in format. the clock has no time zone, so it is interpreted in loc.
+func ReadClock(ctx context.Context, client Client, format ClockFormat, loc *time.Location) (time.Time, error) {
+	numPoints := int64(4)
+	if format == ClockBinary {
+		numPoints = 6
+	}
+	payload, err := client.ReadContext(ctx, clockDataDevice, clockDataOffset, numPoints)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if int64(len(payload)) != 2*numPoints {
+		return time.Time{}, fmt.Errorf("invalid payload length: expected %v byte but actual is %v byte", 2*numPoints, len(payload))
+	}
+	words := DecodeWords(payload)
+
+	v := make([]int, 6)
+	if format == ClockBinary {
+		for i := range v {
+			v[i] = int(words[i])
+		}
+	} else {
+		// year, month, day, hour, minute and second are upper and lower byte of SD210 to SD212
+		for i := range v {
+			decoded, err := DecodeBCD16(words[i/2] >> (8 * (1 - i%2)) & 0xFF)
+			if err != nil {
+				return time.Time{}, fmt.Errorf("invalid clock data of plc: %w", err)
+			}
+			v[i] = int(decoded)
+		}
+		century, err := DecodeBCD16(words[3] >> 8)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid clock data of plc: %w", err)
+		}
+		switch {
+		case century > 0:
+			v[0] += 100 * int(century)
+		case v[0] >= 80:
+			// CPU without upper digits of year. 80 to 99 are 1980 to 1999 like time of CPUError
+			v[0] += 1900
+		default:
+			v[0] += 2000
+		}
+	}
+	if v[1] < 1 || v[1] > 12 || v[2] < 1 || v[2] > 31 || v[3] > 23 || v[4] > 59 || v[5] > 59 {
+		return time.Time{}, fmt.Errorf("invalid clock data of plc: %v", v)
+	}
+	return time.Date(v[0], time.Month(v[1]), v[2], v[3], v[4], v[5], 0, loc), nil
+}
+
+// WriteClock sets clock of PLC in format to t in location of t. fraction of second is truncated.
+// clock data is written to SD210 and later and set by turning SM210 from OFF to ON,
+// so PLC applies it at END processing of the scan.
+func WriteClock(ctx context.Context, client Client, format ClockFormat, t time.Time) error {
+	if _, err := client.BitWriteContext(ctx, clockSetRequest, clockSetReqOffset, 1, []byte{0x00}); err != nil {
+		return err
+	}
+	var words []uint16
+	if format == ClockBinary {
+		words = []uint16{uint16(t.Year()), uint16(t.Month()), uint16(t.Day()), uint16(t.Hour()), uint16(t.Minute()), uint16(t.Second()), uint16(t.Weekday())}
+	} else {
+		bcd := func(upper, lower int) uint16 {
+			u, _ := EncodeBCD16(uint16(upper % 100))
+			l, _ := EncodeBCD16(uint16(lower % 100))
+			return u<<8 | l
+		}
+		words = []uint16{bcd(t.Year(), int(t.Month())), bcd(t.Day(), t.Hour()), bcd(t.Minute(), t.Second()), bcd(t.Year()/100, int(t.Weekday()))}
+	}
+	data := make([]byte, 0, 2*len(words))
+	for _, w := range words {
+		data = binary.LittleEndian.AppendUint16(data, w)
+	}
+	if _, err := client.WriteContext(ctx, clockDataDevice, clockDataOffset, int64(len(words)), data); err != nil {
+		return err
+	}
+	_, err := client.BitWriteContext(ctx, clockSetRequest, clockSetReqOffset, 1, []byte{0x10})
+	return err
+}
+
+// ClockCorrection is result of checking clock of a PLC by ClockSync.
+type ClockCorrection struct {
+	// name of the PLC given to Add
+	Name string
+	// clock of PLC and host when PLC is checked
+	PLCTime  time.Time
+	HostTime time.Time
+	// PLCTime - HostTime. clock of PLC has resolution of 1 second
+	Drift time.Duration
+	// true when clock of PLC is set to host clock
+	Corrected bool
+	// error of reading or setting clock
+	Err error
+}
+
+// ClockSync keeps clocks of PLCs within drift of host clock.
+//
+//	sync := mcp.NewClockSync(2*time.Second, time.Local)
+//	sync.Add("line1", client1, mcp.ClockBCD)
+//	sync.Add("line2", client2, mcp.ClockBinary)
+//	sync.Run(ctx, time.Hour, func(c mcp.ClockCorrection) { ... })
+type ClockSync struct {
+	maxDrift time.Duration
+	loc      *time.Location
+
+	mu      sync.Mutex
+	names   []string
+	clients map[string]Client
+	formats map[string]ClockFormat
+}
+
+// NewClockSync returns ClockSync that sets clock of PLC when it differs from host clock more than maxDrift.
+// clocks of PLCs are in loc. maxDrift should be 1 second or more because clock of PLC has resolution of 1 second.
+func NewClockSync(maxDrift time.Duration, loc *time.Location) *ClockSync {
+	return &ClockSync{maxDrift: maxDrift, loc: loc, clients: map[string]Client{}, formats: map[string]ClockFormat{}}
+}
+
+// Add adds PLC of client whose clock data is in format as name. name must be unique.
+func (s *ClockSync) Add(name string, client Client, format ClockFormat) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.clients[name]; ok {
+		return errors.New("plc is duplicated: " + name)
+	}
+	s.names = append(s.names, name)
+	s.clients[name] = client
+	s.formats[name] = format
+	return nil
+}
+
+// Sync checks clocks of all PLCs in the order they are added and corrects them.
+// results of all PLCs are returned, including PLCs within maxDrift and PLCs that failed.
+func (s *ClockSync) Sync(ctx context.Context) []ClockCorrection {
+	s.mu.Lock()
+	names := append([]string(nil), s.names...)
+	s.mu.Unlock()
+
+	results := make([]ClockCorrection, len(names))
+	for i, name := range names {
+		s.mu.Lock()
+		client, format := s.clients[name], s.formats[name]
+		s.mu.Unlock()
+		results[i] = s.syncHelper(ctx, name, client, format)
+	}
+	return results
+}
+
+// Run calls Sync at interval until ctx is done, and reports each result to report. report may be nil.
+func (s *ClockSync) Run(ctx context.Context, interval time.Duration, report func(ClockCorrection)) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		for _, c := range s.Sync(ctx) {
+			if report != nil {
+				report(c)
+			}
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (s *ClockSync) syncHelper(ctx context.Context, name string, client Client, format ClockFormat) ClockCorrection {
+	c := ClockCorrection{Name: name}
+	c.PLCTime, c.Err = ReadClock(ctx, client, format, s.loc)
+	c.HostTime = time.Now().In(s.loc)
+	if c.Err != nil {
+		return c
+	}
+
+	c.Drift = c.PLCTime.Sub(c.HostTime.Truncate(time.Second))
+	if c.Drift <= s.maxDrift && c.Drift >= -s.maxDrift {
+		return c
+	}
+	c.Err = WriteClock(ctx, client, format, time.Now().In(s.loc))
+	c.Corrected = c.Err == nil
+	return c
+}
@@ -0,0 +1,107 @@
+package mcp
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestClockSync(t *testing.T) {
+	client, mem := newTestMemoryClient(t)
+	// SD210 to SD215 is 2020-01-02 03:04:05
+	for i, v := range []uint16{2020, 1, 2, 3, 4, 5} {
+		mem.words[[2]int64{0xA9, 210 + int64(i)}] = v
+	}
+
+	plcTime, err := ReadClock(context.Background(), client, ClockBinary, time.UTC)
+	if err != nil {
+		t.Fatalf("unexpected read clock err: %v", err)
+	}
+	if expected := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC); !plcTime.Equal(expected) {
+		t.Fatalf("expected %v but actual is %v", expected, plcTime)
+	}
+
+	s := NewClockSync(2*time.Second, time.UTC)
+	if err := s.Add("line1", client, ClockBinary); err != nil {
+		t.Fatalf("unexpected add err: %v", err)
+	}
+	if err := s.Add("line1", client, ClockBinary); err == nil {
+		t.Fatalf("expected error for duplicated plc")
+	}
+
+	results := s.Sync(context.Background())
+	if len(results) != 1 || results[0].Err != nil || !results[0].Corrected || results[0].Name != "line1" || results[0].Drift > -time.Hour {
+		t.Fatalf("unexpected result: %+v", results)
+	}
+	mem.mu.Lock()
+	setRequest := mem.bits[[2]int64{0x91, 210}]
+	weekday := mem.words[[2]int64{0xA9, 216}]
+	mem.mu.Unlock()
+	if !setRequest {
+		t.Fatalf("SM210 is not turned ON")
+	}
+	if expected := uint16(time.Now().UTC().Weekday()); weekday != expected {
+		t.Fatalf("expected %v but actual is %v", expected, weekday)
+	}
+
+	// clock set by the test PLC is within drift
+	results = s.Sync(context.Background())
+	if len(results) != 1 || results[0].Err != nil || results[0].Corrected {
+		t.Fatalf("unexpected result: %+v", results)
+	}
+
+	mem.mu.Lock()
+	mem.words[[2]int64{0xA9, 211}] = 13
+	mem.mu.Unlock()
+	if _, err := ReadClock(context.Background(), client, ClockBinary, time.UTC); err == nil {
+		t.Fatalf("expected error for invalid month")
+	}
+}
+
+func TestClockBCD(t *testing.T) {
+	client, mem := newTestMemoryClient(t)
+	// SD210 to SD213 of Q series is 2020-01-02 03:04:05 Thursday in BCD
+	for i, v := range []uint16{0x2001, 0x0203, 0x0405, 0x2004} {
+		mem.words[[2]int64{0xA9, 210 + int64(i)}] = v
+	}
+
+	plcTime, err := ReadClock(context.Background(), client, ClockBCD, time.UTC)
+	if err != nil {
+		t.Fatalf("unexpected read clock err: %v", err)
+	}
+	if expected := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC); !plcTime.Equal(expected) {
+		t.Fatalf("expected %v but actual is %v", expected, plcTime)
+	}
+
+	if err := WriteClock(context.Background(), client, ClockBCD, time.Date(2031, 12, 25, 23, 59, 58, 0, time.UTC)); err != nil {
+		t.Fatalf("unexpected write clock err: %v", err)
+	}
+	mem.mu.Lock()
+	words := []uint16{mem.words[[2]int64{0xA9, 210}], mem.words[[2]int64{0xA9, 211}], mem.words[[2]int64{0xA9, 212}], mem.words[[2]int64{0xA9, 213}], mem.words[[2]int64{0xA9, 214}]}
+	mem.mu.Unlock()
+	// 2031-12-25 is Thursday. SD214 is not written
+	if diff := cmp.Diff(words, []uint16{0x3112, 0x2523, 0x5958, 0x2004, 0}); diff != "" {
+		t.Errorf("clock data differs: (-got +want)\n%s", diff)
+	}
+
+	// CPU without upper digits of year
+	mem.mu.Lock()
+	mem.words[[2]int64{0xA9, 210}], mem.words[[2]int64{0xA9, 213}] = 0x9901, 0x0005
+	mem.mu.Unlock()
+	plcTime, err = ReadClock(context.Background(), client, ClockBCD, time.UTC)
+	if err != nil {
+		t.Fatalf("unexpected read clock err: %v", err)
+	}
+	if plcTime.Year() != 1999 {
+		t.Fatalf("expected %v but actual is %v", 1999, plcTime.Year())
+	}
+
+	mem.mu.Lock()
+	mem.words[[2]int64{0xA9, 210}] = 0x201A
+	mem.mu.Unlock()
+	if _, err := ReadClock(context.Background(), client, ClockBCD, time.UTC); err == nil {
+		t.Fatalf("expected error for invalid BCD")
+	}
+}
@@ -1,8 +1,6 @@
 package mcp
 
 import (
-	"bytes"
-	"encoding/binary"
 	"encoding/hex"
 )
 
@@ -30,7 +28,9 @@ func (c Code) EncodeHex(s string) ([]byte, error) {
 		return nil, err
 	}
 
-	buff := new(bytes.Buffer)
-	_ = binary.Write(buff, binary.LittleEndian, decode)
-	return buff.Bytes(), nil
+	// stored from lower byte to upper byte
+	for i, j := 0, len(decode)-1; i < j; i, j = i+1, j-1 {
+		decode[i], decode[j] = decode[j], decode[i]
+	}
+	return decode, nil
 }
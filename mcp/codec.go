@@ -0,0 +1,91 @@
+package mcp
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// Codec encodes requests to bytes on the wire and decodes responses from them.
+// custom codec lets the client talk exotic frame variants like vendor gateways,
+// keeping transport, retry and middleware of the client. middleware still sees 3E frame of Request.
+type Codec interface {
+	// EncodeRequest returns bytes of req that are sent to PLC. req.Frame returns its 3E frame.
+	EncodeRequest(req Request) []byte
+	// DecodeResponse decodes a response frame read from PLC.
+	DecodeResponse(resp []byte) (*Response, error)
+}
+
+// ResponseReader is implemented by Codec whose response frames are not delimited like 3E frame.
+// without it, response frames are read by data length of 3E frame header.
+type ResponseReader interface {
+	// ReadResponse reads exactly one response frame from r.
+	ReadResponse(r io.Reader) ([]byte, error)
+}
+
+// DefaultCodec is codec of 3E frame that the client uses by default.
+// custom codec can wrap it, e.g. to add a header of gateway to 3E frame.
+var DefaultCodec Codec = codec3E{}
+
+type codec3E struct{}
+
+func (codec3E) EncodeRequest(req Request) []byte {
+	return req.frame
+}
+
+func (codec3E) DecodeResponse(resp []byte) (*Response, error) {
+	return NewStrictParser().Do(resp)
+}
+
+// WithCodec encodes requests and decodes responses by codec instead of 3E frame.
+// requests are sent one by one, so WithFrame4E is ignored.
+func WithCodec(codec Codec) Option {
+	return func(o *options) {
+		o.codec = codec
+	}
+}
+
+// readFrameHelper reads a response frame of request from connection. response decoded by codec is returned as 3E frame.
+// request is nil when late responses are drained.
+func (c *client3E) readFrameHelper(buff, request []byte) ([]byte, error) {
+	if c.opts.codec == nil {
+		return readResponseHelper(c.conn, buff)
+	}
+
+	var raw []byte
+	var err error
+	if r, ok := c.opts.codec.(requestResponseReader); ok {
+		raw, err = r.readResponseOf(c.conn, request)
+	} else if r, ok := c.opts.codec.(ResponseReader); ok {
+		raw, err = r.ReadResponse(c.conn)
+	} else {
+		raw, err = readResponseHelper(c.conn, buff)
+	}
+	if err != nil {
+		return nil, err
+	}
+	response, err := c.opts.codec.DecodeResponse(raw)
+	if err != nil {
+		return nil, err
+	}
+	return encodeResponse(response), nil
+}
+
+// encodeResponse encodes response as 3E frame.
+func encodeResponse(response *Response) []byte {
+	data := response.Payload
+	if response.EndCode != 0 && response.ErrInfo != nil {
+		e := response.ErrInfo
+		data = []byte{e.NetworkNum, e.PCNum}
+		data = binary.LittleEndian.AppendUint16(data, e.UnitIONum)
+		data = append(data, e.UnitStationNum)
+		data = binary.LittleEndian.AppendUint16(data, e.Command)
+		data = binary.LittleEndian.AppendUint16(data, e.SubCommand)
+	}
+
+	resp := []byte{0xD0, 0x00, response.NetworkNum, response.PCNum}
+	resp = binary.LittleEndian.AppendUint16(resp, response.UnitIONum)
+	resp = append(resp, response.UnitStationNum)
+	resp = binary.LittleEndian.AppendUint16(resp, uint16(2+len(data)))
+	resp = binary.LittleEndian.AppendUint16(resp, response.EndCode)
+	return append(resp, data...)
+}
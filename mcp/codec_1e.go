@@ -0,0 +1,121 @@
+package mcp
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// requestResponseReader is implemented by Codec whose response length depends on its request like 1E frame.
+// request is nil when late responses of timed out requests are drained.
+type requestResponseReader interface {
+	readResponseOf(r io.Reader, request []byte) ([]byte, error)
+}
+
+// codec1E sends batch read, batch write and loopback test by A-compatible 1E frame of local station.
+// requests are converted from Request, so other commands are not supported.
+type codec1E struct{}
+
+func (codec1E) EncodeRequest(req Request) []byte {
+	// sub header[1byte] + PC number[1byte] + monitoring timer[2byte]
+	frame := []byte{0x00, 0xFF}
+	frame = binary.LittleEndian.AppendUint16(frame, monitoringTimer)
+	if req.Op == OpHealthCheck {
+		// number of loopback data[1byte] + loopback data
+		frame[0] = 0x16
+		if len(req.frame) > 17 {
+			frame = append(frame, byte(len(req.frame)-17))
+			frame = append(frame, req.frame[17:]...)
+		}
+		return frame
+	}
+
+	switch req.Op {
+	case OpBitRead:
+		frame[0] = 0x00
+	case OpRead:
+		frame[0] = 0x01
+	case OpBitWrite:
+		frame[0] = 0x02
+	case OpWrite:
+		frame[0] = 0x03
+	}
+	// head device[4byte] + device code[2byte] + number of points[1byte] + fixed 00[1byte]
+	frame = binary.LittleEndian.AppendUint32(frame, uint32(req.Offset))
+	frame = binary.LittleEndian.AppendUint16(frame, e1DeviceCodes[req.DeviceName])
+	frame = append(frame, byte(req.NumPoints), 0x00) // 256 points is 0
+	if (req.Op == OpWrite || req.Op == OpBitWrite) && len(req.frame) > 21 {
+		// write data of 3E frame follows device number[3byte] + device code[1byte] + points[2byte]
+		frame = append(frame, req.frame[21:]...)
+	}
+	return frame
+}
+
+func (codec1E) DecodeResponse(resp []byte) (*Response, error) {
+	f, err := decodeFrame1E(resp)
+	if err != nil {
+		return nil, err
+	}
+	if !f.Response {
+		return nil, fmt.Errorf("sub header %02X is not response", f.SubHeader)
+	}
+
+	response := &Response{SubHeader: 0xD000, PCNum: 0xFF, UnitIONum: 0x03FF, EndCode: f.EndCode}
+	switch {
+	case f.EndCode != 0:
+	case f.Command == 0x16:
+		// number of loopback data is 2byte in 3E frame
+		if len(f.Data) > 0 {
+			response.Payload = binary.LittleEndian.AppendUint16(nil, uint16(f.Data[0]))
+			response.Payload = append(response.Payload, f.Data[1:]...)
+		}
+	default:
+		response.Payload = f.Data
+	}
+	response.DataLen = uint16(2 + len(response.Payload))
+	return response, nil
+}
+
+func (codec1E) readResponseOf(r io.Reader, request []byte) ([]byte, error) {
+	if len(request) < 4 {
+		// length of late response is unknown, so everything is read until the deadline of the drain
+		buff := make([]byte, 64)
+		for {
+			if _, err := r.Read(buff); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// sub header[1byte] + complete code[1byte]
+	resp := make([]byte, 2, 64)
+	if _, err := io.ReadFull(r, resp); err != nil {
+		return nil, err
+	}
+	var rest int
+	switch {
+	case resp[1] == 0x5B:
+		// abnormal code[1byte]
+		rest = 1
+	case resp[1] != 0x00:
+	case request[0] == 0x00 && len(request) >= 11:
+		rest = (points1E(request[10]) + 1) / 2
+	case request[0] == 0x01 && len(request) >= 11:
+		rest = 2 * points1E(request[10])
+	case request[0] == 0x16 && len(request) >= 5:
+		rest = 1 + int(request[4])
+	}
+	resp = append(resp, make([]byte, rest)...)
+	if _, err := io.ReadFull(r, resp[2:]); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// points1E returns number of points of 1E request. 0 means 256 points.
+func points1E(b byte) int {
+	if b == 0 {
+		return 256
+	}
+	return int(b)
+}
@@ -0,0 +1,103 @@
+package mcp
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// gatewayCodec wraps 3E frames by "GW" and length of the frame like a vendor gateway.
+type gatewayCodec struct{}
+
+func (gatewayCodec) EncodeRequest(req Request) []byte {
+	frame := DefaultCodec.EncodeRequest(req)
+	return append(binary.BigEndian.AppendUint16([]byte("GW"), uint16(len(frame))), frame...)
+}
+
+func (gatewayCodec) DecodeResponse(resp []byte) (*Response, error) {
+	return DefaultCodec.DecodeResponse(resp[4:])
+}
+
+func (gatewayCodec) ReadResponse(r io.Reader) ([]byte, error) {
+	return readGatewayFrame(r)
+}
+
+func readGatewayFrame(r io.Reader) ([]byte, error) {
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+	if string(header[:2]) != "GW" {
+		return nil, errors.New("invalid gateway header")
+	}
+	frame := make([]byte, 4+binary.BigEndian.Uint16(header[2:]))
+	copy(frame, header)
+	_, err := io.ReadFull(r, frame[4:])
+	return frame, err
+}
+
+func TestClient3E_Codec(t *testing.T) {
+	mem := newTestMemory()
+	mem.words[[2]int64{0xA8, 100}] = 0x1234
+
+	clientConn, plcConn := net.Pipe()
+	t.Cleanup(func() { plcConn.Close() })
+	go func() {
+		for {
+			req, err := readGatewayFrame(plcConn)
+			if err != nil {
+				return
+			}
+			resp := mem.handle(req[4:])
+			if _, err := plcConn.Write(append(binary.BigEndian.AppendUint16([]byte("GW"), uint16(len(resp))), resp...)); err != nil {
+				return
+			}
+		}
+	}()
+
+	client := New3EClientWithConn(clientConn, NewLocalStation(), WithCodec(gatewayCodec{}), WithFrame4E())
+	defer client.ShutDown()
+
+	if err := client.WriteUint16("D", 101, 0x5678); err != nil {
+		t.Fatalf("unexpected mcp write err: %v", err)
+	}
+	payload, err := client.Read("D", 100, 2)
+	if err != nil {
+		t.Fatalf("unexpected mcp read err: %v", err)
+	}
+	if diff := cmp.Diff(payload, []byte{0x34, 0x12, 0x78, 0x56}); diff != "" {
+		t.Errorf("payload differs: (-got +want)\n%s", diff)
+	}
+	if err := client.HealthCheck(); err != nil {
+		t.Fatalf("unexpected health check err: %v", err)
+	}
+
+	// abnormal end code and its error information are kept through codec
+	_, err = client.Execute(0x0403, 0x0000, []byte{0x01, 0x00, 0x64, 0x00, 0x00, 0xA8})
+	var endCodeErr *EndCodeError
+	if !errors.As(err, &endCodeErr) || endCodeErr.EndCode != 0xC059 || endCodeErr.Response.ErrInfo == nil || endCodeErr.Response.ErrInfo.Command != 0x0403 {
+		t.Fatalf("expected end code C059 of command 0403 but actual is %v", err)
+	}
+}
+
+func TestCodec1E(t *testing.T) {
+	stn := NewLocalStation()
+	frame, _ := stn.AppendWriteRequest(nil, "D", 101, 2, []byte{0xCD, 0xAB, 0x01, 0x00})
+	req := Request{Op: OpWrite, DeviceName: "D", Offset: 101, NumPoints: 2, frame: frame}
+	if diff := cmp.Diff(codec1E{}.EncodeRequest(req), []byte{0x03, 0xFF, 0x10, 0x00, 0x65, 0x00, 0x00, 0x00, 0x20, 0x44, 0x02, 0x00, 0xCD, 0xAB, 0x01, 0x00}); diff != "" {
+		t.Errorf("request differs: (-got +want)\n%s", diff)
+	}
+
+	// abnormal response has complete code 5B and abnormal code
+	response, err := codec1E{}.DecodeResponse([]byte{0x83, 0x5B, 0x10})
+	if err != nil {
+		t.Fatalf("unexpected decode err: %v", err)
+	}
+	if response.EndCode != 0x5B10 || response.Payload != nil {
+		t.Fatalf("expected end code 5B10 but actual is %04X % X", response.EndCode, response.Payload)
+	}
+}
@@ -0,0 +1,163 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// FILE_READ_SIZE is max number of bytes of a file read command.
+const FILE_READ_SIZE = 1920
+
+// DeviceComments is device comments keyed by device address like "D100".
+type DeviceComments map[string]string
+
+// Comment returns comment of device. empty string means the device has no comment.
+func (c DeviceComments) Comment(deviceName string, offset int64) string {
+	return c[FormatDevice(deviceName, offset)]
+}
+
+// ReadCommentExport reads device comment export like "COMMENT.CSV" that user exported by GX Works and copied to drive
+// of CPU, and parses it by ParseCommentExport. it does not decode comment files of the project written to CPU.
+// drive is 0 for program memory, 2 for SD memory card and 4 for standard ROM.
+func ReadCommentExport(ctx context.Context, client Client, drive uint16, fileName string) (DeviceComments, error) {
+	data, err := ReadFile(ctx, client, drive, fileName)
+	if err != nil {
+		return nil, err
+	}
+	return ParseCommentExport(bytes.NewReader(data))
+}
+
+// ParseCommentExport parses device comment export of GX Works2 or GX Works3 that has columns of device and comment.
+// the export is CSV or tab separated text in UTF-8, UTF-16 or Shift-JIS. devices without comment are skipped.
+func ParseCommentExport(r io.Reader) (DeviceComments, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	data = decodeGXWorksText(data)
+	if !utf8.Valid(data) {
+		decoded, err := DecodeSJIS(data)
+		if err != nil {
+			return nil, err
+		}
+		data = []byte(decoded)
+	}
+
+	// header row may follow title rows, so find it by column names
+	var rows [][]string
+	header, device, comment := -1, -1, -1
+	for _, comma := range []rune{'\t', ','} {
+		reader := csv.NewReader(bytes.NewReader(data))
+		reader.Comma, reader.FieldsPerRecord, reader.LazyQuotes = comma, -1, true
+		if rows, err = reader.ReadAll(); err != nil {
+			continue
+		}
+		if header, device, comment = commentHeader(rows); header >= 0 {
+			break
+		}
+	}
+	if header < 0 {
+		return nil, errors.New("device comment export needs device and comment columns")
+	}
+
+	comments := DeviceComments{}
+	for _, row := range rows[header+1:] {
+		if device >= len(row) || comment >= len(row) {
+			continue
+		}
+		deviceName, offset, err := ParseDevice(strings.TrimSpace(row[device]))
+		if err != nil {
+			continue
+		}
+		if text := strings.TrimSpace(row[comment]); text != "" {
+			comments[FormatDevice(deviceName, offset)] = text
+		}
+	}
+	return comments, nil
+}
+
+// commentHeader returns index of header row and its device and comment columns. -1 means not found.
+func commentHeader(rows [][]string) (int, int, int) {
+	for i, row := range rows {
+		device, comment := -1, -1
+		for j, column := range row {
+			switch c := strings.ToLower(strings.TrimSpace(column)); {
+			case c == "device" || c == "device name":
+				device = j
+			case comment < 0 && strings.HasPrefix(c, "comment"):
+				comment = j
+			}
+		}
+		if device >= 0 && comment >= 0 {
+			return i, device, comment
+		}
+	}
+	return -1, -1, -1
+}
+
+// ReadFile reads whole file of drive of CPU by file commands open (1827), read (1828) and close (182A).
+// fileName is Shift-JIS like "COMMENT.CSV". the file is opened in read mode without password.
+func ReadFile(ctx context.Context, client Client, drive uint16, fileName string) ([]byte, error) {
+	name, err := EncodeSJIS(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	// password[4byte] + open mode[2byte] + drive[2byte] + file name length[2byte] + file name
+	open := make([]byte, 10, 10+len(name))
+	binary.LittleEndian.PutUint16(open[6:8], drive)
+	binary.LittleEndian.PutUint16(open[8:10], uint16(len(name)))
+	payload, err := client.ExecuteContext(ctx, 0x1827, 0x0000, append(open, name...))
+	if err != nil {
+		return nil, err
+	}
+	if len(payload) < 2 {
+		return nil, errors.New("response of file open is too short")
+	}
+	pointer := binary.LittleEndian.Uint16(payload)
+
+	data, err := readFileHelper(ctx, client, pointer)
+
+	// file pointer[2byte] + close type[2byte]
+	closeData := binary.LittleEndian.AppendUint16(nil, pointer)
+	closeData = binary.LittleEndian.AppendUint16(closeData, 0)
+	if _, closeErr := client.ExecuteContext(ctx, 0x182A, 0x0000, closeData); err == nil && closeErr != nil {
+		return nil, closeErr
+	}
+	return data, err
+}
+
+// readFileHelper reads opened file by FILE_READ_SIZE bytes until short read.
+func readFileHelper(ctx context.Context, client Client, pointer uint16) ([]byte, error) {
+	var data []byte
+	for {
+		// file pointer[2byte] + offset[4byte] + number of bytes[2byte]
+		read := binary.LittleEndian.AppendUint16(nil, pointer)
+		read = binary.LittleEndian.AppendUint32(read, uint32(len(data)))
+		read = binary.LittleEndian.AppendUint16(read, FILE_READ_SIZE)
+		payload, err := client.ExecuteContext(ctx, 0x1828, 0x0000, read)
+		if err != nil {
+			return nil, err
+		}
+
+		// number of bytes read[2byte] + data
+		if len(payload) < 2 {
+			return nil, errors.New("response of file read is too short")
+		}
+		n := int(binary.LittleEndian.Uint16(payload))
+		if len(payload)-2 < n {
+			return nil, fmt.Errorf("%w: file read of %v byte has %v byte", ErrTruncatedResponse, n, len(payload)-2)
+		}
+		data = append(data, payload[2:2+n]...)
+		if n < FILE_READ_SIZE {
+			return data, nil
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// newTestFileServer returns test PLC that answers file commands of files.
+func newTestFileServer(t *testing.T, files map[string][]byte) (Client, *[]uint16) {
+	t.Helper()
+
+	var commands []uint16
+	var opened []byte
+	host, port := newTestServer(t, func(req []byte) []byte {
+		command := binary.LittleEndian.Uint16(req[11:13])
+		commands = append(commands, command)
+		data := req[15:]
+
+		var payload []byte
+		switch command {
+		case 0x1827:
+			name := string(data[10 : 10+binary.LittleEndian.Uint16(data[8:10])])
+			opened = files[name]
+			payload = []byte{0x05, 0x00}
+		case 0x1828:
+			offset := int(binary.LittleEndian.Uint32(data[2:6]))
+			n := min(len(opened)-offset, int(binary.LittleEndian.Uint16(data[6:8])))
+			payload = binary.LittleEndian.AppendUint16(nil, uint16(n))
+			payload = append(payload, opened[offset:offset+n]...)
+		}
+
+		resp := []byte{0xD0, 0x00, req[2], req[3], req[4], req[5], req[6], 0, 0, 0x00, 0x00}
+		binary.LittleEndian.PutUint16(resp[7:9], uint16(2+len(payload)))
+		return append(resp, payload...)
+	})
+	client, err := New3EClient(host, port, NewLocalStation(), true, WithReadOnly())
+	if err != nil {
+		t.Fatalf("unexpected connect err: %v", err)
+	}
+	t.Cleanup(client.ShutDown)
+	return client, &commands
+}
+
+func TestReadFile(t *testing.T) {
+	large := bytes.Repeat([]byte("0123456789"), 400)
+	client, commands := newTestFileServer(t, map[string][]byte{"LARGE.BIN": large})
+
+	data, err := ReadFile(context.Background(), client, 2, "LARGE.BIN")
+	if err != nil {
+		t.Fatalf("unexpected read err: %v", err)
+	}
+	if !bytes.Equal(data, large) {
+		t.Fatalf("expected %v byte but actual is %v byte", len(large), len(data))
+	}
+	// 4000 byte is read by 1920 + 1920 + 160 byte
+	if diff := cmp.Diff(*commands, []uint16{0x1827, 0x1828, 0x1828, 0x1828, 0x182A}); diff != "" {
+		t.Errorf("commands differs: (-got +want)\n%s", diff)
+	}
+}
+
+func TestReadCommentExport(t *testing.T) {
+	export := "\"PLC1\"\n" +
+		"\"Device Name\",\"Comment\"\n" +
+		"\"D100\",\"Tank level\"\n" +
+		"\"M0\",\"\"\n" +
+		"\"X1F\",\"Start button\"\n"
+	sjis, err := EncodeSJIS("Device\tComment\nD200\t温度\n")
+	if err != nil {
+		t.Fatalf("unexpected encode err: %v", err)
+	}
+	client, _ := newTestFileServer(t, map[string][]byte{"COMMENT.CSV": []byte(export), "COMMENT.TXT": sjis})
+
+	comments, err := ReadCommentExport(context.Background(), client, 2, "COMMENT.CSV")
+	if err != nil {
+		t.Fatalf("unexpected read err: %v", err)
+	}
+	if diff := cmp.Diff(comments, DeviceComments{"D100": "Tank level", "X1F": "Start button"}); diff != "" {
+		t.Errorf("comments differs: (-got +want)\n%s", diff)
+	}
+	if comment := comments.Comment("X", 0x1F); comment != "Start button" {
+		t.Fatalf("expected %v but actual is %v", "Start button", comment)
+	}
+
+	comments, err = ReadCommentExport(context.Background(), client, 2, "COMMENT.TXT")
+	if err != nil {
+		t.Fatalf("unexpected read err: %v", err)
+	}
+	if comment := comments.Comment("D", 200); comment != "温度" {
+		t.Fatalf("expected %v but actual is %v", "温度", comment)
+	}
+
+	if _, err := ParseCommentExport(strings.NewReader("a,b\n1,2\n")); err == nil {
+		t.Fatalf("expected error for export without device and comment columns")
+	}
+}
@@ -0,0 +1,80 @@
+package mcp
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"syscall"
+)
+
+// ConnState is state of connection to PLC.
+type ConnState int
+
+const (
+	// Connected is emitted when connection to PLC is established.
+	Connected ConnState = iota
+
+	// Disconnected is emitted when connection is closed by ShutDown or broken by I/O error.
+	Disconnected
+
+	// Reconnecting is emitted before each attempt to connect again.
+	Reconnecting
+
+	// Changeover is emitted when the other system of redundant CPU becomes control system
+	// and client switches to it. Addr is endpoint of the new control system.
+	Changeover
+)
+
+func (s ConnState) String() string {
+	switch s {
+	case Connected:
+		return "Connected"
+	case Disconnected:
+		return "Disconnected"
+	case Reconnecting:
+		return "Reconnecting"
+	case Changeover:
+		return "Changeover"
+	}
+	return "Unknown"
+}
+
+// ConnEvent is change of connection state.
+type ConnEvent struct {
+	State ConnState
+	// endpoint address like "192.168.0.1:5000"
+	Addr string
+	// cause of Disconnected and Reconnecting. nil when connection is closed by ShutDown or on the first attempt
+	Err error
+}
+
+// emitHelper notifies handler and logger of connection state change.
+func (c *client3E) emitHelper(state ConnState, err error) {
+	if c.opts.onConnState == nil && c.opts.logger == nil {
+		return
+	}
+	var addr string
+	if len(c.addrs) > 0 {
+		addr = c.addrs[c.active]
+	}
+	e := ConnEvent{State: state, Addr: addr, Err: err}
+	c.logConnHelper(e)
+	if c.opts.onConnState != nil {
+		c.opts.onConnState(e)
+	}
+}
+
+// ErrConnectionClosed is returned when PLC closed the connection or the connection is already closed.
+// with WithAutoReconnect, client connects again and retries the request.
+var ErrConnectionClosed = errors.New("connection is closed")
+
+// connClosedError wraps err by ErrConnectionClosed when err means the connection is lost.
+// read of closed connection returns io.EOF, or io.ErrUnexpectedEOF in the middle of a frame.
+func connClosedError(err error) error {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, net.ErrClosed) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE) {
+		return fmt.Errorf("%w: %w", ErrConnectionClosed, err)
+	}
+	return err
+}
@@ -0,0 +1,113 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// diagnosis error data of MELSEC-Q/L series. SD0 is error code, SD1 to SD3 are time of the error in BCD,
+// SD4 is category of error information, SD5 to SD15 are common information and SD16 to SD26 are individual information.
+const (
+	errorDataDevice = "SD"
+	errorDataOffset = 0
+	errorDataPoints = 27
+)
+
+// CPUError is self-diagnosis error of CPU.
+type CPUError struct {
+	// error code like 2110 (fuse blown). see manual of the CPU for the meaning
+	Code uint16
+	// time when the error was detected by clock of CPU
+	Time time.Time
+	// category code of common information (lower byte) and individual information (upper byte)
+	Category uint16
+	// common information like module number or file name of the error
+	Common []uint16
+	// individual information like parameter number of the error
+	Detail []uint16
+}
+
+func (e *CPUError) String() string {
+	return fmt.Sprintf("error %v at %v", e.Code, e.Time.Format(time.DateTime))
+}
+
+// ReadCPUError reads the latest self-diagnosis error of CPU from SD0 to SD26 by a batch read.
+// the clock of CPU has no time zone, so time of the error is interpreted in loc. nil means no error.
+func ReadCPUError(ctx context.Context, client Client, loc *time.Location) (*CPUError, error) {
+	payload, err := client.ReadContext(ctx, errorDataDevice, errorDataOffset, errorDataPoints)
+	if err != nil {
+		return nil, err
+	}
+	if len(payload) != 2*errorDataPoints {
+		return nil, fmt.Errorf("invalid payload length: expected %v byte but actual is %v byte", 2*errorDataPoints, len(payload))
+	}
+	words := DecodeWords(payload)
+	if words[0] == 0 {
+		return nil, nil
+	}
+
+	// upper and lower byte of SD1 to SD3 are year and month, day and hour, minute and second
+	v := make([]int, 6)
+	for i := range v {
+		bcd := words[1+i/2] >> (8 * (1 - i%2)) & 0xFF
+		decoded, err := DecodeBCD16(bcd)
+		if err != nil {
+			return nil, fmt.Errorf("invalid error time of plc: %w", err)
+		}
+		v[i] = int(decoded)
+	}
+	// year has lower 2 digits. 80 to 99 are 1980 to 1999
+	year := 2000 + v[0]
+	if v[0] >= 80 {
+		year = 1900 + v[0]
+	}
+	return &CPUError{
+		Code:     words[0],
+		Time:     time.Date(year, time.Month(v[1]), v[2], v[3], v[4], v[5], 0, loc),
+		Category: words[4],
+		Common:   words[5:16],
+		Detail:   words[16:27],
+	}, nil
+}
+
+// ErrorHistory keeps history of self-diagnosis errors of CPU in the order of detection.
+// CPU reports only the latest error by SD devices, so the history is collected by calling Poll periodically.
+type ErrorHistory struct {
+	mu     sync.Mutex
+	size   int
+	errors []*CPUError
+}
+
+// NewErrorHistory returns history that keeps last size errors. zero size means unlimited.
+func NewErrorHistory(size int) *ErrorHistory {
+	return &ErrorHistory{size: size}
+}
+
+// Poll reads the latest error by ReadCPUError and adds it to history when its code or time differs from the last one.
+// it returns the added error. nil means CPU has no error or the error is already in history.
+func (h *ErrorHistory) Poll(ctx context.Context, client Client, loc *time.Location) (*CPUError, error) {
+	e, err := ReadCPUError(ctx, client, loc)
+	if err != nil || e == nil {
+		return nil, err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if n := len(h.errors); n > 0 && h.errors[n-1].Code == e.Code && h.errors[n-1].Time.Equal(e.Time) {
+		return nil, nil
+	}
+	h.errors = append(h.errors, e)
+	if h.size > 0 && len(h.errors) > h.size {
+		h.errors = h.errors[len(h.errors)-h.size:]
+	}
+	return e, nil
+}
+
+// Errors returns errors of history from the oldest.
+func (h *ErrorHistory) Errors() []*CPUError {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]*CPUError{}, h.errors...)
+}
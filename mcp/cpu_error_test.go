@@ -0,0 +1,63 @@
+package mcp
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReadCPUError(t *testing.T) {
+	client, mem := newTestMemoryClient(t)
+	ctx := context.Background()
+
+	e, err := ReadCPUError(ctx, client, time.UTC)
+	if err != nil {
+		t.Fatalf("unexpected read err: %v", err)
+	}
+	if e != nil {
+		t.Fatalf("expected no error but actual is %v", e)
+	}
+
+	// error 2110 at 2024-03-15 10:20:30, module number 0x0010
+	mem.mu.Lock()
+	for offset, word := range map[int64]uint16{0: 2110, 1: 0x2403, 2: 0x1510, 3: 0x2030, 4: 0x0001, 5: 0x0010, 16: 0x0007} {
+		mem.words[[2]int64{0xA9, offset}] = word
+	}
+	mem.mu.Unlock()
+
+	history := NewErrorHistory(2)
+	e, err = history.Poll(ctx, client, time.UTC)
+	if err != nil {
+		t.Fatalf("unexpected read err: %v", err)
+	}
+	want := &CPUError{
+		Code:     2110,
+		Time:     time.Date(2024, 3, 15, 10, 20, 30, 0, time.UTC),
+		Category: 0x0001,
+		Common:   []uint16{0x0010, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+		Detail:   []uint16{0x0007, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+	}
+	if diff := cmp.Diff(e, want); diff != "" {
+		t.Errorf("error differs: (-got +want)\n%s", diff)
+	}
+
+	// the same error is not added again
+	if e, err = history.Poll(ctx, client, time.UTC); err != nil || e != nil {
+		t.Fatalf("expected no new error but actual is %v, %v", e, err)
+	}
+
+	for _, code := range []uint16{3300, 4100} {
+		mem.mu.Lock()
+		mem.words[[2]int64{0xA9, 0}] = code
+		mem.mu.Unlock()
+		if _, err := history.Poll(ctx, client, time.UTC); err != nil {
+			t.Fatalf("unexpected read err: %v", err)
+		}
+	}
+	errs := history.Errors()
+	if len(errs) != 2 || errs[0].Code != 3300 || errs[1].Code != 4100 {
+		t.Fatalf("expected errors 3300 and 4100 but actual is %v", errs)
+	}
+}
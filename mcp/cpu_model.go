@@ -0,0 +1,95 @@
+package mcp
+
+import (
+	"context"
+	"encoding/binary"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// CPUModel is model of CPU read by ReadCPUModel.
+type CPUModel struct {
+	// model name like Q03UDVCPU or FX5U-32MR/ES
+	Name string
+	// model code
+	Code uint16
+}
+
+// ReadCPUModel reads model name and model code of CPU.
+// PLCs of 1E frame like FX3U do not support this command.
+func ReadCPUModel(ctx context.Context, client Client) (CPUModel, error) {
+	payload, err := client.ExecuteContext(ctx, 0x0101, 0x0000, nil)
+	if err != nil {
+		return CPUModel{}, err
+	}
+	// model name[16byte] + model code[2byte]
+	if len(payload) < 18 {
+		return CPUModel{}, ErrTruncatedResponse
+	}
+	return CPUModel{
+		Name: strings.TrimRight(string(payload[:16]), " \x00"),
+		Code: binary.LittleEndian.Uint16(payload[16:18]),
+	}, nil
+}
+
+// ProfileOf returns profile of CPU model name. ok is false when the model has no profile,
+// like MELSEC-Q/L series that can access all devices.
+func ProfileOf(model string) (p Profile, ok bool) {
+	if strings.HasPrefix(model, "FX5") {
+		return FX5U, true
+	}
+	return Profile{}, false
+}
+
+// isIQR returns true when model is MELSEC iQ-R series CPU like R04CPU or R08ENCPU.
+func isIQR(model string) bool {
+	return len(model) > 1 && model[0] == 'R' && '0' <= model[1] && model[1] <= '9'
+}
+
+// WithAutoDetect reads CPU model when client connects to PLC first, and selects profile of the model when no profile is given.
+// iQ-R series CPU is accessed by iQ-R subcommands that have 4byte device number like WithSLMP,
+// so extended devices like J1\W are not supported.
+// it logs by logger of WithLogger when the given profile or frame conflicts with the model, keeping the given one.
+// failure of the read is logged and does not fail the connect. profile of 1E frame is never detected.
+func WithAutoDetect() Option {
+	return func(o *options) {
+		o.autoDetect = true
+	}
+}
+
+// detectHelper reads CPU model and applies it to options. it is called before client is shared with other goroutines.
+func (c *client3E) detectHelper() {
+	if p := c.opts.profile; p != nil && p.Frame == Frame1E {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	model, err := ReadCPUModel(ctx, c)
+	if err != nil {
+		c.detectLogHelper(slog.LevelWarn, "plc cpu model detection failed", slog.Any("err", err))
+		return
+	}
+	c.detectLogHelper(slog.LevelInfo, "plc cpu model detected", slog.String("model", model.Name))
+
+	detected, ok := ProfileOf(model.Name)
+	switch {
+	case c.opts.profile == nil && ok:
+		c.opts.profile = &detected
+	case c.opts.profile != nil && (!ok || c.opts.profile.Name != detected.Name):
+		c.detectLogHelper(slog.LevelWarn, "plc cpu model conflicts with profile",
+			slog.String("model", model.Name), slog.String("profile", c.opts.profile.Name))
+	}
+	if isIQR(model.Name) && !c.opts.slmp {
+		c.opts.slmp = true
+		c.stn = c.stn.slmpHelper()
+	}
+}
+
+func (c *client3E) detectLogHelper(level slog.Level, msg string, attrs ...slog.Attr) {
+	if c.opts.logger == nil {
+		return
+	}
+	c.opts.logger.LogAttrs(context.Background(), level, msg, attrs...)
+}
@@ -0,0 +1,85 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// newTestModelClient returns client of PLC of model. it records subcommands of device access.
+func newTestModelClient(t *testing.T, model string, opts ...Option) (Client, func() []uint16) {
+	t.Helper()
+
+	var mu sync.Mutex
+	var subCommands []uint16
+	host, port := newTestServer(t, func(req []byte) []byte {
+		command := binary.LittleEndian.Uint16(req[11:13])
+		subCommand := binary.LittleEndian.Uint16(req[13:15])
+		payload := []byte{0x00, 0x00}
+		if command == 0x0101 {
+			payload = append([]byte(model), bytes.Repeat([]byte(" "), 16-len(model))...)
+			payload = append(payload, 0x41, 0x48)
+		} else {
+			mu.Lock()
+			subCommands = append(subCommands, subCommand)
+			mu.Unlock()
+		}
+		resp := []byte{0xD0, 0x00, req[2], req[3], req[4], req[5], req[6], 0, 0, 0x00, 0x00}
+		binary.LittleEndian.PutUint16(resp[7:9], uint16(2+len(payload)))
+		return append(resp, payload...)
+	})
+	client, err := New3EClient(host, port, NewLocalStation(), true, opts...)
+	if err != nil {
+		t.Fatalf("unexpected connect err: %v", err)
+	}
+	t.Cleanup(client.ShutDown)
+	return client, func() []uint16 {
+		mu.Lock()
+		defer mu.Unlock()
+		return subCommands
+	}
+}
+
+func TestReadCPUModel(t *testing.T) {
+	client, _ := newTestModelClient(t, "Q03UDVCPU")
+	model, err := ReadCPUModel(context.Background(), client)
+	if err != nil {
+		t.Fatalf("unexpected read err: %v", err)
+	}
+	if model != (CPUModel{Name: "Q03UDVCPU", Code: 0x4841}) {
+		t.Fatalf("expected %v but actual is %v", "Q03UDVCPU", model)
+	}
+}
+
+func TestWithAutoDetect(t *testing.T) {
+	// FX5U has no ZR
+	client, _ := newTestModelClient(t, "FX5U-32MR/ES", WithAutoDetect())
+	if _, err := client.Read("ZR", 0, 1); !errors.Is(err, ErrInvalidDevice) {
+		t.Fatalf("expected %v but actual is %v", ErrInvalidDevice, err)
+	}
+
+	// iQ-R is accessed by iQ-R subcommands
+	client, subCommands := newTestModelClient(t, "R04CPU", WithAutoDetect())
+	if _, err := client.Read("D", 0, 1); err != nil {
+		t.Fatalf("unexpected mcp read err: %v", err)
+	}
+	if got := subCommands(); len(got) != 1 || got[0] != 0x0002 {
+		t.Fatalf("expected %v but actual is %v", []uint16{0x0002}, got)
+	}
+
+	// given profile is kept with warning
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	client, _ = newTestModelClient(t, "Q03UDVCPU", WithAutoDetect(), WithProfile(FX5U), WithLogger(logger))
+	if _, err := client.Read("ZR", 0, 1); !errors.Is(err, ErrInvalidDevice) {
+		t.Fatalf("expected %v but actual is %v", ErrInvalidDevice, err)
+	}
+	if !strings.Contains(buf.String(), "plc cpu model conflicts with profile") {
+		t.Fatalf("expected warning but log is %v", buf.String())
+	}
+}
@@ -0,0 +1,87 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+)
+
+// operating status of MELSEC-Q/L series. lower 4 bits of SD203 are operating status (0: RUN, 1: STOP, 2: PAUSE) and
+// upper 4 bits of the lower byte are cause of STOP or PAUSE.
+const (
+	statusDevice = "SD"
+	statusOffset = 203
+)
+
+// CPUMode is operating status of CPU in the encoding of SD203 of MELSEC-Q/L series.
+type CPUMode int
+
+const (
+	CPURun CPUMode = iota
+	CPUStop
+	CPUPause
+)
+
+func (m CPUMode) String() string {
+	switch m {
+	case CPURun:
+		return "RUN"
+	case CPUStop:
+		return "STOP"
+	case CPUPause:
+		return "PAUSE"
+	}
+	return "Unknown"
+}
+
+// StopCause is cause of the latest change to STOP or PAUSE.
+type StopCause int
+
+const (
+	CauseSwitch StopCause = iota
+	CauseRemoteContact
+	CauseRemote
+	CauseInstruction
+	CauseError
+)
+
+func (c StopCause) String() string {
+	switch c {
+	case CauseSwitch:
+		return "RUN/STOP switch"
+	case CauseRemoteContact:
+		return "remote contact"
+	case CauseRemote:
+		return "remote operation from peripheral or network"
+	case CauseInstruction:
+		return "instruction of program"
+	case CauseError:
+		return "error"
+	}
+	return "Unknown"
+}
+
+// CPUStatus is operating status of CPU and the cause of it.
+type CPUStatus struct {
+	Mode CPUMode
+	// Cause is meaningful only when Mode is CPUStop or CPUPause
+	Cause StopCause
+}
+
+func (s CPUStatus) String() string {
+	if s.Mode == CPUStop || s.Mode == CPUPause {
+		return fmt.Sprintf("%v by %v", s.Mode, s.Cause)
+	}
+	return s.Mode.String()
+}
+
+// ReadCPUStatus reads operating status of CPU like RUN and STOP from SD203 of MELSEC-Q/L series.
+func ReadCPUStatus(ctx context.Context, client Client) (CPUStatus, error) {
+	payload, err := client.ReadContext(ctx, statusDevice, statusOffset, 1)
+	if err != nil {
+		return CPUStatus{}, err
+	}
+	if len(payload) != 2 {
+		return CPUStatus{}, fmt.Errorf("invalid payload length: expected 2 byte but actual is %v byte", len(payload))
+	}
+	return CPUStatus{Mode: CPUMode(payload[0] & 0x0F), Cause: StopCause(payload[0] >> 4)}, nil
+}
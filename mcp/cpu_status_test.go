@@ -0,0 +1,39 @@
+package mcp
+
+import (
+	"context"
+	"testing"
+)
+
+func TestReadCPUStatus(t *testing.T) {
+	client, mem := newTestMemoryClient(t)
+
+	cases := []struct {
+		sd203    uint16
+		expected CPUStatus
+		str      string
+	}{
+		{sd203: 0x0000, expected: CPUStatus{Mode: CPURun}, str: "RUN"},
+		{sd203: 0x0001, expected: CPUStatus{Mode: CPUStop, Cause: CauseSwitch}, str: "STOP by RUN/STOP switch"},
+		{sd203: 0x0011, expected: CPUStatus{Mode: CPUStop, Cause: CauseRemoteContact}, str: "STOP by remote contact"},
+		{sd203: 0x0041, expected: CPUStatus{Mode: CPUStop, Cause: CauseError}, str: "STOP by error"},
+		{sd203: 0x0022, expected: CPUStatus{Mode: CPUPause, Cause: CauseRemote}, str: "PAUSE by remote operation from peripheral or network"},
+		{sd203: 0x0003, expected: CPUStatus{Mode: CPUMode(3)}, str: "Unknown"},
+	}
+	for _, v := range cases {
+		mem.mu.Lock()
+		mem.words[[2]int64{0xA9, 203}] = v.sd203
+		mem.mu.Unlock()
+
+		status, err := ReadCPUStatus(context.Background(), client)
+		if err != nil {
+			t.Fatalf("unexpected read err: %v", err)
+		}
+		if status != v.expected {
+			t.Errorf("expected %v but actual is %v", v.expected, status)
+		}
+		if status.String() != v.str {
+			t.Errorf("expected %v but actual is %v", v.str, status.String())
+		}
+	}
+}
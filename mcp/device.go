@@ -0,0 +1,104 @@
+package mcp
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// hexDevices is device names whose device number is hexadecimal.
+var hexDevices = map[string]bool{
+	"X":  true,
+	"Y":  true,
+	"B":  true,
+	"W":  true,
+	"SB": true,
+	"SW": true,
+}
+
+// ParseDevice parses device address like D100, X1F or J1\W10 to device name and offset.
+// device number of X, Y, B, W, SB and SW is hexadecimal, others are decimal.
+func ParseDevice(address string) (string, int64, error) {
+	// qualifier like J1\ or U3E0\ belongs to device name
+	qualifier := ""
+	if i := strings.LastIndex(address, `\`); i >= 0 {
+		qualifier, address = address[:i+1], address[i+1:]
+	}
+
+	deviceName := knownDeviceName(strings.ToUpper(address))
+	if deviceName == "" {
+		// unknown device name ends at the first digit
+		i := strings.IndexFunc(address, func(r rune) bool { return '0' <= r && r <= '9' })
+		if i <= 0 {
+			return "", 0, errors.New("invalid device address: " + qualifier + address)
+		}
+		deviceName = strings.ToUpper(address[:i])
+	}
+	number := address[len(deviceName):]
+
+	base := 10
+	if hexDevices[deviceName] {
+		base = 16
+	}
+	offset, err := strconv.ParseInt(number, base, 64)
+	if err != nil {
+		return "", 0, errors.New("invalid device address: " + qualifier + address)
+	}
+	return qualifier + deviceName, offset, nil
+}
+
+// knownDeviceName returns the longest device name of DeviceCodes and IQRDeviceCodes that address starts with.
+// hex device number may start with A-F like XA0, so device name cannot end at the first digit.
+func knownDeviceName(address string) string {
+	var name string
+	for _, codes := range []map[string]string{DeviceCodes, IQRDeviceCodes} {
+		for n := range codes {
+			if len(n) > len(name) && len(n) < len(address) && strings.HasPrefix(address, n) {
+				name = n
+			}
+		}
+	}
+	return name
+}
+
+// FormatDevice formats device name and offset to device address like D100 or X1F. it is the reverse of ParseDevice.
+// empty device name is formatted like ?100.
+func FormatDevice(deviceName string, offset int64) string {
+	if deviceName == "" {
+		deviceName = "?"
+	}
+	name := deviceName
+	if i := strings.LastIndex(name, `\`); i >= 0 {
+		name = name[i+1:]
+	}
+	if hexDevices[name] {
+		return deviceName + strings.ToUpper(strconv.FormatInt(offset, 16))
+	}
+	return deviceName + strconv.FormatInt(offset, 10)
+}
+
+// bitDevices is device names of bit devices. word unit access to them covers 16 points per word.
+var bitDevices = map[string]bool{
+	"X":  true,
+	"Y":  true,
+	"SM": true,
+	"M":  true,
+	"L":  true,
+	"F":  true,
+	"V":  true,
+	"B":  true,
+	"SB": true,
+	"S":  true,
+	"TS": true,
+	"TC": true,
+	"CS": true,
+	"CC": true,
+}
+
+// IsBitDevice returns true when device name like M or J1\X is a bit device. word unit access to it covers 16 points per word.
+func IsBitDevice(deviceName string) bool {
+	if i := strings.LastIndex(deviceName, `\`); i >= 0 {
+		deviceName = deviceName[i+1:]
+	}
+	return bitDevices[deviceName]
+}
@@ -0,0 +1,54 @@
+package mcp
+
+import "testing"
+
+func TestParseDevice(t *testing.T) {
+	cases := []struct {
+		input      string
+		deviceName string
+		offset     int64
+	}{
+		{input: "D100", deviceName: "D", offset: 100},
+		{input: "X1F", deviceName: "X", offset: 0x1F},
+		{input: "sw10", deviceName: "SW", offset: 0x10},
+		{input: `J1\W100`, deviceName: `J1\W`, offset: 0x100},
+		{input: `U3E0\G10000`, deviceName: `U3E0\G`, offset: 10000},
+		{input: "XA0", deviceName: "X", offset: 0xA0},
+		{input: "YF", deviceName: "Y", offset: 0xF},
+		{input: "BA", deviceName: "B", offset: 0xA},
+		{input: "WFF", deviceName: "W", offset: 0xFF},
+		{input: "SBC", deviceName: "SB", offset: 0xC},
+		{input: "SWA", deviceName: "SW", offset: 0xA},
+		{input: `J1\WFF`, deviceName: `J1\W`, offset: 0xFF},
+		{input: "ZR100", deviceName: "ZR", offset: 100},
+	}
+
+	for _, v := range cases {
+		deviceName, offset, err := ParseDevice(v.input)
+		if err != nil {
+			t.Errorf("unexpected err: input is %v: %v", v.input, err)
+			continue
+		}
+		if deviceName != v.deviceName || offset != v.offset {
+			t.Errorf("expected %v %v but actual is %v %v", v.deviceName, v.offset, deviceName, offset)
+		}
+	}
+
+	for _, input := range []string{"", "100", "D", "X", "DXYZ", "D1A", "XG"} {
+		if _, _, err := ParseDevice(input); err == nil {
+			t.Errorf("expected error: input is %v", input)
+		}
+	}
+}
+
+func TestFormatDevice(t *testing.T) {
+	for _, address := range []string{"D100", "X1F", "XA0", "YF", "BA", "WFF", "SBC", "SWA", `J1\W100`, `U3E0\G10000`} {
+		deviceName, offset, err := ParseDevice(address)
+		if err != nil {
+			t.Fatalf("unexpected err: input is %v: %v", address, err)
+		}
+		if actual := FormatDevice(deviceName, offset); actual != address {
+			t.Errorf("expected %v but actual is %v", address, actual)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// special registers of MELSEC-Q/L series read by ReadDiagnostics. they are read by a batch read of SD0 to SD527.
+const (
+	diagDevice      = "SD"
+	diagPoints      = 528
+	diagErrorCode   = 0
+	diagBattery     = 52
+	diagBatteryLat  = 51
+	diagScanTime    = 520
+	diagMinScanTime = 524
+	diagMaxScanTime = 526
+)
+
+// Diagnostics is key self-diagnostic information of CPU.
+type Diagnostics struct {
+	// current self-diagnosis error code of SD0. 0 means no error
+	ErrorCode uint16
+	// current, minimum and maximum scan time of SD520 to SD527
+	ScanTime    time.Duration
+	MinScanTime time.Duration
+	MaxScanTime time.Duration
+	// battery of CPU or memory card is low now (SD52) or has been low since reset (SD51)
+	BatteryLow      bool
+	BatteryLowLatch bool
+}
+
+// ReadDiagnostics reads error code, scan time and battery status of CPU from SD0 to SD527 by a batch read.
+func ReadDiagnostics(ctx context.Context, client Client) (*Diagnostics, error) {
+	payload, err := client.ReadContext(ctx, diagDevice, 0, diagPoints)
+	if err != nil {
+		return nil, err
+	}
+	if len(payload) != 2*diagPoints {
+		return nil, fmt.Errorf("invalid payload length: expected %v byte but actual is %v byte", 2*diagPoints, len(payload))
+	}
+	words := DecodeWords(payload)
+
+	// scan time is ms in the first word and us in the second word
+	scanTime := func(offset int) time.Duration {
+		return time.Duration(words[offset])*time.Millisecond + time.Duration(words[offset+1])*time.Microsecond
+	}
+	return &Diagnostics{
+		ErrorCode:       words[diagErrorCode],
+		ScanTime:        scanTime(diagScanTime),
+		MinScanTime:     scanTime(diagMinScanTime),
+		MaxScanTime:     scanTime(diagMaxScanTime),
+		BatteryLow:      words[diagBattery] != 0,
+		BatteryLowLatch: words[diagBatteryLat] != 0,
+	}, nil
+}
@@ -0,0 +1,34 @@
+package mcp
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReadDiagnostics(t *testing.T) {
+	client, mem := newTestMemoryClient(t)
+
+	mem.mu.Lock()
+	for offset, word := range map[int64]uint16{0: 1600, 51: 0x0001, 520: 3, 521: 250, 524: 2, 525: 900, 526: 12, 527: 0} {
+		mem.words[[2]int64{0xA9, offset}] = word
+	}
+	mem.mu.Unlock()
+
+	diag, err := ReadDiagnostics(context.Background(), client)
+	if err != nil {
+		t.Fatalf("unexpected read err: %v", err)
+	}
+	want := &Diagnostics{
+		ErrorCode:       1600,
+		ScanTime:        3250 * time.Microsecond,
+		MinScanTime:     2900 * time.Microsecond,
+		MaxScanTime:     12 * time.Millisecond,
+		BatteryLowLatch: true,
+	}
+	if diff := cmp.Diff(diag, want); diff != "" {
+		t.Errorf("diagnostics differs: (-got +want)\n%s", diff)
+	}
+}
@@ -0,0 +1,50 @@
+package mcp
+
+import (
+	"context"
+	"log/slog"
+)
+
+// WithDryRun builds write requests and Execute of commands that may change PLC but does not send them to PLC, so new deployments can be validated
+// against a production PLC without risk. read requests are sent as usual.
+// fn is called with decoded frame of each write request that is not sent, and it may be nil.
+// the writes are also logged to logger of WithLogger at Info level.
+// writes succeed as if PLC returned normal completion, and they are not audited by WithAuditHook.
+func WithDryRun(fn func(*Frame)) Option {
+	return func(o *options) {
+		o.dryRun = true
+		o.onDryRun = fn
+	}
+}
+
+// dryRunMiddleware answers write requests with normal completion without calling next.
+func (c *client3E) dryRunMiddleware(next Handler) Handler {
+	return func(ctx context.Context, req *Request) ([]byte, error) {
+		if req.Op != OpWrite && req.Op != OpBitWrite && (req.Op != OpCommand || readCommands[req.command()]) {
+			return next(ctx, req)
+		}
+
+		// request frame may be reused after return, so fn gets a copy to keep
+		frame, err := DecodeFrame(append([]byte(nil), req.frame...))
+		if err != nil {
+			return nil, err
+		}
+		if c.opts.onDryRun != nil {
+			c.opts.onDryRun(frame)
+		}
+		if c.opts.logger != nil {
+			c.opts.logger.LogAttrs(ctx, slog.LevelInfo, "plc write dry run",
+				slog.String("op", req.Op.String()),
+				slog.String("device", req.DeviceName),
+				slog.Int64("offset", req.Offset),
+				slog.Int64("points", req.NumPoints),
+				slog.String("frame", annotateFrame(req.frame)),
+			)
+		}
+
+		// response header of 3E frame with the route of request and end code of normal completion
+		resp := []byte{0xD0, 0x00}
+		resp = append(resp, req.frame[2:7]...)
+		return append(resp, 0x02, 0x00, 0x00, 0x00), nil
+	}
+}
@@ -0,0 +1,68 @@
+package mcp
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestClient3E_DryRun(t *testing.T) {
+	for _, frame4E := range []bool{false, true} {
+		var frames []*Frame
+		opts := []Option{WithDryRun(func(f *Frame) { frames = append(frames, f) })}
+		if frame4E {
+			opts = append(opts, WithFrame4E())
+		}
+		client, mem := newTestMemoryClient(t, opts...)
+		mem.words[[2]int64{0xA8, 100}] = 0x1234
+
+		if _, err := client.Write("D", 100, 1, []byte{0x78, 0x56}); err != nil {
+			t.Fatalf("unexpected mcp write err: %v", err)
+		}
+		if _, err := client.BitWrite("M", 10, 2, []byte{0x11}); err != nil {
+			t.Fatalf("unexpected mcp write err: %v", err)
+		}
+		if _, err := client.Batch([]Request{{Op: OpWrite, DeviceName: "D", Offset: 101, NumPoints: 1, WriteData: []byte{0x01, 0x00}}}); err != nil {
+			t.Fatalf("unexpected mcp batch err: %v", err)
+		}
+
+		// reads are sent as usual and writes did not reach PLC
+		payload, err := client.Read("D", 100, 2)
+		if err != nil {
+			t.Fatalf("unexpected mcp read err: %v", err)
+		}
+		if diff := cmp.Diff(payload, []byte{0x34, 0x12, 0x00, 0x00}); diff != "" {
+			t.Errorf("payload differs: (-got +want)\n%s", diff)
+		}
+
+		expected := []*Frame{
+			{Type: Frame3E, Command: 0x1401, SubCommand: 0x0000, DeviceName: "D", Offset: 100, NumPoints: 1, Data: []byte{0x78, 0x56}},
+			{Type: Frame3E, Command: 0x1401, SubCommand: 0x0001, DeviceName: "M", Offset: 10, NumPoints: 2, Data: []byte{0x11}},
+			{Type: Frame3E, Command: 0x1401, SubCommand: 0x0000, DeviceName: "D", Offset: 101, NumPoints: 1, Data: []byte{0x01, 0x00}},
+		}
+		ignore := cmpopts.IgnoreFields(Frame{}, "SubHeader", "NetworkNum", "PCNum", "UnitIONum", "UnitStationNum", "DataLen", "MonitoringTimer")
+		if diff := cmp.Diff(frames, expected, ignore); diff != "" {
+			t.Errorf("frames differs: 4E=%v (-got +want)\n%s", frame4E, diff)
+		}
+	}
+}
+
+func TestClient3E_DryRunAudit(t *testing.T) {
+	var events []AuditEvent
+	hook := func(ctx context.Context, e AuditEvent) { events = append(events, e) }
+	client, mem := newTestMemoryClient(t, WithDryRun(nil), WithAuditHook(hook), WithAuditReadBack())
+	mem.words[[2]int64{0xA8, 100}] = 0x1234
+
+	if _, err := client.Write("D", 100, 1, []byte{0x78, 0x56}); err != nil {
+		t.Fatalf("unexpected mcp write err: %v", err)
+	}
+	// writes that are not sent are not audited
+	if len(events) != 0 {
+		t.Fatalf("expected no audit event but actual is %v", events)
+	}
+	if got := mem.words[[2]int64{0xA8, 100}]; got != 0x1234 {
+		t.Fatalf("expected %04X but actual is %04X", 0x1234, got)
+	}
+}
@@ -0,0 +1,66 @@
+package mcp
+
+import (
+	"fmt"
+)
+
+// EndCodeMessages is end code and description map of common abnormal end codes.
+// MELSECコミュニケーションプロトコル リファレンス エラーコード一覧
+var EndCodeMessages = map[uint16]string{
+	0x4000: "serial communication checksum error",
+	0x4010: "cannot be executed because the CPU module is in RUN",
+	0x4030: "the specified device cannot be used by the CPU module",
+	0x4031: "the specified device range is outside the range of the CPU module",
+	0x4080: "the requested data is incorrect",
+	0x4A00: "the specified station cannot be accessed because routing parameters are not set",
+	0x4A01: "the specified network cannot be accessed because routing parameters are not set",
+	0xC050: "ASCII data that cannot be converted to binary was received",
+	0xC051: "the number of bit device points is outside the allowable range",
+	0xC052: "the number of word device points is outside the allowable range",
+	0xC053: "the number of random bit device points is outside the allowable range",
+	0xC054: "the number of random word device points is outside the allowable range",
+	0xC056: "the requested range exceeds the maximum device number",
+	0xC058: "the request data length does not match the number of data",
+	0xC059: "the command or subcommand is incorrect or not supported by the CPU module",
+	0xC05B: "the CPU module cannot read or write the specified device",
+	0xC05C: "the request content is incorrect",
+	0xC05F: "the request cannot be executed to the target CPU module",
+	0xC060: "the request content is incorrect for bit device",
+	0xC061: "the request data length does not match the number of data",
+	0xC06F: "the communication data code (ASCII/binary) does not match the setting",
+	0xC070: "device memory extension cannot be specified for the target station",
+	0xC0B5: "the CPU module cannot handle the specified data",
+	// end codes of SLMP compatible devices
+	0xCEE0: "the device is processing other request",
+	0xCEE1: "the request message size exceeds the allowable size",
+	0xCEE2: "the response message size exceeds the allowable size",
+	0xCF10: "the server information of the request is incorrect",
+	0xCF20: "the setting value of the request is incorrect",
+	0xCF30: "the parameter is not supported by the device",
+	0xCF31: "the parameter cannot be read by the device",
+	0xCF70: "an error occurred on the network of the target device",
+	0xCF71: "the target device did not respond within the monitoring time",
+}
+
+// EndCodeError represents abnormal end code that is returned by PLC.
+type EndCodeError struct {
+	// EndCode is the end code of response. 0 means normal completion.
+	EndCode uint16
+	// Response is the parsed abnormal response
+	Response *Response
+}
+
+// Message returns description of the end code.
+func (e *EndCodeError) Message() string {
+	if message, ok := EndCodeMessages[e.EndCode]; ok {
+		return message
+	}
+	if 0x4000 <= e.EndCode && e.EndCode <= 0x4FFF {
+		return "error detected by the CPU module"
+	}
+	return "unknown end code"
+}
+
+func (e *EndCodeError) Error() string {
+	return fmt.Sprintf("plc returned abnormal end code: %04X (%s)", e.EndCode, e.Message())
+}
@@ -0,0 +1,22 @@
+package mcp
+
+import "testing"
+
+func TestEndCodeError_Error(t *testing.T) {
+	cases := []struct {
+		endCode  uint16
+		expected string
+	}{
+		{endCode: 0xC059, expected: "plc returned abnormal end code: C059 (the command or subcommand is incorrect or not supported by the CPU module)"},
+		{endCode: 0x4031, expected: "plc returned abnormal end code: 4031 (the specified device range is outside the range of the CPU module)"},
+		{endCode: 0x4FFF, expected: "plc returned abnormal end code: 4FFF (error detected by the CPU module)"},
+		{endCode: 0xCFFF, expected: "plc returned abnormal end code: CFFF (unknown end code)"},
+	}
+
+	for _, v := range cases {
+		err := &EndCodeError{EndCode: v.endCode}
+		if err.Error() != v.expected {
+			t.Errorf("expected %v but actual is %v", v.expected, err.Error())
+		}
+	}
+}
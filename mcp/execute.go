@@ -0,0 +1,54 @@
+package mcp
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+)
+
+// readCommands is commands that do not change PLC. Execute of the others is denied by WithReadOnly and
+// WithWritableRange, skipped by WithDryRun and invalidates WithReadCache.
+var readCommands = map[uint16]bool{
+	0x0401: true, // batch read
+	0x0403: true, // random read
+	0x0406: true, // multiple blocks batch read
+	0x0619: true, // loopback test
+	0x0101: true, // read cpu model name
+	0x1827: true, // open file. writing the file needs write file
+	0x1828: true, // read file
+	0x182A: true, // close file
+}
+
+// AppendCommandRequest appends binary frame of any MCP command with subcommand and request data to dst.
+// data is put after subcommand as it is.
+func (h *station) AppendCommandRequest(dst []byte, command, subCommand uint16, data []byte) []byte {
+	start := len(dst)
+	dst = h.appendHeaderHelper(dst, command, subCommand)
+	dst = append(dst, data...)
+	return finishFrame(dst, start)
+}
+
+// Execute sends command with subcommand and request data that this package does not model yet,
+// and returns payload of response after end code. the frame is 3E or 4E frame of the client.
+// If PLC returns abnormal end code, *EndCodeError is returned.
+// Execute is not audited by WithAuditHook because its devices are unknown.
+func (c *client3E) Execute(command, subCommand uint16, data []byte) ([]byte, error) {
+	return c.ExecuteContext(context.Background(), command, subCommand, data)
+}
+
+// ExecuteContext is Execute that is aborted when ctx is canceled or its deadline exceeded.
+func (c *client3E) ExecuteContext(ctx context.Context, command, subCommand uint16, data []byte) ([]byte, error) {
+	if !readCommands[command] && (c.opts.readOnly || len(c.opts.writableRanges) > 0) {
+		return nil, fmt.Errorf("%w: command %04X may change plc", ErrWriteNotAllowed, command)
+	}
+	frame := c.stationHelper(ctx).AppendCommandRequest(nil, command, subCommand, data)
+	return payloadHelper(c.requestHelper(ctx, &Request{Op: OpCommand, WriteData: data}, frame, 22))
+}
+
+// command returns command of frame of r.
+func (r *Request) command() uint16 {
+	if len(r.frame) < 13 {
+		return 0
+	}
+	return binary.LittleEndian.Uint16(r.frame[11:13])
+}
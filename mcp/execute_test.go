@@ -0,0 +1,54 @@
+package mcp
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestClient3E_Execute(t *testing.T) {
+	for _, frame4E := range []bool{false, true} {
+		var opts []Option
+		if frame4E {
+			opts = append(opts, WithFrame4E())
+		}
+		client, mem := newTestMemoryClient(t, opts...)
+		mem.words[[2]int64{0xA8, 100}] = 0x1234
+
+		// batch read of D100 by raw request data: device number[3byte] + device code[1byte] + points[2byte]
+		payload, err := client.Execute(0x0401, 0x0000, []byte{0x64, 0x00, 0x00, 0xA8, 0x01, 0x00})
+		if err != nil {
+			t.Fatalf("unexpected execute err: %v", err)
+		}
+		if diff := cmp.Diff(payload, []byte{0x34, 0x12}); diff != "" {
+			t.Errorf("payload differs: 4E=%v (-got +want)\n%s", frame4E, diff)
+		}
+
+		// random read of D100 is not supported by test PLC
+		_, err = client.Execute(0x0403, 0x0000, []byte{0x01, 0x00, 0x64, 0x00, 0x00, 0xA8})
+		var endCodeErr *EndCodeError
+		if !errors.As(err, &endCodeErr) || endCodeErr.EndCode != 0xC059 {
+			t.Fatalf("expected end code C059 but actual is %v", err)
+		}
+	}
+}
+
+func TestClient3E_ExecuteGuard(t *testing.T) {
+	client, _ := newTestMemoryClient(t, WithReadOnly())
+	if _, err := client.Execute(0x1001, 0x0000, []byte{0x01, 0x00, 0x00, 0x00}); !errors.Is(err, ErrWriteNotAllowed) {
+		t.Fatalf("expected %v but actual is %v", ErrWriteNotAllowed, err)
+	}
+	if _, err := client.Execute(0x0401, 0x0000, []byte{0x64, 0x00, 0x00, 0xA8, 0x01, 0x00}); err != nil {
+		t.Fatalf("unexpected execute err: %v", err)
+	}
+
+	var frames []*Frame
+	client, _ = newTestMemoryClient(t, WithDryRun(func(f *Frame) { frames = append(frames, f) }))
+	if _, err := client.Execute(0x1001, 0x0000, []byte{0x01, 0x00, 0x00, 0x00}); err != nil {
+		t.Fatalf("unexpected execute err: %v", err)
+	}
+	if len(frames) != 1 || frames[0].Command != 0x1001 {
+		t.Fatalf("expected dry run of command 1001 but actual is %v", frames)
+	}
+}
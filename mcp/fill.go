@@ -0,0 +1,56 @@
+package mcp
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+)
+
+// Fill writes value to numPoints word devices from offset, like clearing a buffer or initializing a test area.
+// large range is split by WriteBlocksContext into block writes of MAX_READ_POINTS or write limit of WithProfile.
+// word unit write to bit devices like M fills 16 points per word.
+// when a block fails, devices of the blocks before it are already written.
+func (c *client3E) Fill(deviceName string, offset, numPoints int64, value uint16) error {
+	if numPoints <= 0 {
+		return fmt.Errorf("%w: %v", ErrInvalidPoints, numPoints)
+	}
+	writeData := make([]byte, 2*numPoints)
+	for i := 0; i < len(writeData); i += 2 {
+		binary.LittleEndian.PutUint16(writeData[i:], value)
+	}
+	return c.WriteBlocksContext(context.Background(), deviceName, offset, numPoints, writeData)
+}
+
+// WriteBlocksContext is WriteContext of a range that may be larger than a request. the range is split into
+// block writes of MAX_READ_POINTS or write limit of WithProfile.
+// when a block fails, devices of the blocks before it are already written.
+func (c *client3E) WriteBlocksContext(ctx context.Context, deviceName string, offset, numPoints int64, writeData []byte) error {
+	return c.writeChunksHelper(ctx, OpWrite, deviceName, offset, numPoints, writeData, c.WriteContext)
+}
+
+// BitWriteBlocksContext is BitWriteContext of a range that may be larger than a request. the range is split into
+// block writes of MAX_BIT_READ_POINTS or bit write limit of WithProfile.
+// when a block fails, devices of the blocks before it are already written.
+func (c *client3E) BitWriteBlocksContext(ctx context.Context, deviceName string, offset, numPoints int64, writeData []byte) error {
+	return c.writeChunksHelper(ctx, OpBitWrite, deviceName, offset, numPoints, writeData, c.BitWriteContext)
+}
+
+// writeChunksHelper writes numPoints devices by requests of at most write limit of op.
+// short writeData is left to write, so the block that lacks data is padded or rejected by it.
+func (c *client3E) writeChunksHelper(ctx context.Context, op Op, deviceName string, offset, numPoints int64, writeData []byte, write func(context.Context, string, int64, int64, []byte) ([]byte, error)) error {
+	if numPoints <= 0 {
+		return fmt.Errorf("%w: %v", ErrInvalidPoints, numPoints)
+	}
+	bit := op == OpBitWrite
+	maxPoints := c.writeLimitHelper(op)
+	for start := int64(0); start < numPoints; start += maxPoints {
+		points := min(maxPoints, numPoints-start)
+		// maxPoints of bit unit is even, so a block starts at the upper 4 bits of a byte
+		from := WriteDataLen(start, bit)
+		to := min(from+WriteDataLen(points, bit), int64(len(writeData)))
+		if _, err := write(ctx, deviceName, offset+start, points, writeData[min(from, to):to]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
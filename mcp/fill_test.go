@@ -0,0 +1,53 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+)
+
+func TestClient3E_Fill(t *testing.T) {
+	var writes int32
+	count := func(next Handler) Handler {
+		return func(ctx context.Context, req *Request) ([]byte, error) {
+			if req.Op == OpWrite {
+				atomic.AddInt32(&writes, 1)
+			}
+			return next(ctx, req)
+		}
+	}
+	client, mem := newTestMemoryClient(t, WithMiddleware(count))
+
+	if err := client.Fill("D", 100, 2000, 0xABCD); err != nil {
+		t.Fatalf("unexpected mcp fill err: %v", err)
+	}
+	// 960 + 960 + 80 points
+	if got := atomic.LoadInt32(&writes); got != 3 {
+		t.Fatalf("expected %v but actual is %v", 3, got)
+	}
+	for _, i := range []int64{100, 1059, 1060, 2099} {
+		if got := mem.words[[2]int64{0xA8, i}]; got != 0xABCD {
+			t.Fatalf("D%v: expected %04X but actual is %04X", i, 0xABCD, got)
+		}
+	}
+	if got := mem.words[[2]int64{0xA8, 2100}]; got != 0 {
+		t.Fatalf("expected %v but actual is %v", 0, got)
+	}
+
+	if err := client.Fill("D", 0, 0, 0); !errors.Is(err, ErrInvalidPoints) {
+		t.Fatalf("expected %v but actual is %v", ErrInvalidPoints, err)
+	}
+
+	// write limit of the profile splits blocks
+	atomic.StoreInt32(&writes, 0)
+	small := FX5U
+	small.MaxWritePoints = 64
+	client, _ = newTestMemoryClient(t, WithProfile(small), WithMiddleware(count))
+	if err := client.Fill("D", 0, 100, 1); err != nil {
+		t.Fatalf("unexpected mcp fill err: %v", err)
+	}
+	if got := atomic.LoadInt32(&writes); got != 2 {
+		t.Fatalf("expected %v but actual is %v", 2, got)
+	}
+}
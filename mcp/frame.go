@@ -0,0 +1,295 @@
+package mcp
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// FrameType is type of MC protocol frame.
+type FrameType int
+
+const (
+	Frame1E FrameType = iota + 1
+	Frame3E
+	Frame4E
+)
+
+func (t FrameType) String() string {
+	switch t {
+	case Frame1E:
+		return "1E"
+	case Frame3E:
+		return "3E"
+	case Frame4E:
+		return "4E"
+	}
+	return "Unknown"
+}
+
+// e1DeviceCodes is device name and device code map of 1E frame.
+var e1DeviceCodes = map[string]uint16{
+	"X":  0x5820,
+	"Y":  0x5920,
+	"M":  0x4D20,
+	"S":  0x5320,
+	"F":  0x4620,
+	"B":  0x4220,
+	"D":  0x4420,
+	"W":  0x5720,
+	"R":  0x5220,
+	"TN": 0x544E,
+	"TS": 0x5453,
+	"CN": 0x434E,
+	"CS": 0x4353,
+}
+
+// commandNames is name of commands of 3E and 4E frame.
+var commandNames = map[uint16]string{
+	0x0401: "batch read",
+	0x1401: "batch write",
+	0x0403: "random read",
+	0x1402: "random write",
+	0x0406: "multiple blocks batch read",
+	0x1406: "multiple blocks batch write",
+	0x0619: "loopback test",
+	0x1001: "remote run",
+	0x1002: "remote stop",
+	0x0101: "read cpu model name",
+	0x1827: "open file",
+	0x1828: "read file",
+	0x182A: "close file",
+}
+
+// e1CommandNames is name of commands of 1E frame.
+var e1CommandNames = map[byte]string{
+	0x00: "batch read in bit units",
+	0x01: "batch read in word units",
+	0x02: "batch write in bit units",
+	0x03: "batch write in word units",
+	0x16: "loopback test",
+}
+
+// Frame is decoded MC protocol frame. fields that the frame does not have are zero.
+type Frame struct {
+	Type     FrameType
+	Response bool
+	// sub header. 1E frame has 1 byte sub header that is command or command|0x80 of response
+	SubHeader uint16
+	// serial number of 4E frame
+	SerialNum uint16
+	// route of 3E and 4E frame
+	NetworkNum     byte
+	PCNum          byte
+	UnitIONum      uint16
+	UnitStationNum byte
+	// data length field of 3E and 4E frame
+	DataLen uint16
+	// monitoring timer of request in 250ms units
+	MonitoringTimer uint16
+	// command and subcommand of request
+	Command    uint16
+	SubCommand uint16
+	// end code of response. 1E frame has complete code and abnormal code
+	EndCode uint16
+	// device of batch read and write request. DeviceName is empty when device code is unknown
+	DeviceName string
+	Offset     int64
+	NumPoints  int64
+	// rest of frame like write data, read data or error information
+	Data []byte
+}
+
+// DecodeFrame decodes binary 1E, 3E or 4E frame of request or response.
+// it does not verify data length, so it can decode truncated frame as much as possible.
+func DecodeFrame(frame []byte) (*Frame, error) {
+	if len(frame) < 2 {
+		return nil, errors.New("frame is too short")
+	}
+
+	switch {
+	case frame[1] == 0x00 && (frame[0] == 0x50 || frame[0] == 0xD0):
+		return decodeFrame34E(frame, Frame3E)
+	case frame[1] == 0x00 && (frame[0] == 0x54 || frame[0] == 0xD4):
+		return decodeFrame34E(frame, Frame4E)
+	}
+	return decodeFrame1E(frame)
+}
+
+func decodeFrame34E(frame []byte, t FrameType) (*Frame, error) {
+	f := &Frame{Type: t, Response: frame[0]&0x80 != 0, SubHeader: binary.BigEndian.Uint16(frame[0:2])}
+	rest := frame[2:]
+	if t == Frame4E {
+		if len(rest) < 4 {
+			return nil, errors.New("4E frame is too short")
+		}
+		f.SerialNum = binary.LittleEndian.Uint16(rest[0:2])
+		rest = rest[4:]
+	}
+	if len(rest) < 7 {
+		return nil, errors.New(t.String() + " frame is too short")
+	}
+	f.NetworkNum = rest[0]
+	f.PCNum = rest[1]
+	f.UnitIONum = binary.LittleEndian.Uint16(rest[2:4])
+	f.UnitStationNum = rest[4]
+	f.DataLen = binary.LittleEndian.Uint16(rest[5:7])
+	rest = rest[7:]
+
+	if f.Response {
+		if len(rest) >= 2 {
+			f.EndCode = binary.LittleEndian.Uint16(rest[0:2])
+			rest = rest[2:]
+		}
+		f.Data = rest
+		return f, nil
+	}
+
+	if len(rest) < 6 {
+		f.Data = rest
+		return f, nil
+	}
+	f.MonitoringTimer = binary.LittleEndian.Uint16(rest[0:2])
+	f.Command = binary.LittleEndian.Uint16(rest[2:4])
+	f.SubCommand = binary.LittleEndian.Uint16(rest[4:6])
+	rest = rest[6:]
+
+	if f.Command == 0x0401 || f.Command == 0x1401 {
+		switch f.SubCommand {
+		case 0x0000, 0x0001:
+			// device number[3byte] + device code[1byte] + points[2byte]
+			if len(rest) >= 6 {
+				f.Offset = int64(rest[0]) | int64(rest[1])<<8 | int64(rest[2])<<16
+				f.DeviceName = deviceNameByCode(fmt.Sprintf("%02X", rest[3]))
+				f.NumPoints = int64(binary.LittleEndian.Uint16(rest[4:6]))
+				rest = rest[6:]
+			}
+		case 0x0002, 0x0003:
+			// device number[4byte] + device code[2byte] + points[2byte]
+			if len(rest) >= 8 {
+				f.Offset = int64(binary.LittleEndian.Uint32(rest[0:4]))
+				f.DeviceName = deviceNameByCode(fmt.Sprintf("%02X%02X", rest[4], rest[5]))
+				f.NumPoints = int64(binary.LittleEndian.Uint16(rest[6:8]))
+				rest = rest[8:]
+			}
+		}
+	}
+	f.Data = rest
+	return f, nil
+}
+
+func decodeFrame1E(frame []byte) (*Frame, error) {
+	f := &Frame{Type: Frame1E, Response: frame[0]&0x80 != 0, SubHeader: uint16(frame[0])}
+	if f.Response {
+		f.Command = uint16(frame[0] & 0x7F)
+		f.EndCode = uint16(frame[1])
+		rest := frame[2:]
+		if f.EndCode == 0x5B && len(rest) > 0 {
+			// abnormal code follows complete code 5B
+			f.EndCode = f.EndCode<<8 | uint16(rest[0])
+			rest = rest[1:]
+		}
+		f.Data = rest
+		return f, nil
+	}
+
+	f.Command = uint16(frame[0])
+	f.PCNum = frame[1]
+	rest := frame[2:]
+	if len(rest) < 2 {
+		f.Data = rest
+		return f, nil
+	}
+	f.MonitoringTimer = binary.LittleEndian.Uint16(rest[0:2])
+	rest = rest[2:]
+	if f.Command <= 0x03 && len(rest) >= 8 {
+		// device number[4byte] + device code[2byte] + points[1byte] + fixed 00[1byte]
+		f.Offset = int64(binary.LittleEndian.Uint32(rest[0:4]))
+		code := binary.LittleEndian.Uint16(rest[4:6])
+		for name, c := range e1DeviceCodes {
+			if c == code {
+				f.DeviceName = name
+			}
+		}
+		f.NumPoints = int64(rest[6])
+		if f.NumPoints == 0 {
+			f.NumPoints = 256
+		}
+		rest = rest[8:]
+	}
+	f.Data = rest
+	return f, nil
+}
+
+// deviceNameByCode returns device name of 3E device code hex like "A8".
+func deviceNameByCode(code string) string {
+	for name, c := range DeviceCodes {
+		if c == code {
+			return name
+		}
+	}
+	for name, c := range IQRDeviceCodes {
+		if c == code {
+			return name
+		}
+	}
+	if len(code) == 4 && strings.HasSuffix(code, "00") {
+		// 1byte device code accessed by iQ-R series subcommand like "A800"
+		for name, c := range DeviceCodes {
+			if c == code[:2] {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+// String returns printable breakdown of frame. each field is written in a line.
+func (f *Frame) String() string {
+	var b strings.Builder
+	kind := "request"
+	if f.Response {
+		kind = "response"
+	}
+	fmt.Fprintf(&b, "frame:       %v %v\n", f.Type, kind)
+	if f.Type == Frame1E {
+		fmt.Fprintf(&b, "sub header:  %02X\n", f.SubHeader)
+	} else {
+		fmt.Fprintf(&b, "sub header:  %04X\n", f.SubHeader)
+	}
+	if f.Type == Frame4E {
+		fmt.Fprintf(&b, "serial:      %04X\n", f.SerialNum)
+	}
+	if f.Type == Frame1E {
+		if !f.Response {
+			fmt.Fprintf(&b, "pc:          %02X\n", f.PCNum)
+		}
+	} else {
+		fmt.Fprintf(&b, "route:       network=%02X pc=%02X io=%04X station=%02X\n", f.NetworkNum, f.PCNum, f.UnitIONum, f.UnitStationNum)
+		fmt.Fprintf(&b, "data length: %v\n", f.DataLen)
+	}
+
+	if f.Response {
+		if f.Type == Frame1E {
+			fmt.Fprintf(&b, "command:     %02X (%v)\n", f.Command, e1CommandNames[byte(f.Command)])
+		}
+		message := "normal completion"
+		if f.EndCode != 0 {
+			message = (&EndCodeError{EndCode: f.EndCode}).Message()
+		}
+		fmt.Fprintf(&b, "end code:    %04X (%v)\n", f.EndCode, message)
+	} else {
+		fmt.Fprintf(&b, "timer:       %v (x250ms)\n", f.MonitoringTimer)
+		if f.Type == Frame1E {
+			fmt.Fprintf(&b, "command:     %02X (%v)\n", f.Command, e1CommandNames[byte(f.Command)])
+		} else {
+			fmt.Fprintf(&b, "command:     %04X (%v) subcommand=%04X\n", f.Command, commandNames[f.Command], f.SubCommand)
+		}
+		if f.NumPoints > 0 {
+			fmt.Fprintf(&b, "device:      %v points=%v\n", FormatDevice(f.DeviceName, f.Offset), f.NumPoints)
+		}
+	}
+	fmt.Fprintf(&b, "data:        % X\n", f.Data)
+	return b.String()
+}
@@ -0,0 +1,71 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// WithFrameDump logs every request and response frame as annotated hex to logger of WithLogger.
+// frames are logged at Debug level with labels of header fields like "sub=5000 net=00 pc=FF io=FF03 st=00 len=0C00 ...".
+func WithFrameDump() Option {
+	return func(o *options) {
+		o.frameDump = true
+	}
+}
+
+// dumpHelper logs frame sent or received. direction is "send" or "recv".
+func (c *client3E) dumpHelper(direction string, frame []byte) {
+	if !c.opts.frameDump || c.opts.logger == nil {
+		return
+	}
+	c.opts.logger.LogAttrs(context.Background(), slog.LevelDebug, "plc frame "+direction,
+		slog.String("frame", annotateFrame(frame)))
+}
+
+// annotateFrame formats 3E or 4E frame as hex labeled by header fields.
+// fields are written in the order of bytes on the wire.
+func annotateFrame(frame []byte) string {
+	var b strings.Builder
+	field := func(label string, n int) {
+		if len(frame) == 0 {
+			return
+		}
+		if n > len(frame) || n < 0 {
+			n = len(frame)
+		}
+		if b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		fmt.Fprintf(&b, "%s=%X", label, frame[:n])
+		frame = frame[n:]
+	}
+
+	if len(frame) < 2 {
+		field("data", -1)
+		return b.String()
+	}
+	response := frame[0]&0x80 != 0 // D000, D400
+	is4E := frame[0]&0x0F == 0x04  // 5400, D400
+
+	field("sub", 2)
+	if is4E {
+		field("serial", 2)
+		field("fixed", 2)
+	}
+	field("net", 1)
+	field("pc", 1)
+	field("io", 2)
+	field("st", 1)
+	field("len", 2)
+	if response {
+		field("end", 2)
+	} else {
+		field("timer", 2)
+		field("cmd", 2)
+		field("sub_cmd", 2)
+	}
+	field("data", -1)
+	return b.String()
+}
@@ -0,0 +1,60 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/hex"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestAnnotateFrame(t *testing.T) {
+	cases := []struct {
+		frame    string
+		expected string
+	}{
+		{
+			frame:    "500000ffff03000c00100001040000640000a80100",
+			expected: "sub=5000 net=00 pc=FF io=FF03 st=00 len=0C00 timer=1000 cmd=0104 sub_cmd=0000 data=640000A80100",
+		},
+		{
+			frame:    "d00000ffff030004000000cdab",
+			expected: "sub=D000 net=00 pc=FF io=FF03 st=00 len=0400 end=0000 data=CDAB",
+		},
+		{
+			frame:    "d4003412000000ffff0300020059c0",
+			expected: "sub=D400 serial=3412 fixed=0000 net=00 pc=FF io=FF03 st=00 len=0200 end=59C0",
+		},
+		{
+			frame:    "d00000ff",
+			expected: "sub=D000 net=00 pc=FF",
+		},
+	}
+
+	for _, v := range cases {
+		frame, _ := hex.DecodeString(v.frame)
+		if actual := annotateFrame(frame); actual != v.expected {
+			t.Errorf("expected %v but actual is %v", v.expected, actual)
+		}
+	}
+}
+
+func TestClient3E_FrameDump(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	client, _ := newTestMemoryClient(t, WithLogger(logger), WithFrameDump())
+	if _, err := client.Read("D", 100, 1); err != nil {
+		t.Fatalf("unexpected mcp read err: %v", err)
+	}
+
+	logs := buf.String()
+	for _, expected := range []string{
+		`msg="plc frame send" frame="sub=5000 net=00 pc=FF io=FF03 st=00 len=0C00 timer=1000 cmd=0104 sub_cmd=0000 data=640000A80100"`,
+		`msg="plc frame recv" frame="sub=D000 net=00 pc=FF io=FF03 st=00 len=0400 end=0000 data=0000"`,
+	} {
+		if !strings.Contains(logs, expected) {
+			t.Errorf("expected log contains %v but actual is\n%s", expected, logs)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package mcp
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrUnknownFrame is returned when sub header of frame is not 3E or 4E frame.
+var ErrUnknownFrame = errors.New("unknown sub header of frame")
+
+// MAX_FRAME_SIZE is maximum size of 3E or 4E frame. 4E header is 13 byte and length field is 2 byte.
+const MAX_FRAME_SIZE = 13 + 0xFFFF
+
+// frameHeaderSize returns size of header and offset of length field of frame by its sub header.
+func frameHeaderSize(subHeader []byte) (int, error) {
+	if subHeader[1] == 0x00 {
+		switch subHeader[0] {
+		case 0x50, 0xD0:
+			// sub header + network num + pc num + unit i/o num + unit station num + length
+			return 9, nil
+		case 0x54, 0xD4:
+			// 4E frame has serial number[2byte] and 0000[2byte] more
+			return 13, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: %02X%02X", ErrUnknownFrame, subHeader[0], subHeader[1])
+}
+
+// ScanFrames is bufio.SplitFunc that splits stream into 3E or 4E frames of requests or responses.
+// each token is a whole frame including header.
+func ScanFrames(data []byte, atEOF bool) (int, []byte, error) {
+	if len(data) < 2 {
+		if atEOF && len(data) > 0 {
+			return 0, nil, io.ErrUnexpectedEOF
+		}
+		return 0, nil, nil
+	}
+	headerSize, err := frameHeaderSize(data)
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(data) >= headerSize {
+		size := headerSize + int(binary.LittleEndian.Uint16(data[headerSize-2:headerSize]))
+		if len(data) >= size {
+			return size, data[:size], nil
+		}
+	}
+	if atEOF {
+		return 0, nil, io.ErrUnexpectedEOF
+	}
+	return 0, nil, nil
+}
+
+// FrameReader reads 3E or 4E frames from stream.
+type FrameReader struct {
+	scanner *bufio.Scanner
+}
+
+// NewFrameReader returns FrameReader that reads frames from r.
+func NewFrameReader(r io.Reader) *FrameReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 512), MAX_FRAME_SIZE)
+	scanner.Split(ScanFrames)
+	return &FrameReader{scanner: scanner}
+}
+
+// ReadFrame returns next frame. returned frame is not overwritten by next ReadFrame.
+// io.EOF is returned when stream ends at boundary of frames.
+func (f *FrameReader) ReadFrame() ([]byte, error) {
+	if !f.scanner.Scan() {
+		if err := f.scanner.Err(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+	frame := make([]byte, len(f.scanner.Bytes()))
+	copy(frame, f.scanner.Bytes())
+	return frame, nil
+}
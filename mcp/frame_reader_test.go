@@ -0,0 +1,81 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestScanFrames(t *testing.T) {
+	frames := []string{
+		"500000FFFF03000C00100001040000F40100A83200",
+		"d00000ffff03000600000034127856",
+		"540034120000" + "00FFFF03000C00100001040000F40100A83200",
+		"d4003412000000ffff030004000000cdab",
+	}
+	var stream []byte
+	for _, f := range frames {
+		b, _ := hex.DecodeString(f)
+		stream = append(stream, b...)
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(stream))
+	scanner.Split(ScanFrames)
+	var got []string
+	for scanner.Scan() {
+		got = append(got, hex.EncodeToString(scanner.Bytes()))
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("unexpected scan err: %v", err)
+	}
+
+	var expected []string
+	for _, f := range frames {
+		b, _ := hex.DecodeString(f)
+		expected = append(expected, hex.EncodeToString(b))
+	}
+	if diff := cmp.Diff(got, expected); diff != "" {
+		t.Errorf("frames differs: (-got +want)\n%s", diff)
+	}
+}
+
+func TestFrameReader(t *testing.T) {
+	resp, _ := hex.DecodeString("d00000ffff03000600000034127856" + "d00000ffff030004000000")
+	// stream that returns 1 byte per read
+	r := NewFrameReader(&oneByteReader{r: bytes.NewReader(resp)})
+
+	frame, err := r.ReadFrame()
+	if err != nil {
+		t.Fatalf("unexpected read err: %v", err)
+	}
+	if expected := resp[:15]; !cmp.Equal(frame, expected) {
+		t.Fatalf("expected %X but actual is %X", expected, frame)
+	}
+	// the second frame is truncated
+	if _, err := r.ReadFrame(); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("expected %v but actual is %v", io.ErrUnexpectedEOF, err)
+	}
+
+	if _, err := NewFrameReader(bytes.NewReader([]byte{0x12, 0x34})).ReadFrame(); !errors.Is(err, ErrUnknownFrame) {
+		t.Fatalf("expected %v but actual is %v", ErrUnknownFrame, err)
+	}
+	if _, err := NewFrameReader(bytes.NewReader(nil)).ReadFrame(); err != io.EOF {
+		t.Fatalf("expected %v but actual is %v", io.EOF, err)
+	}
+}
+
+type oneByteReader struct {
+	r io.Reader
+}
+
+func (o *oneByteReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	return o.r.Read(p[:1])
+}
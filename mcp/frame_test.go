@@ -0,0 +1,108 @@
+package mcp
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDecodeFrame(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected *Frame
+	}{
+		{
+			input: "500000FFFF03000C00100001040000F40100A83200",
+			expected: &Frame{
+				Type: Frame3E, SubHeader: 0x5000, PCNum: 0xFF, UnitIONum: 0x03FF, DataLen: 12,
+				MonitoringTimer: 0x10, Command: 0x0401, DeviceName: "D", Offset: 500, NumPoints: 50, Data: []byte{},
+			},
+		},
+		{
+			input: "500000FFFF03000E00100001140100640000900300" + "1010",
+			expected: &Frame{
+				Type: Frame3E, SubHeader: 0x5000, PCNum: 0xFF, UnitIONum: 0x03FF, DataLen: 14,
+				MonitoringTimer: 0x10, Command: 0x1401, SubCommand: 0x0001, DeviceName: "M", Offset: 100, NumPoints: 3, Data: []byte{0x10, 0x10},
+			},
+		},
+		{
+			input: "500000FFFF03000E001000010402002C0100002C000300",
+			expected: &Frame{
+				Type: Frame3E, SubHeader: 0x5000, PCNum: 0xFF, UnitIONum: 0x03FF, DataLen: 14,
+				MonitoringTimer: 0x10, Command: 0x0401, SubCommand: 0x0002, DeviceName: "RD", Offset: 300, NumPoints: 3, Data: []byte{},
+			},
+		},
+		{
+			input: "d00000ffff03000b0059c000ffff030001040000",
+			expected: &Frame{
+				Type: Frame3E, Response: true, SubHeader: 0xD000, PCNum: 0xFF, UnitIONum: 0x03FF, DataLen: 11,
+				EndCode: 0xC059, Data: []byte{0x00, 0xFF, 0xFF, 0x03, 0x00, 0x01, 0x04, 0x00, 0x00},
+			},
+		},
+		{
+			input: "540034120000" + "00FFFF03000C00100001040000F40100A83200",
+			expected: &Frame{
+				Type: Frame4E, SubHeader: 0x5400, SerialNum: 0x1234, PCNum: 0xFF, UnitIONum: 0x03FF, DataLen: 12,
+				MonitoringTimer: 0x10, Command: 0x0401, DeviceName: "D", Offset: 500, NumPoints: 50, Data: []byte{},
+			},
+		},
+		{
+			input: "d4003412000000ffff030004000000cdab",
+			expected: &Frame{
+				Type: Frame4E, Response: true, SubHeader: 0xD400, SerialNum: 0x1234, PCNum: 0xFF, UnitIONum: 0x03FF, DataLen: 4,
+				Data: []byte{0xCD, 0xAB},
+			},
+		},
+		{
+			input: "01FF0A00" + "64000000" + "2044" + "0300",
+			expected: &Frame{
+				Type: Frame1E, SubHeader: 0x01, PCNum: 0xFF, MonitoringTimer: 10,
+				Command: 0x01, DeviceName: "D", Offset: 100, NumPoints: 3, Data: []byte{},
+			},
+		},
+		{
+			input: "8100" + "34120000",
+			expected: &Frame{
+				Type: Frame1E, Response: true, SubHeader: 0x81, Command: 0x01, Data: []byte{0x34, 0x12, 0x00, 0x00},
+			},
+		},
+		{
+			input: "835B10",
+			expected: &Frame{
+				Type: Frame1E, Response: true, SubHeader: 0x83, Command: 0x03, EndCode: 0x5B10, Data: []byte{},
+			},
+		},
+	}
+
+	for _, v := range cases {
+		frame, _ := hex.DecodeString(v.input)
+		got, err := DecodeFrame(frame)
+		if err != nil {
+			t.Fatalf("%v: unexpected decode err: %v", v.input, err)
+		}
+		if diff := cmp.Diff(got, v.expected); diff != "" {
+			t.Errorf("%v: decoded frame differs: (-got +want)\n%s", v.input, diff)
+		}
+	}
+
+	if _, err := DecodeFrame([]byte{0x50}); err == nil {
+		t.Fatalf("expected error for too short frame")
+	}
+}
+
+func TestFrame_String(t *testing.T) {
+	frame, _ := hex.DecodeString("500000FFFF03000C00100001040000F40100A83200")
+	f, err := DecodeFrame(frame)
+	if err != nil {
+		t.Fatalf("unexpected decode err: %v", err)
+	}
+
+	s := f.String()
+	for _, expected := range []string{"3E request", "network=00 pc=FF io=03FF station=00", "0401 (batch read)", "D500 points=50"} {
+		if !strings.Contains(s, expected) {
+			t.Errorf("expected %q in %v", expected, s)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package mcp
+
+// Value is data types that ReadValue and WriteValue support.
+// bool is read and written as bit device, others are word devices.
+type Value interface {
+	int16 | uint16 | int32 | uint32 | float32 | float64 | bool
+}
+
+// ReadValue reads device as T.
+// int16 and uint16 use 1 word, int32, uint32 and float32 use 2 words, float64 uses 4 words.
+func ReadValue[T Value](c Client, deviceName string, offset int64) (T, error) {
+	var v T
+	var err error
+	switch p := any(&v).(type) {
+	case *int16:
+		*p, err = c.ReadInt16(deviceName, offset)
+	case *uint16:
+		*p, err = c.ReadUint16(deviceName, offset)
+	case *int32:
+		*p, err = c.ReadInt32(deviceName, offset)
+	case *uint32:
+		*p, err = c.ReadUint32(deviceName, offset)
+	case *float32:
+		*p, err = c.ReadFloat32(deviceName, offset)
+	case *float64:
+		*p, err = c.ReadFloat64(deviceName, offset)
+	case *bool:
+		var values []bool
+		if values, err = c.ReadBools(deviceName, offset, 1); err == nil {
+			*p = values[0]
+		}
+	}
+	return v, err
+}
+
+// WriteValue writes value of T to device.
+func WriteValue[T Value](c Client, deviceName string, offset int64, value T) error {
+	switch v := any(value).(type) {
+	case int16:
+		return c.WriteInt16(deviceName, offset, v)
+	case uint16:
+		return c.WriteUint16(deviceName, offset, v)
+	case int32:
+		return c.WriteInt32(deviceName, offset, v)
+	case uint32:
+		return c.WriteUint32(deviceName, offset, v)
+	case float32:
+		return c.WriteFloat32(deviceName, offset, v)
+	case float64:
+		return c.WriteFloat64(deviceName, offset, v)
+	case bool:
+		return c.WriteBools(deviceName, offset, []bool{v})
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package mcp
+
+import "testing"
+
+func TestReadWriteValue(t *testing.T) {
+	client, _ := newTestMemoryClient(t)
+
+	if err := WriteValue(client, "D", 100, int16(-300)); err != nil {
+		t.Fatalf("unexpected write err: %v", err)
+	}
+	if err := WriteValue(client, "D", 110, float32(2.5)); err != nil {
+		t.Fatalf("unexpected write err: %v", err)
+	}
+	if err := WriteValue(client, "M", 10, true); err != nil {
+		t.Fatalf("unexpected write err: %v", err)
+	}
+
+	i16, err := ReadValue[int16](client, "D", 100)
+	if err != nil {
+		t.Fatalf("unexpected read err: %v", err)
+	}
+	if i16 != -300 {
+		t.Fatalf("expected %v but actual is %v", -300, i16)
+	}
+
+	f32, err := ReadValue[float32](client, "D", 110)
+	if err != nil {
+		t.Fatalf("unexpected read err: %v", err)
+	}
+	if f32 != 2.5 {
+		t.Fatalf("expected %v but actual is %v", 2.5, f32)
+	}
+
+	u32, err := ReadValue[uint32](client, "D", 110)
+	if err != nil {
+		t.Fatalf("unexpected read err: %v", err)
+	}
+	if u32 != 0x40200000 {
+		t.Fatalf("expected %X but actual is %X", 0x40200000, u32)
+	}
+
+	b, err := ReadValue[bool](client, "M", 10)
+	if err != nil {
+		t.Fatalf("unexpected read err: %v", err)
+	}
+	if !b {
+		t.Fatalf("expected %v but actual is %v", true, b)
+	}
+}
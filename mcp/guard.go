@@ -0,0 +1,49 @@
+package mcp
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrWriteNotAllowed is returned without sending request when write is denied by WithReadOnly or WithWritableRange.
+var ErrWriteNotAllowed = errors.New("write is not allowed")
+
+// WithReadOnly denies all write requests and Execute of commands that may change PLC
+// with ErrWriteNotAllowed before their frames are built.
+// it guards PLC from clients like dashboards that must never write.
+func WithReadOnly() Option {
+	return func(o *options) {
+		o.readOnly = true
+	}
+}
+
+// WithWritableRange allows write to numPoints devices from offset of deviceName like ("D", 1000, 100).
+// once a range is given, write requests out of the given ranges are denied with ErrWriteNotAllowed
+// before their frames are built. word unit write to bit device like M covers 16 points per word.
+// Execute of commands that may change PLC is denied because their devices are unknown.
+func WithWritableRange(deviceName string, offset, numPoints int64) Option {
+	return func(o *options) {
+		o.writableRanges = append(o.writableRanges, Request{Op: OpWrite, DeviceName: deviceName, Offset: offset, NumPoints: numPoints})
+	}
+}
+
+// guardHelper returns ErrWriteNotAllowed when write of op to numPoints devices from offset is denied by options.
+func (c *client3E) guardHelper(op Op, deviceName string, offset, numPoints int64) error {
+	if c.opts.readOnly {
+		return fmt.Errorf("%w: client is read-only", ErrWriteNotAllowed)
+	}
+	if len(c.opts.writableRanges) == 0 {
+		return nil
+	}
+
+	points := numPoints
+	if op == OpWrite && IsBitDevice(deviceName) {
+		points *= 16
+	}
+	for _, r := range c.opts.writableRanges {
+		if r.DeviceName == deviceName && r.Offset <= offset && offset+points <= r.Offset+r.NumPoints {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %v points=%v is out of writable ranges", ErrWriteNotAllowed, FormatDevice(deviceName, offset), points)
+}
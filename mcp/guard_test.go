@@ -0,0 +1,86 @@
+package mcp
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestClient3E_ReadOnly(t *testing.T) {
+	client, mem := newTestMemoryClient(t, WithReadOnly())
+	mem.words[[2]int64{0xA8, 100}] = 0x1234
+
+	if _, err := client.Write("D", 100, 1, []byte{0x78, 0x56}); !errors.Is(err, ErrWriteNotAllowed) {
+		t.Fatalf("expected %v but actual is %v", ErrWriteNotAllowed, err)
+	}
+	if _, err := client.BitWrite("M", 10, 1, []byte{0x10}); !errors.Is(err, ErrWriteNotAllowed) {
+		t.Fatalf("expected %v but actual is %v", ErrWriteNotAllowed, err)
+	}
+	if _, err := client.Batch([]Request{{Op: OpWrite, DeviceName: "D", Offset: 100, NumPoints: 1, WriteData: []byte{0x78, 0x56}}}); !errors.Is(err, ErrWriteNotAllowed) {
+		t.Fatalf("expected %v but actual is %v", ErrWriteNotAllowed, err)
+	}
+	if got := mem.words[[2]int64{0xA8, 100}]; got != 0x1234 {
+		t.Fatalf("expected %X but actual is %X", 0x1234, got)
+	}
+
+	if _, err := client.Read("D", 100, 1); err != nil {
+		t.Fatalf("unexpected mcp read err: %v", err)
+	}
+}
+
+func TestClient3E_WritableRange(t *testing.T) {
+	client, mem := newTestMemoryClient(t, WithWritableRange("D", 100, 10), WithWritableRange("M", 0, 32))
+
+	cases := []struct {
+		req     Request
+		allowed bool
+	}{
+		{req: Request{Op: OpWrite, DeviceName: "D", Offset: 100, NumPoints: 10}, allowed: true},
+		{req: Request{Op: OpWrite, DeviceName: "D", Offset: 105, NumPoints: 6}},
+		{req: Request{Op: OpWrite, DeviceName: "D", Offset: 99, NumPoints: 1}},
+		{req: Request{Op: OpWrite, DeviceName: "W", Offset: 100, NumPoints: 1}},
+		{req: Request{Op: OpBitWrite, DeviceName: "M", Offset: 31, NumPoints: 1}, allowed: true},
+		{req: Request{Op: OpBitWrite, DeviceName: "M", Offset: 31, NumPoints: 2}},
+		// word unit write to bit device covers 16 points per word
+		{req: Request{Op: OpWrite, DeviceName: "M", Offset: 0, NumPoints: 2}, allowed: true},
+		{req: Request{Op: OpWrite, DeviceName: "M", Offset: 16, NumPoints: 2}},
+	}
+
+	for _, v := range cases {
+		v.req.WriteData = make([]byte, WriteDataLen(v.req.NumPoints, v.req.Op == OpBitWrite))
+		var err error
+		if v.req.Op == OpWrite {
+			_, err = client.Write(v.req.DeviceName, v.req.Offset, v.req.NumPoints, v.req.WriteData)
+		} else {
+			_, err = client.BitWrite(v.req.DeviceName, v.req.Offset, v.req.NumPoints, v.req.WriteData)
+		}
+		if v.allowed && err != nil {
+			t.Errorf("unexpected mcp write err: %v: %v", v.req, err)
+		}
+		if !v.allowed && !errors.Is(err, ErrWriteNotAllowed) {
+			t.Errorf("expected %v but actual is %v: %v", ErrWriteNotAllowed, err, v.req)
+		}
+
+		if _, err := client.Prepare(v.req); v.allowed != (err == nil) {
+			t.Errorf("unexpected prepare err: %v: %v", v.req, err)
+		}
+	}
+
+	mem.mu.Lock()
+	defer mem.mu.Unlock()
+	if _, ok := mem.words[[2]int64{0xA8, 110}]; ok {
+		t.Errorf("D110 is written out of writable range")
+	}
+}
+
+func TestClient3E_WritableRangeQualified(t *testing.T) {
+	client, _ := newTestMemoryClient(t, WithWritableRange(`J1\X`, 0, 16))
+
+	// word unit write to qualified bit device covers 16 points per word too
+	if _, err := client.Write(`J1\X`, 0, 2, make([]byte, 4)); !errors.Is(err, ErrWriteNotAllowed) {
+		t.Fatalf("expected %v but actual is %v", ErrWriteNotAllowed, err)
+	}
+	// test PLC does not support extended devices, so the allowed write fails after the guard
+	if _, err := client.Write(`J1\X`, 0, 1, make([]byte, 2)); errors.Is(err, ErrWriteNotAllowed) {
+		t.Fatalf("unexpected guard err: %v", err)
+	}
+}
@@ -0,0 +1,150 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/csv"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// gxWorksTypes maps data types of GX Works2 and GX Works3 labels to types of Tag.
+var gxWorksTypes = map[string]string{
+	// GX Works2
+	"bit":                                 "bool",
+	"word [signed]":                       "int16",
+	"word [unsigned]/bit string [16-bit]": "uint16",
+	"double word [signed]":                "int32",
+	"double word [unsigned]/bit string [32-bit]": "uint32",
+	"float (single precision)":                   "float32",
+	"float (double precision)":                   "float64",
+	"float [single precision]":                   "float32",
+	"float [double precision]":                   "float64",
+	// GX Works3
+	"bool":  "bool",
+	"int":   "int16",
+	"word":  "uint16",
+	"uint":  "uint16",
+	"dint":  "int32",
+	"dword": "uint32",
+	"udint": "uint32",
+	"real":  "float32",
+	"lreal": "float64",
+}
+
+// ImportGXWorksLabels reads global label export of GX Works2 or GX Works3 and returns tags of the labels.
+// the export is CSV or tab separated text in UTF-8 or UTF-16 with columns of label name, data type, device and comment.
+// labels without assigned device and labels of unsupported data type like arrays and structures are skipped
+// because they can not be accessed by device address. load the tags by NewTagDB.
+func ImportGXWorksLabels(r io.Reader) ([]Tag, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	data = decodeGXWorksText(data)
+
+	// header row may follow title rows, so find it by column names
+	var rows [][]string
+	for _, comma := range []rune{'\t', ','} {
+		reader := csv.NewReader(bytes.NewReader(data))
+		reader.Comma, reader.FieldsPerRecord, reader.LazyQuotes = comma, -1, true
+		if rows, err = reader.ReadAll(); err == nil && gxWorksHeader(rows) >= 0 {
+			break
+		}
+	}
+	header := gxWorksHeader(rows)
+	if header < 0 {
+		return nil, errors.New("header of label export is not found")
+	}
+
+	name, dataType, device, comment := -1, -1, -1, -1
+	for i, column := range rows[header] {
+		switch c := strings.ToLower(strings.TrimSpace(column)); {
+		case c == "label name" || c == "label":
+			name = i
+		case c == "data type":
+			dataType = i
+		case c == "device" || strings.HasPrefix(c, "assign"):
+			device = i
+		case comment < 0 && (strings.HasPrefix(c, "comment") || strings.HasPrefix(c, "english")):
+			comment = i
+		}
+	}
+	if name < 0 || dataType < 0 || device < 0 {
+		return nil, errors.New("label export needs label name, data type and device columns")
+	}
+
+	var tags []Tag
+	for _, row := range rows[header+1:] {
+		field := func(i int) string {
+			if i < 0 || i >= len(row) {
+				return ""
+			}
+			return strings.TrimSpace(row[i])
+		}
+
+		t := Tag{Name: field(name), Device: field(device), Comment: field(comment)}
+		if t.Name == "" || t.Device == "" {
+			continue
+		}
+		if t.Type = gxWorksType(field(dataType)); t.Type == "" {
+			continue
+		}
+		if _, _, err := ParseDevice(t.Device); err != nil {
+			// like bit of word device D100.F
+			continue
+		}
+		tags = append(tags, t)
+	}
+	return tags, nil
+}
+
+// gxWorksHeader returns index of header row. -1 means not found.
+func gxWorksHeader(rows [][]string) int {
+	for i, row := range rows {
+		for _, column := range row {
+			if c := strings.ToLower(strings.TrimSpace(column)); c == "label name" || c == "label" {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// gxWorksType returns type of Tag for data type of label. empty string means unsupported type.
+func gxWorksType(dataType string) string {
+	t := strings.ToLower(strings.TrimSpace(dataType))
+	if v, ok := gxWorksTypes[t]; ok {
+		return v
+	}
+	// string like String(32) or STRING(32)
+	if strings.HasPrefix(t, "string(") && strings.HasSuffix(t, ")") {
+		if length, err := strconv.Atoi(t[len("string(") : len(t)-1]); err == nil && length > 0 {
+			return "string:" + strconv.Itoa(length)
+		}
+	}
+	return ""
+}
+
+// decodeGXWorksText converts UTF-16 text with BOM to UTF-8 and removes UTF-8 BOM.
+func decodeGXWorksText(data []byte) []byte {
+	var order binary.ByteOrder
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}):
+		order = binary.LittleEndian
+	case bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		order = binary.BigEndian
+	default:
+		return bytes.TrimPrefix(data, []byte{0xEF, 0xBB, 0xBF})
+	}
+
+	data = data[2:]
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = order.Uint16(data[2*i:])
+	}
+	return []byte(string(utf16.Decode(units)))
+}
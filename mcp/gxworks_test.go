@@ -0,0 +1,57 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestImportGXWorksLabels(t *testing.T) {
+	gxWorks2 := "Global Label Setting\n" +
+		"Class\tLabel Name\tData Type\tConstant\tDevice\tAddress\tComment\tRemark\n" +
+		"VAR_GLOBAL\tFurnaceTemp\tFLOAT (Single Precision)\t\tD1500\t%MD0.1500\tfurnace temperature\t\n" +
+		"VAR_GLOBAL\tRunning\tBit\t\tM100\t%MX0.100\t\t\n" +
+		"VAR_GLOBAL\tRecipe\tString(32)\t\tD1600\t%MW0.1600\trecipe name\t\n" +
+		"VAR_GLOBAL\tAuto\tWord [Signed]\t\t\t\tauto assigned\t\n" +
+		"VAR_GLOBAL\tTable\tWord [Signed](0..9)\t\tD2000\t%MW0.2000\t\t\n"
+
+	gxWorks3 := "\"Label Name\",\"Data Type\",\"Class\",\"Assign (Device/Label)\",\"Initial Value\",\"Constant\",\"English(Display Target)\"\n" +
+		"\"FurnaceTemp\",\"FLOAT [Single Precision]\",\"VAR_GLOBAL\",\"D1500\",\"\",\"\",\"furnace temperature\"\n" +
+		"\"Running\",\"Bit\",\"VAR_GLOBAL\",\"M100\",\"\",\"\",\"\"\n" +
+		"\"Recipe\",\"String(32)\",\"VAR_GLOBAL\",\"D1600\",\"\",\"\",\"recipe name\"\n" +
+		"\"Flag\",\"Bit\",\"VAR_GLOBAL\",\"D100.F\",\"\",\"\",\"\"\n"
+
+	// GX Works3 exports UTF-16 text
+	units := utf16.Encode([]rune(gxWorks3))
+	utf16Text := []byte{0xFF, 0xFE}
+	for _, u := range units {
+		utf16Text = binary.LittleEndian.AppendUint16(utf16Text, u)
+	}
+
+	expected := []Tag{
+		{Name: "FurnaceTemp", Device: "D1500", Type: "float32", Comment: "furnace temperature"},
+		{Name: "Running", Device: "M100", Type: "bool"},
+		{Name: "Recipe", Device: "D1600", Type: "string:32", Comment: "recipe name"},
+	}
+	for _, input := range [][]byte{[]byte(gxWorks2), []byte(gxWorks3), utf16Text} {
+		tags, err := ImportGXWorksLabels(bytes.NewReader(input))
+		if err != nil {
+			t.Fatalf("unexpected import err: %v", err)
+		}
+		if diff := cmp.Diff(tags, expected, cmpopts.IgnoreUnexported(Tag{})); diff != "" {
+			t.Errorf("tags differs: (-got +want)\n%s", diff)
+		}
+		if _, err := NewTagDB(tags); err != nil {
+			t.Errorf("unexpected tag err: %v", err)
+		}
+	}
+
+	if _, err := ImportGXWorksLabels(strings.NewReader("a,b,c\n1,2,3\n")); err == nil {
+		t.Fatalf("expected error for unknown format")
+	}
+}
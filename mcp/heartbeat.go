@@ -0,0 +1,99 @@
+package mcp
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// heartbeat is state of background health check.
+type heartbeat struct {
+	// unix nano time of the last successful request
+	lastActivity int64
+	// 1 when the last health check failed
+	unhealthy int32
+	// stops heartbeat goroutine
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// touch records successful request.
+func (h *heartbeat) touch() {
+	atomic.StoreInt64(&h.lastActivity, time.Now().UnixNano())
+}
+
+// idle returns duration since the last successful request.
+func (h *heartbeat) idle() time.Duration {
+	return time.Since(time.Unix(0, atomic.LoadInt64(&h.lastActivity)))
+}
+
+// Healthy returns false when the last background health check failed.
+// it is always true when heartbeat is not enabled by WithHeartbeat.
+func (c *client3E) Healthy() bool {
+	return atomic.LoadInt32(&c.heartbeat.unhealthy) == 0
+}
+
+// startHeartbeat starts goroutine that issues loopback test while connection is idle for heartbeat interval.
+// when loopback test fails, client is marked unhealthy and connects to PLC again.
+func (c *client3E) startHeartbeat() {
+	interval := c.opts.heartbeatInterval
+	if interval <= 0 {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c.heartbeat.cancel = cancel
+	c.heartbeat.done = make(chan struct{})
+	c.heartbeat.touch()
+
+	go func() {
+		defer close(c.heartbeat.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			if c.heartbeat.idle() < interval {
+				continue
+			}
+			c.beatHelper(ctx, interval)
+		}
+	}()
+}
+
+// beatHelper issues loopback test and connects again when it fails.
+func (c *client3E) beatHelper(ctx context.Context, timeout time.Duration) {
+	checkCtx, cancel := context.WithTimeout(ctx, timeout)
+	err := c.HealthCheckContext(checkCtx)
+	cancel()
+	if ctx.Err() != nil {
+		return
+	}
+	if err == nil {
+		atomic.StoreInt32(&c.heartbeat.unhealthy, 0)
+		return
+	}
+
+	atomic.StoreInt32(&c.heartbeat.unhealthy, 1)
+	if len(c.addrs) == 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.emitHelper(Disconnected, err)
+	if c.reconnectHelper(ctx, err) == nil {
+		atomic.StoreInt32(&c.heartbeat.unhealthy, 0)
+	}
+}
+
+// stopHeartbeat stops heartbeat goroutine and waits it.
+func (c *client3E) stopHeartbeat() {
+	if c.heartbeat.cancel == nil {
+		return
+	}
+	c.heartbeat.cancel()
+	<-c.heartbeat.done
+}
@@ -0,0 +1,59 @@
+package mcp
+
+import (
+	"encoding/hex"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClient3E_Heartbeat(t *testing.T) {
+	var beats, failing int32
+	host, port := newTestServer(t, func(req []byte) []byte {
+		if hex.EncodeToString(req[11:13]) != "1906" {
+			return nil
+		}
+		atomic.AddInt32(&beats, 1)
+		respHex := "d000 00 ff ff03 00 0900 0000 0500 4142434445"
+		if atomic.LoadInt32(&failing) == 1 {
+			respHex = "d000 00 ff ff03 00 0b00 59c0 00 ff ff03 00 1906 0000"
+		}
+		resp, _ := hex.DecodeString(strings.ReplaceAll(respHex, " ", ""))
+		return resp
+	})
+
+	reconnecting := make(chan ConnEvent, 10)
+	client, err := New3EClient(host, port, NewLocalStation(), false,
+		WithHeartbeat(10*time.Millisecond), WithBackoff(Backoff{Initial: time.Millisecond, MaxAttempts: 1}),
+		WithConnStateHandler(func(e ConnEvent) {
+			if e.State == Reconnecting {
+				select {
+				case reconnecting <- e:
+				default:
+				}
+			}
+		}))
+	if err != nil {
+		t.Fatalf("unexpected connect err: %v", err)
+	}
+	defer client.ShutDown()
+
+	time.Sleep(100 * time.Millisecond)
+	if atomic.LoadInt32(&beats) == 0 {
+		t.Fatalf("expected heartbeat while idle but no loopback test is issued")
+	}
+	if !client.Healthy() {
+		t.Fatalf("expected healthy client")
+	}
+
+	atomic.StoreInt32(&failing, 1)
+	select {
+	case e := <-reconnecting:
+		if _, ok := e.Err.(*EndCodeError); !ok {
+			t.Fatalf("expected *EndCodeError cause but actual is %v", e.Err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected reconnection after failed heartbeat")
+	}
+}
@@ -0,0 +1,96 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// LogLevels is levels of logs of client.
+type LogLevels struct {
+	// level of connects, reconnects and disconnects
+	Conn slog.Level
+	// level of request summaries
+	Request slog.Level
+	// level of failed requests and abnormal end codes
+	Error slog.Level
+}
+
+// DefaultLogLevels logs connection state by Info, requests by Debug and errors by Warn.
+var DefaultLogLevels = LogLevels{
+	Conn:    slog.LevelInfo,
+	Request: slog.LevelDebug,
+	Error:   slog.LevelWarn,
+}
+
+// WithLogger logs connection state changes, requests and abnormal end codes to logger.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithLogLevels sets levels of logs of WithLogger. default is DefaultLogLevels.
+func WithLogLevels(levels LogLevels) Option {
+	return func(o *options) {
+		o.logLevels = levels
+	}
+}
+
+// logConnHelper logs connection state change.
+func (c *client3E) logConnHelper(e ConnEvent) {
+	if c.opts.logger == nil {
+		return
+	}
+	attrs := []slog.Attr{slog.String("addr", e.Addr)}
+	level := c.opts.logLevels.Conn
+	if e.Err != nil {
+		attrs = append(attrs, slog.Any("error", e.Err))
+		if e.State == Disconnected {
+			level = c.opts.logLevels.Error
+		}
+	}
+	c.opts.logger.LogAttrs(context.Background(), level, "plc connection "+e.State.String(), attrs...)
+}
+
+// loggingMiddleware logs summary of each request and its end code.
+func (c *client3E) loggingMiddleware(next Handler) Handler {
+	return func(ctx context.Context, req *Request) ([]byte, error) {
+		start := time.Now()
+		resp, err := next(ctx, req)
+
+		attrs := []slog.Attr{
+			slog.String("op", req.Op.String()),
+			slog.Duration("duration", time.Since(start)),
+		}
+		switch req.Op {
+		case OpHealthCheck:
+		case OpCommand:
+			attrs = append(attrs, slog.String("command", fmt.Sprintf("%04X", req.command())))
+		default:
+			attrs = append(attrs,
+				slog.String("device", req.DeviceName),
+				slog.Int64("offset", req.Offset),
+				slog.Int64("points", req.NumPoints),
+			)
+		}
+		if err != nil {
+			attrs = append(attrs, slog.Any("error", err))
+			c.opts.logger.LogAttrs(ctx, c.opts.logLevels.Error, "plc request failed", attrs...)
+			return resp, err
+		}
+
+		if response, perr := NewParser().Do(resp); perr == nil && response.EndCode != 0 {
+			endCodeErr := &EndCodeError{EndCode: response.EndCode, Response: response}
+			attrs = append(attrs,
+				slog.String("end_code", fmt.Sprintf("%04X", response.EndCode)),
+				slog.String("end_code_message", endCodeErr.Message()),
+			)
+			c.opts.logger.LogAttrs(ctx, c.opts.logLevels.Error, "plc returned abnormal end code", attrs...)
+			return resp, err
+		}
+		c.opts.logger.LogAttrs(ctx, c.opts.logLevels.Request, "plc request", attrs...)
+		return resp, err
+	}
+}
@@ -0,0 +1,35 @@
+package mcp
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestClient3E_Logger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	client, _ := newTestMemoryClient(t, WithLogger(logger))
+	if _, err := client.Read("D", 100, 2); err != nil {
+		t.Fatalf("unexpected mcp read err: %v", err)
+	}
+	// test PLC does not support iQ-R subcommand
+	if _, err := client.Read("RD", 0, 1); err == nil {
+		t.Fatalf("expected *EndCodeError but actual is nil")
+	}
+
+	logs := buf.String()
+	for _, expected := range []string{
+		`level=INFO msg="plc connection Connected"`,
+		`level=DEBUG msg="plc request" op=Read`,
+		"device=D offset=100 points=2",
+		`level=WARN msg="plc returned abnormal end code" op=Read`,
+		"end_code=C059",
+	} {
+		if !strings.Contains(logs, expected) {
+			t.Errorf("expected log contains %v but actual is\n%s", expected, logs)
+		}
+	}
+}
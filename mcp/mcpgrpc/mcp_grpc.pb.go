@@ -0,0 +1,258 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             v25.3.0
+// source: mcp.proto
+
+// MC protocol service that exposes devices of PLC to non-Go services.
+
+package mcpgrpc
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	MCProtocol_Read_FullMethodName        = "/mcprotocol.v1.MCProtocol/Read"
+	MCProtocol_Write_FullMethodName       = "/mcprotocol.v1.MCProtocol/Write"
+	MCProtocol_Subscribe_FullMethodName   = "/mcprotocol.v1.MCProtocol/Subscribe"
+	MCProtocol_HealthCheck_FullMethodName = "/mcprotocol.v1.MCProtocol/HealthCheck"
+)
+
+// MCProtocolClient is the client API for MCProtocol service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type MCProtocolClient interface {
+	// Read reads word devices, or bit devices when bits is true.
+	Read(ctx context.Context, in *ReadRequest, opts ...grpc.CallOption) (*Values, error)
+	// Write writes words to word devices or bits to bit devices.
+	Write(ctx context.Context, in *WriteRequest, opts ...grpc.CallOption) (*Values, error)
+	// Subscribe polls device ranges and streams their values.
+	Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (MCProtocol_SubscribeClient, error)
+	// HealthCheck checks PLC by loopback test.
+	HealthCheck(ctx context.Context, in *HealthCheckRequest, opts ...grpc.CallOption) (*HealthCheckResponse, error)
+}
+
+type mCProtocolClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewMCProtocolClient(cc grpc.ClientConnInterface) MCProtocolClient {
+	return &mCProtocolClient{cc}
+}
+
+func (c *mCProtocolClient) Read(ctx context.Context, in *ReadRequest, opts ...grpc.CallOption) (*Values, error) {
+	out := new(Values)
+	err := c.cc.Invoke(ctx, MCProtocol_Read_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mCProtocolClient) Write(ctx context.Context, in *WriteRequest, opts ...grpc.CallOption) (*Values, error) {
+	out := new(Values)
+	err := c.cc.Invoke(ctx, MCProtocol_Write_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mCProtocolClient) Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (MCProtocol_SubscribeClient, error) {
+	stream, err := c.cc.NewStream(ctx, &MCProtocol_ServiceDesc.Streams[0], MCProtocol_Subscribe_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &mCProtocolSubscribeClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type MCProtocol_SubscribeClient interface {
+	Recv() (*Update, error)
+	grpc.ClientStream
+}
+
+type mCProtocolSubscribeClient struct {
+	grpc.ClientStream
+}
+
+func (x *mCProtocolSubscribeClient) Recv() (*Update, error) {
+	m := new(Update)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *mCProtocolClient) HealthCheck(ctx context.Context, in *HealthCheckRequest, opts ...grpc.CallOption) (*HealthCheckResponse, error) {
+	out := new(HealthCheckResponse)
+	err := c.cc.Invoke(ctx, MCProtocol_HealthCheck_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// MCProtocolServer is the server API for MCProtocol service.
+// All implementations must embed UnimplementedMCProtocolServer
+// for forward compatibility
+type MCProtocolServer interface {
+	// Read reads word devices, or bit devices when bits is true.
+	Read(context.Context, *ReadRequest) (*Values, error)
+	// Write writes words to word devices or bits to bit devices.
+	Write(context.Context, *WriteRequest) (*Values, error)
+	// Subscribe polls device ranges and streams their values.
+	Subscribe(*SubscribeRequest, MCProtocol_SubscribeServer) error
+	// HealthCheck checks PLC by loopback test.
+	HealthCheck(context.Context, *HealthCheckRequest) (*HealthCheckResponse, error)
+	mustEmbedUnimplementedMCProtocolServer()
+}
+
+// UnimplementedMCProtocolServer must be embedded to have forward compatible implementations.
+type UnimplementedMCProtocolServer struct {
+}
+
+func (UnimplementedMCProtocolServer) Read(context.Context, *ReadRequest) (*Values, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Read not implemented")
+}
+func (UnimplementedMCProtocolServer) Write(context.Context, *WriteRequest) (*Values, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Write not implemented")
+}
+func (UnimplementedMCProtocolServer) Subscribe(*SubscribeRequest, MCProtocol_SubscribeServer) error {
+	return status.Errorf(codes.Unimplemented, "method Subscribe not implemented")
+}
+func (UnimplementedMCProtocolServer) HealthCheck(context.Context, *HealthCheckRequest) (*HealthCheckResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method HealthCheck not implemented")
+}
+func (UnimplementedMCProtocolServer) mustEmbedUnimplementedMCProtocolServer() {}
+
+// UnsafeMCProtocolServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to MCProtocolServer will
+// result in compilation errors.
+type UnsafeMCProtocolServer interface {
+	mustEmbedUnimplementedMCProtocolServer()
+}
+
+func RegisterMCProtocolServer(s grpc.ServiceRegistrar, srv MCProtocolServer) {
+	s.RegisterService(&MCProtocol_ServiceDesc, srv)
+}
+
+func _MCProtocol_Read_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MCProtocolServer).Read(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MCProtocol_Read_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MCProtocolServer).Read(ctx, req.(*ReadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MCProtocol_Write_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WriteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MCProtocolServer).Write(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MCProtocol_Write_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MCProtocolServer).Write(ctx, req.(*WriteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MCProtocol_Subscribe_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MCProtocolServer).Subscribe(m, &mCProtocolSubscribeServer{stream})
+}
+
+type MCProtocol_SubscribeServer interface {
+	Send(*Update) error
+	grpc.ServerStream
+}
+
+type mCProtocolSubscribeServer struct {
+	grpc.ServerStream
+}
+
+func (x *mCProtocolSubscribeServer) Send(m *Update) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _MCProtocol_HealthCheck_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HealthCheckRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MCProtocolServer).HealthCheck(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MCProtocol_HealthCheck_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MCProtocolServer).HealthCheck(ctx, req.(*HealthCheckRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// MCProtocol_ServiceDesc is the grpc.ServiceDesc for MCProtocol service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var MCProtocol_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "mcprotocol.v1.MCProtocol",
+	HandlerType: (*MCProtocolServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Read",
+			Handler:    _MCProtocol_Read_Handler,
+		},
+		{
+			MethodName: "Write",
+			Handler:    _MCProtocol_Write_Handler,
+		},
+		{
+			MethodName: "HealthCheck",
+			Handler:    _MCProtocol_HealthCheck_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Subscribe",
+			Handler:       _MCProtocol_Subscribe_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "mcp.proto",
+}
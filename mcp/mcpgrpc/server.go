@@ -0,0 +1,168 @@
+// Package mcpgrpc serves devices of PLC over gRPC, so non-Go services can use this package through a sidecar.
+// service is defined in mcp.proto.
+package mcpgrpc
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative mcp.proto
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"time"
+
+	"github.com/CaptainPineapple/go-mcprotocol/mcp"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Server implements MCProtocolServer by client.
+//
+//	s := grpc.NewServer()
+//	mcpgrpc.RegisterMCProtocolServer(s, mcpgrpc.NewServer(client))
+//	s.Serve(lis)
+type Server struct {
+	UnimplementedMCProtocolServer
+	client mcp.Client
+}
+
+// NewServer returns Server that reads and writes devices by client.
+func NewServer(client mcp.Client) *Server {
+	return &Server{client: client}
+}
+
+func (s *Server) Read(ctx context.Context, req *ReadRequest) (*Values, error) {
+	r, err := readRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	var payload []byte
+	if r.Op == mcp.OpBitRead {
+		payload, err = s.client.BitReadContext(ctx, r.DeviceName, r.Offset, r.NumPoints)
+	} else {
+		payload, err = s.client.ReadContext(ctx, r.DeviceName, r.Offset, r.NumPoints)
+	}
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return values(r, payload), nil
+}
+
+func (s *Server) Write(ctx context.Context, req *WriteRequest) (*Values, error) {
+	deviceName, offset, err := mcp.ParseDevice(req.Device)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if (len(req.Words) == 0) == (len(req.Bits) == 0) {
+		return nil, status.Error(codes.InvalidArgument, "either words or bits must be set")
+	}
+
+	if len(req.Bits) > 0 {
+		_, err = s.client.BitWriteContext(ctx, deviceName, offset, int64(len(req.Bits)), mcp.EncodeBits(req.Bits))
+	} else {
+		data := make([]byte, len(req.Words)*2)
+		for i, v := range req.Words {
+			if v > 0xFFFF {
+				return nil, status.Errorf(codes.InvalidArgument, "word must be 16bit: %v", v)
+			}
+			binary.LittleEndian.PutUint16(data[i*2:], uint16(v))
+		}
+		_, err = s.client.WriteContext(ctx, deviceName, offset, int64(len(req.Words)), data)
+	}
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &Values{Device: mcp.FormatDevice(deviceName, offset), Words: req.Words, Bits: req.Bits}, nil
+}
+
+func (s *Server) Subscribe(req *SubscribeRequest, stream MCProtocol_SubscribeServer) error {
+	if req.IntervalMs == 0 {
+		return status.Error(codes.InvalidArgument, "interval must be set")
+	}
+	ranges := make([]mcp.Request, len(req.Ranges))
+	for i, v := range req.Ranges {
+		r, err := readRequest(v)
+		if err != nil {
+			return err
+		}
+		ranges[i] = r
+	}
+
+	var opts []mcp.SubscribeOption
+	if req.OnlyChanges {
+		opts = append(opts, mcp.OnlyChanges())
+	}
+	sub, err := mcp.Subscribe(stream.Context(), s.client, time.Duration(req.IntervalMs)*time.Millisecond, ranges, opts...)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	defer sub.Close()
+
+	for u := range sub.C {
+		update := &Update{Index: uint32(u.Index), TimeUnixNano: u.Time.UnixNano()}
+		if u.Err != nil {
+			update.Error = u.Err.Error()
+		} else {
+			update.Values = values(u.Request, u.Payload)
+		}
+		if err := stream.Send(update); err != nil {
+			return err
+		}
+	}
+	return status.FromContextError(stream.Context().Err()).Err()
+}
+
+func (s *Server) HealthCheck(ctx context.Context, req *HealthCheckRequest) (*HealthCheckResponse, error) {
+	if err := s.client.HealthCheckContext(ctx); err != nil {
+		return nil, statusError(err)
+	}
+	return &HealthCheckResponse{}, nil
+}
+
+// readRequest converts ReadRequest to read request of mcp.
+func readRequest(req *ReadRequest) (mcp.Request, error) {
+	deviceName, offset, err := mcp.ParseDevice(req.Device)
+	if err != nil {
+		return mcp.Request{}, status.Error(codes.InvalidArgument, err.Error())
+	}
+	r := mcp.Request{Op: mcp.OpRead, DeviceName: deviceName, Offset: offset, NumPoints: int64(req.Points)}
+	if req.Bits {
+		r.Op = mcp.OpBitRead
+	}
+	if r.NumPoints == 0 {
+		r.NumPoints = 1
+	}
+	return r, nil
+}
+
+// values converts payload of read request r to Values.
+func values(r mcp.Request, payload []byte) *Values {
+	v := &Values{Device: mcp.FormatDevice(r.DeviceName, r.Offset)}
+	if r.Op == mcp.OpBitRead {
+		v.Bits = mcp.DecodeBits(payload, r.NumPoints)
+		return v
+	}
+	v.Words = make([]uint32, len(payload)/2)
+	for i := range v.Words {
+		v.Words[i] = uint32(binary.LittleEndian.Uint16(payload[i*2:]))
+	}
+	return v
+}
+
+// statusError converts error of client to gRPC status.
+// invalid requests are InvalidArgument, errors returned by PLC are FailedPrecondition and others are Unavailable.
+func statusError(err error) error {
+	var endCodeErr *mcp.EndCodeError
+	switch {
+	case errors.Is(err, mcp.ErrInvalidDevice), errors.Is(err, mcp.ErrInvalidOffset),
+		errors.Is(err, mcp.ErrInvalidPoints), errors.Is(err, mcp.ErrShortWriteData):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, mcp.ErrWriteNotAllowed):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.As(err, &endCodeErr):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+		return status.FromContextError(err).Err()
+	default:
+		return status.Error(codes.Unavailable, err.Error())
+	}
+}
@@ -0,0 +1,151 @@
+package mcpgrpc
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/CaptainPineapple/go-mcprotocol/mcp"
+	"github.com/CaptainPineapple/go-mcprotocol/mcp/mcptest"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+// newTestClient returns MCProtocolClient of Server connected to mcptest.Server that has 1, 2, 3 in D100-D102,
+// ON, OFF, ON in X1F-X21 and ON in M10.
+func newTestClient(t *testing.T) (MCProtocolClient, *mcptest.Server) {
+	t.Helper()
+	plc, err := mcptest.NewServer()
+	if err != nil {
+		t.Fatalf("failed to start test server: %v", err)
+	}
+	t.Cleanup(func() { plc.Close() })
+	if err := plc.SetWords("D100", 1, 2, 3); err != nil {
+		t.Fatalf("unexpected set err: %v", err)
+	}
+	if err := plc.SetBits("X1F", true, false, true); err != nil {
+		t.Fatalf("unexpected set err: %v", err)
+	}
+	if err := plc.SetBits("M10", true); err != nil {
+		t.Fatalf("unexpected set err: %v", err)
+	}
+	client, err := mcp.New3EClient(plc.Host(), plc.Port(), mcp.NewLocalStation(), true)
+	if err != nil {
+		t.Fatalf("unexpected connect err: %v", err)
+	}
+	t.Cleanup(client.ShutDown)
+
+	lis := bufconn.Listen(1024 * 1024)
+	s := grpc.NewServer()
+	RegisterMCProtocolServer(s, NewServer(client))
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.DialContext(context.Background(), "bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("unexpected dial err: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return NewMCProtocolClient(conn), plc
+}
+
+func TestServer_ReadWrite(t *testing.T) {
+	client, plc := newTestClient(t)
+	ctx := context.Background()
+
+	values, err := client.Read(ctx, &ReadRequest{Device: "D100", Points: 3})
+	if err != nil {
+		t.Fatalf("unexpected read err: %v", err)
+	}
+	if diff := cmp.Diff(values, &Values{Device: "D100", Words: []uint32{1, 2, 3}}, protocmp.Transform()); diff != "" {
+		t.Errorf("read values differs: (-got +want)\n%s", diff)
+	}
+
+	values, err = client.Read(ctx, &ReadRequest{Device: "x1f", Points: 3, Bits: true})
+	if err != nil {
+		t.Fatalf("unexpected bit read err: %v", err)
+	}
+	if diff := cmp.Diff(values, &Values{Device: "X1F", Bits: []bool{true, false, true}}, protocmp.Transform()); diff != "" {
+		t.Errorf("bit read values differs: (-got +want)\n%s", diff)
+	}
+
+	if _, err := client.Write(ctx, &WriteRequest{Device: "D200", Words: []uint32{4, 5}}); err != nil {
+		t.Fatalf("unexpected write err: %v", err)
+	}
+	words, err := plc.Words("D200", 2)
+	if err != nil {
+		t.Fatalf("unexpected words err: %v", err)
+	}
+	if diff := cmp.Diff(words, []uint16{4, 5}); diff != "" {
+		t.Errorf("words differs: (-got +want)\n%s", diff)
+	}
+	if _, err := client.HealthCheck(ctx, &HealthCheckRequest{}); err != nil {
+		t.Fatalf("unexpected health check err: %v", err)
+	}
+
+	cases := []struct {
+		err  error
+		code codes.Code
+	}{
+		{err: func() error { _, err := client.Read(ctx, &ReadRequest{Device: "D1A"}); return err }(), code: codes.InvalidArgument},
+		{err: func() error { _, err := client.Write(ctx, &WriteRequest{Device: "D100"}); return err }(), code: codes.InvalidArgument},
+		{err: func() error {
+			_, err := client.Write(ctx, &WriteRequest{Device: "D100", Words: []uint32{0x10000}})
+			return err
+		}(), code: codes.InvalidArgument},
+		// extended device is not supported by the PLC
+		{err: func() error { _, err := client.Read(ctx, &ReadRequest{Device: `J1\W0`}); return err }(), code: codes.FailedPrecondition},
+		{err: func() error { plc.Close(); _, err := client.HealthCheck(ctx, &HealthCheckRequest{}); return err }(), code: codes.Unavailable},
+	}
+	for i, v := range cases {
+		if code := status.Code(v.err); code != v.code {
+			t.Errorf("%v: expected %v but actual is %v: %v", i, v.code, code, v.err)
+		}
+	}
+}
+
+func TestServer_Subscribe(t *testing.T) {
+	client, _ := newTestClient(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream, err := client.Subscribe(ctx, &SubscribeRequest{
+		Ranges:      []*ReadRequest{{Device: "D100", Points: 2}, {Device: "M10", Points: 1, Bits: true}},
+		IntervalMs:  10,
+		OnlyChanges: true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected subscribe err: %v", err)
+	}
+
+	var updates []*Values
+	for i := 0; i < 2; i++ {
+		u, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("unexpected recv err: %v", err)
+		}
+		if u.Index != uint32(i) || u.Error != "" {
+			t.Fatalf("unexpected update: %v", u)
+		}
+		updates = append(updates, u.Values)
+	}
+	expected := []*Values{{Device: "D100", Words: []uint32{1, 2}}, {Device: "M10", Bits: []bool{true}}}
+	if diff := cmp.Diff(updates, expected, protocmp.Transform()); diff != "" {
+		t.Errorf("updates differs: (-got +want)\n%s", diff)
+	}
+
+	stream, err = client.Subscribe(ctx, &SubscribeRequest{Ranges: []*ReadRequest{{Device: "D100"}}})
+	if err != nil {
+		t.Fatalf("unexpected subscribe err: %v", err)
+	}
+	if _, err := stream.Recv(); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected %v but actual is %v", codes.InvalidArgument, err)
+	}
+}
@@ -0,0 +1,212 @@
+// Package mcphttp serves devices of PLC over HTTP, so integrations and diagnostics by curl are possible without writing Go.
+//
+//	GET  /devices/D/100?points=4      read 4 words from D100
+//	GET  /devices/M/10?bits=1&points=8 read 8 bits from M10
+//	POST /devices/D/100               write {"words":[1,2]} or {"bits":[true,false]}
+//	GET  /health                      health check of PLC
+package mcphttp
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/CaptainPineapple/go-mcprotocol/mcp"
+)
+
+// MAX_POINTS is max number of device points of a request to avoid reading whole device memory by a request.
+const MAX_POINTS = 960
+
+// Values is JSON of read response and write request.
+// words are set to word devices and bits are set to bit devices.
+type Values struct {
+	Device string   `json:"device,omitempty"`
+	Words  []uint16 `json:"words,omitempty"`
+	Bits   []bool   `json:"bits,omitempty"`
+}
+
+// errorBody is JSON of error response.
+type errorBody struct {
+	Error   string `json:"error"`
+	EndCode string `json:"end_code,omitempty"`
+}
+
+// Option configures Handler.
+type Option func(*Handler)
+
+// ReadOnly rejects write requests with 405, for gateways that are used only for monitoring.
+func ReadOnly() Option {
+	return func(h *Handler) {
+		h.readOnly = true
+	}
+}
+
+// Handler serves devices of PLC by client.
+type Handler struct {
+	client   mcp.Client
+	readOnly bool
+	mux      *http.ServeMux
+}
+
+// NewHandler returns Handler that reads and writes devices by client.
+//
+//	http.ListenAndServe(":8080", mcphttp.NewHandler(client))
+func NewHandler(client mcp.Client, opts ...Option) *Handler {
+	h := &Handler{client: client, mux: http.NewServeMux()}
+	for _, opt := range opts {
+		opt(h)
+	}
+	h.mux.HandleFunc("/devices/", h.serveDevice)
+	h.mux.HandleFunc("/health", h.serveHealth)
+	return h
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *Handler) serveHealth(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	if err := h.client.HealthCheckContext(r.Context()); err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (h *Handler) serveDevice(w http.ResponseWriter, r *http.Request) {
+	// path is /devices/{device}/{number}. device number of X, Y, B, W, SB and SW is hexadecimal.
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/devices/"), "/")
+	if len(parts) != 2 {
+		writeError(w, http.StatusNotFound, errors.New("path must be /devices/{device}/{number}"))
+		return
+	}
+	address := parts[0] + parts[1]
+	deviceName, offset, err := mcp.ParseDevice(address)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		h.read(w, r, deviceName, offset)
+	case http.MethodPost:
+		if h.readOnly {
+			writeError(w, http.StatusMethodNotAllowed, errors.New("gateway is read only"))
+			return
+		}
+		h.write(w, r, deviceName, offset)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+	}
+}
+
+func (h *Handler) read(w http.ResponseWriter, r *http.Request, deviceName string, offset int64) {
+	query := r.URL.Query()
+	points := int64(1)
+	if s := query.Get("points"); s != "" {
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || n <= 0 || n > MAX_POINTS {
+			writeError(w, http.StatusBadRequest, errors.New("points must be 1 to "+strconv.Itoa(MAX_POINTS)))
+			return
+		}
+		points = n
+	}
+	bits, _ := strconv.ParseBool(query.Get("bits"))
+
+	values := Values{Device: mcp.FormatDevice(deviceName, offset)}
+	if bits {
+		payload, err := h.client.BitReadContext(r.Context(), deviceName, offset, points)
+		if err != nil {
+			writeError(w, statusOf(err), err)
+			return
+		}
+		values.Bits = mcp.DecodeBits(payload, points)
+	} else {
+		payload, err := h.client.ReadContext(r.Context(), deviceName, offset, points)
+		if err != nil {
+			writeError(w, statusOf(err), err)
+			return
+		}
+		values.Words = make([]uint16, len(payload)/2)
+		for i := range values.Words {
+			values.Words[i] = binary.LittleEndian.Uint16(payload[i*2:])
+		}
+	}
+	writeJSON(w, http.StatusOK, values)
+}
+
+func (h *Handler) write(w http.ResponseWriter, r *http.Request, deviceName string, offset int64) {
+	var values Values
+	if err := json.NewDecoder(r.Body).Decode(&values); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if (len(values.Words) == 0) == (len(values.Bits) == 0) {
+		writeError(w, http.StatusBadRequest, errors.New("either words or bits must be set"))
+		return
+	}
+	if len(values.Words) > MAX_POINTS || len(values.Bits) > MAX_POINTS {
+		writeError(w, http.StatusBadRequest, errors.New("points must be 1 to "+strconv.Itoa(MAX_POINTS)))
+		return
+	}
+
+	var err error
+	if len(values.Bits) > 0 {
+		_, err = h.client.BitWriteContext(r.Context(), deviceName, offset, int64(len(values.Bits)), mcp.EncodeBits(values.Bits))
+	} else {
+		data := make([]byte, len(values.Words)*2)
+		for i, v := range values.Words {
+			binary.LittleEndian.PutUint16(data[i*2:], v)
+		}
+		_, err = h.client.WriteContext(r.Context(), deviceName, offset, int64(len(values.Words)), data)
+	}
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+	values.Device = mcp.FormatDevice(deviceName, offset)
+	writeJSON(w, http.StatusOK, values)
+}
+
+// statusOf returns HTTP status of error of client.
+// invalid requests are 400, errors returned by PLC are 502 and others like timeout are 504 or 503.
+func statusOf(err error) int {
+	var endCodeErr *mcp.EndCodeError
+	switch {
+	case errors.Is(err, mcp.ErrInvalidDevice), errors.Is(err, mcp.ErrInvalidOffset),
+		errors.Is(err, mcp.ErrInvalidPoints), errors.Is(err, mcp.ErrShortWriteData):
+		return http.StatusBadRequest
+	case errors.Is(err, mcp.ErrWriteNotAllowed):
+		return http.StatusForbidden
+	case errors.As(err, &endCodeErr):
+		return http.StatusBadGateway
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusServiceUnavailable
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	body := errorBody{Error: err.Error()}
+	var endCodeErr *mcp.EndCodeError
+	if errors.As(err, &endCodeErr) {
+		body.EndCode = strings.ToUpper(strconv.FormatUint(uint64(endCodeErr.EndCode), 16))
+	}
+	writeJSON(w, status, body)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
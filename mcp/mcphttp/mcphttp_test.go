@@ -0,0 +1,123 @@
+package mcphttp
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/CaptainPineapple/go-mcprotocol/mcp"
+	"github.com/CaptainPineapple/go-mcprotocol/mcp/mcptest"
+	"github.com/google/go-cmp/cmp"
+)
+
+// newTestClient returns client connected to mcptest.Server that has 1, 2, 3 in D100-D102 and ON, OFF, ON in X1F-X21.
+func newTestClient(t *testing.T) (mcp.Client, *mcptest.Server) {
+	t.Helper()
+	plc, err := mcptest.NewServer()
+	if err != nil {
+		t.Fatalf("failed to start test server: %v", err)
+	}
+	t.Cleanup(func() { plc.Close() })
+	if err := plc.SetWords("D100", 1, 2, 3); err != nil {
+		t.Fatalf("unexpected set err: %v", err)
+	}
+	if err := plc.SetBits("X1F", true, false, true); err != nil {
+		t.Fatalf("unexpected set err: %v", err)
+	}
+	client, err := mcp.New3EClient(plc.Host(), plc.Port(), mcp.NewLocalStation(), true)
+	if err != nil {
+		t.Fatalf("unexpected connect err: %v", err)
+	}
+	t.Cleanup(client.ShutDown)
+	return client, plc
+}
+
+func TestHandler(t *testing.T) {
+	client, plc := newTestClient(t)
+	server := httptest.NewServer(NewHandler(client))
+	defer server.Close()
+
+	cases := []struct {
+		method string
+		path   string
+		body   string
+		status int
+		resp   string
+	}{
+		{method: "GET", path: "/devices/D/100?points=3", status: 200, resp: `{"device":"D100","words":[1,2,3]}`},
+		{method: "GET", path: "/devices/D/100", status: 200, resp: `{"device":"D100","words":[1]}`},
+		{method: "GET", path: "/devices/X/1F?bits=1&points=3", status: 200, resp: `{"device":"X1F","bits":[true,false,true]}`},
+		{method: "POST", path: "/devices/D/100", body: `{"words":[1,2]}`, status: 200, resp: `{"device":"D100","words":[1,2]}`},
+		{method: "POST", path: "/devices/M/10", body: `{"bits":[true]}`, status: 200, resp: `{"device":"M10","bits":[true]}`},
+		{method: "POST", path: "/devices/D/100", body: `{}`, status: 400, resp: `{"error":"either words or bits must be set"}`},
+		{method: "GET", path: "/devices/D/100?points=0", status: 400, resp: `{"error":"points must be 1 to 960"}`},
+		{method: "GET", path: "/devices/D/1A", status: 400, resp: `{"error":"invalid device address: D1A"}`},
+		{method: "GET", path: "/devices/D", status: 404, resp: `{"error":"path must be /devices/{device}/{number}"}`},
+		{method: "DELETE", path: "/devices/D/100", status: 405, resp: `{"error":"method not allowed"}`},
+		{method: "GET", path: "/health", status: 200, resp: `{"status":"ok"}`},
+	}
+
+	for _, v := range cases {
+		req, _ := http.NewRequest(v.method, server.URL+v.path, strings.NewReader(v.body))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("unexpected http err: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != v.status {
+			t.Errorf("%v %v: expected %v but actual is %v: %s", v.method, v.path, v.status, resp.StatusCode, body)
+			continue
+		}
+		if v.resp == "" {
+			continue
+		}
+		if diff := cmp.Diff(strings.TrimSpace(string(body)), v.resp); diff != "" {
+			t.Errorf("%v %v: response differs: (-got +want)\n%s", v.method, v.path, diff)
+		}
+	}
+
+	// written devices are in memory of PLC
+	words, err := plc.Words("D100", 2)
+	if err != nil {
+		t.Fatalf("unexpected words err: %v", err)
+	}
+	if diff := cmp.Diff(words, []uint16{1, 2}); diff != "" {
+		t.Errorf("words differs: (-got +want)\n%s", diff)
+	}
+	bits, err := plc.Bits("M10", 1)
+	if err != nil {
+		t.Fatalf("unexpected bits err: %v", err)
+	}
+	if diff := cmp.Diff(bits, []bool{true}); diff != "" {
+		t.Errorf("bits differs: (-got +want)\n%s", diff)
+	}
+
+	// PLC that does not answer is unavailable
+	plc.Close()
+	resp, err := http.Get(server.URL + "/health")
+	if err != nil {
+		t.Fatalf("unexpected http err: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("expected %v but actual is %v", http.StatusServiceUnavailable, resp.StatusCode)
+	}
+}
+
+func TestHandler_ReadOnly(t *testing.T) {
+	client, _ := newTestClient(t)
+	server := httptest.NewServer(NewHandler(client, ReadOnly()))
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/devices/D/100", "application/json", strings.NewReader(`{"words":[1]}`))
+	if err != nil {
+		t.Fatalf("unexpected http err: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("expected %v but actual is %v", http.StatusMethodNotAllowed, resp.StatusCode)
+	}
+}
@@ -0,0 +1,404 @@
+// Package mcpmodbus bridges Modbus TCP to MC protocol, so legacy Modbus HMIs can read and write devices of MELSEC PLCs.
+// configured device ranges are mapped onto holding registers and coils.
+package mcpmodbus
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/CaptainPineapple/go-mcprotocol/mcp"
+)
+
+// function codes of Modbus
+const (
+	FUNC_READ_COILS               = 0x01
+	FUNC_READ_DISCRETE_INPUTS     = 0x02
+	FUNC_READ_HOLDING_REGISTERS   = 0x03
+	FUNC_READ_INPUT_REGISTERS     = 0x04
+	FUNC_WRITE_SINGLE_COIL        = 0x05
+	FUNC_WRITE_SINGLE_REGISTER    = 0x06
+	FUNC_WRITE_MULTIPLE_COILS     = 0x0F
+	FUNC_WRITE_MULTIPLE_REGISTERS = 0x10
+)
+
+// exception codes of Modbus
+const (
+	EXCEPTION_ILLEGAL_FUNCTION      = 0x01
+	EXCEPTION_ILLEGAL_DATA_ADDRESS  = 0x02
+	EXCEPTION_ILLEGAL_DATA_VALUE    = 0x03
+	EXCEPTION_SERVER_DEVICE_FAILURE = 0x04
+	EXCEPTION_GATEWAY_TARGET_FAILED = 0x0B
+)
+
+// Range maps Count Modbus addresses from Address onto devices from Device like D100 or M0.
+type Range struct {
+	Address uint16
+	Count   uint16
+	Device  string
+}
+
+// mapping is parsed Range.
+type mapping struct {
+	Range
+	deviceName string
+	offset     int64
+}
+
+// Option configures Server.
+type Option func(*Server)
+
+// WithRequestTimeout sets timeout of requests to PLC. default is 5 seconds.
+func WithRequestTimeout(timeout time.Duration) Option {
+	return func(s *Server) {
+		s.timeout = timeout
+	}
+}
+
+// WithReadOnly rejects write functions by illegal function exception.
+func WithReadOnly() Option {
+	return func(s *Server) {
+		s.readOnly = true
+	}
+}
+
+// Server is Modbus TCP server that reads and writes devices by client.
+// holding registers (and input registers) are mapped onto word devices and coils (and discrete inputs) are mapped onto bit devices.
+// a request must be in one range, otherwise illegal data address exception is returned.
+type Server struct {
+	client    mcp.Client
+	registers []mapping
+	coils     []mapping
+	timeout   time.Duration
+	readOnly  bool
+
+	mu        sync.Mutex
+	listeners map[net.Listener]struct{}
+	conns     map[net.Conn]struct{}
+	closed    bool
+}
+
+// NewServer returns Server that maps registers onto word devices and coils onto bit devices.
+//
+//	s, _ := mcpmodbus.NewServer(client, []mcpmodbus.Range{{Address: 0, Count: 100, Device: "D100"}}, []mcpmodbus.Range{{Address: 0, Count: 64, Device: "M0"}})
+//	lis, _ := net.Listen("tcp", ":502")
+//	s.Serve(lis)
+func NewServer(client mcp.Client, registers, coils []Range, opts ...Option) (*Server, error) {
+	s := &Server{
+		client:    client,
+		timeout:   5 * time.Second,
+		listeners: map[net.Listener]struct{}{},
+		conns:     map[net.Conn]struct{}{},
+	}
+	var err error
+	if s.registers, err = parseRanges(registers); err != nil {
+		return nil, err
+	}
+	if s.coils, err = parseRanges(coils); err != nil {
+		return nil, err
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s, nil
+}
+
+func parseRanges(ranges []Range) ([]mapping, error) {
+	mappings := make([]mapping, len(ranges))
+	for i, r := range ranges {
+		if r.Count == 0 || int(r.Address)+int(r.Count) > 0x10000 {
+			return nil, errors.New("invalid modbus range of " + r.Device)
+		}
+		deviceName, offset, err := mcp.ParseDevice(r.Device)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range mappings[:i] {
+			if r.Address < m.Address+m.Count && m.Address < r.Address+r.Count {
+				return nil, errors.New("modbus range of " + r.Device + " overlaps " + m.Device)
+			}
+		}
+		mappings[i] = mapping{Range: r, deviceName: deviceName, offset: offset}
+	}
+	return mappings, nil
+}
+
+// Serve accepts connections on lis and serves them until Close is called.
+func (s *Server) Serve(lis net.Listener) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return net.ErrClosed
+	}
+	s.listeners[lis] = struct{}{}
+	s.mu.Unlock()
+
+	for {
+		conn, err := lis.Accept()
+		if err != nil {
+			s.mu.Lock()
+			closed := s.closed
+			delete(s.listeners, lis)
+			s.mu.Unlock()
+			if closed {
+				return net.ErrClosed
+			}
+			return err
+		}
+		go s.ServeConn(conn)
+	}
+}
+
+// ServeConn serves requests on conn until it is closed.
+func (s *Server) ServeConn(conn net.Conn) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		conn.Close()
+		return net.ErrClosed
+	}
+	s.conns[conn] = struct{}{}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+
+	// MBAP header is transaction id[2byte], protocol id[2byte], length[2byte] and unit id[1byte].
+	header := make([]byte, 7)
+	for {
+		if _, err := io.ReadFull(conn, header); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		length := binary.BigEndian.Uint16(header[4:6])
+		if binary.BigEndian.Uint16(header[2:4]) != 0 || length < 2 || length > 254 {
+			return errors.New("invalid modbus tcp header")
+		}
+		pdu := make([]byte, length-1)
+		if _, err := io.ReadFull(conn, pdu); err != nil {
+			return err
+		}
+
+		resp := s.handle(pdu)
+		frame := make([]byte, 7, 7+len(resp))
+		copy(frame, header)
+		binary.BigEndian.PutUint16(frame[4:6], uint16(len(resp)+1))
+		if _, err := conn.Write(append(frame, resp...)); err != nil {
+			return err
+		}
+	}
+}
+
+// Close closes all listeners and connections.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	for lis := range s.listeners {
+		lis.Close()
+	}
+	for conn := range s.conns {
+		conn.Close()
+	}
+	return nil
+}
+
+// handle returns response PDU of request PDU.
+func (s *Server) handle(pdu []byte) []byte {
+	function := pdu[0]
+	data := pdu[1:]
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	switch function {
+	case FUNC_READ_HOLDING_REGISTERS, FUNC_READ_INPUT_REGISTERS:
+		if len(data) != 4 {
+			return exception(function, EXCEPTION_ILLEGAL_DATA_VALUE)
+		}
+		address, count := binary.BigEndian.Uint16(data[0:2]), binary.BigEndian.Uint16(data[2:4])
+		if count == 0 || count > 125 {
+			return exception(function, EXCEPTION_ILLEGAL_DATA_VALUE)
+		}
+		m, ok := find(s.registers, address, count)
+		if !ok {
+			return exception(function, EXCEPTION_ILLEGAL_DATA_ADDRESS)
+		}
+		payload, err := s.client.ReadContext(ctx, m.deviceName, m.offset+int64(address-m.Address), int64(count))
+		if err != nil {
+			return exception(function, exceptionOf(err))
+		}
+		if len(payload) != int(count)*2 {
+			return exception(function, EXCEPTION_SERVER_DEVICE_FAILURE)
+		}
+		// MC protocol is little endian and Modbus is big endian
+		resp := []byte{function, byte(count * 2)}
+		for i := 0; i < len(payload); i += 2 {
+			resp = append(resp, payload[i+1], payload[i])
+		}
+		return resp
+
+	case FUNC_READ_COILS, FUNC_READ_DISCRETE_INPUTS:
+		if len(data) != 4 {
+			return exception(function, EXCEPTION_ILLEGAL_DATA_VALUE)
+		}
+		address, count := binary.BigEndian.Uint16(data[0:2]), binary.BigEndian.Uint16(data[2:4])
+		if count == 0 || count > 2000 {
+			return exception(function, EXCEPTION_ILLEGAL_DATA_VALUE)
+		}
+		m, ok := find(s.coils, address, count)
+		if !ok {
+			return exception(function, EXCEPTION_ILLEGAL_DATA_ADDRESS)
+		}
+		payload, err := s.client.BitReadContext(ctx, m.deviceName, m.offset+int64(address-m.Address), int64(count))
+		if err != nil {
+			return exception(function, exceptionOf(err))
+		}
+		coils := packCoils(mcp.DecodeBits(payload, int64(count)))
+		return append([]byte{function, byte(len(coils))}, coils...)
+
+	case FUNC_WRITE_SINGLE_REGISTER:
+		if s.readOnly {
+			return exception(function, EXCEPTION_ILLEGAL_FUNCTION)
+		}
+		if len(data) != 4 {
+			return exception(function, EXCEPTION_ILLEGAL_DATA_VALUE)
+		}
+		address := binary.BigEndian.Uint16(data[0:2])
+		m, ok := find(s.registers, address, 1)
+		if !ok {
+			return exception(function, EXCEPTION_ILLEGAL_DATA_ADDRESS)
+		}
+		if _, err := s.client.WriteContext(ctx, m.deviceName, m.offset+int64(address-m.Address), 1, []byte{data[3], data[2]}); err != nil {
+			return exception(function, exceptionOf(err))
+		}
+		return pdu
+
+	case FUNC_WRITE_SINGLE_COIL:
+		if s.readOnly {
+			return exception(function, EXCEPTION_ILLEGAL_FUNCTION)
+		}
+		if len(data) != 4 {
+			return exception(function, EXCEPTION_ILLEGAL_DATA_VALUE)
+		}
+		address, value := binary.BigEndian.Uint16(data[0:2]), binary.BigEndian.Uint16(data[2:4])
+		if value != 0x0000 && value != 0xFF00 {
+			return exception(function, EXCEPTION_ILLEGAL_DATA_VALUE)
+		}
+		m, ok := find(s.coils, address, 1)
+		if !ok {
+			return exception(function, EXCEPTION_ILLEGAL_DATA_ADDRESS)
+		}
+		if _, err := s.client.BitWriteContext(ctx, m.deviceName, m.offset+int64(address-m.Address), 1, mcp.EncodeBits([]bool{value == 0xFF00})); err != nil {
+			return exception(function, exceptionOf(err))
+		}
+		return pdu
+
+	case FUNC_WRITE_MULTIPLE_REGISTERS:
+		if s.readOnly {
+			return exception(function, EXCEPTION_ILLEGAL_FUNCTION)
+		}
+		if len(data) < 5 {
+			return exception(function, EXCEPTION_ILLEGAL_DATA_VALUE)
+		}
+		address, count := binary.BigEndian.Uint16(data[0:2]), binary.BigEndian.Uint16(data[2:4])
+		values := data[5:]
+		if count == 0 || count > 123 || int(data[4]) != int(count)*2 || len(values) != int(count)*2 {
+			return exception(function, EXCEPTION_ILLEGAL_DATA_VALUE)
+		}
+		m, ok := find(s.registers, address, count)
+		if !ok {
+			return exception(function, EXCEPTION_ILLEGAL_DATA_ADDRESS)
+		}
+		writeData := make([]byte, len(values))
+		for i := 0; i < len(values); i += 2 {
+			writeData[i], writeData[i+1] = values[i+1], values[i]
+		}
+		if _, err := s.client.WriteContext(ctx, m.deviceName, m.offset+int64(address-m.Address), int64(count), writeData); err != nil {
+			return exception(function, exceptionOf(err))
+		}
+		return []byte{function, data[0], data[1], data[2], data[3]}
+
+	case FUNC_WRITE_MULTIPLE_COILS:
+		if s.readOnly {
+			return exception(function, EXCEPTION_ILLEGAL_FUNCTION)
+		}
+		if len(data) < 5 {
+			return exception(function, EXCEPTION_ILLEGAL_DATA_VALUE)
+		}
+		address, count := binary.BigEndian.Uint16(data[0:2]), binary.BigEndian.Uint16(data[2:4])
+		values := data[5:]
+		if count == 0 || count > 1968 || int(data[4]) != (int(count)+7)/8 || len(values) != int(data[4]) {
+			return exception(function, EXCEPTION_ILLEGAL_DATA_VALUE)
+		}
+		m, ok := find(s.coils, address, count)
+		if !ok {
+			return exception(function, EXCEPTION_ILLEGAL_DATA_ADDRESS)
+		}
+		writeData := mcp.EncodeBits(unpackCoils(values, int(count)))
+		if _, err := s.client.BitWriteContext(ctx, m.deviceName, m.offset+int64(address-m.Address), int64(count), writeData); err != nil {
+			return exception(function, exceptionOf(err))
+		}
+		return []byte{function, data[0], data[1], data[2], data[3]}
+
+	default:
+		return exception(function, EXCEPTION_ILLEGAL_FUNCTION)
+	}
+}
+
+// find returns mapping that contains count addresses from address.
+func find(mappings []mapping, address, count uint16) (mapping, bool) {
+	for _, m := range mappings {
+		if m.Address <= address && int(address)+int(count) <= int(m.Address)+int(m.Count) {
+			return m, true
+		}
+	}
+	return mapping{}, false
+}
+
+// exception returns exception response PDU.
+func exception(function, code byte) []byte {
+	return []byte{function | 0x80, code}
+}
+
+// exceptionOf returns exception code of error of client.
+// errors returned by PLC are server device failure and the others like timeout are gateway target failed to respond.
+func exceptionOf(err error) byte {
+	var endCodeErr *mcp.EndCodeError
+	switch {
+	case errors.Is(err, mcp.ErrInvalidDevice), errors.Is(err, mcp.ErrInvalidOffset), errors.Is(err, mcp.ErrInvalidPoints):
+		return EXCEPTION_ILLEGAL_DATA_ADDRESS
+	case errors.As(err, &endCodeErr):
+		return EXCEPTION_SERVER_DEVICE_FAILURE
+	default:
+		return EXCEPTION_GATEWAY_TARGET_FAILED
+	}
+}
+
+// packCoils packs values to Modbus coil bytes that has 8 coils per 1 byte. LSB is first coil.
+func packCoils(values []bool) []byte {
+	coils := make([]byte, (len(values)+7)/8)
+	for i, v := range values {
+		if v {
+			coils[i/8] |= 1 << (i % 8)
+		}
+	}
+	return coils
+}
+
+// unpackCoils unpacks count values from Modbus coil bytes.
+func unpackCoils(coils []byte, count int) []bool {
+	values := make([]bool, count)
+	for i := range values {
+		values[i] = coils[i/8]&(1<<(i%8)) != 0
+	}
+	return values
+}
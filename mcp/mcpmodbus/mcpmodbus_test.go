@@ -0,0 +1,155 @@
+package mcpmodbus
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/CaptainPineapple/go-mcprotocol/mcp"
+	"github.com/CaptainPineapple/go-mcprotocol/mcp/mcptest"
+	"github.com/google/go-cmp/cmp"
+)
+
+// newTestClient returns client connected to mcptest.Server that has memory of word and bit devices.
+func newTestClient(t *testing.T) mcp.Client {
+	t.Helper()
+	plc, err := mcptest.NewServer()
+	if err != nil {
+		t.Fatalf("failed to start test server: %v", err)
+	}
+	t.Cleanup(func() { plc.Close() })
+	client, err := mcp.New3EClient(plc.Host(), plc.Port(), mcp.NewLocalStation(), true)
+	if err != nil {
+		t.Fatalf("unexpected connect err: %v", err)
+	}
+	t.Cleanup(client.ShutDown)
+	return client
+}
+
+// newTestConn returns connection to s.
+func newTestConn(t *testing.T, s *Server) net.Conn {
+	t.Helper()
+	conn, serverConn := net.Pipe()
+	go s.ServeConn(serverConn)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// exchange sends request PDU by MBAP header and returns response PDU.
+func exchange(t *testing.T, conn net.Conn, transaction uint16, pdu []byte) []byte {
+	t.Helper()
+	frame := binary.BigEndian.AppendUint16(nil, transaction)
+	frame = append(frame, 0x00, 0x00)
+	frame = binary.BigEndian.AppendUint16(frame, uint16(len(pdu)+1))
+	frame = append(frame, 0x01)
+	if _, err := conn.Write(append(frame, pdu...)); err != nil {
+		t.Fatalf("unexpected write err: %v", err)
+	}
+	header := make([]byte, 7)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		t.Fatalf("unexpected read err: %v", err)
+	}
+	if binary.BigEndian.Uint16(header[0:2]) != transaction || header[6] != 0x01 {
+		t.Fatalf("unexpected header: %X", header)
+	}
+	resp := make([]byte, binary.BigEndian.Uint16(header[4:6])-1)
+	if _, err := io.ReadFull(conn, resp); err != nil {
+		t.Fatalf("unexpected read err: %v", err)
+	}
+	return resp
+}
+
+func TestServer(t *testing.T) {
+	s, err := NewServer(newTestClient(t),
+		[]Range{{Address: 0, Count: 10, Device: "D100"}, {Address: 100, Count: 10, Device: "W1A0"}},
+		[]Range{{Address: 0, Count: 16, Device: "M0"}})
+	if err != nil {
+		t.Fatalf("unexpected server err: %v", err)
+	}
+	conn := newTestConn(t, s)
+
+	cases := []struct {
+		request  []byte
+		expected []byte
+	}{
+		// write 0x1234, 0x5678 to D102 and read D101-D103
+		{request: []byte{0x10, 0x00, 0x02, 0x00, 0x02, 0x04, 0x12, 0x34, 0x56, 0x78}, expected: []byte{0x10, 0x00, 0x02, 0x00, 0x02}},
+		{request: []byte{0x03, 0x00, 0x01, 0x00, 0x03}, expected: []byte{0x03, 0x06, 0x00, 0x00, 0x12, 0x34, 0x56, 0x78}},
+		// write W1A1 by single register and read it as input register
+		{request: []byte{0x06, 0x00, 0x65, 0xAB, 0xCD}, expected: []byte{0x06, 0x00, 0x65, 0xAB, 0xCD}},
+		{request: []byte{0x04, 0x00, 0x65, 0x00, 0x01}, expected: []byte{0x04, 0x02, 0xAB, 0xCD}},
+		// write M1, M3 and M8 and read M0-M9
+		{request: []byte{0x0F, 0x00, 0x00, 0x00, 0x09, 0x02, 0x0A, 0x01}, expected: []byte{0x0F, 0x00, 0x00, 0x00, 0x09}},
+		{request: []byte{0x05, 0x00, 0x09, 0xFF, 0x00}, expected: []byte{0x05, 0x00, 0x09, 0xFF, 0x00}},
+		{request: []byte{0x01, 0x00, 0x00, 0x00, 0x0A}, expected: []byte{0x01, 0x02, 0x0A, 0x03}},
+		// exceptions
+		{request: []byte{0x03, 0x00, 0x08, 0x00, 0x03}, expected: []byte{0x83, EXCEPTION_ILLEGAL_DATA_ADDRESS}},
+		{request: []byte{0x03, 0x00, 0x00, 0x00, 0x00}, expected: []byte{0x83, EXCEPTION_ILLEGAL_DATA_VALUE}},
+		{request: []byte{0x05, 0x00, 0x00, 0x12, 0x34}, expected: []byte{0x85, EXCEPTION_ILLEGAL_DATA_VALUE}},
+		{request: []byte{0x2B, 0x0E, 0x01, 0x00}, expected: []byte{0xAB, EXCEPTION_ILLEGAL_FUNCTION}},
+	}
+
+	for i, v := range cases {
+		if diff := cmp.Diff(exchange(t, conn, uint16(i), v.request), v.expected); diff != "" {
+			t.Errorf("%v: response of %X differs: (-got +want)\n%s", i, v.request, diff)
+		}
+	}
+}
+
+func TestServer_ReadOnly(t *testing.T) {
+	s, err := NewServer(newTestClient(t), []Range{{Address: 0, Count: 10, Device: "D100"}}, nil, WithReadOnly())
+	if err != nil {
+		t.Fatalf("unexpected server err: %v", err)
+	}
+	conn := newTestConn(t, s)
+
+	resp := exchange(t, conn, 1, []byte{0x06, 0x00, 0x00, 0x00, 0x01})
+	if diff := cmp.Diff(resp, []byte{0x86, EXCEPTION_ILLEGAL_FUNCTION}); diff != "" {
+		t.Errorf("response differs: (-got +want)\n%s", diff)
+	}
+}
+
+func TestNewServer(t *testing.T) {
+	cases := [][]Range{
+		{{Address: 0, Count: 0, Device: "D100"}},
+		{{Address: 0xFFFF, Count: 2, Device: "D100"}},
+		{{Address: 0, Count: 10, Device: "D1A"}},
+		{{Address: 0, Count: 10, Device: "D100"}, {Address: 9, Count: 10, Device: "D200"}},
+	}
+	for _, v := range cases {
+		if _, err := NewServer(nil, v, nil); err == nil {
+			t.Errorf("expected error: ranges is %v", v)
+		}
+	}
+}
+
+func TestServer_Close(t *testing.T) {
+	s, err := NewServer(newTestClient(t), []Range{{Address: 0, Count: 10, Device: "D100"}}, nil)
+	if err != nil {
+		t.Fatalf("unexpected server err: %v", err)
+	}
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- s.Serve(lis) }()
+
+	conn, err := net.Dial("tcp", lis.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer conn.Close()
+	exchange(t, conn, 1, []byte{0x03, 0x00, 0x00, 0x00, 0x01})
+
+	s.Close()
+	if err := <-done; !errors.Is(err, net.ErrClosed) {
+		t.Errorf("expected %v but actual is %v", net.ErrClosed, err)
+	}
+	// connections are closed too
+	if _, err := conn.Read(make([]byte, 1)); err == nil {
+		t.Errorf("expected connection is closed")
+	}
+}
@@ -0,0 +1,194 @@
+// Package mcpmqtt publishes values of tags polled from PLC to MQTT broker.
+package mcpmqtt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"text/template"
+	"time"
+
+	"github.com/CaptainPineapple/go-mcprotocol/mcp"
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+// Publisher publishes message to MQTT broker. PahoPublisher adapts paho client.
+type Publisher interface {
+	Publish(topic string, qos byte, retained bool, payload []byte) error
+}
+
+type pahoPublisher struct {
+	client  mqtt.Client
+	timeout time.Duration
+}
+
+// PahoPublisher returns Publisher that publishes by paho client and waits the publish for timeout.
+func PahoPublisher(client mqtt.Client, timeout time.Duration) Publisher {
+	return &pahoPublisher{client: client, timeout: timeout}
+}
+
+func (p *pahoPublisher) Publish(topic string, qos byte, retained bool, payload []byte) error {
+	token := p.client.Publish(topic, qos, retained, payload)
+	if !token.WaitTimeout(p.timeout) {
+		return errors.New("publish to " + topic + " timed out")
+	}
+	return token.Error()
+}
+
+// Message is JSON payload of published message.
+type Message struct {
+	Name  string    `json:"name"`
+	Value any       `json:"value,omitempty"`
+	Unit  string    `json:"unit,omitempty"`
+	Time  time.Time `json:"time"`
+	Error string    `json:"error,omitempty"`
+}
+
+// Option configures Bridge.
+type Option func(*Bridge)
+
+// WithTopic sets template of topics. fields of Message and Tag of mcp.Tag can be used.
+// default is "plc/{{.Name}}".
+func WithTopic(topic string) Option {
+	return func(b *Bridge) {
+		b.topic = topic
+	}
+}
+
+// WithQoS sets QoS of messages. default is 0.
+func WithQoS(qos byte) Option {
+	return func(b *Bridge) {
+		b.qos = qos
+	}
+}
+
+// WithRetained publishes messages as retained, so new subscribers get the last values.
+func WithRetained() Option {
+	return func(b *Bridge) {
+		b.retained = true
+	}
+}
+
+// WithWordOrder sets word order of multi-word values. it must be the same as the client.
+func WithWordOrder(order mcp.WordOrder) Option {
+	return func(b *Bridge) {
+		b.wordOrder = order
+	}
+}
+
+// WithAllValues publishes all polled values. by default, only changes over deadbands of tags are published.
+func WithAllValues() Option {
+	return func(b *Bridge) {
+		b.allValues = true
+	}
+}
+
+// Bridge polls tags and publishes their values as JSON.
+//
+//	bridge, _ := mcpmqtt.New(mcpmqtt.PahoPublisher(mqttClient, 5*time.Second), tags, mcpmqtt.WithRetained())
+//	err := bridge.Run(ctx, client, time.Second, "FurnaceTemp", "Pressure")
+type Bridge struct {
+	pub       Publisher
+	tags      *mcp.TagDB
+	topic     string
+	qos       byte
+	retained  bool
+	wordOrder mcp.WordOrder
+	allValues bool
+
+	tmpl *template.Template
+}
+
+// topicData is data of topic template.
+type topicData struct {
+	Message
+	Tag mcp.Tag
+}
+
+// New returns Bridge that publishes tags of db by pub.
+func New(pub Publisher, db *mcp.TagDB, opts ...Option) (*Bridge, error) {
+	b := &Bridge{pub: pub, tags: db, topic: "plc/{{.Name}}", wordOrder: mcp.LowWordFirst}
+	for _, opt := range opts {
+		opt(b)
+	}
+	tmpl, err := template.New("topic").Option("missingkey=error").Parse(b.topic)
+	if err != nil {
+		return nil, err
+	}
+	b.tmpl = tmpl
+	return b, nil
+}
+
+// Run polls tags of names by client at interval and publishes their values until ctx is done.
+// failed reads are published with error, so subscribers can see the tag is stale.
+func (b *Bridge) Run(ctx context.Context, client mcp.Client, interval time.Duration, names ...string) error {
+	ranges := make([]mcp.Request, len(names))
+	for i, name := range names {
+		r, err := b.tags.Request(name)
+		if err != nil {
+			return err
+		}
+		ranges[i] = r
+	}
+
+	var opts []mcp.SubscribeOption
+	if !b.allValues {
+		opts = append(opts, b.tags.Deadband(b.wordOrder, names...))
+	}
+	s, err := mcp.Subscribe(ctx, client, interval, ranges, opts...)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	// buffered messages of Spool are forwarded at interval even if values do not change
+	var flush <-chan time.Time
+	spool, _ := b.pub.(*Spool)
+	if spool != nil {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		flush = ticker.C
+	}
+
+	for {
+		select {
+		case u, ok := <-s.C:
+			if !ok {
+				return ctx.Err()
+			}
+			if err := b.Publish(names[u.Index], u); err != nil {
+				return err
+			}
+		case <-flush:
+			_ = spool.Flush()
+		}
+	}
+}
+
+// Publish publishes update of tag of name.
+func (b *Bridge) Publish(name string, u mcp.Update) error {
+	tag, ok := b.tags.Tag(name)
+	if !ok {
+		return errors.New("unknown tag: " + name)
+	}
+
+	m := Message{Name: name, Unit: tag.Unit, Time: u.Time}
+	if u.Err != nil {
+		m.Error = u.Err.Error()
+	} else if value, err := b.tags.Decode(name, u.Payload, b.wordOrder); err != nil {
+		m.Error = err.Error()
+	} else {
+		m.Value = value
+	}
+
+	payload, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	var topic bytes.Buffer
+	if err := b.tmpl.Execute(&topic, topicData{Message: m, Tag: tag}); err != nil {
+		return err
+	}
+	return b.pub.Publish(topic.String(), b.qos, b.retained, payload)
+}
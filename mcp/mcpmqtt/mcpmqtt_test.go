@@ -0,0 +1,74 @@
+package mcpmqtt
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CaptainPineapple/go-mcprotocol/mcp"
+	"github.com/google/go-cmp/cmp"
+)
+
+type published struct {
+	Topic    string
+	QoS      byte
+	Retained bool
+	Message  Message
+}
+
+type fakePublisher struct {
+	published []published
+}
+
+func (p *fakePublisher) Publish(topic string, qos byte, retained bool, payload []byte) error {
+	var m Message
+	if err := json.Unmarshal(payload, &m); err != nil {
+		return err
+	}
+	p.published = append(p.published, published{Topic: topic, QoS: qos, Retained: retained, Message: m})
+	return nil
+}
+
+func TestBridge_Publish(t *testing.T) {
+	db, err := mcp.LoadTags(strings.NewReader(`[
+		{"name": "FurnaceTemp", "device": "D100", "type": "int16", "scale": 0.1, "unit": "degC"},
+		{"name": "Running", "device": "M10", "type": "bool"}
+	]`))
+	if err != nil {
+		t.Fatalf("unexpected load err: %v", err)
+	}
+
+	pub := &fakePublisher{}
+	bridge, err := New(pub, db, WithTopic("factory/{{.Tag.Device}}/{{.Name}}"), WithQoS(1), WithRetained())
+	if err != nil {
+		t.Fatalf("unexpected bridge err: %v", err)
+	}
+
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	updates := map[string]mcp.Update{
+		"FurnaceTemp": {Time: now, Payload: []byte{0xE8, 0x03}},
+		"Running":     {Time: now, Err: errors.New("timeout")},
+	}
+	for _, name := range []string{"FurnaceTemp", "Running"} {
+		if err := bridge.Publish(name, updates[name]); err != nil {
+			t.Fatalf("unexpected publish err: %v", err)
+		}
+	}
+
+	expected := []published{
+		{Topic: "factory/D100/FurnaceTemp", QoS: 1, Retained: true, Message: Message{Name: "FurnaceTemp", Value: 100.0, Unit: "degC", Time: now}},
+		{Topic: "factory/M10/Running", QoS: 1, Retained: true, Message: Message{Name: "Running", Time: now, Error: "timeout"}},
+	}
+	if diff := cmp.Diff(pub.published, expected); diff != "" {
+		t.Errorf("published differs: (-got +want)\n%s", diff)
+	}
+
+	if err := bridge.Publish("Unknown", mcp.Update{}); err == nil {
+		t.Errorf("expected error of unknown tag")
+	}
+	if _, err := New(pub, db, WithTopic("plc/{{.Name")); err == nil {
+		t.Errorf("expected error of invalid topic template")
+	}
+}
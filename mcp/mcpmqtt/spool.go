@@ -0,0 +1,176 @@
+package mcpmqtt
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// spoolFile is name of file of messages buffered in dir of Spool.
+const spoolFile = "spool.jsonl"
+
+// spooled is message buffered in spool file as a JSON line.
+type spooled struct {
+	Topic    string `json:"topic"`
+	QoS      byte   `json:"qos"`
+	Retained bool   `json:"retained"`
+	Payload  []byte `json:"payload"`
+}
+
+// Spool is Publisher that stores messages to disk while pub fails, like while MQTT broker is unreachable,
+// and forwards them in order when pub recovers, so history of PLC is not lost during network blips.
+// buffered messages are kept across restarts of the process.
+//
+//	spool, _ := mcpmqtt.NewSpool(mcpmqtt.PahoPublisher(mqttClient, 5*time.Second), "/var/spool/plc")
+//	bridge, _ := mcpmqtt.New(spool, tags)
+type Spool struct {
+	pub  Publisher
+	path string
+
+	mu sync.Mutex
+	// number of messages in spool file
+	pending int
+}
+
+// NewSpool returns Spool that buffers messages to pub in dir. messages left in dir are forwarded first.
+func NewSpool(pub Publisher, dir string) (*Spool, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	s := &Spool{pub: pub, path: filepath.Join(dir, spoolFile)}
+	messages, err := s.readHelper()
+	if err != nil {
+		return nil, err
+	}
+	s.pending = len(messages)
+	return s, nil
+}
+
+// Publish publishes message by pub after buffered messages. when it fails, the message is buffered
+// and nil is returned. error is returned only when the message can not be buffered.
+func (s *Spool) Publish(topic string, qos byte, retained bool, payload []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.pending > 0 {
+		_ = s.flushHelper()
+	}
+	if s.pending == 0 && s.pub.Publish(topic, qos, retained, payload) == nil {
+		return nil
+	}
+	return s.appendHelper(spooled{Topic: topic, QoS: qos, Retained: retained, Payload: payload})
+}
+
+// Flush forwards buffered messages in order until pub fails. it returns the error of pub.
+// Bridge.Run calls it at its interval, so buffered messages are forwarded even if values do not change.
+func (s *Spool) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.pending == 0 {
+		return nil
+	}
+	return s.flushHelper()
+}
+
+// Len returns number of buffered messages.
+func (s *Spool) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.pending
+}
+
+func (s *Spool) flushHelper() error {
+	messages, err := s.readHelper()
+	if err != nil {
+		return err
+	}
+
+	sent := 0
+	for _, m := range messages {
+		if err = s.pub.Publish(m.Topic, m.QoS, m.Retained, m.Payload); err != nil {
+			break
+		}
+		sent++
+	}
+	if sent == 0 {
+		return err
+	}
+	if werr := s.writeHelper(messages[sent:]); werr != nil {
+		return werr
+	}
+	return err
+}
+
+// readHelper reads buffered messages. missing spool file has no messages.
+func (s *Spool) readHelper() ([]spooled, error) {
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var messages []spooled
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, len(data)+1)
+	for scanner.Scan() {
+		var m spooled
+		if err := json.Unmarshal(scanner.Bytes(), &m); err != nil {
+			// a line may be broken by crash while it is appended
+			continue
+		}
+		messages = append(messages, m)
+	}
+	return messages, scanner.Err()
+}
+
+// appendHelper appends m to spool file.
+func (s *Spool) appendHelper(m spooled) error {
+	line, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	s.pending++
+	return nil
+}
+
+// writeHelper replaces spool file by messages atomically.
+func (s *Spool) writeHelper(messages []spooled) error {
+	if len(messages) == 0 {
+		s.pending = 0
+		return os.Remove(s.path)
+	}
+
+	var buf bytes.Buffer
+	for _, m := range messages {
+		line, err := json.Marshal(m)
+		if err != nil {
+			return err
+		}
+		buf.Write(append(line, '\n'))
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return err
+	}
+	s.pending = len(messages)
+	return nil
+}
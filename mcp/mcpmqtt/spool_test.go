@@ -0,0 +1,85 @@
+package mcpmqtt
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// flakyPublisher records topics of published messages and fails while down is true.
+type flakyPublisher struct {
+	down   bool
+	topics []string
+}
+
+func (p *flakyPublisher) Publish(topic string, qos byte, retained bool, payload []byte) error {
+	if p.down {
+		return errors.New("broker is unreachable")
+	}
+	if string(payload) != "payload of "+topic {
+		return errors.New("unexpected payload: " + string(payload))
+	}
+	p.topics = append(p.topics, topic)
+	return nil
+}
+
+func TestSpool(t *testing.T) {
+	dir := t.TempDir()
+	pub := &flakyPublisher{}
+	spool, err := NewSpool(pub, dir)
+	if err != nil {
+		t.Fatalf("unexpected spool err: %v", err)
+	}
+	publish := func(s *Spool, topic string) {
+		t.Helper()
+		if err := s.Publish(topic, 1, false, []byte("payload of "+topic)); err != nil {
+			t.Fatalf("unexpected publish err: %v", err)
+		}
+	}
+
+	publish(spool, "a")
+	pub.down = true
+	publish(spool, "b")
+	publish(spool, "c")
+	if n := spool.Len(); n != 2 {
+		t.Fatalf("expected %v buffered messages but actual is %v", 2, n)
+	}
+
+	// buffered messages are kept across restarts
+	spool, err = NewSpool(pub, dir)
+	if err != nil {
+		t.Fatalf("unexpected spool err: %v", err)
+	}
+	if n := spool.Len(); n != 2 {
+		t.Fatalf("expected %v buffered messages but actual is %v", 2, n)
+	}
+	if err := spool.Flush(); err == nil {
+		t.Fatalf("expected flush error while broker is unreachable")
+	}
+
+	// buffered messages are forwarded in order before new message
+	pub.down = false
+	publish(spool, "d")
+	if diff := cmp.Diff(pub.topics, []string{"a", "b", "c", "d"}); diff != "" {
+		t.Errorf("topics differs: (-got +want)\n%s", diff)
+	}
+	if n := spool.Len(); n != 0 {
+		t.Fatalf("expected %v buffered messages but actual is %v", 0, n)
+	}
+	if _, err := os.Stat(filepath.Join(dir, spoolFile)); !os.IsNotExist(err) {
+		t.Fatalf("expected spool file is removed but actual is %v", err)
+	}
+
+	pub.down = true
+	publish(spool, "e")
+	pub.down = false
+	if err := spool.Flush(); err != nil {
+		t.Fatalf("unexpected flush err: %v", err)
+	}
+	if diff := cmp.Diff(pub.topics, []string{"a", "b", "c", "d", "e"}); diff != "" {
+		t.Errorf("topics differs: (-got +want)\n%s", diff)
+	}
+}
@@ -0,0 +1,78 @@
+// Package mcpotel instruments mcp client with OpenTelemetry tracing.
+package mcpotel
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/CaptainPineapple/go-mcprotocol/mcp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/CaptainPineapple/go-mcprotocol/mcp/mcpotel"
+
+// config is configuration of Middleware.
+type config struct {
+	tracerProvider trace.TracerProvider
+}
+
+// Option configures Middleware.
+type Option func(*config)
+
+// WithTracerProvider sets tracer provider. default is the global tracer provider.
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(c *config) {
+		c.tracerProvider = provider
+	}
+}
+
+// Middleware returns mcp.Middleware that records span of each request to PLC.
+// span has device, offset, points, end code and bytes of request and response.
+//
+//	client, err := mcp.New3EClient(host, port, mcp.NewLocalStation(), true, mcp.WithMiddleware(mcpotel.Middleware()))
+func Middleware(opts ...Option) mcp.Middleware {
+	c := config{tracerProvider: otel.GetTracerProvider()}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	tracer := c.tracerProvider.Tracer(instrumentationName)
+
+	return func(next mcp.Handler) mcp.Handler {
+		return func(ctx context.Context, req *mcp.Request) ([]byte, error) {
+			attrs := []attribute.KeyValue{
+				attribute.String("mcp.op", req.Op.String()),
+				attribute.Int("mcp.request.bytes", len(req.Frame())),
+			}
+			if req.Op != mcp.OpHealthCheck {
+				attrs = append(attrs,
+					attribute.String("mcp.device", req.DeviceName),
+					attribute.Int64("mcp.offset", req.Offset),
+					attribute.Int64("mcp.points", req.NumPoints),
+				)
+			}
+			ctx, span := tracer.Start(ctx, "mcp."+req.Op.String(),
+				trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+			defer span.End()
+
+			resp, err := next(ctx, req)
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+				return resp, err
+			}
+
+			span.SetAttributes(attribute.Int("mcp.response.bytes", len(resp)))
+			if response, perr := mcp.NewParser().Do(resp); perr == nil {
+				span.SetAttributes(attribute.String("mcp.end_code", fmt.Sprintf("%04X", response.EndCode)))
+				if response.EndCode != 0 {
+					endCodeErr := &mcp.EndCodeError{EndCode: response.EndCode, Response: response}
+					span.SetStatus(codes.Error, endCodeErr.Error())
+				}
+			}
+			return resp, err
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package mcpotel
+
+import (
+	"net"
+	"testing"
+
+	"github.com/CaptainPineapple/go-mcprotocol/mcp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestMiddleware(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	// PLC that answers normal completion to read and C059 to the others
+	clientConn, plcConn := net.Pipe()
+	go func() {
+		defer plcConn.Close()
+		buf := make([]byte, 1024)
+		for {
+			n, err := plcConn.Read(buf)
+			if err != nil {
+				return
+			}
+			resp := []byte{0xD0, 0x00, 0x00, 0xFF, 0xFF, 0x03, 0x00, 0x04, 0x00, 0x00, 0x00, 0x34, 0x12}
+			if n < 13 || buf[11] != 0x01 || buf[12] != 0x04 {
+				resp = []byte{0xD0, 0x00, 0x00, 0xFF, 0xFF, 0x03, 0x00, 0x02, 0x00, 0x59, 0xC0}
+			}
+			if _, err := plcConn.Write(resp); err != nil {
+				return
+			}
+		}
+	}()
+
+	client := mcp.New3EClientWithConn(clientConn, mcp.NewLocalStation(), mcp.WithMiddleware(Middleware(WithTracerProvider(provider))))
+	defer client.ShutDown()
+
+	if _, err := client.Read("D", 100, 1); err != nil {
+		t.Fatalf("unexpected mcp read err: %v", err)
+	}
+	if _, err := client.Write("D", 100, 1, []byte{0x00, 0x00}); err == nil {
+		t.Fatalf("expected *EndCodeError but actual is nil")
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected %v spans but actual is %v", 2, len(spans))
+	}
+
+	read := spans[0]
+	if read.Name() != "mcp.Read" {
+		t.Fatalf("expected %v but actual is %v", "mcp.Read", read.Name())
+	}
+	attrs := map[attribute.Key]attribute.Value{}
+	for _, kv := range read.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	if attrs["mcp.device"].AsString() != "D" || attrs["mcp.offset"].AsInt64() != 100 || attrs["mcp.points"].AsInt64() != 1 {
+		t.Fatalf("unexpected device attributes: %v", read.Attributes())
+	}
+	if attrs["mcp.end_code"].AsString() != "0000" || attrs["mcp.response.bytes"].AsInt64() != 13 {
+		t.Fatalf("unexpected response attributes: %v", read.Attributes())
+	}
+
+	if spans[1].Status().Code != codes.Error {
+		t.Fatalf("expected %v but actual is %v", codes.Error, spans[1].Status().Code)
+	}
+}
@@ -0,0 +1,106 @@
+// Package mcpprom instruments mcp client with Prometheus metrics.
+package mcpprom
+
+import (
+	"context"
+	"time"
+
+	"github.com/CaptainPineapple/go-mcprotocol/mcp"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector collects metrics of mcp clients. register it to prometheus registry
+// and give Middleware and ConnStateHandler to clients.
+//
+//	collector := mcpprom.NewCollector()
+//	prometheus.MustRegister(collector)
+//	client, err := mcp.New3EClient(host, port, mcp.NewLocalStation(), true,
+//		mcp.WithMiddleware(collector.Middleware()), mcp.WithConnStateHandler(collector.ConnStateHandler()))
+type Collector struct {
+	requests     *prometheus.CounterVec
+	duration     *prometheus.HistogramVec
+	reconnects   prometheus.Counter
+	bytesRead    prometheus.Counter
+	bytesWritten prometheus.Counter
+}
+
+// NewCollector returns collector of metrics named mcp_*.
+func NewCollector() *Collector {
+	return &Collector{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "mcp_requests_total",
+			Help: "Number of requests to PLC by op and result (ok, end_code or error).",
+		}, []string{"op", "result"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "mcp_request_duration_seconds",
+			Help:    "Duration of requests to PLC.",
+			Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 3},
+		}, []string{"op"}),
+		reconnects: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "mcp_reconnects_total",
+			Help: "Number of attempts to connect to PLC again.",
+		}),
+		bytesRead: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "mcp_bytes_read_total",
+			Help: "Bytes of responses received from PLC.",
+		}),
+		bytesWritten: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "mcp_bytes_written_total",
+			Help: "Bytes of requests sent to PLC.",
+		}),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.requests.Describe(ch)
+	c.duration.Describe(ch)
+	c.reconnects.Describe(ch)
+	c.bytesRead.Describe(ch)
+	c.bytesWritten.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.requests.Collect(ch)
+	c.duration.Collect(ch)
+	c.reconnects.Collect(ch)
+	c.bytesRead.Collect(ch)
+	c.bytesWritten.Collect(ch)
+}
+
+// Middleware returns mcp.Middleware that counts requests, their duration and bytes.
+func (c *Collector) Middleware() mcp.Middleware {
+	return func(next mcp.Handler) mcp.Handler {
+		return func(ctx context.Context, req *mcp.Request) ([]byte, error) {
+			start := time.Now()
+			resp, err := next(ctx, req)
+			op := req.Op.String()
+			c.duration.WithLabelValues(op).Observe(time.Since(start).Seconds())
+
+			result := "ok"
+			if err != nil {
+				result = "error"
+			} else {
+				c.bytesWritten.Add(float64(len(req.Frame())))
+				c.bytesRead.Add(float64(len(resp)))
+				if response, perr := mcp.NewParser().Do(resp); perr != nil {
+					result = "error"
+				} else if response.EndCode != 0 {
+					result = "end_code"
+				}
+			}
+			c.requests.WithLabelValues(op, result).Inc()
+			return resp, err
+		}
+	}
+}
+
+// ConnStateHandler returns handler of mcp.WithConnStateHandler that counts reconnects.
+func (c *Collector) ConnStateHandler() func(mcp.ConnEvent) {
+	return func(e mcp.ConnEvent) {
+		if e.State == mcp.Reconnecting {
+			c.reconnects.Inc()
+		}
+	}
+}
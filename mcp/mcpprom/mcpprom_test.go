@@ -0,0 +1,57 @@
+package mcpprom
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/CaptainPineapple/go-mcprotocol/mcp"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCollector(t *testing.T) {
+	// PLC that answers normal completion to read and C059 to the others
+	clientConn, plcConn := net.Pipe()
+	go func() {
+		defer plcConn.Close()
+		buf := make([]byte, 1024)
+		for {
+			n, err := plcConn.Read(buf)
+			if err != nil {
+				return
+			}
+			resp := []byte{0xD0, 0x00, 0x00, 0xFF, 0xFF, 0x03, 0x00, 0x04, 0x00, 0x00, 0x00, 0x34, 0x12}
+			if n < 13 || buf[11] != 0x01 || buf[12] != 0x04 {
+				resp = []byte{0xD0, 0x00, 0x00, 0xFF, 0xFF, 0x03, 0x00, 0x02, 0x00, 0x59, 0xC0}
+			}
+			if _, err := plcConn.Write(resp); err != nil {
+				return
+			}
+		}
+	}()
+
+	collector := NewCollector()
+	client := mcp.New3EClientWithConn(clientConn, mcp.NewLocalStation(),
+		mcp.WithMiddleware(collector.Middleware()), mcp.WithConnStateHandler(collector.ConnStateHandler()))
+	defer client.ShutDown()
+
+	if _, err := client.Read("D", 100, 1); err != nil {
+		t.Fatalf("unexpected mcp read err: %v", err)
+	}
+	if _, err := client.Write("D", 100, 1, []byte{0x00, 0x00}); err == nil {
+		t.Fatalf("expected *EndCodeError but actual is nil")
+	}
+
+	expected := `
+# HELP mcp_requests_total Number of requests to PLC by op and result (ok, end_code or error).
+# TYPE mcp_requests_total counter
+mcp_requests_total{op="Read",result="ok"} 1
+mcp_requests_total{op="Write",result="end_code"} 1
+# HELP mcp_bytes_read_total Bytes of responses received from PLC.
+# TYPE mcp_bytes_read_total counter
+mcp_bytes_read_total 24
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected), "mcp_requests_total", "mcp_bytes_read_total"); err != nil {
+		t.Fatalf("unexpected metrics: %v", err)
+	}
+}
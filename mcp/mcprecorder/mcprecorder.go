@@ -0,0 +1,187 @@
+// Package mcprecorder records values polled by mcp.Subscribe to rotating CSV files.
+package mcprecorder
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/CaptainPineapple/go-mcprotocol/mcp"
+)
+
+// Option configures Recorder.
+type Option func(*Recorder)
+
+// WithPrefix sets prefix of file names. default is "plc".
+func WithPrefix(prefix string) Option {
+	return func(r *Recorder) {
+		r.prefix = prefix
+	}
+}
+
+// WithMaxSize rotates file when it becomes larger than size bytes. default is 10MB.
+func WithMaxSize(size int64) Option {
+	return func(r *Recorder) {
+		r.maxSize = size
+	}
+}
+
+// WithRotateInterval rotates file when interval passes from the first sample of the file. zero means disabled.
+func WithRotateInterval(interval time.Duration) Option {
+	return func(r *Recorder) {
+		r.interval = interval
+	}
+}
+
+// WithMaxFiles removes old files to keep n files. zero means files are never removed.
+func WithMaxFiles(n int) Option {
+	return func(r *Recorder) {
+		r.maxFiles = n
+	}
+}
+
+// Recorder appends updates of subscription to CSV files in a directory.
+// each row is time, device, number of points, payload as hex and error.
+//
+//	s, _ := mcp.Subscribe(ctx, client, time.Second, ranges)
+//	recorder, _ := mcprecorder.New("/var/log/plc", mcprecorder.WithRotateInterval(24*time.Hour))
+//	defer recorder.Close()
+//	err := recorder.Run(ctx, s.C)
+type Recorder struct {
+	dir      string
+	prefix   string
+	maxSize  int64
+	interval time.Duration
+	maxFiles int
+
+	// current file
+	file    *os.File
+	w       *csv.Writer
+	size    int64
+	started time.Time
+}
+
+// header is the first row of each file.
+var header = []string{"time", "device", "points", "payload", "error"}
+
+// New returns Recorder that writes files to dir. dir is created if it does not exist.
+func New(dir string, opts ...Option) (*Recorder, error) {
+	r := &Recorder{dir: dir, prefix: "plc", maxSize: 10 << 20}
+	for _, opt := range opts {
+		opt(r)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Run records updates until updates is closed or ctx is done.
+func (r *Recorder) Run(ctx context.Context, updates <-chan mcp.Update) error {
+	for {
+		select {
+		case u, ok := <-updates:
+			if !ok {
+				return nil
+			}
+			if err := r.Record(u); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Record appends an update to current file. file is rotated before the update if it is due.
+func (r *Recorder) Record(u mcp.Update) error {
+	if r.file != nil && (r.size >= r.maxSize || (r.interval > 0 && u.Time.Sub(r.started) >= r.interval)) {
+		if err := r.Close(); err != nil {
+			return err
+		}
+	}
+	if r.file == nil {
+		if err := r.openHelper(u.Time); err != nil {
+			return err
+		}
+	}
+
+	errStr := ""
+	if u.Err != nil {
+		errStr = u.Err.Error()
+	}
+	return r.writeHelper([]string{
+		u.Time.Format(time.RFC3339Nano),
+		mcp.FormatDevice(u.Request.DeviceName, u.Request.Offset),
+		strconv.FormatInt(u.Request.NumPoints, 10),
+		fmt.Sprintf("%X", u.Payload),
+		errStr,
+	})
+}
+
+// Close closes current file. next Record opens new file.
+func (r *Recorder) Close() error {
+	if r.file == nil {
+		return nil
+	}
+	r.w.Flush()
+	err := errors.Join(r.w.Error(), r.file.Close())
+	r.file, r.w = nil, nil
+	return err
+}
+
+// openHelper opens new file named by prefix and time of the first sample, and removes old files.
+func (r *Recorder) openHelper(t time.Time) error {
+	name := filepath.Join(r.dir, r.prefix+"-"+t.UTC().Format("20060102T150405.000000000Z")+".csv")
+	file, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	r.file, r.w, r.size, r.started = file, csv.NewWriter(file), 0, t
+	if err := r.writeHelper(header); err != nil {
+		return err
+	}
+	return r.cleanHelper()
+}
+
+// writeHelper writes a row and flushes it, so recorded samples survive crash of the process.
+func (r *Recorder) writeHelper(row []string) error {
+	if err := r.w.Write(row); err != nil {
+		return err
+	}
+	r.w.Flush()
+	if err := r.w.Error(); err != nil {
+		return err
+	}
+	info, err := r.file.Stat()
+	if err != nil {
+		return err
+	}
+	r.size = info.Size()
+	return nil
+}
+
+// cleanHelper removes old files more than maxFiles. file names sort in time order.
+func (r *Recorder) cleanHelper() error {
+	if r.maxFiles <= 0 {
+		return nil
+	}
+	files, err := filepath.Glob(filepath.Join(r.dir, r.prefix+"-*.csv"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+	for len(files) > r.maxFiles {
+		if err := os.Remove(files[0]); err != nil {
+			return err
+		}
+		files = files[1:]
+	}
+	return nil
+}
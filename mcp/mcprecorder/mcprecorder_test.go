@@ -0,0 +1,89 @@
+package mcprecorder
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/CaptainPineapple/go-mcprotocol/mcp"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRecorder(t *testing.T) {
+	dir := t.TempDir()
+	recorder, err := New(dir, WithRotateInterval(time.Hour), WithMaxFiles(2))
+	if err != nil {
+		t.Fatalf("unexpected recorder err: %v", err)
+	}
+
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	d100 := mcp.Request{Op: mcp.OpRead, DeviceName: "D", Offset: 100, NumPoints: 1}
+	x1f := mcp.Request{Op: mcp.OpBitRead, DeviceName: "X", Offset: 0x1F, NumPoints: 2}
+	updates := make(chan mcp.Update, 10)
+	updates <- mcp.Update{Request: d100, Time: start, Payload: []byte{0x34, 0x12}}
+	updates <- mcp.Update{Index: 1, Request: x1f, Time: start.Add(time.Minute), Err: errors.New("timeout")}
+	// rotated by interval
+	updates <- mcp.Update{Request: d100, Time: start.Add(time.Hour), Payload: []byte{0x35, 0x12}}
+	updates <- mcp.Update{Request: d100, Time: start.Add(2 * time.Hour), Payload: []byte{0x36, 0x12}}
+	close(updates)
+
+	if err := recorder.Run(context.Background(), updates); err != nil {
+		t.Fatalf("unexpected run err: %v", err)
+	}
+	if err := recorder.Close(); err != nil {
+		t.Fatalf("unexpected close err: %v", err)
+	}
+
+	// the first file is removed by max files
+	files, _ := filepath.Glob(filepath.Join(dir, "*.csv"))
+	sort.Strings(files)
+	var names []string
+	for _, f := range files {
+		names = append(names, filepath.Base(f))
+	}
+	expectedNames := []string{"plc-20240102T040405.000000000Z.csv", "plc-20240102T050405.000000000Z.csv"}
+	if diff := cmp.Diff(names, expectedNames); diff != "" {
+		t.Fatalf("files differs: (-got +want)\n%s", diff)
+	}
+
+	f, err := os.Open(files[0])
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to read file: %v", err)
+	}
+	expected := [][]string{header, {"2024-01-02T04:04:05Z", "D100", "1", "3512", ""}}
+	if diff := cmp.Diff(rows, expected); diff != "" {
+		t.Errorf("rows differs: (-got +want)\n%s", diff)
+	}
+}
+
+func TestRecorder_MaxSize(t *testing.T) {
+	dir := t.TempDir()
+	recorder, err := New(dir, WithPrefix("line1"), WithMaxSize(1))
+	if err != nil {
+		t.Fatalf("unexpected recorder err: %v", err)
+	}
+	defer recorder.Close()
+
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		u := mcp.Update{Request: mcp.Request{DeviceName: "X", Offset: 0x1F, NumPoints: 2}, Time: start.Add(time.Duration(i) * time.Second), Payload: []byte{0x10}}
+		if err := recorder.Record(u); err != nil {
+			t.Fatalf("unexpected record err: %v", err)
+		}
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "line1-*.csv"))
+	if len(files) != 3 {
+		t.Fatalf("expected %v files but actual is %v", 3, files)
+	}
+}
@@ -0,0 +1,444 @@
+// Package mcptest provides in-memory PLC server for integration tests of code built on mcp client.
+//
+//	s, _ := mcptest.NewServer()
+//	defer s.Close()
+//	s.SetWords("D100", 1234)
+//	client, _ := mcp.New3EClient(s.Host(), s.Port(), mcp.NewLocalStation(), true)
+package mcptest
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+
+	"github.com/CaptainPineapple/go-mcprotocol/mcp"
+)
+
+// end codes returned by Server
+const (
+	// END_CODE_UNSUPPORTED is returned to commands that Server does not support.
+	END_CODE_UNSUPPORTED = 0xC059
+	// END_CODE_INVALID_DEVICE is returned to requests of unknown device code.
+	END_CODE_INVALID_DEVICE = 0xC05B
+	// E1_ABNORMAL_CODE is abnormal code of 1E frame returned with complete code 5B.
+	E1_ABNORMAL_CODE = 0x10
+)
+
+// Server is PLC that listens on a port and maintains device memory.
+// it answers batch read and write requests in word and bit units and loopback test of 1E, 3E and 4E frame.
+// device memory is shared by all frames and connections, and devices not written are 0.
+// bit devices like M are kept in bit units, and word unit access to them covers 16 points per word like PLC.
+type Server struct {
+	lis net.Listener
+
+	mu       sync.Mutex
+	words    map[device]uint16
+	bits     map[device]bool
+	requests []*mcp.Frame
+	conns    map[net.Conn]struct{}
+	wg       sync.WaitGroup
+}
+
+// device is device name and device number.
+type device struct {
+	name   string
+	offset int64
+}
+
+// NewServer starts Server on a random port of 127.0.0.1.
+func NewServer() (*Server, error) {
+	return Listen("127.0.0.1:0")
+}
+
+// Listen starts Server on addr.
+func Listen(addr string) (*Server, error) {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{
+		lis:   lis,
+		words: map[device]uint16{},
+		bits:  map[device]bool{},
+		conns: map[net.Conn]struct{}{},
+	}
+	s.wg.Add(1)
+	go s.acceptLoop()
+	return s, nil
+}
+
+// Addr returns address that Server listens on.
+func (s *Server) Addr() string {
+	return s.lis.Addr().String()
+}
+
+// Host returns host that Server listens on.
+func (s *Server) Host() string {
+	return s.lis.Addr().(*net.TCPAddr).IP.String()
+}
+
+// Port returns port that Server listens on.
+func (s *Server) Port() int {
+	return s.lis.Addr().(*net.TCPAddr).Port
+}
+
+// Close stops listening and closes all connections.
+func (s *Server) Close() error {
+	err := s.lis.Close()
+	s.mu.Lock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+	return err
+}
+
+// SetWords sets values to word devices from address like D100.
+func (s *Server) SetWords(address string, values ...uint16) error {
+	deviceName, offset, err := mcp.ParseDevice(address)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, v := range values {
+		s.setWord(deviceName, offset, int64(i), v)
+	}
+	return nil
+}
+
+// Words returns numPoints values of word devices from address like D100.
+func (s *Server) Words(address string, numPoints int) ([]uint16, error) {
+	deviceName, offset, err := mcp.ParseDevice(address)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	values := make([]uint16, numPoints)
+	for i := range values {
+		values[i] = s.word(deviceName, offset, int64(i))
+	}
+	return values, nil
+}
+
+// SetBits sets values to bit devices from address like M0.
+func (s *Server) SetBits(address string, values ...bool) error {
+	deviceName, offset, err := mcp.ParseDevice(address)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, v := range values {
+		s.bits[device{deviceName, offset + int64(i)}] = v
+	}
+	return nil
+}
+
+// Bits returns numPoints values of bit devices from address like M0.
+func (s *Server) Bits(address string, numPoints int) ([]bool, error) {
+	deviceName, offset, err := mcp.ParseDevice(address)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	values := make([]bool, numPoints)
+	for i := range values {
+		values[i] = s.bits[device{deviceName, offset + int64(i)}]
+	}
+	return values, nil
+}
+
+// Memory is image of device memory of Server. devices are keyed by address like D100 and devices of 0 or false are omitted.
+// bit devices are in Bits, and words of bit devices given to Restore are set to 16 points from the address.
+// it is encoded as JSON like {"words":{"D100":1234},"bits":{"M0":true}}.
+type Memory struct {
+	Words map[string]uint16 `json:"words"`
+	Bits  map[string]bool   `json:"bits"`
+}
+
+// Snapshot returns image of device memory.
+func (s *Server) Snapshot() Memory {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m := Memory{Words: map[string]uint16{}, Bits: map[string]bool{}}
+	for d, v := range s.words {
+		if v != 0 {
+			m.Words[mcp.FormatDevice(d.name, d.offset)] = v
+		}
+	}
+	for d, v := range s.bits {
+		if v {
+			m.Bits[mcp.FormatDevice(d.name, d.offset)] = v
+		}
+	}
+	return m
+}
+
+// Restore replaces device memory by m.
+func (s *Server) Restore(m Memory) error {
+	restored := &Server{words: make(map[device]uint16, len(m.Words)), bits: make(map[device]bool, len(m.Bits))}
+	for address, v := range m.Bits {
+		deviceName, offset, err := mcp.ParseDevice(address)
+		if err != nil {
+			return err
+		}
+		restored.bits[device{deviceName, offset}] = v
+	}
+	for address, v := range m.Words {
+		deviceName, offset, err := mcp.ParseDevice(address)
+		if err != nil {
+			return err
+		}
+		restored.setWord(deviceName, offset, 0, v)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.words, s.bits = restored.words, restored.bits
+	return nil
+}
+
+// Requests returns decoded requests that Server received in order.
+func (s *Server) Requests() []*mcp.Frame {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*mcp.Frame(nil), s.requests...)
+}
+
+func (s *Server) acceptLoop() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.lis.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+		s.wg.Add(1)
+		go s.serveConn(conn)
+	}
+}
+
+func (s *Server) serveConn(conn net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+
+	r := bufio.NewReader(conn)
+	for {
+		req, err := readRequest(r)
+		if err != nil {
+			return
+		}
+		f, err := mcp.DecodeFrame(req)
+		if err != nil {
+			return
+		}
+		if _, err := conn.Write(s.handle(f)); err != nil {
+			return
+		}
+	}
+}
+
+// readRequest reads a request frame of 1E, 3E or 4E frame.
+func readRequest(r *bufio.Reader) ([]byte, error) {
+	subHeader, err := r.Peek(2)
+	if err != nil {
+		return nil, err
+	}
+
+	var headerSize, dataLen int
+	switch {
+	case subHeader[0] == 0x50 && subHeader[1] == 0x00, subHeader[0] == 0x54 && subHeader[1] == 0x00:
+		headerSize = 9
+		if subHeader[0] == 0x54 {
+			// 4E frame has serial number[2byte] and 0000[2byte] more
+			headerSize = 13
+		}
+		header, err := r.Peek(headerSize)
+		if err != nil {
+			return nil, err
+		}
+		dataLen = int(binary.LittleEndian.Uint16(header[headerSize-2:]))
+	case subHeader[0] <= 0x03:
+		// 1E frame has no length field. sub header + pc num + monitoring timer[2byte] +
+		// device number[4byte] + device code[2byte] + points[1byte] + fixed 00[1byte] + write data
+		headerSize = 12
+		header, err := r.Peek(headerSize)
+		if err != nil {
+			return nil, err
+		}
+		points := int(header[10])
+		if points == 0 {
+			points = 256
+		}
+		switch subHeader[0] {
+		case 0x02:
+			dataLen = (points + 1) / 2
+		case 0x03:
+			dataLen = points * 2
+		}
+	case subHeader[0] == 0x16:
+		// 1E loopback test has number of loopback data[1byte] after monitoring timer
+		headerSize = 5
+		header, err := r.Peek(headerSize)
+		if err != nil {
+			return nil, err
+		}
+		dataLen = int(header[4])
+	default:
+		return nil, errors.New("unknown sub header of request: " + strconv.Itoa(int(subHeader[0])))
+	}
+
+	req := make([]byte, headerSize+dataLen)
+	if _, err := io.ReadFull(r, req); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// handle returns response of request f.
+func (s *Server) handle(f *mcp.Frame) []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, f)
+
+	if f.Type == mcp.Frame1E {
+		payload, ok := s.handle1E(f)
+		if !ok {
+			return []byte{byte(f.Command) | 0x80, 0x5B, E1_ABNORMAL_CODE}
+		}
+		return append([]byte{byte(f.Command) | 0x80, 0x00}, payload...)
+	}
+
+	payload, endCode := s.handle3E(f)
+	var resp []byte
+	if f.Type == mcp.Frame4E {
+		resp = []byte{0xD4, 0x00, byte(f.SerialNum), byte(f.SerialNum >> 8), 0x00, 0x00}
+	} else {
+		resp = []byte{0xD0, 0x00}
+	}
+	resp = append(resp, f.NetworkNum, f.PCNum, byte(f.UnitIONum), byte(f.UnitIONum>>8), f.UnitStationNum)
+	if endCode != 0 {
+		// error information is route, command and subcommand of request
+		resp = binary.LittleEndian.AppendUint16(resp, 11)
+		resp = binary.LittleEndian.AppendUint16(resp, endCode)
+		resp = append(resp, f.NetworkNum, f.PCNum, byte(f.UnitIONum), byte(f.UnitIONum>>8), f.UnitStationNum)
+		resp = binary.LittleEndian.AppendUint16(resp, f.Command)
+		return binary.LittleEndian.AppendUint16(resp, f.SubCommand)
+	}
+	resp = binary.LittleEndian.AppendUint16(resp, uint16(2+len(payload)))
+	resp = binary.LittleEndian.AppendUint16(resp, 0)
+	return append(resp, payload...)
+}
+
+// handle3E returns payload of request of 3E or 4E frame, or abnormal end code.
+func (s *Server) handle3E(f *mcp.Frame) ([]byte, uint16) {
+	switch f.Command {
+	case 0x0619:
+		// loopback test returns received data as it is
+		return f.Data, 0
+	case 0x0401, 0x1401:
+		if f.SubCommand > 0x0003 {
+			return nil, END_CODE_UNSUPPORTED
+		}
+		if f.DeviceName == "" {
+			return nil, END_CODE_INVALID_DEVICE
+		}
+		// subcommand 0001 and 0003 are in bit units
+		payload, ok := s.access(f, f.Command == 0x1401, f.SubCommand&0x1 != 0)
+		if !ok {
+			return nil, END_CODE_INVALID_DEVICE
+		}
+		return payload, 0
+	}
+	return nil, END_CODE_UNSUPPORTED
+}
+
+// handle1E returns payload of request of 1E frame. false means abnormal end.
+func (s *Server) handle1E(f *mcp.Frame) ([]byte, bool) {
+	switch f.Command {
+	case 0x16:
+		// loopback test returns number of loopback data and the data
+		return f.Data, true
+	case 0x00, 0x01, 0x02, 0x03:
+		if f.DeviceName == "" {
+			return nil, false
+		}
+		// command 00 and 02 are in bit units
+		return s.access(f, f.Command >= 0x02, f.Command%2 == 0)
+	}
+	return nil, false
+}
+
+// access reads or writes device memory by batch request f. s.mu must be held.
+func (s *Server) access(f *mcp.Frame, write, bitUnits bool) ([]byte, bool) {
+	var payload []byte
+	switch {
+	case !write && bitUnits:
+		payload = make([]byte, (f.NumPoints+1)/2)
+		for i := int64(0); i < f.NumPoints; i++ {
+			if s.bits[device{f.DeviceName, f.Offset + i}] {
+				payload[i/2] |= 0x10 >> (4 * (i % 2))
+			}
+		}
+	case !write:
+		for i := int64(0); i < f.NumPoints; i++ {
+			payload = binary.LittleEndian.AppendUint16(payload, s.word(f.DeviceName, f.Offset, i))
+		}
+	case bitUnits:
+		if int64(len(f.Data)) < (f.NumPoints+1)/2 {
+			return nil, false
+		}
+		for i, v := range mcp.DecodeBits(f.Data, f.NumPoints) {
+			s.bits[device{f.DeviceName, f.Offset + int64(i)}] = v
+		}
+	default:
+		if int64(len(f.Data)) < f.NumPoints*2 {
+			return nil, false
+		}
+		for i := int64(0); i < f.NumPoints; i++ {
+			s.setWord(f.DeviceName, f.Offset, i, binary.LittleEndian.Uint16(f.Data[i*2:]))
+		}
+	}
+	return payload, true
+}
+
+// word returns i-th word from offset. word of bit device is 16 points from offset+16*i, and the first point is bit 0.
+// s.mu must be held.
+func (s *Server) word(deviceName string, offset, i int64) uint16 {
+	if !mcp.IsBitDevice(deviceName) {
+		return s.words[device{deviceName, offset + i}]
+	}
+	var v uint16
+	for j := int64(0); j < 16; j++ {
+		if s.bits[device{deviceName, offset + 16*i + j}] {
+			v |= 1 << j
+		}
+	}
+	return v
+}
+
+// setWord sets i-th word from offset like word. s.mu must be held.
+func (s *Server) setWord(deviceName string, offset, i int64, v uint16) {
+	if !mcp.IsBitDevice(deviceName) {
+		s.words[device{deviceName, offset + i}] = v
+		return
+	}
+	for j := int64(0); j < 16; j++ {
+		s.bits[device{deviceName, offset + 16*i + j}] = v&(1<<j) != 0
+	}
+}
@@ -0,0 +1,281 @@
+package mcptest
+
+import (
+	"encoding/hex"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/CaptainPineapple/go-mcprotocol/mcp"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestServer_Client(t *testing.T) {
+	s, err := NewServer()
+	if err != nil {
+		t.Fatalf("unexpected server err: %v", err)
+	}
+	defer s.Close()
+
+	for _, frame4E := range []bool{false, true} {
+		var opts []mcp.Option
+		if frame4E {
+			opts = append(opts, mcp.WithFrame4E())
+		}
+		client, err := mcp.New3EClient(s.Host(), s.Port(), mcp.NewLocalStation(), true, opts...)
+		if err != nil {
+			t.Fatalf("unexpected connect err: %v", err)
+		}
+		defer client.ShutDown()
+
+		if err := client.HealthCheck(); err != nil {
+			t.Fatalf("unexpected health check err: %v", err)
+		}
+		if err := client.WriteInt32("D", 100, -2); err != nil {
+			t.Fatalf("unexpected write err: %v", err)
+		}
+		if err := client.WriteBools("X", 0x1E, []bool{true, false, true}); err != nil {
+			t.Fatalf("unexpected bit write err: %v", err)
+		}
+		// iQ-R series device is accessed by 4 byte device number
+		if err := client.WriteUint16("RD", 100000, 0x1234); err != nil {
+			t.Fatalf("unexpected write err: %v", err)
+		}
+	}
+
+	words, _ := s.Words("D100", 3)
+	if diff := cmp.Diff(words, []uint16{0xFFFE, 0xFFFF, 0}); diff != "" {
+		t.Errorf("words differs: (-got +want)\n%s", diff)
+	}
+	bits, _ := s.Bits("X1E", 3)
+	if diff := cmp.Diff(bits, []bool{true, false, true}); diff != "" {
+		t.Errorf("bits differs: (-got +want)\n%s", diff)
+	}
+	rd, _ := s.Words("RD100000", 1)
+	if diff := cmp.Diff(rd, []uint16{0x1234}); diff != "" {
+		t.Errorf("iQ-R words differs: (-got +want)\n%s", diff)
+	}
+
+	s.SetWords("D200", 10, 20)
+	s.SetBits("M5", true)
+	client, err := mcp.New3EClient(s.Host(), s.Port(), mcp.NewLocalStation(), true)
+	if err != nil {
+		t.Fatalf("unexpected connect err: %v", err)
+	}
+	defer client.ShutDown()
+	payload, err := client.Read("D", 200, 2)
+	if err != nil {
+		t.Fatalf("unexpected read err: %v", err)
+	}
+	if diff := cmp.Diff(payload, []byte{10, 0, 20, 0}); diff != "" {
+		t.Errorf("payload differs: (-got +want)\n%s", diff)
+	}
+	values, err := client.ReadBools("M", 4, 2)
+	if err != nil {
+		t.Fatalf("unexpected bit read err: %v", err)
+	}
+	if diff := cmp.Diff(values, []bool{false, true}); diff != "" {
+		t.Errorf("bit values differs: (-got +want)\n%s", diff)
+	}
+
+	// extended device specification is not supported
+	var endCodeErr *mcp.EndCodeError
+	if _, err := client.Read(`J1\W`, 0, 1); !errors.As(err, &endCodeErr) || endCodeErr.EndCode != END_CODE_UNSUPPORTED {
+		t.Errorf("expected end code %X but actual is %v", END_CODE_UNSUPPORTED, err)
+	}
+
+	requests := s.Requests()
+	if len(requests) == 0 || requests[0].Command != 0x0619 || requests[len(requests)-1].SubCommand != 0x0080 {
+		t.Errorf("unexpected requests: %v", requests)
+	}
+}
+
+func TestServer_1E(t *testing.T) {
+	s, err := NewServer()
+	if err != nil {
+		t.Fatalf("unexpected server err: %v", err)
+	}
+	defer s.Close()
+	s.SetWords("D100", 0x1234)
+
+	conn, err := net.Dial("tcp", s.Addr())
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer conn.Close()
+
+	cases := []struct {
+		request  string
+		expected string
+	}{
+		// batch read in word units of D100
+		{request: "01 ff 0a00 64000000 2044 01 00", expected: "81 00 3412"},
+		// batch write in word units of D101-D102
+		{request: "03 ff 0a00 65000000 2044 02 00 cdab 0100", expected: "83 00"},
+		{request: "01 ff 0a00 64000000 2044 03 00", expected: "81 00 3412 cdab 0100"},
+		// batch write and read in bit units of M10-M12
+		{request: "02 ff 0a00 0a000000 204d 03 00 1010", expected: "82 00"},
+		{request: "00 ff 0a00 0a000000 204d 03 00", expected: "80 00 1010"},
+		// loopback test
+		{request: "16 ff 0a00 02 4142", expected: "96 00 02 4142"},
+		// unknown device code
+		{request: "01 ff 0a00 00000000 2099 01 00", expected: "81 5b 10"},
+	}
+	for _, v := range cases {
+		request, _ := hex.DecodeString(strings.ReplaceAll(v.request, " ", ""))
+		if _, err := conn.Write(request); err != nil {
+			t.Fatalf("unexpected write err: %v", err)
+		}
+		expected, _ := hex.DecodeString(strings.ReplaceAll(v.expected, " ", ""))
+		resp := make([]byte, len(expected))
+		if _, err := io.ReadFull(conn, resp); err != nil {
+			t.Fatalf("unexpected read err: %v", err)
+		}
+		if diff := cmp.Diff(resp, expected); diff != "" {
+			t.Errorf("response of %v differs: (-got +want)\n%s", v.request, diff)
+		}
+	}
+
+	words, _ := s.Words("D101", 2)
+	if diff := cmp.Diff(words, []uint16{0xABCD, 0x0001}); diff != "" {
+		t.Errorf("words differs: (-got +want)\n%s", diff)
+	}
+}
+
+func TestServer_FX3U(t *testing.T) {
+	s, err := NewServer()
+	if err != nil {
+		t.Fatalf("unexpected server err: %v", err)
+	}
+	defer s.Close()
+	s.SetWords("D100", 0x1234)
+
+	client, err := mcp.NewProfileClient(s.Host(), s.Port(), mcp.FX3U)
+	if err != nil {
+		t.Fatalf("unexpected connect err: %v", err)
+	}
+	defer client.ShutDown()
+
+	if err := client.HealthCheck(); err != nil {
+		t.Fatalf("unexpected health check err: %v", err)
+	}
+	if _, err := client.Write("D", 169, 1, []byte{0xCD, 0xAB}); err != nil {
+		t.Fatalf("unexpected write err: %v", err)
+	}
+	// 70 points are read by 64 and 6 points
+	payload, err := client.Read("D", 100, 70)
+	if err != nil {
+		t.Fatalf("unexpected read err: %v", err)
+	}
+	if hex.EncodeToString(payload[:2]) != "3412" || hex.EncodeToString(payload[138:]) != "cdab" {
+		t.Fatalf("expected D100=1234 and D169=ABCD but actual is %x", payload)
+	}
+
+	// Y17 is octal
+	deviceName, offset, err := mcp.FX3U.ParseDevice("Y17")
+	if err != nil {
+		t.Fatalf("unexpected parse err: %v", err)
+	}
+	if err := client.WriteBools(deviceName, offset, []bool{true, false, true}); err != nil {
+		t.Fatalf("unexpected write err: %v", err)
+	}
+	bits, _ := s.Bits("Y0F", 3)
+	if diff := cmp.Diff(bits, []bool{true, false, true}); diff != "" {
+		t.Errorf("bits differs: (-got +want)\n%s", diff)
+	}
+	values, err := client.ReadBools(deviceName, offset, 3)
+	if err != nil {
+		t.Fatalf("unexpected read err: %v", err)
+	}
+	if diff := cmp.Diff(values, []bool{true, false, true}); diff != "" {
+		t.Errorf("values differs: (-got +want)\n%s", diff)
+	}
+
+	if _, err := client.Read("W", 0, 1); !errors.Is(err, mcp.ErrInvalidDevice) {
+		t.Fatalf("expected %v but actual is %v", mcp.ErrInvalidDevice, err)
+	}
+	if _, err := client.Execute(0x0101, 0x0000, nil); err == nil {
+		t.Fatalf("expected error for command by 1E frame")
+	}
+
+	requests := s.Requests()
+	if f := requests[len(requests)-1]; f.Type != mcp.Frame1E {
+		t.Fatalf("expected 1E frame but actual is %v", f.Type)
+	}
+}
+
+func TestServer_SnapshotRestore(t *testing.T) {
+	s, err := NewServer()
+	if err != nil {
+		t.Fatalf("unexpected server err: %v", err)
+	}
+	defer s.Close()
+	s.SetWords("D100", 1, 0, 2)
+	s.SetBits("X1E", true, false)
+
+	expected := Memory{Words: map[string]uint16{"D100": 1, "D102": 2}, Bits: map[string]bool{"X1E": true}}
+	snapshot := s.Snapshot()
+	if diff := cmp.Diff(snapshot, expected); diff != "" {
+		t.Fatalf("snapshot differs: (-got +want)\n%s", diff)
+	}
+
+	if err := s.Restore(Memory{Words: map[string]uint16{"W1F": 3}}); err != nil {
+		t.Fatalf("unexpected restore err: %v", err)
+	}
+	words, _ := s.Words("D100", 1)
+	w1f, _ := s.Words("W1F", 1)
+	if diff := cmp.Diff(append(words, w1f...), []uint16{0, 3}); diff != "" {
+		t.Errorf("restored words differs: (-got +want)\n%s", diff)
+	}
+	if err := s.Restore(Memory{Bits: map[string]bool{"M1A": true}}); err == nil {
+		t.Errorf("expected error of invalid address")
+	}
+}
+
+func TestServer_WordUnitBitDevice(t *testing.T) {
+	s, err := NewServer()
+	if err != nil {
+		t.Fatalf("unexpected server err: %v", err)
+	}
+	defer s.Close()
+	client, err := mcp.New3EClient(s.Host(), s.Port(), mcp.NewLocalStation(), true)
+	if err != nil {
+		t.Fatalf("unexpected connect err: %v", err)
+	}
+	defer client.ShutDown()
+
+	// word read of bit device packs 16 points from the head, the first point is bit 0
+	s.SetBits("M0", true, true)
+	s.SetBits("M17", true)
+	payload, err := client.Read("M", 0, 2)
+	if err != nil {
+		t.Fatalf("unexpected read err: %v", err)
+	}
+	if diff := cmp.Diff(payload, []byte{0x03, 0x00, 0x02, 0x00}); diff != "" {
+		t.Errorf("payload differs: (-got +want)\n%s", diff)
+	}
+
+	// word write of bit device sets 16 points
+	if err := client.WriteUint16("M", 32, 0x8001); err != nil {
+		t.Fatalf("unexpected write err: %v", err)
+	}
+	bits, _ := s.Bits("M32", 16)
+	want := make([]bool, 16)
+	want[0], want[15] = true, true
+	if diff := cmp.Diff(bits, want); diff != "" {
+		t.Errorf("bits differs: (-got +want)\n%s", diff)
+	}
+	words, _ := s.Words("M32", 1)
+	if diff := cmp.Diff(words, []uint16{0x8001}); diff != "" {
+		t.Errorf("words differs: (-got +want)\n%s", diff)
+	}
+
+	if err := s.Restore(Memory{Words: map[string]uint16{"Y10": 0x0002}}); err != nil {
+		t.Fatalf("unexpected restore err: %v", err)
+	}
+	if diff := cmp.Diff(s.Snapshot(), Memory{Words: map[string]uint16{}, Bits: map[string]bool{"Y11": true}}); diff != "" {
+		t.Errorf("memory differs: (-got +want)\n%s", diff)
+	}
+}
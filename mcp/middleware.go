@@ -0,0 +1,50 @@
+package mcp
+
+import "context"
+
+// Handler sends request to PLC and returns raw response including header.
+// abnormal end code is not an error of Handler. parse response by Parser to inspect it.
+type Handler func(ctx context.Context, req *Request) ([]byte, error)
+
+// Middleware wraps Handler to add logging, metrics, retries, authorization checks and so on.
+// middleware can reject request by returning error without calling next.
+type Middleware func(next Handler) Handler
+
+// WithMiddleware wraps requests of client by middlewares.
+// the first middleware is the outermost, so it is called first.
+func WithMiddleware(middlewares ...Middleware) Option {
+	return func(o *options) {
+		o.middlewares = append(o.middlewares, middlewares...)
+	}
+}
+
+// chainHelper wraps terminal handler by middlewares of client.
+func (c *client3E) chainHelper(terminal Handler) Handler {
+	h := terminal
+	if c.opts.verifyWrites && !c.opts.dryRun {
+		// writes are verified against PLC, not cache
+		h = c.verifyMiddleware(h)
+	}
+	if c.opts.cache != nil {
+		h = c.cacheMiddleware(h)
+	}
+	if c.opts.auditHook != nil {
+		h = c.auditMiddleware(h)
+	}
+	if c.opts.dryRun {
+		// writes are answered here, so they never reach audit, cache and PLC
+		h = c.dryRunMiddleware(h)
+	}
+	if c.opts.profile != nil {
+		// requests that PLC does not support never reach audit, cache and PLC
+		h = c.profileMiddleware(h)
+	}
+	for i := len(c.opts.middlewares) - 1; i >= 0; i-- {
+		h = c.opts.middlewares[i](h)
+	}
+	if c.opts.logger != nil {
+		// log requests as they are given by caller
+		h = c.loggingMiddleware(h)
+	}
+	return h
+}
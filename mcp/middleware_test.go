@@ -0,0 +1,74 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestClient3E_Middleware(t *testing.T) {
+	var calls []string
+	logging := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(ctx context.Context, req *Request) ([]byte, error) {
+				calls = append(calls, name+" "+req.Op.String()+" "+req.DeviceName)
+				return next(ctx, req)
+			}
+		}
+	}
+	readOnly := func(next Handler) Handler {
+		return func(ctx context.Context, req *Request) ([]byte, error) {
+			if req.Op == OpWrite || req.Op == OpBitWrite {
+				return nil, errors.New("write is not allowed")
+			}
+			return next(ctx, req)
+		}
+	}
+
+	client, mem := newTestMemoryClient(t, WithMiddleware(logging("outer"), logging("inner"), readOnly))
+	mem.words[[2]int64{0xA8, 100}] = 0x1234
+
+	value, err := client.ReadUint16("D", 100)
+	if err != nil {
+		t.Fatalf("unexpected mcp read err: %v", err)
+	}
+	if value != 0x1234 {
+		t.Fatalf("expected %X but actual is %X", 0x1234, value)
+	}
+	if err := client.WriteUint16("D", 100, 1); err == nil || err.Error() != "write is not allowed" {
+		t.Fatalf("expected %v but actual is %v", "write is not allowed", err)
+	}
+
+	expected := []string{"outer Read D", "inner Read D", "outer Write D", "inner Write D"}
+	if diff := cmp.Diff(calls, expected); diff != "" {
+		t.Errorf("middleware calls differs: (-got +want)\n%s", diff)
+	}
+}
+
+func TestClient3E_MiddlewareBatch4E(t *testing.T) {
+	rejectBitRead := func(next Handler) Handler {
+		return func(ctx context.Context, req *Request) ([]byte, error) {
+			if req.Op == OpBitRead {
+				return nil, errors.New("bit read is not allowed")
+			}
+			return next(ctx, req)
+		}
+	}
+
+	client, mem := newTestMemoryClient(t, WithFrame4E(), WithMiddleware(rejectBitRead))
+	mem.words[[2]int64{0xA8, 100}] = 0x1234
+
+	results, err := client.Batch([]Request{
+		{Op: OpWrite, DeviceName: "D", Offset: 101, NumPoints: 1, WriteData: []byte{0x78, 0x56}},
+		{Op: OpBitRead, DeviceName: "M", Offset: 0, NumPoints: 1},
+		{Op: OpRead, DeviceName: "D", Offset: 100, NumPoints: 2},
+	})
+	if err == nil || err.Error() != "bit read is not allowed" {
+		t.Fatalf("expected %v but actual is %v", "bit read is not allowed", err)
+	}
+	if diff := cmp.Diff(results[2].Payload, []byte{0x34, 0x12, 0x78, 0x56}); diff != "" {
+		t.Errorf("batch payload differs: (-got +want)\n%s", diff)
+	}
+}
@@ -0,0 +1,293 @@
+package mcp
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"time"
+)
+
+// Option configures client.
+type Option func(*options)
+
+// options is configuration of client.
+type options struct {
+	// order of words of multi-word values
+	wordOrder WordOrder
+	// pad short write data with zero
+	zeroPadding bool
+	// encode and decode strings in Shift-JIS
+	shiftJIS bool
+	// enable tcp keep-alive. it is set by keep_alive of New3EClient
+	keepAlive bool
+	// interval of tcp keep-alive probes. zero means os default
+	keepAlivePeriod time.Duration
+	// tcp socket tuning. nil means os default
+	noDelay     *bool
+	linger      *int
+	readBuffer  int
+	writeBuffer int
+	localAddr   *net.TCPAddr
+	// dial function that is used instead of default dialer
+	dialContext func(ctx context.Context, network, address string) (net.Conn, error)
+	// failover endpoints like "192.168.0.2:5000"
+	failoverAddrs []string
+	// called with endpoint address when client connects to it
+	onConnect func(addr string)
+	// called when connection state changes
+	onConnState func(ConnEvent)
+	// policy of waiting between reconnect attempts
+	backoff Backoff
+	// reconnect and retry request once when I/O to the connection fails
+	autoReconnect bool
+	// interval of background health check. zero means disabled
+	heartbeatInterval time.Duration
+	// use 4E frame and pipeline requests
+	frame4E bool
+	// limiter of requests per second. nil means unlimited
+	limiter *rateLimiter
+	// circuit breaker. nil means disabled
+	breaker *breaker
+	// middlewares that wrap requests
+	middlewares []Middleware
+	// logger of client. nil means no logging
+	logger    *slog.Logger
+	logLevels LogLevels
+	// log frames as annotated hex
+	frameDump bool
+	// how long late responses of timed out requests are waited
+	drainTimeout time.Duration
+	// hook of write requests. nil means no audit
+	auditHook     AuditHook
+	auditReadBack bool
+	// read devices back after each write
+	verifyWrites bool
+	// records frames of connections. nil means no recording
+	recorder *recorder
+	// interval of tracking control system of redundant CPU. zero means disabled
+	redundantInterval time.Duration
+	// bit device that is ON in control system like "SM1515"
+	controlSystemDevice string
+	// deny all write requests
+	readOnly bool
+	// device ranges that can be written. nil means all devices
+	writableRanges []Request
+	// access devices by SLMP format
+	slmp bool
+	// read CPU model on connect to select profile
+	autoDetect bool
+	// device set and point limits of PLC model. nil means all devices
+	profile *Profile
+	// codec of frames on the wire. nil means 3E or 4E frame
+	codec Codec
+	// cache of read responses. nil means disabled
+	cache *readCache
+	// build write requests but do not send them
+	dryRun   bool
+	onDryRun func(*Frame)
+}
+
+func newOptions(opts []Option) options {
+	o := options{
+		wordOrder:           LowWordFirst,
+		backoff:             DefaultBackoff,
+		logLevels:           DefaultLogLevels,
+		drainTimeout:        100 * time.Millisecond,
+		controlSystemDevice: "SM1515",
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.codec != nil {
+		// responses of codec are not demultiplexed by serial number
+		o.frame4E = false
+	}
+	return o
+}
+
+// applySocketOptions applies keep-alive and socket tuning options to conn.
+func (o options) applySocketOptions(conn *net.TCPConn) error {
+	if err := conn.SetKeepAlive(o.keepAlive); err != nil {
+		return err
+	}
+	if o.keepAlive && o.keepAlivePeriod > 0 {
+		if err := conn.SetKeepAlivePeriod(o.keepAlivePeriod); err != nil {
+			return err
+		}
+	}
+	if o.noDelay != nil {
+		if err := conn.SetNoDelay(*o.noDelay); err != nil {
+			return err
+		}
+	}
+	if o.linger != nil {
+		if err := conn.SetLinger(*o.linger); err != nil {
+			return err
+		}
+	}
+	if o.readBuffer > 0 {
+		if err := conn.SetReadBuffer(o.readBuffer); err != nil {
+			return err
+		}
+	}
+	if o.writeBuffer > 0 {
+		if err := conn.SetWriteBuffer(o.writeBuffer); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WithWordOrder sets order of words of multi-word values like DWORD, REAL and LREAL.
+// default is LowWordFirst that is the layout of GX Works.
+func WithWordOrder(order WordOrder) Option {
+	return func(o *options) {
+		o.wordOrder = order
+	}
+}
+
+// WithZeroPadding pads write data shorter than write device points with zero
+// instead of returning ErrShortWriteData.
+func WithZeroPadding() Option {
+	return func(o *options) {
+		o.zeroPadding = true
+	}
+}
+
+// WithKeepAlivePeriod sets interval of tcp keep-alive probes.
+// it takes effect only when keep_alive of New3EClient is true.
+func WithKeepAlivePeriod(period time.Duration) Option {
+	return func(o *options) {
+		o.keepAlivePeriod = period
+	}
+}
+
+// WithNoDelay sets TCP_NODELAY of connection. go enables it by default.
+func WithNoDelay(noDelay bool) Option {
+	return func(o *options) {
+		o.noDelay = &noDelay
+	}
+}
+
+// WithLinger sets SO_LINGER of connection in seconds.
+// see net.TCPConn.SetLinger about negative and zero value.
+func WithLinger(sec int) Option {
+	return func(o *options) {
+		o.linger = &sec
+	}
+}
+
+// WithReadBuffer sets size of os receive buffer of connection.
+func WithReadBuffer(bytes int) Option {
+	return func(o *options) {
+		o.readBuffer = bytes
+	}
+}
+
+// WithWriteBuffer sets size of os transmit buffer of connection.
+func WithWriteBuffer(bytes int) Option {
+	return func(o *options) {
+		o.writeBuffer = bytes
+	}
+}
+
+// WithLocalAddr binds connection to local address like the address of the interface connected to plc network.
+// it is ignored when dialer is given by WithDialer or WithDialContext.
+func WithLocalAddr(addr *net.TCPAddr) Option {
+	return func(o *options) {
+		o.localAddr = addr
+	}
+}
+
+// WithDialer connects to plc by dialer instead of default dialer that has 3 seconds timeout.
+func WithDialer(dialer *net.Dialer) Option {
+	return func(o *options) {
+		o.dialContext = dialer.DialContext
+	}
+}
+
+// WithDialContext connects to plc by dial function instead of default dialer, e.g. through VPN or proxy.
+// dial may return any net.Conn, and socket options like WithNoDelay are applied only when it is *net.TCPConn.
+func WithDialContext(dial func(ctx context.Context, network, address string) (net.Conn, error)) Option {
+	return func(o *options) {
+		o.dialContext = dial
+	}
+}
+
+// WithFailover adds endpoints like "192.168.0.2:5000" that are used when connect or I/O to the active endpoint fails.
+// e.g. the other Ethernet port of redundant CPU. request that failed is not resent to the next endpoint.
+func WithFailover(addrs ...string) Option {
+	return func(o *options) {
+		o.failoverAddrs = append(o.failoverAddrs, addrs...)
+	}
+}
+
+// WithOnConnect sets fn that is called with endpoint address each time client connects to PLC.
+// it is useful to observe which endpoint is active when failover endpoints are given.
+func WithOnConnect(fn func(addr string)) Option {
+	return func(o *options) {
+		o.onConnect = fn
+	}
+}
+
+// WithBackoff sets policy of waiting between reconnect attempts of Reconnect and ReconnectUntil.
+func WithBackoff(b Backoff) Option {
+	return func(o *options) {
+		o.backoff = b
+	}
+}
+
+// WithAutoReconnect reconnects by backoff policy when I/O to the connection fails and retries the request once.
+// note that write request may be executed twice when the connection is broken after PLC received it.
+func WithAutoReconnect() Option {
+	return func(o *options) {
+		o.autoReconnect = true
+	}
+}
+
+// WithConnStateHandler sets fn that is called when connection state changes.
+// fn is called synchronously from the goroutine that uses the client, so it must not block.
+func WithConnStateHandler(fn func(ConnEvent)) Option {
+	return func(o *options) {
+		o.onConnState = fn
+	}
+}
+
+// WithHeartbeat issues loopback test at interval while the connection is idle.
+// when it fails, client is marked unhealthy (see Healthy) and connects to PLC again by backoff policy.
+func WithHeartbeat(interval time.Duration) Option {
+	return func(o *options) {
+		o.heartbeatInterval = interval
+	}
+}
+
+// WithFrame4E communicates by 4E frame instead of 3E frame.
+// 4E frame has serial number, so requests from multiple goroutines are pipelined on one connection
+// without waiting responses of the others.
+func WithFrame4E() Option {
+	return func(o *options) {
+		o.frame4E = true
+	}
+}
+
+// WithRateLimit limits requests sent to PLC to perSecond on average, allowing burst requests at once.
+// older Ethernet modules drop connections when they are flooded by requests.
+// requests over the limit wait for their turn. perSecond of zero or less means unlimited.
+func WithRateLimit(perSecond float64, burst int) Option {
+	return func(o *options) {
+		if perSecond <= 0 {
+			o.limiter = nil
+			return
+		}
+		o.limiter = newRateLimiter(perSecond, burst)
+	}
+}
+
+// WithCircuitBreaker opens circuit after threshold consecutive failures of communication with PLC.
+// while circuit is open, requests fail fast with ErrCircuitOpen for coolDown,
+// so that callers do not queue up on dead connection. abnormal end code is not counted as failure.
+func WithCircuitBreaker(threshold int, coolDown time.Duration) Option {
+	return func(o *options) {
+		o.breaker = newBreaker(threshold, coolDown)
+	}
+}
@@ -0,0 +1,208 @@
+package mcp
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+)
+
+// frame4E converts 3E frame request to 4E frame request that has serial number.
+// 4E frame has serial number[2byte] and fixed 0000[2byte] after sub header.
+func frame4E(request []byte, serial uint16) []byte {
+	frame := make([]byte, 0, len(request)+4)
+	frame = append(frame, 0x54, 0x00, byte(serial), byte(serial>>8), 0x00, 0x00)
+	return append(frame, request[2:]...)
+}
+
+// demuxResult is response or error of one request.
+type demuxResult struct {
+	resp []byte
+	err  error
+}
+
+// demux reads 4E frame responses from conn and hands them to the requests by serial number.
+type demux struct {
+	conn net.Conn
+	// dump logs received frame
+	dump    func(direction string, frame []byte)
+	mu      sync.Mutex
+	pending map[uint16]chan demuxResult
+	// set when reading responses is stopped
+	err error
+}
+
+// newDemux starts reading responses from conn.
+func newDemux(conn net.Conn, dump func(direction string, frame []byte)) *demux {
+	d := &demux{conn: conn, dump: dump, pending: map[uint16]chan demuxResult{}}
+	go d.readLoop()
+	return d
+}
+
+// register returns channel that receives response of serial.
+func (d *demux) register(serial uint16) (chan demuxResult, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.err != nil {
+		return nil, d.err
+	}
+	if _, ok := d.pending[serial]; ok {
+		return nil, errors.New("too many requests in flight: serial number is in use")
+	}
+	ch := make(chan demuxResult, 1)
+	d.pending[serial] = ch
+	return ch, nil
+}
+
+// alive returns true while demux is reading responses.
+func (d *demux) alive() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.err == nil
+}
+
+// cancel discards response of serial. response that arrives later is dropped.
+func (d *demux) cancel(serial uint16) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.pending, serial)
+}
+
+func (d *demux) readLoop() {
+	for {
+		// 4E response header is 13 byte and its last 2 byte is length of the rest.
+		header := make([]byte, 13)
+		if _, err := io.ReadFull(d.conn, header); err != nil {
+			d.fail(err)
+			return
+		}
+		if header[0] != 0xD4 || header[1] != 0x00 {
+			d.fail(errors.New("unexpected sub header of 4E frame response"))
+			return
+		}
+		resp := make([]byte, 13+int(binary.LittleEndian.Uint16(header[11:13])))
+		copy(resp, header)
+		if _, err := io.ReadFull(d.conn, resp[13:]); err != nil {
+			d.fail(err)
+			return
+		}
+
+		d.dump("recv", resp)
+
+		serial := binary.LittleEndian.Uint16(header[2:4])
+		d.mu.Lock()
+		ch, ok := d.pending[serial]
+		delete(d.pending, serial)
+		d.mu.Unlock()
+		if ok {
+			ch <- demuxResult{resp: resp}
+		}
+	}
+}
+
+// fail stops demux and notifies err to all waiting requests.
+func (d *demux) fail(err error) {
+	// requests waiting for response get ErrConnectionClosed when connection is closed
+	err = connClosedError(err)
+	d.conn.Close()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.err = err
+	for serial, ch := range d.pending {
+		ch <- demuxResult{err: err}
+		delete(d.pending, serial)
+	}
+}
+
+// pipelineHelper sends request by 4E frame and waits its response without blocking other requests.
+// responses are matched to requests by serial number, so multiple requests can be in flight on one connection.
+func (c *client3E) pipelineHelper(ctx context.Context, request []byte) ([]byte, error) {
+	resp, mux, err := c.pipelineExchangeHelper(ctx, request)
+	if err != nil && ctx.Err() == nil && c.pipelineRecoverHelper(ctx, mux, err) && c.opts.autoReconnect {
+		// retry once on the new connection
+		resp, _, err = c.pipelineExchangeHelper(ctx, request)
+	}
+	if err != nil {
+		return nil, err
+	}
+	c.heartbeat.touch()
+	return resp, nil
+}
+
+// pipelineExchangeHelper sends request and waits its response. it returns demux of the connection used.
+func (c *client3E) pipelineExchangeHelper(ctx context.Context, request []byte) ([]byte, *demux, error) {
+	p, err := c.pipelineSendHelper(ctx, request)
+	if err != nil {
+		return nil, p.mux, err
+	}
+	resp, err := p.wait(ctx)
+	return resp, p.mux, err
+}
+
+// pendingRequest is request sent by 4E frame that is waiting its response.
+type pendingRequest struct {
+	mux    *demux
+	serial uint16
+	ch     chan demuxResult
+}
+
+// wait waits response of request until ctx is done.
+func (p pendingRequest) wait(ctx context.Context) ([]byte, error) {
+	select {
+	case result := <-p.ch:
+		return result.resp, result.err
+	case <-ctx.Done():
+		p.mux.cancel(p.serial)
+		return nil, ctx.Err()
+	}
+}
+
+// pipelineSendHelper sends request by 4E frame without waiting its response.
+func (c *client3E) pipelineSendHelper(ctx context.Context, request []byte) (pendingRequest, error) {
+	if err := ctx.Err(); err != nil {
+		return pendingRequest{}, err
+	}
+	if c.opts.limiter != nil {
+		if err := c.opts.limiter.wait(ctx); err != nil {
+			return pendingRequest{}, err
+		}
+	}
+
+	serial := uint16(atomic.AddUint32(&c.serial, 1))
+	frame := frame4E(request, serial)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	p := pendingRequest{mux: c.mux, serial: serial}
+	ch, err := p.mux.register(serial)
+	if err != nil {
+		return p, err
+	}
+	deadline, _ := ctx.Deadline() // zero value means no deadline
+	if err = c.conn.SetWriteDeadline(deadline); err == nil {
+		c.dumpHelper("send", frame)
+		_, err = c.conn.Write(frame)
+	}
+	if err != nil {
+		p.mux.cancel(serial)
+		return p, connClosedError(err)
+	}
+	p.ch = ch
+	return p, nil
+}
+
+// pipelineRecoverHelper connects again when connection of mux is broken. it returns true when connection is established.
+// requests failed on the same connection recover it only once.
+func (c *client3E) pipelineRecoverHelper(ctx context.Context, mux *demux, cause error) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.mux != mux {
+		// already recovered by other request
+		return c.mux.alive()
+	}
+	return c.recoverHelper(ctx, cause)
+}
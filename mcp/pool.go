@@ -0,0 +1,59 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+)
+
+// Pool maintains multiple connections to the same PLC and dispatches requests across them.
+// Ethernet module like QJ71E71 accepts several simultaneous connections,
+// so requests on different connections are processed in parallel.
+type Pool struct {
+	clients []Client
+	idle    chan Client
+}
+
+// NewPool connects size clients to the PLC. arguments are the same as New3EClient.
+func NewPool(size int, host string, port int, stn *station, keep_alive bool, opts ...Option) (*Pool, error) {
+	if size <= 0 {
+		return nil, errors.New("pool size must be positive")
+	}
+
+	p := &Pool{idle: make(chan Client, size)}
+	for i := 0; i < size; i++ {
+		client, err := New3EClient(host, port, stn, keep_alive, opts...)
+		if err != nil {
+			p.Close()
+			return nil, err
+		}
+		p.clients = append(p.clients, client)
+		p.idle <- client
+	}
+	return p, nil
+}
+
+// Do runs fn with an idle client of the pool. it waits until any client becomes idle or ctx is done.
+// the client must not be used after fn returns.
+func (p *Pool) Do(ctx context.Context, fn func(c Client) error) error {
+	var client Client
+	select {
+	case client = <-p.idle:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() { p.idle <- client }()
+
+	return fn(client)
+}
+
+// Size returns number of connections of the pool.
+func (p *Pool) Size() int {
+	return len(p.clients)
+}
+
+// Close shuts down all connections of the pool.
+func (p *Pool) Close() {
+	for _, client := range p.clients {
+		client.ShutDown()
+	}
+}
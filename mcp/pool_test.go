@@ -0,0 +1,84 @@
+package mcp
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPool_Do(t *testing.T) {
+	mem := newTestMemory()
+	host, port := newTestServer(t, mem.handle)
+
+	pool, err := NewPool(3, host, port, NewLocalStation(), false)
+	if err != nil {
+		t.Fatalf("unexpected connect err: %v", err)
+	}
+	defer pool.Close()
+
+	var wg sync.WaitGroup
+	var inUse, maxInUse int32
+	for i := int64(0); i < 10; i++ {
+		wg.Add(1)
+		go func(offset int64) {
+			defer wg.Done()
+			err := pool.Do(context.Background(), func(c Client) error {
+				n := atomic.AddInt32(&inUse, 1)
+				defer atomic.AddInt32(&inUse, -1)
+				for {
+					m := atomic.LoadInt32(&maxInUse)
+					if n <= m || atomic.CompareAndSwapInt32(&maxInUse, m, n) {
+						break
+					}
+				}
+				time.Sleep(10 * time.Millisecond)
+				return c.WriteUint16("D", offset, uint16(offset))
+			})
+			if err != nil {
+				t.Errorf("unexpected mcp write err: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if maxInUse > 3 {
+		t.Fatalf("expected at most %v clients in use but actual is %v", 3, maxInUse)
+	}
+	for i := int64(0); i < 10; i++ {
+		var value uint16
+		_ = pool.Do(context.Background(), func(c Client) (err error) {
+			value, err = c.ReadUint16("D", i)
+			return err
+		})
+		if int64(value) != i {
+			t.Fatalf("expected %v but actual is %v", i, value)
+		}
+	}
+}
+
+func TestPool_DoContext(t *testing.T) {
+	host, port := newTestServer(t, func(req []byte) []byte { return nil })
+
+	pool, err := NewPool(1, host, port, NewLocalStation(), false)
+	if err != nil {
+		t.Fatalf("unexpected connect err: %v", err)
+	}
+	defer pool.Close()
+
+	acquired, release := make(chan struct{}), make(chan struct{})
+	go pool.Do(context.Background(), func(c Client) error {
+		close(acquired)
+		<-release
+		return nil
+	})
+	defer close(release)
+	<-acquired
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := pool.Do(ctx, func(c Client) error { return nil }); err != context.DeadlineExceeded {
+		t.Fatalf("expected %v but actual is %v", context.DeadlineExceeded, err)
+	}
+}
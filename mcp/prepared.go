@@ -0,0 +1,31 @@
+package mcp
+
+import "context"
+
+// PreparedRequest is request whose frame is built once and sent repeatedly, like a fixed poll.
+type PreparedRequest struct {
+	client *client3E
+	req    Request
+}
+
+// Prepare builds frame of req for repeated requests. req is validated like Batch,
+// so NumPoints must not exceed MAX_READ_POINTS or MAX_BIT_READ_POINTS.
+func (c *client3E) Prepare(req Request) (*PreparedRequest, error) {
+	if err := c.buildBatchHelper(c.stn, &req); err != nil {
+		return nil, err
+	}
+	return &PreparedRequest{client: c, req: req}, nil
+}
+
+// Request returns the prepared request.
+func (p *PreparedRequest) Request() Request {
+	return p.req
+}
+
+// Do sends the prepared frame and returns payload of response.
+// If PLC returns abnormal end code, *EndCodeError is returned.
+func (p *PreparedRequest) Do(ctx context.Context) ([]byte, error) {
+	// middleware may modify request, so each request has its copy. frame is shared because it is not modified.
+	req := p.req
+	return payloadHelper(p.client.chainHelper(p.client.terminalHandler)(ctx, &req))
+}
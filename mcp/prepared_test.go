@@ -0,0 +1,34 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestClient3E_Prepare(t *testing.T) {
+	client, mem := newTestMemoryClient(t)
+	mem.words[[2]int64{0xA8, 100}] = 0x1234
+
+	poll, err := client.Prepare(Request{Op: OpRead, DeviceName: "D", Offset: 100, NumPoints: 2})
+	if err != nil {
+		t.Fatalf("unexpected prepare err: %v", err)
+	}
+
+	for _, v := range []uint16{0x1234, 0x5678} {
+		mem.words[[2]int64{0xA8, 101}] = v
+		payload, err := poll.Do(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected mcp read err: %v", err)
+		}
+		if diff := cmp.Diff(payload, []byte{0x34, 0x12, byte(v), byte(v >> 8)}); diff != "" {
+			t.Errorf("payload differs: (-got +want)\n%s", diff)
+		}
+	}
+
+	if _, err := client.Prepare(Request{Op: OpRead, DeviceName: "D", Offset: 0, NumPoints: MAX_READ_POINTS + 1}); !errors.Is(err, ErrInvalidPoints) {
+		t.Fatalf("expected %v but actual is %v", ErrInvalidPoints, err)
+	}
+}
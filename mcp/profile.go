@@ -0,0 +1,196 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Profile is device set and point limits of a PLC model, so the client uses only what the model supports.
+type Profile struct {
+	Name string
+	// default port of the model. it is used by NewProfileClient when port is 0
+	Port int
+	// frame of the model. Frame1E is A-compatible 1E frame of local station, others are 3E frame
+	Frame FrameType
+	// device names that the model can access
+	Devices []string
+	// device number of X and Y is octal like X17
+	OctalXY bool
+	// max number of device points of a request
+	MaxReadPoints     int64
+	MaxBitReadPoints  int64
+	MaxWritePoints    int64
+	MaxBitWritePoints int64
+}
+
+// FX5U is profile of MELSEC iQ-F FX5U/FX5UC built-in Ethernet over SLMP.
+// iQ-F accepts MELSEC-Q/L series subcommands, and X and Y are octal. point limits are the batch read and write limits
+// of 3E frame (MAX_READ_POINTS words and MAX_BIT_READ_POINTS bits) and are not verified against FX5U hardware,
+// so set lower limits to a copy of the profile if the CPU rejects them.
+// Port is 5000 that is commonly set to SLMP connection of module parameter.
+var FX5U = Profile{
+	Name:              "FX5U",
+	Port:              5000,
+	Frame:             Frame3E,
+	Devices:           []string{"X", "Y", "M", "L", "F", "B", "S", "SM", "SB", "TS", "TC", "TN", "CS", "CC", "CN", "D", "W", "R", "Z", "SD", "SW"},
+	OctalXY:           true,
+	MaxReadPoints:     MAX_READ_POINTS,
+	MaxBitReadPoints:  MAX_BIT_READ_POINTS,
+	MaxWritePoints:    MAX_READ_POINTS,
+	MaxBitWritePoints: MAX_BIT_READ_POINTS,
+}
+
+// FX3U is profile of MELSEC-F FX3U/FX3UC/FX3G Ethernet modules like FX3U-ENET-L and FX3U-ENET-ADP by A-compatible 1E frame.
+// 1E frame has FX device codes, and X and Y are octal. only batch read, batch write and loopback test are supported.
+// point limits are conservative ones of FX series, and Port is 5551 of MC protocol of FX3U-ENET-ADP.
+var FX3U = Profile{
+	Name:              "FX3U",
+	Port:              5551,
+	Frame:             Frame1E,
+	Devices:           []string{"X", "Y", "M", "S", "TS", "TN", "CS", "CN", "D", "R"},
+	OctalXY:           true,
+	MaxReadPoints:     64,
+	MaxBitReadPoints:  256,
+	MaxWritePoints:    64,
+	MaxBitWritePoints: 160,
+}
+
+// WithProfile makes requests of devices that p does not have or of points more than limits of p
+// ErrInvalidDevice or ErrInvalidPoints. Read, BitRead, Fill, WriteBlocksContext and BitWriteBlocksContext are split by limits of p.
+// profile of Frame1E sends requests by 1E frame, and Execute is not supported.
+func WithProfile(p Profile) Option {
+	return func(o *options) {
+		o.profile = &p
+		if p.Frame == Frame1E {
+			o.codec = codec1E{}
+		}
+	}
+}
+
+// NewProfileClient connects to PLC of profile p at host and port of local station. port 0 means Port of p.
+func NewProfileClient(host string, port int, p Profile, opts ...Option) (Client, error) {
+	if port == 0 {
+		port = p.Port
+	}
+	return New3EClient(host, port, NewLocalStation(), true, append([]Option{WithProfile(p)}, opts...)...)
+}
+
+// ParseDevice parses device address like ParseDevice, but X and Y are octal when OctalXY is set.
+// device that p does not have is ErrInvalidDevice.
+func (p Profile) ParseDevice(address string) (string, int64, error) {
+	deviceName, offset, err := ParseDevice(address)
+	if p.OctalXY && (deviceName == "X" || deviceName == "Y") {
+		number := strings.TrimLeft(strings.ToUpper(address), "XY")
+		offset, err = strconv.ParseInt(number, 8, 64)
+		if err != nil {
+			return "", 0, errors.New("invalid device address: " + address)
+		}
+	}
+	if err != nil {
+		return "", 0, err
+	}
+	if !p.hasDevice(deviceName) {
+		return "", 0, fmt.Errorf("%w: %v does not have %v", ErrInvalidDevice, p.Name, deviceName)
+	}
+	return deviceName, offset, nil
+}
+
+// FormatDevice formats device name and offset like FormatDevice, but X and Y are octal when OctalXY is set.
+func (p Profile) FormatDevice(deviceName string, offset int64) string {
+	if p.OctalXY && (deviceName == "X" || deviceName == "Y") {
+		return deviceName + strconv.FormatInt(offset, 8)
+	}
+	return FormatDevice(deviceName, offset)
+}
+
+func (p Profile) hasDevice(deviceName string) bool {
+	for _, d := range p.Devices {
+		if d == deviceName {
+			return true
+		}
+	}
+	return false
+}
+
+// checkHelper validates device and points of req.
+func (p Profile) checkHelper(req *Request) error {
+	var limit int64
+	switch req.Op {
+	case OpRead:
+		limit = p.MaxReadPoints
+	case OpBitRead:
+		limit = p.MaxBitReadPoints
+	case OpWrite:
+		limit = p.MaxWritePoints
+	case OpBitWrite:
+		limit = p.MaxBitWritePoints
+	case OpCommand:
+		if p.Frame == Frame1E {
+			return fmt.Errorf("%v does not support commands by 1E frame", p.Name)
+		}
+		return nil
+	default:
+		return nil
+	}
+	if !p.hasDevice(req.DeviceName) {
+		return fmt.Errorf("%w: %v does not have %v", ErrInvalidDevice, p.Name, req.DeviceName)
+	}
+	if limit > 0 && req.NumPoints > limit {
+		return fmt.Errorf("%w: %v is larger than %v of %v", ErrInvalidPoints, req.NumPoints, limit, p.Name)
+	}
+	return nil
+}
+
+// profileMiddleware rejects requests that the profile does not support before they reach PLC.
+func (c *client3E) profileMiddleware(next Handler) Handler {
+	p := c.opts.profile
+	return func(ctx context.Context, req *Request) ([]byte, error) {
+		if err := p.checkHelper(req); err != nil {
+			return nil, err
+		}
+		return next(ctx, req)
+	}
+}
+
+// readLimitHelper returns max points of a read request of op, that is limit of profile or of MC protocol.
+// limit of bit unit is even because payload of bit unit has 2 points per 1 byte.
+func (c *client3E) readLimitHelper(op Op) int64 {
+	limit := int64(MAX_READ_POINTS)
+	if op == OpBitRead {
+		limit = MAX_BIT_READ_POINTS
+	}
+	if p := c.opts.profile; p != nil {
+		if op == OpBitRead && p.MaxBitReadPoints > 0 && p.MaxBitReadPoints < limit {
+			limit = p.MaxBitReadPoints
+		} else if op == OpRead && p.MaxReadPoints > 0 && p.MaxReadPoints < limit {
+			limit = p.MaxReadPoints
+		}
+	}
+	if op == OpBitRead && limit > 1 {
+		limit &^= 1
+	}
+	return limit
+}
+
+// writeLimitHelper returns max points of a write request of op, that is limit of profile or of MC protocol.
+// limit of bit unit is even because write data of bit unit has 2 points per 1 byte.
+func (c *client3E) writeLimitHelper(op Op) int64 {
+	limit := int64(MAX_READ_POINTS)
+	if op == OpBitWrite {
+		limit = MAX_BIT_READ_POINTS
+	}
+	if p := c.opts.profile; p != nil {
+		if op == OpBitWrite && p.MaxBitWritePoints > 0 && p.MaxBitWritePoints < limit {
+			limit = p.MaxBitWritePoints
+		} else if op == OpWrite && p.MaxWritePoints > 0 && p.MaxWritePoints < limit {
+			limit = p.MaxWritePoints
+		}
+	}
+	if op == OpBitWrite && limit > 1 {
+		limit &^= 1
+	}
+	return limit
+}
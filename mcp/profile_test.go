@@ -0,0 +1,104 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestProfile_ParseDevice(t *testing.T) {
+	cases := []struct {
+		input      string
+		deviceName string
+		offset     int64
+	}{
+		{input: "X17", deviceName: "X", offset: 15},
+		{input: "y20", deviceName: "Y", offset: 16},
+		{input: "D100", deviceName: "D", offset: 100},
+		{input: "W1F", deviceName: "W", offset: 0x1F},
+		{input: "TN10", deviceName: "TN", offset: 10},
+	}
+	for _, v := range cases {
+		deviceName, offset, err := FX5U.ParseDevice(v.input)
+		if err != nil {
+			t.Errorf("unexpected err: input is %v: %v", v.input, err)
+			continue
+		}
+		if deviceName != v.deviceName || offset != v.offset {
+			t.Errorf("expected %v %v but actual is %v %v", v.deviceName, v.offset, deviceName, offset)
+		}
+		if actual := FX5U.FormatDevice(deviceName, offset); actual != FX5U.FormatDevice(v.deviceName, v.offset) {
+			t.Errorf("expected %v but actual is %v", v.input, actual)
+		}
+	}
+	if actual := FX5U.FormatDevice("X", 15); actual != "X17" {
+		t.Errorf("expected %v but actual is %v", "X17", actual)
+	}
+
+	for _, input := range []string{"X18", "X1F", "G100", "V0"} {
+		if _, _, err := FX5U.ParseDevice(input); err == nil {
+			t.Errorf("expected error: input is %v", input)
+		}
+	}
+}
+
+func TestClient3E_Profile(t *testing.T) {
+	small := FX5U
+	small.MaxReadPoints = 4
+	client, mem := newTestMemoryClient(t, WithProfile(small))
+	for i := int64(0); i < 10; i++ {
+		mem.words[[2]int64{0xA8, i}] = uint16(i)
+	}
+
+	// read is split by limit of the profile
+	words, err := client.Read("D", 0, 10)
+	if err != nil {
+		t.Fatalf("unexpected read err: %v", err)
+	}
+	if words[18] != 9 {
+		t.Fatalf("expected %v but actual is %v", 9, words[18])
+	}
+	dst := make([]byte, 20)
+	if err := client.ReadInto(context.Background(), "D", 0, dst); err != nil {
+		t.Fatalf("unexpected read err: %v", err)
+	}
+
+	if _, err := client.Read("G", 0, 1); !errors.Is(err, ErrInvalidDevice) {
+		t.Fatalf("expected %v but actual is %v", ErrInvalidDevice, err)
+	}
+	if _, err := client.Batch([]Request{{Op: OpRead, DeviceName: "D", NumPoints: 5}}); !errors.Is(err, ErrInvalidPoints) {
+		t.Fatalf("expected %v but actual is %v", ErrInvalidPoints, err)
+	}
+}
+
+func TestClient3E_ProfileOddBitLimit(t *testing.T) {
+	small := FX5U
+	small.MaxBitReadPoints = 5
+	client, _ := newTestMemoryClient(t, WithProfile(small))
+	values := []bool{true, false, true, true, false, false, true, false, true, true, false, true}
+	if err := client.WriteBools("M", 0, values); err != nil {
+		t.Fatalf("unexpected write err: %v", err)
+	}
+
+	// bit read is split by 4 points, so payloads of the requests are joined at byte boundary
+	got, err := client.ReadBools("M", 0, int64(len(values)))
+	if err != nil {
+		t.Fatalf("unexpected read err: %v", err)
+	}
+	if diff := cmp.Diff(got, values); diff != "" {
+		t.Errorf("bits differs: (-got +want)\n%s", diff)
+	}
+}
+
+func TestFX5U(t *testing.T) {
+	// limits of FX5U are those of 3E frame
+	limits := []int64{FX5U.MaxReadPoints, FX5U.MaxBitReadPoints, FX5U.MaxWritePoints, FX5U.MaxBitWritePoints}
+	if diff := cmp.Diff(limits, []int64{960, 7168, 960, 7168}); diff != "" {
+		t.Errorf("limits differs: (-got +want)\n%s", diff)
+	}
+	if FX5U.Port != 5000 || FX5U.Frame != Frame3E || !FX5U.OctalXY {
+		t.Fatalf("unexpected profile: %v %v %v", FX5U.Port, FX5U.Frame, FX5U.OctalXY)
+	}
+}
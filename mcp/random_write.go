@@ -0,0 +1,108 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// max number of device points of random write in 1 request of MELSEC-Q/L series
+const (
+	MAX_RANDOM_WRITE_POINTS     = 160 // word unit
+	MAX_BIT_RANDOM_WRITE_POINTS = 188 // bit unit
+)
+
+// WriteRandom writes devices of requests that may be scattered by random write command (1402) instead of a request per range.
+// requests are OpWrite in word units or OpBitWrite in bit units. words are written by one request and bits by another,
+// so each unit is written at once by PLC, but the two requests are not atomic. words of all requests must not exceed
+// MAX_RANDOM_WRITE_POINTS and bits must not exceed MAX_BIT_RANDOM_WRITE_POINTS. extended devices like J1\W are not supported.
+func (c *client3E) WriteRandom(ctx context.Context, requests []Request) error {
+	var words, bits []Request
+	var numWords, numBits int64
+	slmp := c.stationHelper(ctx).slmp
+	for _, r := range requests {
+		if err := validatePoints(r.NumPoints); err != nil {
+			return err
+		}
+		if strings.Contains(r.DeviceName, `\`) {
+			return fmt.Errorf("%w: extended device %v is not supported by random write", ErrInvalidDevice, r.DeviceName)
+		}
+		if err := c.guardHelper(r.Op, r.DeviceName, r.Offset, r.NumPoints); err != nil {
+			return err
+		}
+		bit := r.Op == OpBitWrite
+		if r.Op != OpWrite && !bit {
+			return fmt.Errorf("random write does not support %v", r.Op)
+		}
+		if n := WriteDataLen(r.NumPoints, bit); int64(len(r.WriteData)) < n {
+			return fmt.Errorf("%w: %v points need %v byte but write data is %v byte", ErrShortWriteData, r.NumPoints, n, len(r.WriteData))
+		}
+		if _, iqr := IQRDeviceCodes[r.DeviceName]; iqr {
+			// all devices of a request have the same format
+			slmp = true
+		}
+		if bit {
+			bits, numBits = append(bits, r), numBits+r.NumPoints
+		} else {
+			words, numWords = append(words, r), numWords+r.NumPoints
+		}
+	}
+	if numWords > MAX_RANDOM_WRITE_POINTS {
+		return fmt.Errorf("%w: %v words are larger than %v", ErrInvalidPoints, numWords, MAX_RANDOM_WRITE_POINTS)
+	}
+	if numBits > MAX_BIT_RANDOM_WRITE_POINTS {
+		return fmt.Errorf("%w: %v bits are larger than %v", ErrInvalidPoints, numBits, MAX_BIT_RANDOM_WRITE_POINTS)
+	}
+
+	subCommand := uint16(0x0000)
+	if slmp {
+		subCommand |= iqrSubCommandFlag
+	}
+	if len(words) > 0 {
+		// number of word access points[1byte] + number of double word access points[1byte] + (device + data[2byte])...
+		data := []byte{byte(numWords), 0x00}
+		for _, r := range words {
+			for i := int64(0); i < r.NumPoints; i++ {
+				var err error
+				if data, _, err = appendDeviceHelper(data, r.DeviceName, r.Offset+i, 0, slmp); err != nil {
+					return err
+				}
+				data = append(data, r.WriteData[2*i], r.WriteData[2*i+1])
+			}
+		}
+		if err := c.sendRandomHelper(ctx, subCommand, data); err != nil {
+			return err
+		}
+	}
+	if len(bits) > 0 {
+		// number of bit access points[1byte] + (device + ON/OFF[1byte, 2byte of iQ-R format])...
+		data := []byte{byte(numBits)}
+		for _, r := range bits {
+			for i, v := range DecodeBits(r.WriteData, r.NumPoints) {
+				var err error
+				if data, _, err = appendDeviceHelper(data, r.DeviceName, r.Offset+int64(i), 0, slmp); err != nil {
+					return err
+				}
+				var on byte
+				if v {
+					on = 0x01
+				}
+				data = append(data, on)
+				if slmp {
+					data = append(data, 0x00)
+				}
+			}
+		}
+		if err := c.sendRandomHelper(ctx, subCommand|0x0001, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sendRandomHelper sends random write command. devices are checked by guardHelper, so writable ranges do not reject it.
+func (c *client3E) sendRandomHelper(ctx context.Context, subCommand uint16, data []byte) error {
+	frame := c.stationHelper(ctx).AppendCommandRequest(nil, 0x1402, subCommand, data)
+	_, err := payloadHelper(c.requestHelper(ctx, &Request{Op: OpCommand, WriteData: data}, frame, 22))
+	return err
+}
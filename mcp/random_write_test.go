@@ -0,0 +1,42 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestClient3E_WriteRandom(t *testing.T) {
+	client, mem := newTestMemoryClient(t)
+	ctx := context.Background()
+
+	err := client.WriteRandom(ctx, []Request{
+		{Op: OpWrite, DeviceName: "D", Offset: 100, NumPoints: 2, WriteData: []byte{0x01, 0x00, 0x02, 0x00}},
+		{Op: OpWrite, DeviceName: "D", Offset: 300, NumPoints: 1, WriteData: []byte{0x34, 0x12}},
+		{Op: OpBitWrite, DeviceName: "M", Offset: 10, NumPoints: 3, WriteData: []byte{0x10, 0x10}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	for off, want := range map[int64]uint16{100: 1, 101: 2, 300: 0x1234} {
+		if got := mem.words[[2]int64{0xA8, off}]; got != want {
+			t.Fatalf("D%v: expected %v but actual is %v", off, want, got)
+		}
+	}
+	for off, want := range map[int64]bool{10: true, 11: false, 12: true} {
+		if got := mem.bits[[2]int64{0x90, off}]; got != want {
+			t.Fatalf("M%v: expected %v but actual is %v", off, want, got)
+		}
+	}
+
+	err = client.WriteRandom(ctx, []Request{
+		{Op: OpWrite, DeviceName: "D", Offset: 0, NumPoints: MAX_RANDOM_WRITE_POINTS + 1, WriteData: make([]byte, 2*(MAX_RANDOM_WRITE_POINTS+1))},
+	})
+	if !errors.Is(err, ErrInvalidPoints) {
+		t.Fatalf("expected %v but actual is %v", ErrInvalidPoints, err)
+	}
+	err = client.WriteRandom(ctx, []Request{{Op: OpRead, DeviceName: "D", Offset: 0, NumPoints: 1}})
+	if err == nil {
+		t.Fatalf("expected error of read request")
+	}
+}
@@ -0,0 +1,44 @@
+package mcp
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter_Wait(t *testing.T) {
+	l := newRateLimiter(100, 2)
+
+	start := time.Now()
+	for i := 0; i < 6; i++ {
+		if err := l.wait(context.Background()); err != nil {
+			t.Fatalf("unexpected wait err: %v", err)
+		}
+	}
+	// 2 tokens of burst and 4 tokens added at 10ms intervals
+	if elapsed := time.Since(start); elapsed < 35*time.Millisecond {
+		t.Fatalf("expected at least %v but actual is %v", 40*time.Millisecond, elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	slow := newRateLimiter(0.1, 1)
+	_ = slow.wait(context.Background())
+	if err := slow.wait(ctx); err != context.Canceled {
+		t.Fatalf("expected %v but actual is %v", context.Canceled, err)
+	}
+}
+
+func TestClient3E_RateLimit(t *testing.T) {
+	client, _ := newTestMemoryClient(t, WithRateLimit(100, 1))
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		if _, err := client.ReadUint16("D", 100); err != nil {
+			t.Fatalf("unexpected mcp read err: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 35*time.Millisecond {
+		t.Fatalf("expected at least %v but actual is %v", 40*time.Millisecond, elapsed)
+	}
+}
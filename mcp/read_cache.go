@@ -0,0 +1,188 @@
+package mcp
+
+import (
+	"context"
+	"encoding/binary"
+	"sync"
+	"time"
+)
+
+// WithReadCache shares read responses for ttl, so consumers that read overlapping device ranges within
+// a short window share one PLC round trip. a read is answered from cache when an earlier read of
+// the same device in the same units (word or bit) covers its range, including a read in flight.
+// writes through the client invalidate cached reads of the written device.
+// note that changes made by PLC program or other clients are seen after ttl at most.
+func WithReadCache(ttl time.Duration) Option {
+	return func(o *options) {
+		if ttl <= 0 {
+			o.cache = nil
+			return
+		}
+		o.cache = &readCache{ttl: ttl}
+	}
+}
+
+// noCacheKey is context key of reads that must reach PLC, like the read of read-modify-write.
+type noCacheKey struct{}
+
+// withoutCache returns ctx whose reads are not answered from cache.
+func withoutCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noCacheKey{}, true)
+}
+
+// readCache is cache of read responses.
+type readCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries []*cacheEntry
+}
+
+// cacheEntry is response of a read request. payload and err are set when done is closed.
+type cacheEntry struct {
+	op Op
+	// route of request frame, so entries of other stations are separated
+	route      string
+	deviceName string
+	offset     int64
+	numPoints  int64
+	// time when the entry expires. zero while the read is in flight
+	expires time.Time
+	done    chan struct{}
+	payload []byte
+	err     error
+}
+
+// covers reports whether e has response of numPoints devices from offset of op.
+func (e *cacheEntry) covers(op Op, route, deviceName string, offset, numPoints int64) bool {
+	return e.op == op && e.route == route && e.deviceName == deviceName && e.offset <= offset && offset+numPoints <= e.offset+e.numPoints
+}
+
+// slice returns payload of numPoints devices from offset. e must cover them.
+func (e *cacheEntry) slice(offset, numPoints int64) []byte {
+	if e.op == OpBitRead {
+		return EncodeBits(DecodeBits(e.payload, e.numPoints)[offset-e.offset : offset-e.offset+numPoints])
+	}
+	return append([]byte(nil), e.payload[2*(offset-e.offset):2*(offset-e.offset+numPoints)]...)
+}
+
+// lookup returns entry that covers req, or adds entry of req in flight. added is true when the entry is added.
+func (rc *readCache) lookup(req *Request) (e *cacheEntry, added bool) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	now := time.Now()
+	live := rc.entries[:0]
+	for _, e := range rc.entries {
+		if e.expires.IsZero() || now.Before(e.expires) {
+			live = append(live, e)
+		}
+	}
+	for i := len(live); i < len(rc.entries); i++ {
+		rc.entries[i] = nil
+	}
+	rc.entries = live
+
+	var route string
+	if len(req.frame) >= 7 {
+		route = string(req.frame[2:7])
+	}
+	for _, e := range rc.entries {
+		if e.covers(req.Op, route, req.DeviceName, req.Offset, req.NumPoints) {
+			return e, false
+		}
+	}
+	e = &cacheEntry{op: req.Op, route: route, deviceName: req.DeviceName, offset: req.Offset, numPoints: req.NumPoints, done: make(chan struct{})}
+	rc.entries = append(rc.entries, e)
+	return e, true
+}
+
+// complete sets result of entry in flight. failed entry is removed.
+func (rc *readCache) complete(e *cacheEntry, payload []byte, err error) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	e.payload, e.err = append([]byte(nil), payload...), err
+	e.expires = time.Now().Add(rc.ttl)
+	close(e.done)
+	if err != nil {
+		rc.removeHelper(func(x *cacheEntry) bool { return x == e })
+	}
+}
+
+// invalidate removes entries of deviceName.
+func (rc *readCache) invalidate(deviceName string) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.removeHelper(func(e *cacheEntry) bool { return e.deviceName == deviceName })
+}
+
+// clear removes all entries.
+func (rc *readCache) clear() {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.removeHelper(func(e *cacheEntry) bool { return true })
+}
+
+func (rc *readCache) removeHelper(remove func(*cacheEntry) bool) {
+	kept := rc.entries[:0]
+	for _, e := range rc.entries {
+		if !remove(e) {
+			kept = append(kept, e)
+		}
+	}
+	for i := len(kept); i < len(rc.entries); i++ {
+		rc.entries[i] = nil
+	}
+	rc.entries = kept
+}
+
+// cacheMiddleware answers reads from cache and invalidates cache by writes.
+func (c *client3E) cacheMiddleware(next Handler) Handler {
+	rc := c.opts.cache
+	return func(ctx context.Context, req *Request) ([]byte, error) {
+		switch req.Op {
+		case OpWrite, OpBitWrite:
+			// the device may be written even when the write fails
+			defer rc.invalidate(req.DeviceName)
+			return next(ctx, req)
+		case OpRead, OpBitRead:
+			if ctx.Value(noCacheKey{}) != nil {
+				return next(ctx, req)
+			}
+		case OpCommand:
+			if !readCommands[req.command()] {
+				// devices changed by the command are unknown
+				defer rc.clear()
+			}
+			return next(ctx, req)
+		default:
+			return next(ctx, req)
+		}
+
+		e, added := rc.lookup(req)
+		if added {
+			resp, err := next(ctx, req)
+			payload, perr := payloadHelper(resp, err)
+			rc.complete(e, payload, perr)
+			return resp, err
+		}
+
+		select {
+		case <-e.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if e.err != nil {
+			// the shared read failed, so read by itself
+			return next(ctx, req)
+		}
+		payload := e.slice(req.Offset, req.NumPoints)
+
+		// response of 3E frame with the route of request and normal completion
+		resp := []byte{0xD0, 0x00}
+		resp = append(resp, req.frame[2:7]...)
+		resp = binary.LittleEndian.AppendUint16(resp, uint16(2+len(payload)))
+		resp = append(resp, 0x00, 0x00)
+		return append(resp, payload...), nil
+	}
+}
@@ -0,0 +1,109 @@
+package mcp
+
+import (
+	"encoding/binary"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestClient3E_ReadCache(t *testing.T) {
+	mem := newTestMemory()
+	var reads atomic.Int32
+	host, port := newTestServer(t, func(req []byte) []byte {
+		if binary.LittleEndian.Uint16(req[11:13]) == 0x0401 {
+			reads.Add(1)
+		}
+		return mem.handle(req)
+	})
+	client, err := New3EClient(host, port, NewLocalStation(), true, WithReadCache(time.Hour))
+	if err != nil {
+		t.Fatalf("unexpected connect err: %v", err)
+	}
+	defer client.ShutDown()
+	for i := int64(0); i < 10; i++ {
+		mem.words[[2]int64{0xA8, 100 + i}] = uint16(i)
+	}
+	mem.bits[[2]int64{0x90, 3}] = true
+
+	if _, err := client.Read("D", 100, 10); err != nil {
+		t.Fatalf("unexpected mcp read err: %v", err)
+	}
+	payload, err := client.Read("D", 102, 3)
+	if err != nil {
+		t.Fatalf("unexpected mcp read err: %v", err)
+	}
+	if diff := cmp.Diff(payload, []byte{0x02, 0x00, 0x03, 0x00, 0x04, 0x00}); diff != "" {
+		t.Errorf("payload differs: (-got +want)\n%s", diff)
+	}
+	if _, err := client.BitRead("M", 0, 8); err != nil {
+		t.Fatalf("unexpected mcp read err: %v", err)
+	}
+	values, err := client.ReadBools("M", 3, 3)
+	if err != nil {
+		t.Fatalf("unexpected mcp read err: %v", err)
+	}
+	if diff := cmp.Diff(values, []bool{true, false, false}); diff != "" {
+		t.Errorf("values differs: (-got +want)\n%s", diff)
+	}
+	if n := reads.Load(); n != 2 {
+		t.Fatalf("expected %v reads but actual is %v", 2, n)
+	}
+
+	// write invalidates cache of the device
+	if err := client.WriteUint16("D", 105, 0x1234); err != nil {
+		t.Fatalf("unexpected mcp write err: %v", err)
+	}
+	if v, err := client.ReadUint16("D", 105); err != nil || v != 0x1234 {
+		t.Fatalf("expected %v but actual is %v, %v", 0x1234, v, err)
+	}
+	if n := reads.Load(); n != 3 {
+		t.Fatalf("expected %v reads but actual is %v", 3, n)
+	}
+
+	// concurrent reads share a read in flight
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.Read("W", 0, 16); err != nil {
+				t.Errorf("unexpected mcp read err: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := reads.Load(); n != 4 {
+		t.Fatalf("expected %v reads but actual is %v", 4, n)
+	}
+}
+
+func TestClient3E_ReadCacheExpires(t *testing.T) {
+	mem := newTestMemory()
+	var reads atomic.Int32
+	host, port := newTestServer(t, func(req []byte) []byte {
+		reads.Add(1)
+		return mem.handle(req)
+	})
+	client, err := New3EClient(host, port, NewLocalStation(), true, WithReadCache(20*time.Millisecond))
+	if err != nil {
+		t.Fatalf("unexpected connect err: %v", err)
+	}
+	defer client.ShutDown()
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.Read("D", 100, 1); err != nil {
+			t.Fatalf("unexpected mcp read err: %v", err)
+		}
+	}
+	time.Sleep(40 * time.Millisecond)
+	if _, err := client.Read("D", 100, 1); err != nil {
+		t.Fatalf("unexpected mcp read err: %v", err)
+	}
+	if n := reads.Load(); n != 2 {
+		t.Fatalf("expected %v reads but actual is %v", 2, n)
+	}
+}
@@ -0,0 +1,212 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrRecipeMismatch is error of ApplyRecipe when values read back differ from the recipe.
+var ErrRecipeMismatch = errors.New("read back values differ from recipe")
+
+// Recipe is named set of tag values that are written together, like parameters of a product.
+type Recipe struct {
+	Name string `json:"name" yaml:"name"`
+	// tag name and its value in engineering unit. bool tag needs bool and string tag needs string
+	Values map[string]any `json:"values" yaml:"values"`
+}
+
+// LoadRecipe loads recipe from JSON.
+func LoadRecipe(r io.Reader) (Recipe, error) {
+	var recipe Recipe
+	err := json.NewDecoder(r).Decode(&recipe)
+	return recipe, err
+}
+
+// LoadRecipeYAML loads recipe from YAML.
+func LoadRecipeYAML(r io.Reader) (Recipe, error) {
+	var recipe Recipe
+	err := yaml.NewDecoder(r).Decode(&recipe)
+	return recipe, err
+}
+
+// RecipeRecord is record of a recipe written by ApplyRecipe for audit.
+type RecipeRecord struct {
+	Recipe string
+	// time when the recipe is written
+	Time time.Time
+	// values of the tags before and after the write
+	Before map[string]any
+	After  map[string]any
+}
+
+// ValidateRecipe validates that all tags of recipe exist and their values fit to the tags.
+func (db *TagDB) ValidateRecipe(recipe Recipe) error {
+	_, err := db.recipeRequestsHelper(recipe, LowWordFirst)
+	return err
+}
+
+// ApplyRecipe validates recipe, reads the tags, writes all values by WriteRandom and reads them back for verification.
+// word tags are written by one random write and bool tags by another, so a recipe of both is not atomic.
+// order is word order of multi-word values. values that differ after the write are ErrRecipeMismatch.
+// the record has images before and after the write even if the write or verification failed.
+func (db *TagDB) ApplyRecipe(ctx context.Context, c Client, recipe Recipe, order WordOrder) (*RecipeRecord, error) {
+	writes, err := db.recipeRequestsHelper(recipe, order)
+	if err != nil {
+		return nil, err
+	}
+	names := recipeNames(recipe)
+
+	record := &RecipeRecord{Recipe: recipe.Name}
+	if record.Before, _, err = db.readTagsHelper(ctx, c, names, order); err != nil {
+		return nil, err
+	}
+	record.Time = time.Now()
+	writeErr := c.WriteRandom(ctx, writes)
+	after, payloads, err := db.readTagsHelper(ctx, c, names, order)
+	record.After = after
+	if writeErr != nil {
+		return record, writeErr
+	}
+	if err != nil {
+		return record, err
+	}
+
+	var mismatches []string
+	for i, name := range names {
+		if !bytes.Equal(payloads[i], writes[i].WriteData) {
+			mismatches = append(mismatches, name)
+		}
+	}
+	if len(mismatches) > 0 {
+		return record, fmt.Errorf("%w: %v", ErrRecipeMismatch, mismatches)
+	}
+	return record, nil
+}
+
+// readTagsHelper reads tags of names by a Batch and returns their values and raw payloads.
+func (db *TagDB) readTagsHelper(ctx context.Context, c Client, names []string, order WordOrder) (map[string]any, [][]byte, error) {
+	reads := make([]Request, len(names))
+	for i, name := range names {
+		r, err := db.Request(name)
+		if err != nil {
+			return nil, nil, err
+		}
+		reads[i] = r
+	}
+	results, err := c.BatchContext(ctx, reads)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	values := map[string]any{}
+	payloads := make([][]byte, len(names))
+	for i, name := range names {
+		payloads[i] = results[i].Payload
+		if values[name], err = db.Decode(name, payloads[i], order); err != nil {
+			return nil, nil, err
+		}
+	}
+	return values, payloads, nil
+}
+
+// recipeRequestsHelper returns write requests of recipe in order of tag names.
+func (db *TagDB) recipeRequestsHelper(recipe Recipe, order WordOrder) ([]Request, error) {
+	if len(recipe.Values) == 0 {
+		return nil, fmt.Errorf("recipe %v has no values", recipe.Name)
+	}
+	names := recipeNames(recipe)
+	requests := make([]Request, len(names))
+	for i, name := range names {
+		r, err := db.Encode(name, recipe.Values[name], order)
+		if err != nil {
+			return nil, fmt.Errorf("recipe %v: %w", recipe.Name, err)
+		}
+		requests[i] = r
+	}
+	return requests, nil
+}
+
+func recipeNames(recipe Recipe) []string {
+	names := make([]string, 0, len(recipe.Values))
+	for name := range recipe.Values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Encode returns write request of value to tag of name. it is the reverse of Decode, and value is like that of Write.
+// order is word order of multi-word values, and strings are encoded in Shift-JIS after SetShiftJIS.
+func (db *TagDB) Encode(name string, value any, order WordOrder) (Request, error) {
+	r, err := db.Request(name)
+	if err != nil {
+		return Request{}, err
+	}
+	t := db.tags[name]
+
+	switch t.dataType {
+	case "bool":
+		v, ok := value.(bool)
+		if !ok {
+			return Request{}, fmt.Errorf("tag %v needs bool but got %T", name, value)
+		}
+		r.Op, r.WriteData = OpBitWrite, EncodeBits([]bool{v})
+		return r, nil
+	case "string":
+		v, ok := value.(string)
+		if !ok {
+			return Request{}, fmt.Errorf("tag %v needs string but got %T", name, value)
+		}
+		data, err := encodeStringHelper(v, t.length, db.shiftJIS)
+		if err != nil {
+			return Request{}, fmt.Errorf("tag %v: %w", name, err)
+		}
+		r.Op, r.WriteData = OpWrite, data
+		return r, nil
+	}
+
+	v, ok := toFloat64(value)
+	if !ok {
+		return Request{}, fmt.Errorf("tag %v needs number but got %T", name, value)
+	}
+	raw := t.toRaw(v)
+	var data []byte
+	switch t.dataType {
+	case "int16":
+		if raw = math.Round(raw); raw < math.MinInt16 || raw > math.MaxInt16 {
+			return Request{}, fmt.Errorf("tag %v: %v overflows int16", name, value)
+		}
+		data = binary.LittleEndian.AppendUint16(nil, uint16(int16(raw)))
+	case "uint16":
+		if raw = math.Round(raw); raw < 0 || raw > math.MaxUint16 {
+			return Request{}, fmt.Errorf("tag %v: %v overflows uint16", name, value)
+		}
+		data = binary.LittleEndian.AppendUint16(nil, uint16(raw))
+	case "int32":
+		if raw = math.Round(raw); raw < math.MinInt32 || raw > math.MaxInt32 {
+			return Request{}, fmt.Errorf("tag %v: %v overflows int32", name, value)
+		}
+		data = binary.LittleEndian.AppendUint32(nil, uint32(int32(raw)))
+	case "uint32":
+		if raw = math.Round(raw); raw < 0 || raw > math.MaxUint32 {
+			return Request{}, fmt.Errorf("tag %v: %v overflows uint32", name, value)
+		}
+		data = binary.LittleEndian.AppendUint32(nil, uint32(raw))
+	case "float32":
+		data = binary.LittleEndian.AppendUint32(nil, math.Float32bits(float32(raw)))
+	case "float64":
+		data = binary.LittleEndian.AppendUint64(nil, math.Float64bits(raw))
+	}
+	r.Op, r.WriteData = OpWrite, order.arrange(data)
+	return r, nil
+}
@@ -0,0 +1,103 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTagDB_ApplyRecipe(t *testing.T) {
+	db, err := NewTagDB([]Tag{
+		{Name: "Speed", Device: "D100", Type: "uint16"},
+		{Name: "Temp", Device: "D102", Type: "float32"},
+		{Name: "Pressure", Device: "D104", Scale: 0.1},
+		{Name: "Enable", Device: "M10", Type: "bool"},
+		{Name: "Product", Device: "D110", Type: "string:6"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected tag err: %v", err)
+	}
+	recipe, err := LoadRecipeYAML(strings.NewReader(`
+name: WidgetA
+values:
+  Speed: 1200
+  Temp: 85.5
+  Pressure: 2.5
+  Enable: true
+  Product: WA-01
+`))
+	if err != nil {
+		t.Fatalf("unexpected load err: %v", err)
+	}
+
+	for _, invalid := range []Recipe{
+		{Name: "empty"},
+		{Name: "unknown", Values: map[string]any{"Unknown": 1}},
+		{Name: "type", Values: map[string]any{"Enable": 1}},
+		{Name: "overflow", Values: map[string]any{"Speed": 70000}},
+		{Name: "long", Values: map[string]any{"Product": "WIDGET-A"}},
+	} {
+		if err := db.ValidateRecipe(invalid); err == nil {
+			t.Fatalf("expected error of recipe %v", invalid.Name)
+		}
+	}
+	if err := db.ValidateRecipe(recipe); err != nil {
+		t.Fatalf("unexpected validate err: %v", err)
+	}
+
+	client, mem := newTestMemoryClient(t)
+	ctx := context.Background()
+	mem.words[[2]int64{0xA8, 100}] = 1000
+	record, err := db.ApplyRecipe(ctx, client, recipe, LowWordFirst)
+	if err != nil {
+		t.Fatalf("unexpected apply err: %v", err)
+	}
+	if record.Recipe != "WidgetA" || record.Time.IsZero() {
+		t.Fatalf("unexpected record: %v %v", record.Recipe, record.Time)
+	}
+	if diff := cmp.Diff(record.Before, map[string]any{"Speed": 1000.0, "Temp": 0.0, "Pressure": 0.0, "Enable": false, "Product": ""}); diff != "" {
+		t.Errorf("before differs: (-got +want)\n%s", diff)
+	}
+	if diff := cmp.Diff(record.After, map[string]any{"Speed": 1200.0, "Temp": 85.5, "Pressure": 2.5, "Enable": true, "Product": "WA-01"}); diff != "" {
+		t.Errorf("after differs: (-got +want)\n%s", diff)
+	}
+	if got := mem.words[[2]int64{0xA8, 104}]; got != 25 {
+		t.Fatalf("expected %v but actual is %v", 25, got)
+	}
+
+	// PLC program keeps D100, so read back detects it
+	mem2 := newTestMemory()
+	host, port := newTestServer(t, func(req []byte) []byte {
+		resp := mem2.handle(req)
+		mem2.words[[2]int64{0xA8, 100}] = 0
+		return resp
+	})
+	var commands []uint16
+	count := func(next Handler) Handler {
+		return func(ctx context.Context, req *Request) ([]byte, error) {
+			if req.Op == OpCommand {
+				commands = append(commands, req.command())
+			}
+			return next(ctx, req)
+		}
+	}
+	client, err = New3EClient(host, port, NewLocalStation(), true, WithMiddleware(count))
+	if err != nil {
+		t.Fatalf("unexpected connect err: %v", err)
+	}
+	defer client.ShutDown()
+	record, err = db.ApplyRecipe(ctx, client, recipe, LowWordFirst)
+	if !errors.Is(err, ErrRecipeMismatch) {
+		t.Fatalf("expected %v but actual is %v", ErrRecipeMismatch, err)
+	}
+	if !strings.Contains(err.Error(), "Speed") || record.After["Speed"] != 0.0 {
+		t.Fatalf("unexpected mismatch: %v %v", err, record.After["Speed"])
+	}
+	// words and bits are written by a random write each
+	if diff := cmp.Diff(commands, []uint16{0x1402, 0x1402}); diff != "" {
+		t.Errorf("commands differs: (-got +want)\n%s", diff)
+	}
+}
@@ -0,0 +1,284 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// ErrReplayMismatch is returned by ReplayConn when request differs from the recorded one.
+var ErrReplayMismatch = errors.New("request does not match recording")
+
+// WithRecorder records all frames sent to and received from plc to w, so the session can be replayed by ReplayConn.
+// each frame is a CSV row of timestamp, direction (send or recv) and Base64 encoded frame. w is shared by reconnected connections.
+func WithRecorder(w io.Writer) Option {
+	return func(o *options) {
+		o.recorder = &recorder{w: csv.NewWriter(w)}
+	}
+}
+
+// recorder writes frames to CSV.
+type recorder struct {
+	mu sync.Mutex
+	w  *csv.Writer
+}
+
+func (r *recorder) record(direction string, frame []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// recording is best effort. errors of w are ignored not to break communication with plc
+	r.w.Write([]string{time.Now().UTC().Format(time.RFC3339Nano), direction, base64.StdEncoding.EncodeToString(frame)})
+	r.w.Flush()
+}
+
+// recordConn is net.Conn that records frames of conn.
+type recordConn struct {
+	net.Conn
+	recorder *recorder
+	// received bytes that are not a whole frame yet
+	recv []byte
+}
+
+func (c *recordConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	if n > 0 {
+		c.recorder.record("send", b[:n])
+	}
+	return n, err
+}
+
+func (c *recordConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	c.recv = append(c.recv, b[:n]...)
+	for len(c.recv) > 0 {
+		size, frame, scanErr := ScanFrames(c.recv, false)
+		if scanErr != nil {
+			// not 3E or 4E frame. record received bytes as they are
+			size, frame = len(c.recv), c.recv
+		}
+		if size == 0 {
+			break
+		}
+		c.recorder.record("recv", frame)
+		c.recv = c.recv[size:]
+	}
+	return n, err
+}
+
+// recordedFrame is a row of recording.
+type recordedFrame struct {
+	send  bool
+	frame []byte
+}
+
+// ReplayConn is net.Conn that works as plc by recording of WithRecorder.
+// each request must be the same as the recorded one in order, and the responses recorded after it are returned.
+// serial numbers of 4E frames are not compared.
+//
+//	conn, _ := mcp.LoadReplayFile("testdata/session.csv")
+//	client := mcp.New3EClientWithConn(conn, mcp.NewLocalStation())
+type ReplayConn struct {
+	mu     sync.Mutex
+	frames []recordedFrame
+	// buffer of responses to be read
+	buf      bytes.Buffer
+	ready    chan struct{}
+	closed   chan struct{}
+	once     sync.Once
+	deadline time.Time
+	// closed and replaced when deadline is changed, so waiting Read sees the new deadline
+	deadlineChanged chan struct{}
+}
+
+// NewReplayConn returns ReplayConn that replays recording read from r.
+func NewReplayConn(r io.Reader) (*ReplayConn, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 3
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	c := &ReplayConn{ready: make(chan struct{}, 1), closed: make(chan struct{}), deadlineChanged: make(chan struct{})}
+	for i, row := range rows {
+		frame, err := base64.StdEncoding.DecodeString(row[2])
+		if err != nil {
+			return nil, fmt.Errorf("invalid frame in line %v: %w", i+1, err)
+		}
+		if row[1] != "send" && row[1] != "recv" {
+			return nil, fmt.Errorf("invalid direction in line %v: %v", i+1, row[1])
+		}
+		c.frames = append(c.frames, recordedFrame{send: row[1] == "send", frame: frame})
+	}
+	// responses recorded before the first request are sent on connect
+	c.pushResponsesHelper()
+	return c, nil
+}
+
+// LoadReplayFile returns ReplayConn that replays recording of file.
+func LoadReplayFile(path string) (*ReplayConn, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return NewReplayConn(f)
+}
+
+// Remaining returns number of recorded requests that are not replayed yet.
+// test can check that all requests of recording are sent.
+func (c *ReplayConn) Remaining() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for _, f := range c.frames {
+		if f.send {
+			n++
+		}
+	}
+	return n
+}
+
+func (c *ReplayConn) Write(b []byte) (int, error) {
+	select {
+	case <-c.closed:
+		return 0, net.ErrClosed
+	default:
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.frames) == 0 {
+		return 0, fmt.Errorf("%w: no more requests are recorded: %X", ErrReplayMismatch, b)
+	}
+	expected := c.frames[0].frame
+	if !sameRequest(b, expected) {
+		return 0, fmt.Errorf("%w: expected %X but actual is %X", ErrReplayMismatch, expected, b)
+	}
+	c.frames = c.frames[1:]
+
+	// serial number of response is replaced by the one of request
+	is4E := len(b) >= 4 && b[0] == 0x54 && b[1] == 0x00
+	for len(c.frames) > 0 && !c.frames[0].send {
+		frame := c.frames[0].frame
+		if is4E && len(frame) >= 4 && frame[0] == 0xD4 {
+			frame = append([]byte{frame[0], frame[1], b[2], b[3]}, frame[4:]...)
+		}
+		c.buf.Write(frame)
+		c.frames = c.frames[1:]
+	}
+	c.notifyHelper()
+	return len(b), nil
+}
+
+// pushResponsesHelper buffers responses at the head of recording.
+func (c *ReplayConn) pushResponsesHelper() {
+	for len(c.frames) > 0 && !c.frames[0].send {
+		c.buf.Write(c.frames[0].frame)
+		c.frames = c.frames[1:]
+	}
+	c.notifyHelper()
+}
+
+// notifyHelper wakes up Read waiting for responses. c.mu must be held or c is not shared yet.
+func (c *ReplayConn) notifyHelper() {
+	if c.buf.Len() == 0 {
+		return
+	}
+	select {
+	case c.ready <- struct{}{}:
+	default:
+	}
+}
+
+func (c *ReplayConn) Read(b []byte) (int, error) {
+	for {
+		c.mu.Lock()
+		if c.buf.Len() > 0 {
+			n, _ := c.buf.Read(b)
+			c.notifyHelper()
+			c.mu.Unlock()
+			return n, nil
+		}
+		deadline, changed := c.deadline, c.deadlineChanged
+		c.mu.Unlock()
+
+		if err := c.waitHelper(deadline, changed); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// waitHelper waits until responses are buffered, c is closed, deadline exceeded or deadline is changed.
+func (c *ReplayConn) waitHelper(deadline time.Time, changed <-chan struct{}) error {
+	var timeout <-chan time.Time
+	if !deadline.IsZero() {
+		d := time.Until(deadline)
+		if d <= 0 {
+			return os.ErrDeadlineExceeded
+		}
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case <-c.ready:
+		return nil
+	case <-c.closed:
+		return io.EOF
+	case <-changed:
+		// Read checks the new deadline
+		return nil
+	case <-timeout:
+		return os.ErrDeadlineExceeded
+	}
+}
+
+func (c *ReplayConn) Close() error {
+	c.once.Do(func() { close(c.closed) })
+	return nil
+}
+
+func (c *ReplayConn) LocalAddr() net.Addr  { return replayAddr{} }
+func (c *ReplayConn) RemoteAddr() net.Addr { return replayAddr{} }
+
+func (c *ReplayConn) SetDeadline(t time.Time) error {
+	return c.SetReadDeadline(t)
+}
+
+func (c *ReplayConn) SetReadDeadline(t time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.deadline = t
+	close(c.deadlineChanged)
+	c.deadlineChanged = make(chan struct{})
+	return nil
+}
+
+// SetWriteDeadline does nothing because Write never blocks.
+func (c *ReplayConn) SetWriteDeadline(t time.Time) error {
+	return nil
+}
+
+// replayAddr is address of ReplayConn.
+type replayAddr struct{}
+
+func (replayAddr) Network() string { return "replay" }
+func (replayAddr) String() string  { return "replay" }
+
+// sameRequest compares request with recorded one. serial number of 4E frame is ignored.
+func sameRequest(actual, expected []byte) bool {
+	if len(actual) != len(expected) {
+		return false
+	}
+	if len(actual) >= 4 && actual[0] == 0x54 && actual[1] == 0x00 {
+		return bytes.Equal(actual[:2], expected[:2]) && bytes.Equal(actual[4:], expected[4:])
+	}
+	return bytes.Equal(actual, expected)
+}
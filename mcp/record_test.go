@@ -0,0 +1,114 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRecordReplay(t *testing.T) {
+	for _, frame4E := range []bool{false, true} {
+		var recording bytes.Buffer
+		opts := []Option{WithRecorder(&recording)}
+		if frame4E {
+			opts = append(opts, WithFrame4E())
+		}
+		client, _ := newTestMemoryClient(t, opts...)
+		session := func(c Client) ([]byte, error) {
+			if err := c.HealthCheck(); err != nil {
+				return nil, err
+			}
+			if _, err := c.Write("D", 100, 2, []byte{0x34, 0x12, 0x78, 0x56}); err != nil {
+				return nil, err
+			}
+			return c.Read("D", 100, 2)
+		}
+		expected, err := session(client)
+		if err != nil {
+			t.Fatalf("unexpected session err: %v", err)
+		}
+		client.ShutDown()
+
+		if rows := strings.Count(recording.String(), "\n"); rows != 6 {
+			t.Fatalf("expected 6 frames are recorded but actual is %v:\n%s", rows, recording.String())
+		}
+
+		conn, err := NewReplayConn(bytes.NewReader(recording.Bytes()))
+		if err != nil {
+			t.Fatalf("unexpected replay err: %v", err)
+		}
+		var replayOpts []Option
+		if frame4E {
+			replayOpts = append(replayOpts, WithFrame4E())
+		}
+		replay := New3EClientWithConn(conn, NewLocalStation(), replayOpts...)
+		actual, err := session(replay)
+		if err != nil {
+			t.Fatalf("unexpected replayed session err: %v", err)
+		}
+		if diff := cmp.Diff(actual, expected); diff != "" {
+			t.Errorf("replayed payload differs: (-got +want)\n%s", diff)
+		}
+		if n := conn.Remaining(); n != 0 {
+			t.Errorf("expected all requests are replayed but %v remain", n)
+		}
+		replay.ShutDown()
+	}
+}
+
+func TestReplayConn_Mismatch(t *testing.T) {
+	var recording bytes.Buffer
+	client, _ := newTestMemoryClient(t, WithRecorder(&recording))
+	if _, err := client.Read("D", 100, 1); err != nil {
+		t.Fatalf("unexpected read err: %v", err)
+	}
+
+	conn, err := NewReplayConn(&recording)
+	if err != nil {
+		t.Fatalf("unexpected replay err: %v", err)
+	}
+	replay := New3EClientWithConn(conn, NewLocalStation())
+	defer replay.ShutDown()
+	if _, err := replay.Read("D", 101, 1); !errors.Is(err, ErrReplayMismatch) {
+		t.Fatalf("expected %v but actual is %v", ErrReplayMismatch, err)
+	}
+
+	for _, input := range []string{"2024-01-02T03:04:05Z,send", "2024-01-02T03:04:05Z,send,!!", "2024-01-02T03:04:05Z,sent,UAA="} {
+		if _, err := NewReplayConn(strings.NewReader(input)); err == nil {
+			t.Errorf("expected error: input is %v", input)
+		}
+	}
+}
+
+func TestReplayConn_Cancel(t *testing.T) {
+	var recording bytes.Buffer
+	client, _ := newTestMemoryClient(t, WithRecorder(&recording))
+	if _, err := client.Read("D", 100, 1); err != nil {
+		t.Fatalf("unexpected mcp read err: %v", err)
+	}
+	client.ShutDown()
+
+	// PLC that received the request but never answers
+	rows := strings.SplitAfter(recording.String(), "\n")
+	conn, err := NewReplayConn(strings.NewReader(rows[0]))
+	if err != nil {
+		t.Fatalf("unexpected replay err: %v", err)
+	}
+	replay := New3EClientWithConn(conn, NewLocalStation())
+	defer replay.ShutDown()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	start := time.Now()
+	if _, err := replay.ReadContext(ctx, "D", 100, 1); err != context.Canceled {
+		t.Fatalf("expected %v but actual is %v", context.Canceled, err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("expected Read is unblocked by cancel but it took %v", elapsed)
+	}
+}
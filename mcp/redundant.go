@@ -0,0 +1,137 @@
+package mcp
+
+import (
+	"context"
+	"time"
+)
+
+// WithRedundantSystem connects to system B of redundant CPU at addr like "192.168.0.2:5000" besides system A given to New3EClient.
+// control system is tracked by control system device (see WithControlSystemDevice) of both systems at interval,
+// and client switches the active connection to the other system on changeover. Changeover is emitted to WithConnStateHandler.
+// addr is also used as failover endpoint when I/O to the active system fails.
+func WithRedundantSystem(addr string, interval time.Duration) Option {
+	return func(o *options) {
+		o.failoverAddrs = append(o.failoverAddrs, addr)
+		o.redundantInterval = interval
+	}
+}
+
+// WithControlSystemDevice sets bit device like "SM1515" that is ON in control system and OFF in standby system.
+// default is SM1515 that is control system judgement flag of MELSEC-Q redundant CPU.
+func WithControlSystemDevice(address string) Option {
+	return func(o *options) {
+		o.controlSystemDevice = address
+	}
+}
+
+// redundancy is state of tracking control system of redundant CPU.
+type redundancy struct {
+	// connection to the system that is not active
+	standby *client3E
+	// stops tracking goroutine
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// startRedundancy starts goroutine that tracks control system when WithRedundantSystem is given.
+func (c *client3E) startRedundancy() {
+	interval := c.opts.redundantInterval
+	if interval <= 0 || len(c.addrs) < 2 {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c.redundancy.cancel = cancel
+	c.redundancy.done = make(chan struct{})
+
+	go func() {
+		defer close(c.redundancy.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			checkCtx, cancel := context.WithTimeout(ctx, interval)
+			c.trackHelper(checkCtx)
+			cancel()
+		}
+	}()
+}
+
+// trackHelper checks control system device of both systems and switches to the other system
+// when it is control system and the active system is not.
+func (c *client3E) trackHelper(ctx context.Context) {
+	c.mu.Lock()
+	active := c.active
+	c.mu.Unlock()
+
+	if control, err := controlSystemHelper(ctx, c, c.opts.controlSystemDevice); err == nil && control {
+		return
+	}
+
+	// keep connection to the other system, so changeover is detected quickly
+	other := c.addrs[(active+1)%len(c.addrs)]
+	standby := c.redundancy.standby
+	if standby == nil || standby.addrs[0] != other {
+		if standby != nil {
+			standby.ShutDown()
+		}
+		opts := newOptions(nil)
+		opts.dialContext, opts.localAddr, opts.keepAlive = c.opts.dialContext, c.opts.localAddr, c.opts.keepAlive
+		standby = &client3E{addrs: []string{other}, stn: c.stn, opts: opts}
+		if err := standby.ConnectContext(ctx); err != nil {
+			return
+		}
+		c.redundancy.standby = standby
+	}
+	control, err := controlSystemHelper(ctx, standby, c.opts.controlSystemDevice)
+	if err != nil {
+		standby.ShutDown()
+		c.redundancy.standby = nil
+		return
+	}
+	if !control {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.active != active {
+		// already switched by failover
+		return
+	}
+	c.conn.Close()
+	c.active = (active + 1) % len(c.addrs)
+	if c.connectHelper(ctx) == nil {
+		c.emitHelper(Changeover, nil)
+	}
+}
+
+// controlSystemHelper reads control system device by client.
+func controlSystemHelper(ctx context.Context, client *client3E, address string) (bool, error) {
+	deviceName, offset, err := ParseDevice(address)
+	if err != nil {
+		return false, err
+	}
+	payload, err := client.BitReadContext(ctx, deviceName, offset, 1)
+	if err != nil {
+		return false, err
+	}
+	return DecodeBits(payload, 1)[0], nil
+}
+
+// stopRedundancy stops tracking goroutine and closes connection to the other system.
+func (c *client3E) stopRedundancy() {
+	if c.redundancy.cancel == nil {
+		return
+	}
+	c.redundancy.cancel()
+	<-c.redundancy.done
+	if c.redundancy.standby != nil {
+		c.redundancy.standby.ShutDown()
+		c.redundancy.standby = nil
+	}
+}
@@ -0,0 +1,77 @@
+package mcp
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestClient3E_RedundantSystem(t *testing.T) {
+	// SM1515 is ON in control system. device code of SM is 91
+	controlFlag := [2]int64{0x91, 1515}
+	memA, memB := newTestMemory(), newTestMemory()
+	memA.bits[controlFlag] = true
+	hostA, portA := newTestServer(t, memA.handle)
+	hostB, portB := newTestServer(t, memB.handle)
+	addrB := fmt.Sprintf("%v:%v", hostB, portB)
+
+	var mu sync.Mutex
+	var events []ConnEvent
+	client, err := New3EClient(hostA, portA, NewLocalStation(), true,
+		WithRedundantSystem(addrB, 10*time.Millisecond),
+		WithConnStateHandler(func(e ConnEvent) {
+			mu.Lock()
+			defer mu.Unlock()
+			events = append(events, e)
+		}))
+	if err != nil {
+		t.Fatalf("unexpected connect err: %v", err)
+	}
+	defer client.ShutDown()
+
+	if err := client.WriteUint16("D", 100, 1); err != nil {
+		t.Fatalf("unexpected write err: %v", err)
+	}
+
+	// system B becomes control system
+	memA.mu.Lock()
+	memA.bits[controlFlag] = false
+	memA.mu.Unlock()
+	memB.mu.Lock()
+	memB.bits[controlFlag] = true
+	memB.mu.Unlock()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		mu.Lock()
+		switched := len(events) > 0 && events[len(events)-1].State == Changeover
+		mu.Unlock()
+		if switched {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("changeover is not detected: %v", events)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := client.WriteUint16("D", 100, 2); err != nil {
+		t.Fatalf("unexpected write err: %v", err)
+	}
+	memA.mu.Lock()
+	a := memA.words[[2]int64{0xA8, 100}]
+	memA.mu.Unlock()
+	memB.mu.Lock()
+	b := memB.words[[2]int64{0xA8, 100}]
+	memB.mu.Unlock()
+	if a != 1 || b != 2 {
+		t.Fatalf("expected writes go to control system but actual is A=%v B=%v", a, b)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if e := events[len(events)-1]; e.Addr != addrB {
+		t.Errorf("expected %v but actual is %v", addrB, e.Addr)
+	}
+}
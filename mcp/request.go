@@ -0,0 +1,66 @@
+package mcp
+
+// Op is kind of request.
+type Op int
+
+const (
+	// OpRead is batch read in word units.
+	OpRead Op = iota
+	// OpBitRead is batch read in bit units.
+	OpBitRead
+	// OpWrite is batch write in word units.
+	OpWrite
+	// OpBitWrite is batch write in bit units.
+	OpBitWrite
+	// OpHealthCheck is loopback test.
+	OpHealthCheck
+	// OpCommand is any command sent by Execute.
+	OpCommand
+)
+
+func (o Op) String() string {
+	switch o {
+	case OpRead:
+		return "Read"
+	case OpBitRead:
+		return "BitRead"
+	case OpWrite:
+		return "Write"
+	case OpBitWrite:
+		return "BitWrite"
+	case OpHealthCheck:
+		return "HealthCheck"
+	case OpCommand:
+		return "Command"
+	}
+	return "Unknown"
+}
+
+// Request is a request to PLC. it is given to Batch and passed to middleware.
+type Request struct {
+	Op         Op
+	DeviceName string
+	Offset     int64
+	NumPoints  int64
+	// data to write. it is ignored by read requests. it is request data of OpCommand
+	WriteData []byte
+
+	// encoded request frame and size of response buffer. they are set by client
+	frame    []byte
+	buffSize int64
+}
+
+// Result is result of a request of Batch.
+type Result struct {
+	// payload of response. it is data read by read requests
+	Payload []byte
+	// error of the request. it may be *EndCodeError
+	Err error
+}
+
+// Frame returns encoded request frame. it is set before the request is passed to middleware.
+// the frame may be reused after the request returns, so copy it to keep.
+// frame of 4E request is converted from this 3E frame when it is sent.
+func (r *Request) Frame() []byte {
+	return r.frame
+}
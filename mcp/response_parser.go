@@ -1,61 +1,193 @@
 package mcp
 
 import (
+	"encoding/binary"
 	"errors"
 	"fmt"
 )
 
+var (
+	// ErrTruncatedResponse is returned by strict parser when response is shorter than its data length.
+	ErrTruncatedResponse = errors.New("response is shorter than its data length")
+	// ErrTrailingData is returned by strict parser when response is longer than its data length.
+	ErrTrailingData = errors.New("response has trailing data after its data length")
+)
+
 type parser struct {
+	// verify data length field with actual response length
+	strict bool
 }
 
 func NewParser() *parser {
 	return &parser{}
 }
 
+// NewStrictParser returns parser that cross-checks the data length field with the actual response size.
+// truncated response is ErrTruncatedResponse and response with trailing garbage is ErrTrailingData.
+func NewStrictParser() *parser {
+	return &parser{strict: true}
+}
+
 // Response represents mcp response
 type Response struct {
-	// Sub header
-	SubHeader string
+	// Sub header. 0xD000 for 3E frame and 0xD400 for 4E frame.
+	SubHeader uint16
+	// serial number of 4E frame that is the same as the request
+	SerialNum uint16
 	// network number
-	NetworkNum string
+	NetworkNum byte
 	// PC number
-	PCNum string
-	// Request Unit I/O number
-	UnitIONum string
+	PCNum byte
+	// Request Unit I/O number like 0x03FF
+	UnitIONum uint16
 	// Request Unit station number
-	UnitStationNum string
+	UnitStationNum byte
 	// Response data length
-	DataLen string
-	// Response data code
-	EndCode string
+	DataLen uint16
+	// Response data code. 0 means normal completion.
+	EndCode uint16
 	// Response data
 	Payload []byte
-	// error data
-	ErrInfo []byte
+	// error data. it is set only when EndCode is abnormal.
+	ErrInfo *ErrInfo
+}
+
+// ErrInfo represents error information of abnormal response.
+// it points the station and the command that detected the error.
+type ErrInfo struct {
+	// network number of the station that detected the error
+	NetworkNum byte
+	// PC number of the station that detected the error
+	PCNum byte
+	// Request Unit I/O number of the station that detected the error
+	UnitIONum uint16
+	// Request Unit station number of the station that detected the error
+	UnitStationNum byte
+	// command of the failed request like 0x0401 (batch read)
+	Command uint16
+	// subcommand of the failed request
+	SubCommand uint16
 }
 
+// Do parses resp to new Response. Payload of Response is a view into resp, see DoInto.
 func (p *parser) Do(resp []byte) (*Response, error) {
+	response := &Response{}
+	if err := p.DoInto(resp, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// DoInto parses resp into response without allocation for high-rate monitoring.
+// Payload of response is a view into resp, so it is overwritten when resp is reused.
+// use Clone to keep response after resp is reused.
+func (p *parser) DoInto(resp []byte, response *Response) error {
 	if len(resp) < 11 {
-		return nil, errors.New("length must be larger than 22 byte")
-	}
-
-	subHeaderB := resp[0:2]
-	networkNumB := resp[2:3]
-	pcNumB := resp[3:4]
-	unitIONumB := resp[4:6]
-	unitStationNumB := resp[6:7]
-	dataLenB := resp[7:9]
-	endCodeB := resp[9:11]
-	payloadB := resp[11:]
-
-	return &Response{
-		SubHeader:      fmt.Sprintf("%X", subHeaderB),
-		NetworkNum:     fmt.Sprintf("%X", networkNumB),
-		PCNum:          fmt.Sprintf("%X", pcNumB),
-		UnitIONum:      fmt.Sprintf("%X", unitIONumB),
-		UnitStationNum: fmt.Sprintf("%X", unitStationNumB),
-		DataLen:        fmt.Sprintf("%X", dataLenB),
-		EndCode:        fmt.Sprintf("%X", endCodeB),
-		Payload:        payloadB,
-	}, nil
+		return errors.New("length must be larger than 22 byte")
+	}
+
+	subHeader := binary.BigEndian.Uint16(resp[0:2]) // sub header is written in the order of bytes
+	var serialNum uint16
+	if subHeader == SUB_HEADER_4E_RESPONSE {
+		// 4E frame has serial number[2byte] and fixed 0000[2byte] after sub header
+		if len(resp) < 15 {
+			return errors.New("length must be larger than 30 byte")
+		}
+		serialNum = binary.LittleEndian.Uint16(resp[2:4])
+		resp = resp[4:]
+	}
+
+	*response = Response{
+		SubHeader:      subHeader,
+		SerialNum:      serialNum,
+		NetworkNum:     resp[2],
+		PCNum:          resp[3],
+		UnitIONum:      binary.LittleEndian.Uint16(resp[4:6]),
+		UnitStationNum: resp[6],
+		DataLen:        binary.LittleEndian.Uint16(resp[7:9]),
+		EndCode:        binary.LittleEndian.Uint16(resp[9:11]),
+		Payload:        resp[11:],
+	}
+
+	// data length counts from end code to end of response
+	if p.strict {
+		if actual := len(resp) - 9; actual < int(response.DataLen) {
+			return fmt.Errorf("%w: data length is %v but actual is %v", ErrTruncatedResponse, response.DataLen, actual)
+		} else if actual > int(response.DataLen) {
+			return fmt.Errorf("%w: data length is %v but actual is %v", ErrTrailingData, response.DataLen, actual)
+		}
+	}
+
+	// abnormal response has error information[9byte] instead of response data
+	if response.EndCode != 0 {
+		errInfoB := response.Payload
+		response.Payload = nil
+		if len(errInfoB) >= 9 {
+			response.ErrInfo = &ErrInfo{
+				NetworkNum:     errInfoB[0],
+				PCNum:          errInfoB[1],
+				UnitIONum:      binary.LittleEndian.Uint16(errInfoB[2:4]),
+				UnitStationNum: errInfoB[4],
+				Command:        binary.LittleEndian.Uint16(errInfoB[5:7]),
+				SubCommand:     binary.LittleEndian.Uint16(errInfoB[7:9]),
+			}
+		}
+	}
+
+	return nil
+}
+
+// ParseBitResponse1E parses response of 1E batch read in bit units (command 00) to numPoints values.
+// 1E response packs 2 points per byte in upper and lower 4 bits, so the last lower 4 bits are padding
+// when numPoints is odd. numPoints 0 means 256 points like the number of points byte of the request.
+// abnormal response is *EndCodeError that has complete code 5B and abnormal code in EndCode like 0x5B10.
+func ParseBitResponse1E(resp []byte, numPoints int64) ([]bool, error) {
+	if numPoints == 0 {
+		numPoints = 256
+	}
+	if len(resp) < 2 {
+		return nil, errors.New("length must be larger than 2 byte")
+	}
+	if resp[0] != 0x80 {
+		return nil, fmt.Errorf("sub header must be 80 but %02X", resp[0])
+	}
+	f, err := decodeFrame1E(resp)
+	if err != nil {
+		return nil, err
+	}
+	if f.EndCode != 0 {
+		return nil, &EndCodeError{EndCode: f.EndCode}
+	}
+	if actual, expected := len(f.Data), (numPoints+1)/2; int64(actual) < expected {
+		return nil, fmt.Errorf("%w: %v points need %v byte but actual is %v", ErrTruncatedResponse, numPoints, expected, actual)
+	}
+	return DecodeBits(f.Data, numPoints), nil
+}
+
+// Words returns payload of word device read as words. see DecodeWords.
+func (r *Response) Words() []uint16 {
+	return DecodeWords(r.Payload)
+}
+
+// DWords returns payload of word device read as 2 word values. see DecodeDWords.
+func (r *Response) DWords(order WordOrder) []uint32 {
+	return DecodeDWords(r.Payload, order)
+}
+
+// Int32s returns payload of word device read as signed 2 word values. see DecodeInt32s.
+func (r *Response) Int32s(order WordOrder) []int32 {
+	return DecodeInt32s(r.Payload, order)
+}
+
+// Clone returns deep copy of response that does not share Payload with the parsed buffer.
+func (r *Response) Clone() *Response {
+	clone := *r
+	if r.Payload != nil {
+		clone.Payload = append([]byte{}, r.Payload...)
+	}
+	if r.ErrInfo != nil {
+		errInfo := *r.ErrInfo
+		clone.ErrInfo = &errInfo
+	}
+	return &clone
 }
@@ -2,6 +2,7 @@ package mcp
 
 import (
 	"encoding/hex"
+	"errors"
 	"github.com/google/go-cmp/cmp"
 	"testing"
 )
@@ -16,13 +17,13 @@ func TestParser_Do(t *testing.T) {
 	}
 
 	expected := &Response{
-		SubHeader:      "D000",
-		NetworkNum:     "00",
-		PCNum:          "FF",
-		UnitIONum:      "FF03",
-		UnitStationNum: "00",
-		DataLen:        "0400",
-		EndCode:        "0000",
+		SubHeader:      0xD000,
+		NetworkNum:     0x00,
+		PCNum:          0xFF,
+		UnitIONum:      0x03FF,
+		UnitStationNum: 0x00,
+		DataLen:        4,
+		EndCode:        0x0000,
 		Payload:        []uint8{0x00, 0x00},
 		ErrInfo:        nil,
 	}
@@ -31,3 +32,172 @@ func TestParser_Do(t *testing.T) {
 		t.Errorf("parse Resp differs: (-got +want)\n%s", diff)
 	}
 }
+
+func TestParser_DoErrInfo(t *testing.T) {
+	mcResp, _ := hex.DecodeString("d00000ffff03000b0059c000ffff030001040000")
+
+	p := NewParser()
+	response, err := p.Do(mcResp)
+	if err != nil {
+		t.Fatalf("unexpected parser err: %v", err)
+	}
+
+	expected := &Response{
+		SubHeader:      0xD000,
+		NetworkNum:     0x00,
+		PCNum:          0xFF,
+		UnitIONum:      0x03FF,
+		UnitStationNum: 0x00,
+		DataLen:        11,
+		EndCode:        0xC059,
+		Payload:        nil,
+		ErrInfo: &ErrInfo{
+			NetworkNum:     0x00,
+			PCNum:          0xFF,
+			UnitIONum:      0x03FF,
+			UnitStationNum: 0x00,
+			Command:        0x0401,
+			SubCommand:     0x0000,
+		},
+	}
+
+	if diff := cmp.Diff(response, expected); diff != "" {
+		t.Errorf("parse Resp differs: (-got +want)\n%s", diff)
+	}
+}
+
+func TestParser_Do4E(t *testing.T) {
+	mcResp, _ := hex.DecodeString("d4003412000000ffff030004000000cdab")
+
+	p := NewStrictParser()
+	response, err := p.Do(mcResp)
+	if err != nil {
+		t.Fatalf("unexpected parser err: %v", err)
+	}
+
+	expected := &Response{
+		SubHeader:      0xD400,
+		SerialNum:      0x1234,
+		NetworkNum:     0x00,
+		PCNum:          0xFF,
+		UnitIONum:      0x03FF,
+		UnitStationNum: 0x00,
+		DataLen:        4,
+		EndCode:        0x0000,
+		Payload:        []uint8{0xCD, 0xAB},
+		ErrInfo:        nil,
+	}
+
+	if diff := cmp.Diff(response, expected); diff != "" {
+		t.Errorf("parse Resp differs: (-got +want)\n%s", diff)
+	}
+}
+
+func TestParser_DoStrict(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected error
+	}{
+		{input: "d00000ffff0300040000000000", expected: nil},
+		{input: "d00000ffff03000400000000", expected: ErrTruncatedResponse},
+		{input: "d00000ffff030004000000000000", expected: ErrTrailingData},
+	}
+
+	for _, v := range cases {
+		mcResp, _ := hex.DecodeString(v.input)
+		if _, err := NewStrictParser().Do(mcResp); !errors.Is(err, v.expected) {
+			t.Errorf("%v: expected %v but actual is %v", v.input, v.expected, err)
+		}
+		// not strict parser does not verify data length
+		if _, err := NewParser().Do(mcResp); err != nil {
+			t.Errorf("%v: unexpected parser err: %v", v.input, err)
+		}
+	}
+}
+
+func TestParser_DoInto(t *testing.T) {
+	mcResp, _ := hex.DecodeString("d00000ffff030006000000cdab3412")
+
+	p := NewStrictParser()
+	var response Response
+	allocs := testing.AllocsPerRun(100, func() {
+		if err := p.DoInto(mcResp, &response); err != nil {
+			t.Fatalf("unexpected parser err: %v", err)
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("expected no allocation but actual is %v", allocs)
+	}
+
+	clone := response.Clone()
+	// payload of response is a view into buffer, but clone is not
+	mcResp[11] = 0x00
+	if response.Payload[0] != 0x00 {
+		t.Fatalf("expected payload shares buffer but actual is %X", response.Payload)
+	}
+	if diff := cmp.Diff(clone.Payload, []byte{0xCD, 0xAB, 0x34, 0x12}); diff != "" {
+		t.Errorf("cloned payload differs: (-got +want)\n%s", diff)
+	}
+}
+
+func TestResponse_Words(t *testing.T) {
+	mcResp, _ := hex.DecodeString("d00000ffff030008000000d204ffff3412")
+
+	response, err := NewStrictParser().Do(mcResp)
+	if err != nil {
+		t.Fatalf("unexpected parser err: %v", err)
+	}
+	if diff := cmp.Diff(response.Words(), []uint16{0x04D2, 0xFFFF, 0x1234}); diff != "" {
+		t.Errorf("words differs: (-got +want)\n%s", diff)
+	}
+
+	// odd last byte is ignored
+	if diff := cmp.Diff(DecodeWords([]byte{0x01, 0x00, 0x02}), []uint16{0x0001}); diff != "" {
+		t.Errorf("words differs: (-got +want)\n%s", diff)
+	}
+}
+
+func TestResponse_DWords(t *testing.T) {
+	// D100-D103 = 0x0001 0x0002 0xFFFE 0xFFFF, and a word that is not a pair
+	mcResp, _ := hex.DecodeString("d00000ffff03000c00000001000200feffffff0500")
+
+	response, err := NewStrictParser().Do(mcResp)
+	if err != nil {
+		t.Fatalf("unexpected parser err: %v", err)
+	}
+	if diff := cmp.Diff(response.DWords(LowWordFirst), []uint32{0x00020001, 0xFFFFFFFE}); diff != "" {
+		t.Errorf("low word first differs: (-got +want)\n%s", diff)
+	}
+	if diff := cmp.Diff(response.DWords(HighWordFirst), []uint32{0x00010002, 0xFFFEFFFF}); diff != "" {
+		t.Errorf("high word first differs: (-got +want)\n%s", diff)
+	}
+	if diff := cmp.Diff(response.Int32s(LowWordFirst), []int32{131073, -2}); diff != "" {
+		t.Errorf("int32 differs: (-got +want)\n%s", diff)
+	}
+}
+
+func TestParseBitResponse1E(t *testing.T) {
+	// M0-M4 = ON OFF OFF ON ON, lower 4 bits of the last byte is padding
+	values, err := ParseBitResponse1E([]byte{0x80, 0x00, 0x10, 0x01, 0x10}, 5)
+	if err != nil {
+		t.Fatalf("unexpected parser err: %v", err)
+	}
+	if diff := cmp.Diff(values, []bool{true, false, false, true, true}); diff != "" {
+		t.Errorf("values differs: (-got +want)\n%s", diff)
+	}
+
+	_, err = ParseBitResponse1E([]byte{0x80, 0x00, 0x10}, 5)
+	if !errors.Is(err, ErrTruncatedResponse) {
+		t.Fatalf("expected %v but actual is %v", ErrTruncatedResponse, err)
+	}
+
+	_, err = ParseBitResponse1E([]byte{0x80, 0x5B, 0x10}, 5)
+	var endCodeErr *EndCodeError
+	if !errors.As(err, &endCodeErr) || endCodeErr.EndCode != 0x5B10 {
+		t.Fatalf("expected end code 5B10 but actual is %v", err)
+	}
+
+	if _, err = ParseBitResponse1E([]byte{0x81, 0x00, 0x10, 0x00}, 1); err == nil {
+		t.Fatalf("expected error of word read response")
+	}
+}
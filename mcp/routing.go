@@ -0,0 +1,107 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Route is logical name of PLC and its station on the network behind the connected Ethernet module.
+type Route struct {
+	// logical name of PLC like "Press3"
+	Name string `json:"name" yaml:"name"`
+	// network number and PC number of the station
+	Network int `json:"network" yaml:"network"`
+	PC      int `json:"pc" yaml:"pc"`
+	// request destination module I/O number. zero means 0x03FF (own station cpu)
+	UnitIO int `json:"unit_io,omitempty" yaml:"unit_io,omitempty"`
+	// request destination module station number of multidrop connection
+	Station int `json:"station,omitempty" yaml:"station,omitempty"`
+}
+
+// RoutingTable maps logical names of PLCs to their stations over a shared connection.
+type RoutingTable struct {
+	stations map[string]*station
+	names    []string
+}
+
+// NewRoutingTable returns RoutingTable of routes. route with out of range numbers and duplicated name are errors.
+func NewRoutingTable(routes []Route) (*RoutingTable, error) {
+	t := &RoutingTable{stations: map[string]*station{}}
+	for _, r := range routes {
+		if r.Name == "" {
+			return nil, fmt.Errorf("route name is empty: network %v pc %v", r.Network, r.PC)
+		}
+		if _, ok := t.stations[r.Name]; ok {
+			return nil, errors.New("route is duplicated: " + r.Name)
+		}
+		unitIO := r.UnitIO
+		if unitIO == 0 {
+			unitIO = 0x03FF
+		}
+		stn, err := NewStation(r.Network, r.PC, unitIO, r.Station)
+		if err != nil {
+			return nil, fmt.Errorf("route %v: %w", r.Name, err)
+		}
+		t.stations[r.Name] = stn
+		t.names = append(t.names, r.Name)
+	}
+	return t, nil
+}
+
+// LoadRoutes loads routing table from JSON array of Route.
+func LoadRoutes(r io.Reader) (*RoutingTable, error) {
+	var routes []Route
+	if err := json.NewDecoder(r).Decode(&routes); err != nil {
+		return nil, err
+	}
+	return NewRoutingTable(routes)
+}
+
+// LoadRoutesYAML loads routing table from YAML sequence of Route.
+func LoadRoutesYAML(r io.Reader) (*RoutingTable, error) {
+	var routes []Route
+	if err := yaml.NewDecoder(r).Decode(&routes); err != nil {
+		return nil, err
+	}
+	return NewRoutingTable(routes)
+}
+
+// Names returns names of routes in the order of definition.
+func (t *RoutingTable) Names() []string {
+	return append([]string{}, t.names...)
+}
+
+// Station returns station of route name.
+func (t *RoutingTable) Station(name string) (*station, error) {
+	stn, ok := t.stations[name]
+	if !ok {
+		return nil, errors.New("unknown route: " + name)
+	}
+	return stn, nil
+}
+
+// Context returns ctx that makes context aware methods of client access PLC of route name. see WithTargetStation.
+func (t *RoutingTable) Context(ctx context.Context, name string) (context.Context, error) {
+	stn, err := t.Station(name)
+	if err != nil {
+		return nil, err
+	}
+	return WithTargetStation(ctx, stn), nil
+}
+
+// Verify checks each route is reachable through client by reading cpu model name of the station.
+// the returned error joins errors of unreachable routes. nil means all routes are reachable.
+func (t *RoutingTable) Verify(ctx context.Context, client Client) error {
+	var errs []error
+	for _, name := range t.names {
+		if _, err := client.ExecuteContext(WithTargetStation(ctx, t.stations[name]), 0x0101, 0x0000, nil); err != nil {
+			errs = append(errs, fmt.Errorf("route %v is not reachable: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,75 @@
+package mcp
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRoutingTable(t *testing.T) {
+	routes, err := LoadRoutesYAML(strings.NewReader(`
+- name: Press3
+  network: 1
+  pc: 3
+- name: Press4
+  network: 1
+  pc: 4
+- name: Gone
+  network: 2
+  pc: 1
+`))
+	if err != nil {
+		t.Fatalf("unexpected load err: %v", err)
+	}
+
+	// relay where network 2 is not reachable and device memory is shared
+	mem := newTestMemory()
+	host, port := newTestServer(t, func(req []byte) []byte {
+		var endCode uint16
+		switch {
+		case req[2] == 0x02:
+			endCode = 0x4A01
+		case binary.LittleEndian.Uint16(req[11:13]) != 0x0101:
+			if req[3] == 0x04 {
+				mem.words[[2]int64{0xA8, 100}] = 0x0004
+			}
+			return mem.handle(req)
+		}
+		resp := []byte{0xD0, 0x00, req[2], req[3], req[4], req[5], req[6], 0x02, 0x00}
+		return binary.LittleEndian.AppendUint16(resp, endCode)
+	})
+	client, err := New3EClient(host, port, NewLocalStation(), true)
+	if err != nil {
+		t.Fatalf("unexpected connect err: %v", err)
+	}
+	defer client.ShutDown()
+
+	err = routes.Verify(context.Background(), client)
+	var endCodeErr *EndCodeError
+	if !errors.As(err, &endCodeErr) || endCodeErr.EndCode != 0x4A01 || !strings.Contains(err.Error(), "Gone") || strings.Contains(err.Error(), "Press") {
+		t.Fatalf("expected unreachable route Gone but actual is %v", err)
+	}
+
+	ctx, err := routes.Context(context.Background(), "Press4")
+	if err != nil {
+		t.Fatalf("unexpected route err: %v", err)
+	}
+	payload, err := client.ReadContext(ctx, "D", 100, 1)
+	if err != nil {
+		t.Fatalf("unexpected read err: %v", err)
+	}
+	if payload[0] != 0x04 {
+		t.Fatalf("expected %v but actual is %v", 0x04, payload[0])
+	}
+
+	if _, err := routes.Context(context.Background(), "Press5"); err == nil {
+		t.Fatalf("expected error for unknown route")
+	}
+	for _, r := range [][]Route{{{Name: "A", Network: 1}, {Name: "A", Network: 2}}, {{Name: "A", Network: 256}}, {{Network: 1}}} {
+		if _, err := NewRoutingTable(r); err == nil {
+			t.Errorf("expected error: routes are %v", r)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ScanClass is device ranges read cyclically at the same period, like 100ms for fast signals and 10s for counters.
+type ScanClass struct {
+	Period time.Duration
+	// read requests (OpRead or OpBitRead)
+	Ranges []Request
+}
+
+// ScanUpdate is value of a range read by Scheduler.
+type ScanUpdate struct {
+	// index of the scan class in classes given to Schedule
+	Class int
+	// Index of Update is index of the range in Ranges of the scan class
+	Update
+}
+
+// Overrun is reported when a scan class can not read all of its ranges within its period.
+// cycles that are missed by the overrun are skipped.
+type Overrun struct {
+	// index of the scan class in classes given to Schedule
+	Class  int
+	Period time.Duration
+	// time taken by the cycle
+	Elapsed time.Duration
+	// number of cycles skipped
+	Missed int
+}
+
+// ScheduleOption configures Scheduler.
+type ScheduleOption func(*scheduleOptions)
+
+type scheduleOptions struct {
+	onOverrun  func(Overrun)
+	bufferSize int
+}
+
+// WithOverrunHandler sets fn that is called when a scan class overruns its period.
+// fn is called from the goroutine of the scan class, so it must not block.
+func WithOverrunHandler(fn func(Overrun)) ScheduleOption {
+	return func(o *scheduleOptions) {
+		o.onOverrun = fn
+	}
+}
+
+// WithScanBuffer sets buffer size of channel of updates. default is number of all ranges.
+// scan classes wait while the buffer is full, so slow consumer causes overruns.
+func WithScanBuffer(size int) ScheduleOption {
+	return func(o *scheduleOptions) {
+		o.bufferSize = size
+	}
+}
+
+// Scheduler reads ranges of scan classes cyclically and sends their values to C.
+type Scheduler struct {
+	// C receives updates of all scan classes. it is closed when scheduler stops.
+	C <-chan ScanUpdate
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Schedule starts cyclic read of classes until ctx is done or Close is called.
+// each scan class is read concurrently, and reads of ranges in a class are spread evenly over its period
+// to avoid bursts of requests. overrun of a class is reported to WithOverrunHandler.
+func Schedule(ctx context.Context, client Client, classes []ScanClass, opts ...ScheduleOption) (*Scheduler, error) {
+	o := scheduleOptions{}
+	for _, c := range classes {
+		o.bufferSize += len(c.Ranges)
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	prepared := make([][]*PreparedRequest, len(classes))
+	for i, c := range classes {
+		if c.Period <= 0 {
+			return nil, errors.New("scan period must be positive")
+		}
+		for _, r := range c.Ranges {
+			if r.Op != OpRead && r.Op != OpBitRead {
+				return nil, errors.New("scan range must be read request: " + r.Op.String())
+			}
+			p, err := client.Prepare(r)
+			if err != nil {
+				return nil, err
+			}
+			prepared[i] = append(prepared[i], p)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	ch := make(chan ScanUpdate, o.bufferSize)
+	s := &Scheduler{C: ch, cancel: cancel, done: make(chan struct{})}
+
+	var wg sync.WaitGroup
+	for i, c := range classes {
+		if len(prepared[i]) == 0 {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, period time.Duration) {
+			defer wg.Done()
+			s.runClass(ctx, ch, i, period, prepared[i], o)
+		}(i, c.Period)
+	}
+	go func() {
+		wg.Wait()
+		close(ch)
+		close(s.done)
+	}()
+	return s, nil
+}
+
+// Close stops cyclic read and waits until C is closed.
+func (s *Scheduler) Close() {
+	s.cancel()
+	<-s.done
+}
+
+func (s *Scheduler) runClass(ctx context.Context, ch chan<- ScanUpdate, class int, period time.Duration, prepared []*PreparedRequest, o scheduleOptions) {
+	// interval between reads of ranges in a cycle
+	gap := period / time.Duration(len(prepared))
+	start := time.Now()
+	for {
+		for i, p := range prepared {
+			if !sleepUntil(ctx, start.Add(time.Duration(i)*gap)) {
+				return
+			}
+			payload, err := p.Do(ctx)
+			if ctx.Err() != nil {
+				return
+			}
+			u := ScanUpdate{Class: class, Update: Update{Index: i, Request: p.Request(), Time: time.Now(), Payload: payload, Err: err}}
+			select {
+			case ch <- u:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		next := start.Add(period)
+		if elapsed := time.Since(start); elapsed > period {
+			missed := int(elapsed / period)
+			next = start.Add(time.Duration(missed+1) * period)
+			if o.onOverrun != nil {
+				o.onOverrun(Overrun{Class: class, Period: period, Elapsed: elapsed, Missed: missed})
+			}
+		}
+		if !sleepUntil(ctx, next) {
+			return
+		}
+		start = next
+	}
+}
+
+// sleepUntil waits until t. it returns false when ctx is done.
+func sleepUntil(ctx context.Context, t time.Time) bool {
+	d := time.Until(t)
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
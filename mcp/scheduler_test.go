@@ -0,0 +1,110 @@
+package mcp
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSchedule(t *testing.T) {
+	client, mem := newTestMemoryClient(t)
+	mem.words[[2]int64{0xA8, 100}] = 1
+	mem.bits[[2]int64{0x90, 0}] = true
+
+	classes := []ScanClass{
+		{Period: 40 * time.Millisecond, Ranges: []Request{
+			{Op: OpRead, DeviceName: "D", Offset: 100, NumPoints: 1},
+			{Op: OpRead, DeviceName: "D", Offset: 101, NumPoints: 1},
+		}},
+		{Period: 200 * time.Millisecond, Ranges: []Request{
+			{Op: OpBitRead, DeviceName: "M", Offset: 0, NumPoints: 1},
+		}},
+	}
+	s, err := Schedule(context.Background(), client, classes)
+	if err != nil {
+		t.Fatalf("unexpected schedule err: %v", err)
+	}
+	defer s.Close()
+
+	var fast []ScanUpdate
+	slow := 0
+	for len(fast) < 4 {
+		u := <-s.C
+		if u.Err != nil {
+			t.Fatalf("unexpected update err: %v", u.Err)
+		}
+		if u.Class == 1 {
+			slow++
+			continue
+		}
+		if u.Index != len(fast)%2 {
+			t.Fatalf("expected range %v but actual is %v", len(fast)%2, u.Index)
+		}
+		fast = append(fast, u)
+	}
+	// the slow class is read at most once while the fast class cycles twice
+	if slow > 1 {
+		t.Errorf("expected at most %v update of slow class but actual is %v", 1, slow)
+	}
+	// reads of ranges are spread over the period
+	if d := fast[1].Time.Sub(fast[0].Time); d < 10*time.Millisecond {
+		t.Errorf("reads of ranges are not spread: %v", d)
+	}
+	if d := fast[2].Time.Sub(fast[0].Time); d < 30*time.Millisecond {
+		t.Errorf("cycles are shorter than period: %v", d)
+	}
+
+	s.Close()
+	for range s.C {
+		// drain updates sent before close
+	}
+}
+
+func TestSchedule_Overrun(t *testing.T) {
+	mem := newTestMemory()
+	host, port := newTestServer(t, func(req []byte) []byte {
+		time.Sleep(30 * time.Millisecond)
+		return mem.handle(req)
+	})
+	client, err := New3EClient(host, port, NewLocalStation(), true)
+	if err != nil {
+		t.Fatalf("unexpected connect err: %v", err)
+	}
+	defer client.ShutDown()
+
+	overruns := make(chan Overrun, 10)
+	classes := []ScanClass{{Period: 10 * time.Millisecond, Ranges: []Request{{Op: OpRead, DeviceName: "D", Offset: 0, NumPoints: 1}}}}
+	s, err := Schedule(context.Background(), client, classes, WithOverrunHandler(func(o Overrun) {
+		select {
+		case overruns <- o:
+		default:
+		}
+	}))
+	if err != nil {
+		t.Fatalf("unexpected schedule err: %v", err)
+	}
+	defer s.Close()
+	go func() {
+		for range s.C {
+		}
+	}()
+
+	select {
+	case o := <-overruns:
+		if o.Class != 0 || o.Period != 10*time.Millisecond || o.Elapsed < 30*time.Millisecond || o.Missed < 2 {
+			t.Errorf("unexpected overrun: %+v", o)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("overrun is not reported")
+	}
+}
+
+func TestScheduleValidation(t *testing.T) {
+	client, _ := newTestMemoryClient(t)
+	if _, err := Schedule(context.Background(), client, []ScanClass{{Period: 0}}); err == nil {
+		t.Fatalf("expected error for zero period")
+	}
+	if _, err := Schedule(context.Background(), client, []ScanClass{{Period: time.Second, Ranges: []Request{{Op: OpWrite, DeviceName: "D", NumPoints: 1}}}}); err == nil {
+		t.Fatalf("expected error for write request")
+	}
+}
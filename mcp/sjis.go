@@ -0,0 +1,28 @@
+package mcp
+
+import (
+	"golang.org/x/text/encoding/japanese"
+)
+
+// WithShiftJIS encodes and decodes strings of ReadString, WriteString and string fields of ReadStruct and WriteStruct
+// in Shift-JIS like comments and string data of Q-series. length of the strings is counted in Shift-JIS bytes.
+// TagDB.Read and TagDB.Write follow the client, and TagDB.Encode and TagDB.Decode follow TagDB.SetShiftJIS.
+func WithShiftJIS() Option {
+	return func(o *options) {
+		o.shiftJIS = true
+	}
+}
+
+// DecodeSJIS decodes Shift-JIS bytes read from PLC to UTF-8 string.
+func DecodeSJIS(data []byte) (string, error) {
+	decoded, err := japanese.ShiftJIS.NewDecoder().Bytes(data)
+	if err != nil {
+		return "", err
+	}
+	return string(decoded), nil
+}
+
+// EncodeSJIS encodes UTF-8 string to Shift-JIS bytes. character that Shift-JIS does not have is error.
+func EncodeSJIS(s string) ([]byte, error) {
+	return japanese.ShiftJIS.NewEncoder().Bytes([]byte(s))
+}
@@ -0,0 +1,109 @@
+package mcp
+
+import (
+	"testing"
+)
+
+func TestSJIS(t *testing.T) {
+	encoded, err := EncodeSJIS("温度A")
+	if err != nil {
+		t.Fatalf("unexpected encode err: %v", err)
+	}
+	if string(encoded) != "\x89\xB7\x93\x78A" {
+		t.Fatalf("expected % X but actual is % X", "\x89\xB7\x93\x78A", encoded)
+	}
+
+	decoded, err := DecodeSJIS(encoded)
+	if err != nil {
+		t.Fatalf("unexpected decode err: %v", err)
+	}
+	if decoded != "温度A" {
+		t.Fatalf("expected %v but actual is %v", "温度A", decoded)
+	}
+
+	if _, err := EncodeSJIS("😀"); err == nil {
+		t.Fatalf("expected error for character that Shift-JIS does not have")
+	}
+}
+
+func TestClient3E_StringShiftJIS(t *testing.T) {
+	client, mem := newTestMemoryClient(t, WithShiftJIS())
+
+	if err := client.WriteString("D", 100, 6, "温度"); err != nil {
+		t.Fatalf("unexpected write err: %v", err)
+	}
+
+	mem.mu.Lock()
+	expected := map[int64]uint16{100: 0xB789, 101: 0x7893, 102: 0x0000}
+	for offset, word := range expected {
+		if actual := mem.words[[2]int64{0xA8, offset}]; actual != word {
+			t.Errorf("D%v: expected %X but actual is %X", offset, word, actual)
+		}
+	}
+	mem.mu.Unlock()
+
+	s, err := client.ReadString("D", 100, 6)
+	if err != nil {
+		t.Fatalf("unexpected read err: %v", err)
+	}
+	if s != "温度" {
+		t.Fatalf("expected %v but actual is %v", "温度", s)
+	}
+
+	// length is counted in Shift-JIS bytes
+	if err := client.WriteString("D", 100, 3, "温度"); err == nil {
+		t.Fatalf("expected error for too long string")
+	}
+}
+
+func TestClient3E_StructShiftJIS(t *testing.T) {
+	client, mem := newTestMemoryClient(t, WithShiftJIS())
+
+	type Recipe struct {
+		Name string `mcp:"D100,string:6"`
+	}
+	if err := client.WriteStruct(&Recipe{Name: "温度"}); err != nil {
+		t.Fatalf("unexpected write err: %v", err)
+	}
+	mem.mu.Lock()
+	if actual := mem.words[[2]int64{0xA8, 100}]; actual != 0xB789 {
+		t.Errorf("expected %X but actual is %X", 0xB789, actual)
+	}
+	mem.mu.Unlock()
+
+	var got Recipe
+	if err := client.ReadStruct(&got); err != nil {
+		t.Fatalf("unexpected read err: %v", err)
+	}
+	if got.Name != "温度" {
+		t.Fatalf("expected %v but actual is %v", "温度", got.Name)
+	}
+}
+
+func TestTagDB_ShiftJIS(t *testing.T) {
+	db, err := NewTagDB([]Tag{{Name: "Name", Device: "D100", Type: "string:6"}})
+	if err != nil {
+		t.Fatalf("unexpected tag err: %v", err)
+	}
+	db.SetShiftJIS(true)
+
+	r, err := db.Encode("Name", "温度", LowWordFirst)
+	if err != nil {
+		t.Fatalf("unexpected encode err: %v", err)
+	}
+	if string(r.WriteData) != "\x89\xB7\x93\x78\x00\x00" {
+		t.Fatalf("expected % X but actual is % X", "\x89\xB7\x93\x78\x00\x00", r.WriteData)
+	}
+	v, err := db.Decode("Name", r.WriteData, LowWordFirst)
+	if err != nil {
+		t.Fatalf("unexpected decode err: %v", err)
+	}
+	if v != "温度" {
+		t.Fatalf("expected %v but actual is %v", "温度", v)
+	}
+
+	// length is counted in Shift-JIS bytes
+	if _, err := db.Encode("Name", "温度計A", LowWordFirst); err == nil {
+		t.Fatalf("expected error for too long string")
+	}
+}
@@ -0,0 +1,18 @@
+package mcp
+
+// WithSLMP makes client access devices by SLMP format for devices other than MELSEC CPU like remote I/O and
+// inverters on CC-Link IE TSN. frames are the same 3E or 4E frame, but all devices are accessed by
+// MELSEC iQ-R series subcommands that have 4byte device number and 2byte device code.
+// extended devices like J1\W are not supported. use Execute for SLMP commands that this package does not model.
+func WithSLMP() Option {
+	return func(o *options) {
+		o.slmp = true
+	}
+}
+
+// slmpHelper returns copy of h that accesses devices by SLMP format.
+func (h *station) slmpHelper() *station {
+	s := *h
+	s.slmp = true
+	return &s
+}
@@ -0,0 +1,54 @@
+package mcp
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+func TestClient3E_SLMP(t *testing.T) {
+	var requests []*Frame
+	host, port := newTestServer(t, func(req []byte) []byte {
+		f, err := DecodeFrame(req)
+		if err != nil {
+			t.Errorf("unexpected decode err: %v", err)
+		}
+		requests = append(requests, f)
+		if f.Command == 0x1401 {
+			resp, _ := hex.DecodeString("d00000ffff030002000000")
+			return resp
+		}
+		resp, _ := hex.DecodeString("d00000ffff0300040000003412")
+		return resp
+	})
+	client, err := New3EClient(host, port, NewLocalStation(), true, WithSLMP())
+	if err != nil {
+		t.Fatalf("unexpected connect err: %v", err)
+	}
+	defer client.ShutDown()
+
+	payload, err := client.Read("D", 100, 1)
+	if err != nil {
+		t.Fatalf("unexpected read err: %v", err)
+	}
+	if hex.EncodeToString(payload) != "3412" {
+		t.Fatalf("expected %v but actual is %x", "3412", payload)
+	}
+	if _, err := client.BitWrite("M", 10, 1, []byte{0x10}); err != nil {
+		t.Fatalf("unexpected write err: %v", err)
+	}
+
+	// device number[4byte] + device code[2byte] + points[2byte]
+	read := requests[0]
+	if read.SubCommand != 0x0002 || read.DeviceName != "D" || read.Offset != 100 || read.NumPoints != 1 {
+		t.Fatalf("expected read of D100 by subcommand 0002 but actual is %v", read)
+	}
+	write := requests[1]
+	if write.SubCommand != 0x0003 || write.DeviceName != "M" || write.Offset != 10 || hex.EncodeToString(write.Data) != "10" {
+		t.Fatalf("expected bit write of M10 by subcommand 0003 but actual is %v", write)
+	}
+
+	if _, err := client.Read(`J1\W`, 0x100, 1); !errors.Is(err, ErrInvalidDevice) {
+		t.Fatalf("expected %v but actual is %v", ErrInvalidDevice, err)
+	}
+}
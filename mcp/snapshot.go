@@ -0,0 +1,115 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Snapshot is immutable image of device range read at a time.
+// bit devices like X and M are read in bit units and their values are 0 or 1, the others are read in word units.
+type Snapshot struct {
+	deviceName string
+	offset     int64
+	time       time.Time
+	values     []uint16
+}
+
+// Snapshot reads numPoints devices from offset and returns their image. Diff compares two snapshots.
+func (c *client3E) Snapshot(deviceName string, offset, numPoints int64) (*Snapshot, error) {
+	return c.SnapshotContext(context.Background(), deviceName, offset, numPoints)
+}
+
+// SnapshotContext is Snapshot that is aborted when ctx is canceled or its deadline exceeded.
+func (c *client3E) SnapshotContext(ctx context.Context, deviceName string, offset, numPoints int64) (*Snapshot, error) {
+	if numPoints <= 0 {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPoints, numPoints)
+	}
+	s := &Snapshot{deviceName: deviceName, offset: offset, time: time.Now(), values: make([]uint16, numPoints)}
+	if IsBitDevice(deviceName) {
+		payload, err := c.BitReadContext(ctx, deviceName, offset, numPoints)
+		if err != nil {
+			return nil, err
+		}
+		for i, v := range DecodeBits(payload, numPoints) {
+			if v {
+				s.values[i] = 1
+			}
+		}
+		return s, nil
+	}
+
+	payload, err := c.ReadContext(ctx, deviceName, offset, numPoints)
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(payload)) != 2*numPoints {
+		return nil, fmt.Errorf("invalid payload length: expected %v byte but actual is %v byte", 2*numPoints, len(payload))
+	}
+	s.values = DecodeWords(payload)
+	return s, nil
+}
+
+// DeviceName returns device name of the snapshot like D.
+func (s *Snapshot) DeviceName() string {
+	return s.deviceName
+}
+
+// Offset returns the first device number of the snapshot.
+func (s *Snapshot) Offset() int64 {
+	return s.offset
+}
+
+// NumPoints returns number of devices of the snapshot.
+func (s *Snapshot) NumPoints() int64 {
+	return int64(len(s.values))
+}
+
+// Time returns time when the snapshot is taken.
+func (s *Snapshot) Time() time.Time {
+	return s.time
+}
+
+// Value returns value of device of offset. ok is false when the device is out of the snapshot.
+func (s *Snapshot) Value(offset int64) (value uint16, ok bool) {
+	i := offset - s.offset
+	if i < 0 || i >= int64(len(s.values)) {
+		return 0, false
+	}
+	return s.values[i], true
+}
+
+// Values returns copy of values of all devices in order.
+func (s *Snapshot) Values() []uint16 {
+	return append([]uint16(nil), s.values...)
+}
+
+// Change is device whose value differs between snapshots.
+type Change struct {
+	DeviceName string
+	Offset     int64
+	Old        uint16
+	New        uint16
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%v: %v -> %v", FormatDevice(c.DeviceName, c.Offset), c.Old, c.New)
+}
+
+// Diff returns devices whose values differ from snapshot a to b in order of device number.
+// a and b must be of the same device, and only devices in both snapshots are compared.
+func Diff(a, b *Snapshot) ([]Change, error) {
+	if a.deviceName != b.deviceName {
+		return nil, errors.New("snapshots are of different devices: " + a.deviceName + " and " + b.deviceName)
+	}
+
+	var changes []Change
+	for i, old := range a.values {
+		offset := a.offset + int64(i)
+		if v, ok := b.Value(offset); ok && v != old {
+			changes = append(changes, Change{DeviceName: a.deviceName, Offset: offset, Old: old, New: v})
+		}
+	}
+	return changes, nil
+}
@@ -0,0 +1,108 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestClient3E_Snapshot(t *testing.T) {
+	client, _ := newTestMemoryClient(t)
+
+	if err := client.WriteInt16("D", 100, 1); err != nil {
+		t.Fatalf("unexpected mcp write err: %v", err)
+	}
+	before, err := client.Snapshot("D", 100, 4)
+	if err != nil {
+		t.Fatalf("unexpected snapshot err: %v", err)
+	}
+	bitsBefore, err := client.Snapshot("M", 0, 8)
+	if err != nil {
+		t.Fatalf("unexpected snapshot err: %v", err)
+	}
+
+	// program step touches D100, D102 and M3
+	if err := client.WriteInt16("D", 100, 2); err != nil {
+		t.Fatalf("unexpected mcp write err: %v", err)
+	}
+	if err := client.WriteInt16("D", 102, -1); err != nil {
+		t.Fatalf("unexpected mcp write err: %v", err)
+	}
+	if err := client.WriteBools("M", 3, []bool{true}); err != nil {
+		t.Fatalf("unexpected mcp write err: %v", err)
+	}
+
+	after, err := client.Snapshot("D", 101, 4)
+	if err != nil {
+		t.Fatalf("unexpected snapshot err: %v", err)
+	}
+	bitsAfter, err := client.Snapshot("M", 0, 8)
+	if err != nil {
+		t.Fatalf("unexpected snapshot err: %v", err)
+	}
+	if diff := cmp.Diff(before.Values(), []uint16{1, 0, 0, 0}); diff != "" {
+		t.Errorf("values differs: (-got +want)\n%s", diff)
+	}
+
+	// D100 is not in the later snapshot, so only D101-D103 are compared
+	changes, err := Diff(before, after)
+	if err != nil {
+		t.Fatalf("unexpected diff err: %v", err)
+	}
+	if diff := cmp.Diff(changes, []Change{{DeviceName: "D", Offset: 102, Old: 0, New: 0xFFFF}}); diff != "" {
+		t.Errorf("changes differs: (-got +want)\n%s", diff)
+	}
+
+	changes, err = Diff(bitsBefore, bitsAfter)
+	if err != nil {
+		t.Fatalf("unexpected diff err: %v", err)
+	}
+	if diff := cmp.Diff(changes, []Change{{DeviceName: "M", Offset: 3, Old: 0, New: 1}}); diff != "" {
+		t.Errorf("changes differs: (-got +want)\n%s", diff)
+	}
+	if s := changes[0].String(); s != "M3: 0 -> 1" {
+		t.Errorf("expected %v but actual is %v", "M3: 0 -> 1", s)
+	}
+
+	if _, err := Diff(before, bitsAfter); err == nil {
+		t.Fatalf("expected error for different devices")
+	}
+}
+
+func TestClient3E_SnapshotInvalidPoints(t *testing.T) {
+	client, _ := newTestMemoryClient(t)
+	for _, numPoints := range []int64{0, -1} {
+		if _, err := client.Snapshot("D", 0, numPoints); !errors.Is(err, ErrInvalidPoints) {
+			t.Fatalf("expected %v but actual is %v", ErrInvalidPoints, err)
+		}
+		if err := ExportArea(context.Background(), client, io.Discard, "D", 0, numPoints); !errors.Is(err, ErrInvalidPoints) {
+			t.Fatalf("expected %v but actual is %v", ErrInvalidPoints, err)
+		}
+	}
+}
+
+func TestClient3E_SnapshotQualifiedBit(t *testing.T) {
+	// module answers J1\X0-J1\X3 of ON, OFF, ON, ON by bit unit read
+	var ops []Op
+	answer := func(next Handler) Handler {
+		return func(ctx context.Context, req *Request) ([]byte, error) {
+			ops = append(ops, req.Op)
+			return []byte{0xD0, 0x00, 0x00, 0xFF, 0xFF, 0x03, 0x00, 0x04, 0x00, 0x00, 0x00, 0x10, 0x11}, nil
+		}
+	}
+	client, _ := newTestMemoryClient(t, WithMiddleware(answer))
+
+	s, err := client.Snapshot(`J1\X`, 0, 4)
+	if err != nil {
+		t.Fatalf("unexpected snapshot err: %v", err)
+	}
+	if diff := cmp.Diff(ops, []Op{OpBitRead}); diff != "" {
+		t.Errorf("ops differs: (-got +want)\n%s", diff)
+	}
+	if diff := cmp.Diff(s.Values(), []uint16{1, 0, 1, 1}); diff != "" {
+		t.Errorf("values differs: (-got +want)\n%s", diff)
+	}
+}
@@ -1,16 +1,25 @@
 package mcp
 
 import (
-	"bytes"
 	"encoding/binary"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 )
 
 const (
 	SUB_HEADER = "5000" // 3Eフレームでは固定
 
+	// 4Eフレーム. serial number[2byte] and fixed 0000[2byte] follow sub header.
+	SUB_HEADER_4E          = "5400"
+	SUB_HEADER_4E_RESPONSE = 0xD400
+
 	HEALTH_CHECK_COMMAND    = "1906" // binary mode expression. if ascii mode then 0619
 	HEALTH_CHECK_SUBCOMMAND = "0000"
+	HEALTH_CHECK_DATA_NUM   = "0500"       // 5 device. if ascii mode then 0005
+	HEALTH_CHECK_DATA       = "4142434445" // value is "ABCDE".
 
 	READ_COMMAND         = "0104" // binary mode expression. if ascii mode then 0401
 	READ_SUB_COMMAND     = "0000"
@@ -20,20 +29,76 @@ const (
 	WRITE_SUB_COMMAND     = "0000"
 	BIT_WRITE_SUB_COMMAND = "0100"
 
+	// MELSEC iQ-R series subcommands. device number is 4byte and device code is 2byte.
+	IQR_READ_SUB_COMMAND      = "0200"
+	IQR_BIT_READ_SUB_COMMAND  = "0300"
+	IQR_WRITE_SUB_COMMAND     = "0200"
+	IQR_BIT_WRITE_SUB_COMMAND = "0300"
+
+	// extended device specification subcommands for link direct device (J\W) and module access device (U\G).
+	// device modification and extension specification are added to device.
+	EXT_READ_SUB_COMMAND      = "8000"
+	EXT_BIT_READ_SUB_COMMAND  = "8100"
+	EXT_WRITE_SUB_COMMAND     = "8000"
+	EXT_BIT_WRITE_SUB_COMMAND = "8100"
+
+	// direct memory specification
+	MODULE_ACCESS_MEMORY = "F8" // module access device (U\G)
+	LINK_DIRECT_MEMORY   = "F9" // link direct device (J\W)
+	CPU_BUFFER_MEMORY    = "FA" // cpu buffer memory access device (U3E0\G)
+
 	MONITORING_TIMER = "1000" // 3[sec]
+
+	// max number of device points of batch read in 1 request
+	MAX_READ_POINTS     = 960  // word unit
+	MAX_BIT_READ_POINTS = 7168 // bit unit
+)
+
+var (
+	// ErrShortWriteData is returned when write data is shorter than the size of write device points.
+	ErrShortWriteData = errors.New("write data is shorter than write device points")
+	// ErrInvalidOffset is returned when device offset is negative or overflows device number field.
+	ErrInvalidOffset = errors.New("invalid device offset")
+	// ErrInvalidPoints is returned when number of device points is not positive or overflows points field.
+	ErrInvalidPoints = errors.New("invalid number of device points")
+	// ErrInvalidDevice is returned when device name is unknown.
+	ErrInvalidDevice = errors.New("invalid device name")
+	// ErrInvalidStation is returned when station number is out of range.
+	ErrInvalidStation = errors.New("invalid station number")
 )
 
 // DeviceCodes is device name and hex value map
 var DeviceCodes = map[string]string{
-	"X": "9C",
-	"Y": "9D",
-	"M": "90",
-	"L": "92",
-	"F": "93",
-	"V": "94",
-	"B": "A0",
-	"W": "B4",
-	"D": "A8",
+	"X":  "9C",
+	"Y":  "9D",
+	"SM": "91",
+	"M":  "90",
+	"L":  "92",
+	"F":  "93",
+	"V":  "94",
+	"B":  "A0",
+	"W":  "B4",
+	"D":  "A8",
+	"SD": "A9",
+	"SB": "A1",
+	"SW": "B5",
+	"G":  "AB",
+	"S":  "98",
+	"TS": "C1",
+	"TC": "C0",
+	"TN": "C2",
+	"CS": "C4",
+	"CC": "C3",
+	"CN": "C5",
+	"R":  "AF",
+	"ZR": "B0",
+	"Z":  "CC",
+}
+
+// IQRDeviceCodes is device name and hex value map of devices that can be accessed only by MELSEC iQ-R series subcommands.
+// device code of iQ-R series is 2byte.
+var IQRDeviceCodes = map[string]string{
+	"RD": "2C00", // refresh data register
 }
 
 // Each single PLC that is connected on MELSECNET and CC-Link IE is called a station.
@@ -46,154 +111,362 @@ type station struct {
 	unitIONum string
 	// PLC stn Unit Station Number
 	unitStationNum string
+	// binary of the above fields as they are put in request
+	route [5]byte
+	// access all devices by MELSEC iQ-R series subcommands of SLMP
+	slmp bool
+}
+
+// NewStation returns station of other stn accessed through network.
+// unitIONum is start I/O number of request destination module like 0x03FF (own station cpu) or 0x03E0 (multiple cpu No.1).
+func NewStation(networkNum, pcNum, unitIONum, unitStationNum int) (*station, error) {
+	if networkNum < 0 || networkNum > 0xFF {
+		return nil, fmt.Errorf("%w: network number %v is out of range 0-255", ErrInvalidStation, networkNum)
+	}
+	if pcNum < 0 || pcNum > 0xFF {
+		return nil, fmt.Errorf("%w: pc number %v is out of range 0-255", ErrInvalidStation, pcNum)
+	}
+	if unitIONum < 0 || unitIONum > 0xFFFF {
+		return nil, fmt.Errorf("%w: unit i/o number %v is out of range 0-0xFFFF", ErrInvalidStation, unitIONum)
+	}
+	if unitStationNum < 0 || unitStationNum > 0xFF {
+		return nil, fmt.Errorf("%w: unit station number %v is out of range 0-255", ErrInvalidStation, unitStationNum)
+	}
+
+	unitIO := make([]byte, 2)
+	binary.LittleEndian.PutUint16(unitIO, uint16(unitIONum))
+	return NewStationHex(
+		fmt.Sprintf("%02X", networkNum),
+		fmt.Sprintf("%02X", pcNum),
+		fmt.Sprintf("%X", unitIO),
+		fmt.Sprintf("%02X", unitStationNum),
+	), nil
 }
 
-func NewStation(networkNum, pcNum, unitIONum, unitStationNum string) *station {
-	return &station{
+// NewStationHex returns station from hex layout of each field as they are put in request.
+// this is low-level variant of NewStation. unitIONum is little endian like "FF03".
+func NewStationHex(networkNum, pcNum, unitIONum, unitStationNum string) *station {
+	h := &station{
 		networkNum:     networkNum,
 		pcNum:          pcNum,
 		unitIONum:      unitIONum,
 		unitStationNum: unitStationNum,
 	}
+	// invalid hex is put as zero
+	_, _ = hex.Decode(h.route[:], []byte(networkNum+pcNum+unitIONum+unitStationNum))
+	return h
 }
 
 // local stn stn. local stn is 自局.
 func NewLocalStation() *station {
-	return &station{
-		networkNum:     "00",   // 自局の場合は00固定
-		pcNum:          "FF",   // 自局の場合はFF固定
-		unitIONum:      "FF03", // マルチドロップ接続などでない場合はFF03固定値
-		unitStationNum: "00",   // マルチドロップ接続などでない場合は00固定値
-	}
+	return NewStationHex(
+		"00",   // 自局の場合は00固定
+		"FF",   // 自局の場合はFF固定
+		"FF03", // マルチドロップ接続などでない場合はFF03固定値
+		"00",   // マルチドロップ接続などでない場合は00固定値
+	)
 }
 
+// BuildHealthCheckRequest represents MCP loopback test command as hex string.
 func (h *station) BuildHealthCheckRequest() string {
+	return fmt.Sprintf("%X", h.AppendHealthCheckRequest(nil))
+}
 
-	returnDataNum := "0500"    // 5 device. if ascii mode then 0005
-	returnData := "4142434445" // value is "ABCDE".
-
-	requestStr := HEALTH_CHECK_COMMAND + HEALTH_CHECK_SUBCOMMAND + returnDataNum + returnData
-
-	// data length
-	requestCharLen := len(MONITORING_TIMER+requestStr) / 2 // 1byte=2char
-	dataLenBuff := new(bytes.Buffer)
-	_ = binary.Write(dataLenBuff, binary.LittleEndian, int64(requestCharLen))
-	dataLen := fmt.Sprintf("%X", dataLenBuff.Bytes()[0:2]) // 2byte固定
-
-	return SUB_HEADER +
-		h.networkNum +
-		h.pcNum +
-		h.unitIONum +
-		h.unitStationNum +
-		dataLen +
-		MONITORING_TIMER +
-		requestStr
+// AppendHealthCheckRequest appends binary frame of MCP loopback test command to dst.
+func (h *station) AppendHealthCheckRequest(dst []byte) []byte {
+	start := len(dst)
+	dst = h.appendHeaderHelper(dst, healthCheckCommand, 0)
+	dst = binary.LittleEndian.AppendUint16(dst, uint16(len(healthCheckData)))
+	dst = append(dst, healthCheckData...)
+	return finishFrame(dst, start)
 }
 
 // BuildReadRequest represents MCP read as word command.
 // deviceName is device code name like 'D' register.
 // offset is device offset addr.
 // numPoints is number of read device points.
-func (h *station) BuildReadRequest(deviceName string, offset, numPoints int64) string {
-	return h.buildReadRequestHelper(deviceName, offset, numPoints, READ_SUB_COMMAND)
+// invalid device, offset and numPoints are returned as ErrInvalidDevice, ErrInvalidOffset and ErrInvalidPoints.
+func (h *station) BuildReadRequest(deviceName string, offset, numPoints int64) (string, error) {
+	return hexFrame(h.AppendReadRequest(nil, deviceName, offset, numPoints))
 }
 
 // BuildReadRequest represents MCP read as bit command.
 // deviceName is device code name like 'D' register.
 // offset is device offset addr.
 // numPoints is number of read device points.
-func (h *station) BuildBitReadRequest(deviceName string, offset, numPoints int64) string {
-	return h.buildReadRequestHelper(deviceName, offset, numPoints, BIT_READ_SUB_COMMAND)
+// invalid device, offset and numPoints are returned as ErrInvalidDevice, ErrInvalidOffset and ErrInvalidPoints.
+func (h *station) BuildBitReadRequest(deviceName string, offset, numPoints int64) (string, error) {
+	return hexFrame(h.AppendBitReadRequest(nil, deviceName, offset, numPoints))
 }
 
-func (h *station) buildReadRequestHelper(deviceName string, offset, numPoints int64, subCommand string) string {
-	// get device symbol hex layout
-	deviceCode := DeviceCodes[deviceName]
+// AppendReadRequest appends binary frame of BuildReadRequest to dst.
+func (h *station) AppendReadRequest(dst []byte, deviceName string, offset, numPoints int64) ([]byte, error) {
+	return h.appendReadRequestHelper(dst, deviceName, offset, numPoints, readSubCommand)
+}
 
-	// offset convert to little endian layout
-	// MELSECコミュニケーションプロトコル リファレンス(p67) MELSEC-Q/L: 3[byte], MELSEC iQ-R: 4[byte]
-	offsetBuff := new(bytes.Buffer)
-	_ = binary.Write(offsetBuff, binary.LittleEndian, offset)
-	offsetHex := fmt.Sprintf("%X", offsetBuff.Bytes()[0:3]) // 仮にQシリーズとするので3byte trim
+// AppendBitReadRequest appends binary frame of BuildBitReadRequest to dst.
+func (h *station) AppendBitReadRequest(dst []byte, deviceName string, offset, numPoints int64) ([]byte, error) {
+	return h.appendReadRequestHelper(dst, deviceName, offset, numPoints, bitReadSubCommand)
+}
 
-	// read points
-	pointsBuff := new(bytes.Buffer)
-	_ = binary.Write(pointsBuff, binary.LittleEndian, numPoints)
-	points := fmt.Sprintf("%X", pointsBuff.Bytes()[0:2]) // 2byte固定
+func (h *station) appendReadRequestHelper(dst []byte, deviceName string, offset, numPoints int64, subCommand uint16) ([]byte, error) {
+	if err := validatePoints(numPoints); err != nil {
+		return nil, err
+	}
+
+	start := len(dst)
+	dst = h.appendHeaderHelper(dst, readCommand, subCommand)
 
-	// data length
-	requestCharLen := len(MONITORING_TIMER+READ_COMMAND+READ_SUB_COMMAND+deviceCode+offsetHex+points) / 2 // 1byte=2char
-	dataLenBuff := new(bytes.Buffer)
-	_ = binary.Write(dataLenBuff, binary.LittleEndian, int64(requestCharLen))
-	dataLen := fmt.Sprintf("%X", dataLenBuff.Bytes()[0:2]) // 2byte固定
+	// device number and device symbol
+	dst, subCommand, err := appendDeviceHelper(dst, deviceName, offset, subCommand, h.slmp)
+	if err != nil {
+		return nil, err
+	}
+	binary.LittleEndian.PutUint16(dst[start+13:], subCommand)
 
-	return SUB_HEADER +
-		h.networkNum +
-		h.pcNum +
-		h.unitIONum +
-		h.unitStationNum +
-		dataLen +
-		MONITORING_TIMER +
-		READ_COMMAND +
-		subCommand +
-		offsetHex +
-		deviceCode +
-		points
+	// read points. 2byte固定
+	dst = binary.LittleEndian.AppendUint16(dst, uint16(numPoints))
+	return finishFrame(dst, start), nil
 }
 
-func (h *station) BuildWriteRequest(deviceName string, offset, numPoints int64, writeData []byte) string {
-	return h.buildWriteRequestHelper(deviceName, offset, numPoints, writeData, WRITE_SUB_COMMAND)
+// BuildWriteRequest represents MCP write as word command.
+// writeData must have 2 byte per 1 device point, otherwise ErrShortWriteData is returned.
+// If writeData is larger than 2*numPoints bytes, larger data is ignored.
+func (h *station) BuildWriteRequest(deviceName string, offset, numPoints int64, writeData []byte) (string, error) {
+	return hexFrame(h.AppendWriteRequest(nil, deviceName, offset, numPoints, writeData))
 }
 
-func (h *station) BuildBitWriteRequest(deviceName string, offset, numPoints int64, writeData []byte) string {
-	return h.buildWriteRequestHelper(deviceName, offset, numPoints, writeData, BIT_WRITE_SUB_COMMAND)
+// BuildBitWriteRequest represents MCP write as bit command.
+// writeData has 2 device points per 1 byte. upper 4bit is first point and 1 means ON.
+// writeData must have (numPoints+1)/2 bytes, otherwise ErrShortWriteData is returned.
+// If writeData is larger than (numPoints+1)/2 bytes, larger data is ignored.
+func (h *station) BuildBitWriteRequest(deviceName string, offset, numPoints int64, writeData []byte) (string, error) {
+	return hexFrame(h.AppendBitWriteRequest(nil, deviceName, offset, numPoints, writeData))
 }
 
-// BuildWriteRequest represents MCP write command.
+// AppendWriteRequest appends binary frame of BuildWriteRequest to dst.
+func (h *station) AppendWriteRequest(dst []byte, deviceName string, offset, numPoints int64, writeData []byte) ([]byte, error) {
+	return h.appendWriteRequestHelper(dst, deviceName, offset, numPoints, writeData, writeSubCommand)
+}
+
+// AppendBitWriteRequest appends binary frame of BuildBitWriteRequest to dst.
+func (h *station) AppendBitWriteRequest(dst []byte, deviceName string, offset, numPoints int64, writeData []byte) ([]byte, error) {
+	return h.appendWriteRequestHelper(dst, deviceName, offset, numPoints, writeData, bitWriteSubCommand)
+}
+
+// appendWriteRequestHelper appends MCP write command.
 // deviceName is device code name like 'D' register.
 // offset is device offset addr.
 // writeData is data to write.
 // numPoints is number of write device points.
 // writeData is the data to be written. If writeData is larger than 2*numPoints bytes,
 // data larger than 2*numPoints bytes is ignored.
-func (h *station) buildWriteRequestHelper(deviceName string, offset, numPoints int64, writeData []byte, subCommand string) string {
-	// get device symbol hex layout
-	deviceCode := DeviceCodes[deviceName]
+func (h *station) appendWriteRequestHelper(dst []byte, deviceName string, offset, numPoints int64, writeData []byte, subCommand uint16) ([]byte, error) {
+	if err := validatePoints(numPoints); err != nil {
+		return nil, err
+	}
+
+	writeLen := WriteDataLen(numPoints, subCommand == bitWriteSubCommand)
+	if int64(len(writeData)) < writeLen {
+		return nil, fmt.Errorf("%w: %v points need %v byte but write data is %v byte", ErrShortWriteData, numPoints, writeLen, len(writeData))
+	}
+
+	start := len(dst)
+	dst = h.appendHeaderHelper(dst, writeCommand, subCommand)
+
+	// device number and device symbol
+	dst, subCommand, err := appendDeviceHelper(dst, deviceName, offset, subCommand, h.slmp)
+	if err != nil {
+		return nil, err
+	}
+	binary.LittleEndian.PutUint16(dst[start+13:], subCommand)
+
+	// write points. 2byte固定
+	dst = binary.LittleEndian.AppendUint16(dst, uint16(numPoints))
+	// write data is already little endian word
+	dst = append(dst, writeData[:writeLen]...)
+	return finishFrame(dst, start), nil
+}
+
+// binary values of commands and subcommands. hex constants above are their little endian layout.
+const (
+	healthCheckCommand uint16 = 0x0619
+	readCommand        uint16 = 0x0401
+	writeCommand       uint16 = 0x1401
+
+	readSubCommand     uint16 = 0x0000 // same as write
+	bitReadSubCommand  uint16 = 0x0001 // same as bit write
+	writeSubCommand    uint16 = 0x0000
+	bitWriteSubCommand uint16 = 0x0001
+
+	// MELSEC iQ-R series and extended device specification set these bits to subcommand.
+	iqrSubCommandFlag uint16 = 0x0002
+	extSubCommandFlag uint16 = 0x0080
+
+	monitoringTimer uint16 = 0x0010 // 3[sec]
+)
+
+// healthCheckData is binary of HEALTH_CHECK_DATA.
+var healthCheckData, _ = hex.DecodeString(HEALTH_CHECK_DATA)
+
+// appendHeaderHelper appends 3E frame header, command and subcommand. data length is set by finishFrame.
+func (h *station) appendHeaderHelper(dst []byte, command, subCommand uint16) []byte {
+	dst = append(dst, 0x50, 0x00) // SUB_HEADER
+	dst = append(dst, h.route[:]...)
+	dst = append(dst, 0x00, 0x00) // data length
+	dst = binary.LittleEndian.AppendUint16(dst, monitoringTimer)
+	dst = binary.LittleEndian.AppendUint16(dst, command)
+	return binary.LittleEndian.AppendUint16(dst, subCommand)
+}
+
+// finishFrame sets data length of frame that starts at start of dst. data length is counted from monitoring timer.
+func finishFrame(dst []byte, start int) []byte {
+	binary.LittleEndian.PutUint16(dst[start+7:], uint16(len(dst)-start-9))
+	return dst
+}
+
+// hexFrame formats binary frame as upper case hex string.
+func hexFrame(frame []byte, err error) (string, error) {
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%X", frame), nil
+}
+
+// parseDeviceCode parses hex device code of DeviceCodes and IQRDeviceCodes.
+func parseDeviceCode(code string) uint16 {
+	v, _ := strconv.ParseUint(code, 16, 16)
+	return uint16(v)
+}
+
+// appendOffset appends offset as offsetLen byte little endian.
+func appendOffset(dst []byte, offset int64, offsetLen uint) []byte {
+	for i := uint(0); i < offsetLen; i++ {
+		dst = append(dst, byte(offset>>(8*i)))
+	}
+	return dst
+}
+
+// WriteDataLen returns byte size of write data of numPoints devices.
+// word unit needs 2 byte per 1 device point, bit unit has 2 device points per 1 byte.
+func WriteDataLen(numPoints int64, bit bool) int64 {
+	if bit {
+		return (numPoints + 1) / 2
+	}
+	return 2 * numPoints
+}
+
+// validatePoints checks numPoints fits 2byte points field.
+func validatePoints(numPoints int64) error {
+	if numPoints <= 0 || numPoints > 0xFFFF {
+		return fmt.Errorf("%w: %v", ErrInvalidPoints, numPoints)
+	}
+	return nil
+}
+
+// validateOffset checks offset fits offsetLen byte device number field.
+func validateOffset(offset int64, offsetLen uint) error {
+	if offset < 0 || offset >= 1<<(8*offsetLen) {
+		return fmt.Errorf("%w: %v does not fit %v byte device number", ErrInvalidOffset, offset, offsetLen)
+	}
+	return nil
+}
+
+// appendDeviceHelper appends device number and device code and returns the subcommand to access the device.
+// devices in IQRDeviceCodes and all devices of slmp are accessed by MELSEC iQ-R series subcommand.
+// device name qualified like J1\W is accessed by extended device specification.
+func appendDeviceHelper(dst []byte, deviceName string, offset int64, subCommand uint16, slmp bool) ([]byte, uint16, error) {
+	if i := strings.Index(deviceName, `\`); i >= 0 {
+		if slmp {
+			return nil, 0, fmt.Errorf("%w: extended device %v is not supported by SLMP mode", ErrInvalidDevice, deviceName)
+		}
+		return appendExtendedDeviceHelper(dst, deviceName[:i], deviceName[i+1:], offset, subCommand)
+	}
+
+	// get device symbol
+	deviceCode, ok := DeviceCodes[deviceName]
+	offsetLen := uint(3) // 仮にQシリーズとするので3byte trim
+	if code, iqr := IQRDeviceCodes[deviceName]; iqr {
+		ok = true
+		deviceCode, offsetLen = code, 4
+		subCommand |= iqrSubCommandFlag
+	} else if ok && slmp {
+		// 1byte device code is put in lower byte of 2byte device code like "A800"
+		deviceCode, offsetLen = deviceCode+"00", 4
+		subCommand |= iqrSubCommandFlag
+	}
+
+	if !ok {
+		return nil, 0, fmt.Errorf("%w: %v", ErrInvalidDevice, deviceName)
+	}
+	if err := validateOffset(offset, offsetLen); err != nil {
+		return nil, 0, err
+	}
 
 	// offset convert to little endian layout
 	// MELSECコミュニケーションプロトコル リファレンス(p67) MELSEC-Q/L: 3[byte], MELSEC iQ-R: 4[byte]
-	offsetBuff := new(bytes.Buffer)
-	_ = binary.Write(offsetBuff, binary.LittleEndian, offset)
-	offsetHex := fmt.Sprintf("%X", offsetBuff.Bytes()[0:3]) // 仮にQシリーズとするので3byte trim
-
-	// convert write data to little endian word
-	writeBuff := new(bytes.Buffer)
-	_ = binary.Write(writeBuff, binary.LittleEndian, writeData)
-	writeHex := fmt.Sprintf("%X", writeBuff.Bytes()[0:2*numPoints]) // 2 byte per 1 device point
-
-	// write points
-	pointsBuff := new(bytes.Buffer)
-	_ = binary.Write(pointsBuff, binary.LittleEndian, numPoints)
-	points := fmt.Sprintf("%X", pointsBuff.Bytes()[0:2]) // 2byte固定
-
-	// data length
-	requestCharLen := len(MONITORING_TIMER+WRITE_COMMAND+WRITE_SUB_COMMAND+deviceCode+offsetHex+points+writeHex) / 2 // 1byte=2char
-	dataLenBuff := new(bytes.Buffer)
-	_ = binary.Write(dataLenBuff, binary.LittleEndian, int64(requestCharLen))
-	dataLen := fmt.Sprintf("%X", dataLenBuff.Bytes()[0:2]) // 2byte固定
-	return SUB_HEADER +
-		h.networkNum +
-		h.pcNum +
-		h.unitIONum +
-		h.unitStationNum +
-		dataLen +
-		MONITORING_TIMER +
-		WRITE_COMMAND +
-		subCommand +
-		offsetHex +
-		deviceCode +
-		points +
-		writeHex
+	dst = appendOffset(dst, offset, offsetLen)
+	if offsetLen == 4 {
+		// device code of iQ-R series is 2byte like "2C00"
+		return binary.BigEndian.AppendUint16(dst, parseDeviceCode(deviceCode)), subCommand, nil
+	}
+	return append(dst, byte(parseDeviceCode(deviceCode))), subCommand, nil
+}
+
+// SharedMemoryDevice returns device name of multi-CPU shared memory of cpuNum like U3E0\G.
+// cpuNum is multi-CPU number from 1 to 4, others are ErrInvalidDevice because they are buffer memory of modules.
+func SharedMemoryDevice(cpuNum int64) (string, error) {
+	if cpuNum < 1 || cpuNum > 4 {
+		return "", fmt.Errorf("%w: multi-CPU number %v is not 1 to 4", ErrInvalidDevice, cpuNum)
+	}
+	return fmt.Sprintf(`U%X\G`, 0x3E0+cpuNum-1), nil
+}
+
+// appendExtendedDeviceHelper appends device of extended device specification and returns the subcommand.
+// qualifier is like J1 that is link direct device of network No.1,
+// or U3E0 that is module access device whose start I/O number is 3E0.
+func appendExtendedDeviceHelper(dst []byte, qualifier, deviceName string, offset int64, subCommand uint16) ([]byte, uint16, error) {
+	var extensionNum int64
+	var directMemory string
+	switch {
+	case strings.HasPrefix(qualifier, "J"):
+		// J1 - J239 is network number
+		networkNum, err := strconv.ParseInt(qualifier[1:], 10, 64)
+		if err != nil || networkNum < 1 || networkNum > 239 {
+			return nil, 0, fmt.Errorf("%w: %v", ErrInvalidDevice, qualifier+`\`+deviceName)
+		}
+		extensionNum, directMemory = networkNum, LINK_DIRECT_MEMORY
+	case strings.HasPrefix(qualifier, "U"):
+		// U is upper 3 digits of start I/O number. U3E0 - U3E3 is cpu buffer memory of multi-CPU No.1 - No.4.
+		ioNum, err := strconv.ParseInt(qualifier[1:], 16, 64)
+		if err != nil || ioNum < 0 || ioNum > 0xFFFF {
+			return nil, 0, fmt.Errorf("%w: %v", ErrInvalidDevice, qualifier+`\`+deviceName)
+		}
+		extensionNum, directMemory = ioNum, MODULE_ACCESS_MEMORY
+		if 0x3E0 <= ioNum && ioNum <= 0x3E3 {
+			directMemory = CPU_BUFFER_MEMORY
+		}
+	default:
+		return nil, 0, fmt.Errorf("%w: %v", ErrInvalidDevice, qualifier+`\`+deviceName)
+	}
+
+	deviceCode, ok := DeviceCodes[deviceName]
+	if !ok {
+		return nil, 0, fmt.Errorf("%w: %v", ErrInvalidDevice, qualifier+`\`+deviceName)
+	}
+	if err := validateOffset(offset, 3); err != nil {
+		return nil, 0, err
+	}
+
+	// device modification[2byte] + device[4byte] + extension specification modification[2byte] +
+	// extension specification[2byte] + direct memory specification[1byte]
+	dst = append(dst, 0x00, 0x00)
+	dst = appendOffset(dst, offset, 3)
+	dst = append(dst, byte(parseDeviceCode(deviceCode)))
+	dst = append(dst, 0x00, 0x00)
+	dst = binary.LittleEndian.AppendUint16(dst, uint16(extensionNum)) // 2byte固定
+	dst = append(dst, byte(parseDeviceCode(directMemory)))
+	return dst, subCommand | extSubCommandFlag, nil
 }
 
 func (h *station) BuildAccessPath() {
@@ -1,17 +1,203 @@
 package mcp
 
-import "testing"
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
 
 func TestStation_BuildRRequest(t *testing.T) {
 	station := NewLocalStation()
-	request := station.BuildReadRequest("D", 300, 3)
+	request, _ := station.BuildReadRequest("D", 300, 3)
 
 	if request != "500000FFFF03000C001000010400002C0100A80300" {
 		t.Fatalf("expected %v but actual is %v", "500000FFFF03000C001000010400002C0100A80300", request)
 	}
 
-	request2 := station.BuildReadRequest("D", 500, 50)
+	request2, _ := station.BuildReadRequest("D", 500, 50)
 	if request2 != "500000FFFF03000C00100001040000F40100A83200" {
 		t.Fatalf("expected %v but actual is %v", "500000FFFF03000C00100001040000F40100A83200", request2)
 	}
 }
+
+func TestStation_BuildIQRReadRequest(t *testing.T) {
+	station := NewLocalStation()
+	request, _ := station.BuildReadRequest("RD", 300, 3)
+
+	if request != "500000FFFF03000E001000010402002C0100002C000300" {
+		t.Fatalf("expected %v but actual is %v", "500000FFFF03000E001000010402002C0100002C000300", request)
+	}
+
+	request2, _ := station.BuildBitReadRequest("RD", 0, 1)
+	if request2 != "500000FFFF03000E00100001040300000000002C000100" {
+		t.Fatalf("expected %v but actual is %v", "500000FFFF03000E00100001040300000000002C000100", request2)
+	}
+}
+
+func TestStation_BuildLinkDirectReadRequest(t *testing.T) {
+	station := NewLocalStation()
+	request, _ := station.BuildReadRequest(`J1\W`, 0x100, 4)
+
+	expected := "500000FFFF03001300100001048000" + "0000" + "000100" + "B4" + "0000" + "0100" + "F9" + "0400"
+	if request != expected {
+		t.Fatalf("expected %v but actual is %v", expected, request)
+	}
+
+	request2, _ := station.BuildBitReadRequest(`J2\SB`, 0, 1)
+	expected2 := "500000FFFF03001300100001048100" + "0000" + "000000" + "A1" + "0000" + "0200" + "F9" + "0100"
+	if request2 != expected2 {
+		t.Fatalf("expected %v but actual is %v", expected2, request2)
+	}
+}
+
+func TestStation_BuildSharedMemoryReadRequest(t *testing.T) {
+	if deviceName, err := SharedMemoryDevice(2); deviceName != `U3E1\G` || err != nil {
+		t.Fatalf("expected %v but actual is %v %v", `U3E1\G`, deviceName, err)
+	}
+	// U3DF\G and U3E4\G are buffer memory of modules
+	for _, cpuNum := range []int64{0, 5} {
+		if _, err := SharedMemoryDevice(cpuNum); !errors.Is(err, ErrInvalidDevice) {
+			t.Fatalf("expected %v but actual is %v", ErrInvalidDevice, err)
+		}
+	}
+
+	station := NewLocalStation()
+	deviceName, _ := SharedMemoryDevice(1)
+	request, _ := station.BuildReadRequest(deviceName, 10000, 2)
+
+	expected := "500000FFFF03001300100001048000" + "0000" + "102700" + "AB" + "0000" + "E003" + "FA" + "0200"
+	if request != expected {
+		t.Fatalf("expected %v but actual is %v", expected, request)
+	}
+
+	request2, _ := station.BuildReadRequest(`U1\G`, 0, 1)
+	expected2 := "500000FFFF03001300100001048000" + "0000" + "000000" + "AB" + "0000" + "0100" + "F8" + "0100"
+	if request2 != expected2 {
+		t.Fatalf("expected %v but actual is %v", expected2, request2)
+	}
+}
+
+func TestStation_BuildBitWriteRequest(t *testing.T) {
+	station := NewLocalStation()
+	request, err := station.BuildBitWriteRequest("M", 100, 3, []byte{0x10, 0x10})
+	if err != nil {
+		t.Fatalf("unexpected build err: %v", err)
+	}
+
+	expected := "500000FFFF03000E00100001140100640000900300" + "1010"
+	if request != expected {
+		t.Fatalf("expected %v but actual is %v", expected, request)
+	}
+}
+
+func TestStation_BuildWriteRequestShortData(t *testing.T) {
+	station := NewLocalStation()
+
+	if _, err := station.BuildWriteRequest("D", 100, 3, []byte{0x01, 0x02, 0x03, 0x04}); !errors.Is(err, ErrShortWriteData) {
+		t.Fatalf("expected %v but actual is %v", ErrShortWriteData, err)
+	}
+	if _, err := station.BuildBitWriteRequest("M", 100, 5, []byte{0x10, 0x10}); !errors.Is(err, ErrShortWriteData) {
+		t.Fatalf("expected %v but actual is %v", ErrShortWriteData, err)
+	}
+
+	request, err := station.BuildWriteRequest("D", 100, 1, []byte{0x01, 0x02, 0x03})
+	if err != nil {
+		t.Fatalf("unexpected build err: %v", err)
+	}
+	if expected := "500000FFFF03000E00100001140000640000A801000102"; request != expected {
+		t.Fatalf("expected %v but actual is %v", expected, request)
+	}
+}
+
+func TestStation_BuildRequestValidation(t *testing.T) {
+	station := NewLocalStation()
+
+	cases := []struct {
+		deviceName string
+		offset     int64
+		numPoints  int64
+		expected   error
+	}{
+		{deviceName: "D", offset: -1, numPoints: 1, expected: ErrInvalidOffset},
+		{deviceName: "D", offset: 0x1000000, numPoints: 1, expected: ErrInvalidOffset},
+		{deviceName: "RD", offset: 0x100000000, numPoints: 1, expected: ErrInvalidOffset},
+		{deviceName: "D", offset: 0, numPoints: 0, expected: ErrInvalidPoints},
+		{deviceName: "D", offset: 0, numPoints: -1, expected: ErrInvalidPoints},
+		{deviceName: "D", offset: 0, numPoints: 0x10000, expected: ErrInvalidPoints},
+		{deviceName: "QQ", offset: 0, numPoints: 1, expected: ErrInvalidDevice},
+		{deviceName: `J0\W`, offset: 0, numPoints: 1, expected: ErrInvalidDevice},
+		{deviceName: `J1\QQ`, offset: 0, numPoints: 1, expected: ErrInvalidDevice},
+	}
+
+	for _, v := range cases {
+		if _, err := station.BuildReadRequest(v.deviceName, v.offset, v.numPoints); !errors.Is(err, v.expected) {
+			t.Errorf("%v %v %v: expected %v but actual is %v", v.deviceName, v.offset, v.numPoints, v.expected, err)
+		}
+		if _, err := station.BuildWriteRequest(v.deviceName, v.offset, v.numPoints, make([]byte, 2)); !errors.Is(err, v.expected) {
+			t.Errorf("%v %v %v: expected %v but actual is %v", v.deviceName, v.offset, v.numPoints, v.expected, err)
+		}
+	}
+
+	if _, err := station.BuildReadRequest("RD", 0xFFFFFFFF, 1); err != nil {
+		t.Errorf("unexpected err for 4 byte offset: %v", err)
+	}
+}
+
+func TestNewStation(t *testing.T) {
+	station, err := NewStation(1, 2, 0x03E0, 0)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	expected := NewStationHex("01", "02", "E003", "00")
+	if *station != *expected {
+		t.Fatalf("expected %v but actual is %v", expected, station)
+	}
+
+	local, _ := NewStation(0, 0xFF, 0x03FF, 0)
+	if *local != *NewLocalStation() {
+		t.Fatalf("expected %v but actual is %v", NewLocalStation(), local)
+	}
+
+	cases := [][4]int{
+		{-1, 0xFF, 0x03FF, 0},
+		{0x100, 0xFF, 0x03FF, 0},
+		{0, 0x100, 0x03FF, 0},
+		{0, 0xFF, 0x10000, 0},
+		{0, 0xFF, 0x03FF, -1},
+	}
+	for _, v := range cases {
+		if _, err := NewStation(v[0], v[1], v[2], v[3]); !errors.Is(err, ErrInvalidStation) {
+			t.Errorf("%v: expected %v but actual is %v", v, ErrInvalidStation, err)
+		}
+	}
+}
+
+func TestStation_AppendRequest(t *testing.T) {
+	station := NewLocalStation()
+
+	// frame is appended after existing data
+	prefix := []byte{0xAA}
+	frame, err := station.AppendReadRequest(prefix, "D", 500, 50)
+	if err != nil {
+		t.Fatalf("unexpected build err: %v", err)
+	}
+	if actual := fmt.Sprintf("%X", frame); actual != "AA"+"500000FFFF03000C00100001040000F40100A83200" {
+		t.Fatalf("expected %v but actual is %v", "AA"+"500000FFFF03000C00100001040000F40100A83200", actual)
+	}
+
+	frame2, err := station.AppendBitWriteRequest(nil, "M", 100, 3, []byte{0x10, 0x10})
+	if err != nil {
+		t.Fatalf("unexpected build err: %v", err)
+	}
+	if actual := fmt.Sprintf("%X", frame2); actual != "500000FFFF03000E00100001140100640000900300"+"1010" {
+		t.Fatalf("expected %v but actual is %v", "500000FFFF03000E00100001140100640000900300"+"1010", actual)
+	}
+
+	if actual := fmt.Sprintf("%X", station.AppendHealthCheckRequest(nil)); actual != "500000FFFF03000D00100019060000"+"0500"+"4142434445" {
+		t.Fatalf("expected %v but actual is %v", "500000FFFF03000D00100019060000"+"0500"+"4142434445", actual)
+	}
+
+	if _, err := station.AppendWriteRequest(nil, "QQ", 0, 1, []byte{0x00, 0x00}); !errors.Is(err, ErrInvalidDevice) {
+		t.Fatalf("expected %v but actual is %v", ErrInvalidDevice, err)
+	}
+}
@@ -0,0 +1,86 @@
+package mcp
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// ErrStrayFrame is returned when response frame does not match the request, like a late response of timed out request.
+// the stray frame is discarded, so next request is not affected.
+var ErrStrayFrame = errors.New("stray response frame")
+
+// WithDrainTimeout sets how long client waits late responses of timed out requests before next request.
+// 3E frame has no serial number, so late response must be discarded before sending next request. default is 100ms.
+func WithDrainTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.drainTimeout = d
+	}
+}
+
+// drainHelper discards late responses of timed out requests.
+// responses that do not arrive until drain timeout are regarded as lost.
+func (c *client3E) drainHelper(ctx context.Context) error {
+	deadline := time.Now().Add(c.opts.drainTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err := c.conn.SetReadDeadline(deadline); err != nil {
+		return err
+	}
+
+	buff := make([]byte, 64)
+	for c.stale > 0 {
+		frame, err := c.readFrameHelper(buff, nil)
+		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+			c.stale = 0
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		c.dumpHelper("discard", frame)
+		c.stale--
+	}
+	return nil
+}
+
+// expectedDataLen returns data length of normal response to 3E request frame.
+// false is returned when it is unknown.
+func expectedDataLen(request []byte) (int, bool) {
+	if len(request) < 17 {
+		return 0, false
+	}
+	command := binary.LittleEndian.Uint16(request[11:13])
+	subCommand := binary.LittleEndian.Uint16(request[13:15])
+	switch command {
+	case readCommand:
+		// points is the last 2 byte of read request
+		points := int(binary.LittleEndian.Uint16(request[len(request)-2:]))
+		if subCommand&bitReadSubCommand != 0 {
+			return 2 + (points+1)/2, true
+		}
+		return 2 + 2*points, true
+	case writeCommand:
+		return 2, true
+	case healthCheckCommand:
+		// loopback data number[2byte] and loopback data are returned
+		return 2 + 2 + int(binary.LittleEndian.Uint16(request[15:17])), true
+	}
+	return 0, false
+}
+
+// checkResponseHelper verifies resp is response to request. abnormal response is not verified because its length is fixed.
+func checkResponseHelper(request, resp []byte) error {
+	expected, ok := expectedDataLen(request)
+	if !ok || len(resp) < 11 || binary.LittleEndian.Uint16(resp[9:11]) != 0 {
+		return nil
+	}
+	if actual := int(binary.LittleEndian.Uint16(resp[7:9])); actual != expected {
+		return fmt.Errorf("%w: expected data length %v but actual is %v", ErrStrayFrame, expected, actual)
+	}
+	return nil
+}
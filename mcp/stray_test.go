@@ -0,0 +1,63 @@
+package mcp
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClient3E_DrainLateResponse(t *testing.T) {
+	// PLC returns incremented value and answers the first request late
+	var count int32
+	host, port := newTestServer(t, func(req []byte) []byte {
+		n := atomic.AddInt32(&count, 1)
+		if n == 1 {
+			time.Sleep(100 * time.Millisecond)
+		}
+		resp := []byte{0xD0, 0x00, 0x00, 0xFF, 0xFF, 0x03, 0x00, 0x04, 0x00, 0x00, 0x00, 0x00, 0x00}
+		binary.LittleEndian.PutUint16(resp[11:], uint16(n))
+		return resp
+	})
+	client, err := New3EClient(host, port, NewLocalStation(), true, WithDrainTimeout(time.Second))
+	if err != nil {
+		t.Fatalf("unexpected connect err: %v", err)
+	}
+	defer client.ShutDown()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	if _, err := client.ReadContext(ctx, "D", 100, 1); err != context.DeadlineExceeded {
+		t.Fatalf("expected %v but actual is %v", context.DeadlineExceeded, err)
+	}
+
+	// late response of the first request is discarded
+	value, err := client.ReadUint16("D", 100)
+	if err != nil {
+		t.Fatalf("unexpected mcp read err: %v", err)
+	}
+	if value != 2 {
+		t.Fatalf("expected %v but actual is %v", 2, value)
+	}
+}
+
+func TestClient3E_StrayFrame(t *testing.T) {
+	// PLC returns 2 points to read request of 1 point
+	host, port := newTestServer(t, func(req []byte) []byte {
+		return []byte{0xD0, 0x00, 0x00, 0xFF, 0xFF, 0x03, 0x00, 0x06, 0x00, 0x00, 0x00, 0x01, 0x00, 0x02, 0x00}
+	})
+	client, err := New3EClient(host, port, NewLocalStation(), true, WithDrainTimeout(10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("unexpected connect err: %v", err)
+	}
+	defer client.ShutDown()
+
+	if _, err := client.Read("D", 100, 1); !errors.Is(err, ErrStrayFrame) {
+		t.Fatalf("expected %v but actual is %v", ErrStrayFrame, err)
+	}
+	if payload, err := client.Read("D", 100, 2); err != nil || len(payload) != 4 {
+		t.Fatalf("unexpected mcp read result: %X, %v", payload, err)
+	}
+}
@@ -0,0 +1,352 @@
+package mcp
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// structField is a struct field that is mapped to device by mcp struct tag.
+// tag format is `mcp:"D100,int16"`. type is omissible and inferred from field type.
+// string type needs length of characters like `mcp:"D200,string:10"`.
+type structField struct {
+	// field index of struct
+	index int
+	// device name and offset
+	deviceName string
+	offset     int64
+	// data type like int16, float32, string or bool
+	dataType string
+	// number of device points
+	numPoints int64
+	// length of characters of string type
+	length int64
+}
+
+// deviceRange is continuous device points that is accessed by one request.
+type deviceRange struct {
+	deviceName string
+	offset     int64
+	numPoints  int64
+	bit        bool
+	fields     []structField
+}
+
+// ReadStruct reads devices that are mapped to fields of v by mcp struct tag and stores them to v.
+// v must be a pointer to struct. fields on the same device are read together by as few requests as possible.
+func (c *client3E) ReadStruct(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("ReadStruct needs pointer to struct but got " + rv.Kind().String())
+	}
+	rv = rv.Elem()
+
+	fields, err := parseStructFields(rv.Type())
+	if err != nil {
+		return err
+	}
+
+	for _, r := range groupStructFields(fields, MAX_READ_POINTS) {
+		if r.bit {
+			values, err := c.ReadBools(r.deviceName, r.offset, r.numPoints)
+			if err != nil {
+				return err
+			}
+			for _, f := range r.fields {
+				rv.Field(f.index).SetBool(values[f.offset-r.offset])
+			}
+			continue
+		}
+
+		payload, err := c.readWordsHelper(r.deviceName, r.offset, r.numPoints)
+		if err != nil {
+			return err
+		}
+		for _, f := range r.fields {
+			start := 2 * (f.offset - r.offset)
+			if err := decodeStructField(rv.Field(f.index), f, payload[start:start+2*f.numPoints], c.opts.wordOrder, c.opts.shiftJIS); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// WriteStruct writes fields of v that are mapped to devices by mcp struct tag.
+// v must be a struct or a pointer to struct. fields on continuous devices are written together by one request.
+func (c *client3E) WriteStruct(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return errors.New("WriteStruct needs struct but got " + rv.Kind().String())
+	}
+
+	fields, err := parseStructFields(rv.Type())
+	if err != nil {
+		return err
+	}
+
+	// writes must not touch devices between fields, so only continuous fields are merged
+	for _, r := range groupStructFields(fields, 0) {
+		if r.bit {
+			values := make([]bool, r.numPoints)
+			for _, f := range r.fields {
+				values[f.offset-r.offset] = rv.Field(f.index).Bool()
+			}
+			if err := c.WriteBools(r.deviceName, r.offset, values); err != nil {
+				return err
+			}
+			continue
+		}
+
+		writeData := make([]byte, 0, 2*r.numPoints)
+		for _, f := range r.fields {
+			data, err := encodeStructField(rv.Field(f.index), f, c.opts.wordOrder, c.opts.shiftJIS)
+			if err != nil {
+				return err
+			}
+			writeData = append(writeData, data...)
+		}
+		if err := c.writeWordsHelper(r.deviceName, r.offset, writeData); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseStructFields parses mcp struct tags of t. fields without mcp tag are ignored.
+func parseStructFields(t reflect.Type) ([]structField, error) {
+	var fields []structField
+	for i := 0; i < t.NumField(); i++ {
+		tag, ok := t.Field(i).Tag.Lookup("mcp")
+		if !ok || tag == "-" {
+			continue
+		}
+
+		items := strings.Split(tag, ",")
+		deviceName, offset, err := ParseDevice(items[0])
+		if err != nil {
+			return nil, errors.New("field " + t.Field(i).Name + ": " + err.Error())
+		}
+
+		dataType := ""
+		if len(items) > 1 {
+			dataType = items[1]
+		} else {
+			dataType = structFieldType(t.Field(i).Type.Kind())
+		}
+
+		f := structField{index: i, deviceName: deviceName, offset: offset}
+		switch {
+		case dataType == "int16" || dataType == "uint16":
+			f.dataType, f.numPoints = dataType, 1
+		case dataType == "int32" || dataType == "uint32" || dataType == "float32":
+			f.dataType, f.numPoints = dataType, 2
+		case dataType == "float64":
+			f.dataType, f.numPoints = dataType, 4
+		case dataType == "bool":
+			f.dataType, f.numPoints = dataType, 1
+		case strings.HasPrefix(dataType, "string:"):
+			length, err := strconv.ParseInt(strings.TrimPrefix(dataType, "string:"), 10, 64)
+			if err != nil || length <= 0 {
+				return nil, errors.New("field " + t.Field(i).Name + ": invalid string length: " + dataType)
+			}
+			f.dataType, f.length, f.numPoints = "string", length, (length+1)/2
+		default:
+			return nil, errors.New("field " + t.Field(i).Name + ": unsupported type: " + dataType)
+		}
+		for _, g := range fields {
+			// write data of overlapping fields would be longer than their range
+			if g.deviceName == f.deviceName && (g.dataType == "bool") == (f.dataType == "bool") &&
+				f.offset < g.offset+g.numPoints && g.offset < f.offset+f.numPoints {
+				return nil, errors.New("field " + t.Field(i).Name + ": overlaps field " + t.Field(g.index).Name)
+			}
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// structFieldType returns data type inferred from kind of field.
+func structFieldType(kind reflect.Kind) string {
+	switch kind {
+	case reflect.Int16:
+		return "int16"
+	case reflect.Uint16:
+		return "uint16"
+	case reflect.Int32, reflect.Int:
+		return "int32"
+	case reflect.Uint32, reflect.Uint:
+		return "uint32"
+	case reflect.Float32:
+		return "float32"
+	case reflect.Float64:
+		return "float64"
+	case reflect.Bool:
+		return "bool"
+	}
+	return kind.String()
+}
+
+// groupStructFields groups fields to device ranges. fields on the same device are merged into one range
+// while the gap between them is not larger than maxGap and the range is not larger than MAX_READ_POINTS.
+func groupStructFields(fields []structField, maxGap int64) []deviceRange {
+	sorted := append([]structField(nil), fields...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].deviceName != sorted[j].deviceName {
+			return sorted[i].deviceName < sorted[j].deviceName
+		}
+		return sorted[i].offset < sorted[j].offset
+	})
+
+	var ranges []deviceRange
+	for _, f := range sorted {
+		bit := f.dataType == "bool"
+		if n := len(ranges); n > 0 {
+			last := &ranges[n-1]
+			end := last.offset + last.numPoints
+			if last.deviceName == f.deviceName && last.bit == bit && f.offset <= end+maxGap &&
+				f.offset+f.numPoints-last.offset <= MAX_READ_POINTS {
+				if f.offset+f.numPoints > end {
+					last.numPoints = f.offset + f.numPoints - last.offset
+				}
+				last.fields = append(last.fields, f)
+				continue
+			}
+		}
+		ranges = append(ranges, deviceRange{
+			deviceName: f.deviceName,
+			offset:     f.offset,
+			numPoints:  f.numPoints,
+			bit:        bit,
+			fields:     []structField{f},
+		})
+	}
+	return ranges
+}
+
+// decodeStructField decodes little endian words of data and stores to field.
+// multi-word values are arranged by order, and strings are decoded from Shift-JIS when sjis is set.
+func decodeStructField(field reflect.Value, f structField, data []byte, order WordOrder, sjis bool) error {
+	if f.dataType != "string" {
+		data = order.arrange(data)
+	}
+
+	switch f.dataType {
+	case "int16":
+		return setStructField(field, int64(int16(binary.LittleEndian.Uint16(data))))
+	case "uint16":
+		return setStructField(field, int64(binary.LittleEndian.Uint16(data)))
+	case "int32":
+		return setStructField(field, int64(int32(binary.LittleEndian.Uint32(data))))
+	case "uint32":
+		return setStructField(field, int64(binary.LittleEndian.Uint32(data)))
+	case "float32":
+		return setStructField(field, float64(math.Float32frombits(binary.LittleEndian.Uint32(data))))
+	case "float64":
+		return setStructField(field, math.Float64frombits(binary.LittleEndian.Uint64(data)))
+	case "string":
+		text, err := decodeStringHelper(data, f.length, sjis)
+		if err != nil {
+			return err
+		}
+		return setStructField(field, text)
+	}
+	return errors.New("unsupported type: " + f.dataType)
+}
+
+// setStructField stores v to field converting to kind of field.
+func setStructField(field reflect.Value, v interface{}) error {
+	switch field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		switch n := v.(type) {
+		case int64:
+			field.SetInt(n)
+			return nil
+		case float64:
+			field.SetInt(int64(n))
+			return nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		switch n := v.(type) {
+		case int64:
+			field.SetUint(uint64(n))
+			return nil
+		case float64:
+			field.SetUint(uint64(n))
+			return nil
+		}
+	case reflect.Float32, reflect.Float64:
+		switch n := v.(type) {
+		case int64:
+			field.SetFloat(float64(n))
+			return nil
+		case float64:
+			field.SetFloat(n)
+			return nil
+		}
+	case reflect.String:
+		if s, ok := v.(string); ok {
+			field.SetString(s)
+			return nil
+		}
+	}
+	return errors.New(fmt.Sprintf("can not store %T to %v field", v, field.Kind()))
+}
+
+// encodeStructField encodes field to little endian words.
+// multi-word values are arranged by order, and strings are encoded in Shift-JIS when sjis is set.
+func encodeStructField(field reflect.Value, f structField, order WordOrder, sjis bool) ([]byte, error) {
+	data := make([]byte, 2*f.numPoints)
+	switch f.dataType {
+	case "int16", "uint16", "int32", "uint32":
+		var n uint64
+		switch field.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			n = uint64(field.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			n = field.Uint()
+		case reflect.Float32, reflect.Float64:
+			n = uint64(int64(field.Float()))
+		default:
+			return nil, errors.New("can not encode " + field.Kind().String() + " field as " + f.dataType)
+		}
+		if f.numPoints == 1 {
+			binary.LittleEndian.PutUint16(data, uint16(n))
+		} else {
+			binary.LittleEndian.PutUint32(data, uint32(n))
+		}
+	case "float32", "float64":
+		var n float64
+		switch field.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			n = float64(field.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			n = float64(field.Uint())
+		case reflect.Float32, reflect.Float64:
+			n = field.Float()
+		default:
+			return nil, errors.New("can not encode " + field.Kind().String() + " field as " + f.dataType)
+		}
+		if f.dataType == "float32" {
+			binary.LittleEndian.PutUint32(data, math.Float32bits(float32(n)))
+		} else {
+			binary.LittleEndian.PutUint64(data, math.Float64bits(n))
+		}
+	case "string":
+		if field.Kind() != reflect.String {
+			return nil, errors.New("can not encode " + field.Kind().String() + " field as string")
+		}
+		return encodeStringHelper(field.String(), f.length, sjis)
+	default:
+		return nil, errors.New("unsupported type: " + f.dataType)
+	}
+	return order.arrange(data), nil
+}
@@ -0,0 +1,87 @@
+package mcp
+
+import (
+	"github.com/google/go-cmp/cmp"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+type testRecipe struct {
+	Speed   int16   `mcp:"D100,int16"`
+	Count   uint32  `mcp:"D101"`
+	Temp    float32 `mcp:"D110,float32"`
+	Name    string  `mcp:"D120,string:6"`
+	Running bool    `mcp:"M10"`
+	Alarm   bool    `mcp:"M12"`
+	Ignored int
+}
+
+func TestClient3E_Struct(t *testing.T) {
+	mem := newTestMemory()
+	var requests int32
+	host, port := newTestServer(t, func(req []byte) []byte {
+		atomic.AddInt32(&requests, 1)
+		return mem.handle(req)
+	})
+	client, err := New3EClient(host, port, NewLocalStation(), true)
+	if err != nil {
+		t.Fatalf("unexpected connect err: %v", err)
+	}
+	defer client.ShutDown()
+
+	expected := testRecipe{Speed: -5, Count: 70000, Temp: 12.5, Name: "AB12", Running: true, Alarm: true}
+	if err := client.WriteStruct(expected); err != nil {
+		t.Fatalf("unexpected write err: %v", err)
+	}
+	// D100-D102, D110-D111, D120-D122, M10 and M12. M11 must not be written.
+	if n := atomic.SwapInt32(&requests, 0); n != 5 {
+		t.Fatalf("expected %v writes but actual is %v", 5, n)
+	}
+
+	var actual testRecipe
+	if err := client.ReadStruct(&actual); err != nil {
+		t.Fatalf("unexpected read err: %v", err)
+	}
+	// D100-D122 and M10-M12
+	if n := atomic.SwapInt32(&requests, 0); n != 2 {
+		t.Fatalf("expected %v reads but actual is %v", 2, n)
+	}
+	if diff := cmp.Diff(actual, expected); diff != "" {
+		t.Errorf("struct differs: (-got +want)\n%s", diff)
+	}
+}
+
+func TestClient3E_StructInvalidTag(t *testing.T) {
+	client, mem := newTestMemoryClient(t)
+
+	var v1 struct {
+		A int16 `mcp:"100"`
+	}
+	if err := client.ReadStruct(&v1); err == nil {
+		t.Errorf("expected error for invalid device")
+	}
+
+	var v2 struct {
+		A string `mcp:"D100"`
+	}
+	if err := client.ReadStruct(&v2); err == nil {
+		t.Errorf("expected error for string without length")
+	}
+
+	if err := client.ReadStruct(testRecipe{}); err == nil {
+		t.Errorf("expected error for non pointer")
+	}
+
+	// D101 is upper word of A and B, so write data would overrun D102
+	v3 := struct {
+		A int32 `mcp:"D100"`
+		B int16 `mcp:"D101"`
+	}{A: 1, B: 2}
+	if err := client.WriteStruct(v3); err == nil || !strings.Contains(err.Error(), "overlaps") {
+		t.Errorf("expected error for overlapping fields but actual is %v", err)
+	}
+	if got := mem.words[[2]int64{0xA8, 102}]; got != 0 {
+		t.Errorf("expected %v but actual is %v", 0, got)
+	}
+}
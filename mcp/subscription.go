@@ -0,0 +1,137 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"time"
+)
+
+// Update is value of a device range polled by Subscription.
+type Update struct {
+	// index of the range in ranges given to Subscribe
+	Index int
+	// the range
+	Request Request
+	// time when the range is read
+	Time time.Time
+	// payload of response. it is nil when Err is set
+	Payload []byte
+	// error of the read. it may be *EndCodeError
+	Err error
+}
+
+// SubscribeOption configures Subscription.
+type SubscribeOption func(*subscribeOptions)
+
+type subscribeOptions struct {
+	// reports whether payload of range of index changed from last sent payload. nil sends all updates
+	changed    func(index int, last, payload []byte) bool
+	bufferSize int
+}
+
+// OnlyChanges sends updates only when payload of a range changes or its read fails.
+// the first value of each range is always sent.
+func OnlyChanges() SubscribeOption {
+	return func(o *subscribeOptions) {
+		o.changed = func(index int, last, payload []byte) bool {
+			return !bytes.Equal(last, payload)
+		}
+	}
+}
+
+// WithChangeFilter sends updates only when changed reports that payload of range of index changed from
+// the payload last sent, or its read fails. it replaces comparison of OnlyChanges, e.g. by deadband (see TagDB.Deadband).
+// the first value of each range is always sent.
+func WithChangeFilter(changed func(index int, last, payload []byte) bool) SubscribeOption {
+	return func(o *subscribeOptions) {
+		o.changed = changed
+	}
+}
+
+// WithUpdateBuffer sets buffer size of channel of updates. default is number of ranges.
+// polling waits while the buffer is full, so slow consumer slows down the scan.
+func WithUpdateBuffer(size int) SubscribeOption {
+	return func(o *subscribeOptions) {
+		o.bufferSize = size
+	}
+}
+
+// Subscription polls device ranges at an interval and sends their values to C.
+type Subscription struct {
+	// C receives updates. it is closed when subscription stops.
+	C <-chan Update
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Subscribe starts polling of ranges at interval until ctx is done or Close is called.
+// ranges must be read requests (OpRead or OpBitRead) and they are read in order in each scan.
+func Subscribe(ctx context.Context, client Client, interval time.Duration, ranges []Request, opts ...SubscribeOption) (*Subscription, error) {
+	if interval <= 0 {
+		return nil, errors.New("scan interval must be positive")
+	}
+	o := subscribeOptions{bufferSize: len(ranges)}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	prepared := make([]*PreparedRequest, len(ranges))
+	for i, r := range ranges {
+		if r.Op != OpRead && r.Op != OpBitRead {
+			return nil, errors.New("subscription range must be read request: " + r.Op.String())
+		}
+		p, err := client.Prepare(r)
+		if err != nil {
+			return nil, err
+		}
+		prepared[i] = p
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	ch := make(chan Update, o.bufferSize)
+	s := &Subscription{C: ch, cancel: cancel, done: make(chan struct{})}
+	go s.run(ctx, ch, interval, prepared, o)
+	return s, nil
+}
+
+// Close stops polling and waits until C is closed.
+func (s *Subscription) Close() {
+	s.cancel()
+	<-s.done
+}
+
+func (s *Subscription) run(ctx context.Context, ch chan<- Update, interval time.Duration, prepared []*PreparedRequest, o subscribeOptions) {
+	defer close(s.done)
+	defer close(ch)
+
+	// last sent payload of each range. nil means the range has no value or its last read failed
+	last := make([][]byte, len(prepared))
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		for i, p := range prepared {
+			payload, err := p.Do(ctx)
+			if ctx.Err() != nil {
+				return
+			}
+			if o.changed != nil && err == nil && last[i] != nil && !o.changed(i, last[i], payload) {
+				continue
+			}
+			last[i] = payload
+
+			select {
+			case ch <- Update{Index: i, Request: p.Request(), Time: time.Now(), Payload: payload, Err: err}:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSubscribe(t *testing.T) {
+	client, mem := newTestMemoryClient(t)
+	mem.words[[2]int64{0xA8, 100}] = 1
+	mem.bits[[2]int64{0x90, 10}] = true
+
+	ranges := []Request{
+		{Op: OpRead, DeviceName: "D", Offset: 100, NumPoints: 1},
+		{Op: OpBitRead, DeviceName: "M", Offset: 10, NumPoints: 1},
+	}
+	s, err := Subscribe(context.Background(), client, 10*time.Millisecond, ranges, OnlyChanges())
+	if err != nil {
+		t.Fatalf("unexpected subscribe err: %v", err)
+	}
+	defer s.Close()
+
+	// the first values of all ranges
+	var got []Update
+	for i := 0; i < 2; i++ {
+		got = append(got, <-s.C)
+	}
+	// memory of test PLC is changed by client because it is not safe to change it concurrently
+	if err := client.WriteUint16("D", 100, 2); err != nil {
+		t.Fatalf("unexpected mcp write err: %v", err)
+	}
+	got = append(got, <-s.C)
+
+	var values []string
+	for _, u := range got {
+		if u.Err != nil {
+			t.Fatalf("unexpected update err: %v", u.Err)
+		}
+		if u.Time.IsZero() {
+			t.Errorf("time of update is not set")
+		}
+		values = append(values, fmt.Sprintf("%v %X", u.Request.DeviceName, u.Payload))
+	}
+	// M10 is not sent again because it does not change
+	expected := []string{"D 0100", "M 10", "D 0200"}
+	if diff := cmp.Diff(values, expected); diff != "" {
+		t.Errorf("updates differs: (-got +want)\n%s", diff)
+	}
+
+	s.Close()
+	for range s.C {
+		// drain updates sent before close
+	}
+}
+
+func TestSubscribeValidation(t *testing.T) {
+	client, _ := newTestMemoryClient(t)
+	if _, err := Subscribe(context.Background(), client, 0, nil); err == nil {
+		t.Fatalf("expected error for zero interval")
+	}
+	if _, err := Subscribe(context.Background(), client, time.Second, []Request{{Op: OpWrite, DeviceName: "D", NumPoints: 1}}); err == nil {
+		t.Fatalf("expected error for write request")
+	}
+}
@@ -0,0 +1,448 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Tag is named device with data type and scaling, like FurnaceTemp that is D1500 as float32.
+type Tag struct {
+	Name string `json:"name" yaml:"name"`
+	// device address like D1500, X1F or J1\W10
+	Device string `json:"device" yaml:"device"`
+	// data type like int16, uint16, int32, uint32, float32, float64, bool or string:10. default is int16
+	Type string `json:"type,omitempty" yaml:"type,omitempty"`
+	// numeric value is raw*Scale+Offset. zero Scale means 1
+	Scale  float64 `json:"scale,omitempty" yaml:"scale,omitempty"`
+	Offset float64 `json:"offset,omitempty" yaml:"offset,omitempty"`
+	// linear scaling from raw range to engineering range that is used instead of Scale and Offset.
+	// e.g. analog input of 4-20 mA converted to raw 0-32000 that means 0-300 degC is RawMax 32000 and EngMax 300.
+	RawMin float64 `json:"raw_min,omitempty" yaml:"raw_min,omitempty"`
+	RawMax float64 `json:"raw_max,omitempty" yaml:"raw_max,omitempty"`
+	EngMin float64 `json:"eng_min,omitempty" yaml:"eng_min,omitempty"`
+	EngMax float64 `json:"eng_max,omitempty" yaml:"eng_max,omitempty"`
+	// number of decimal places that read value is rounded to. nil means no rounding
+	Precision *int `json:"precision,omitempty" yaml:"precision,omitempty"`
+	// subscription by TagDB.Deadband ignores changes of numeric value within Deadband in engineering unit
+	// or within DeadbandPercent of engineering range (of last value when tag has no raw range)
+	Deadband        float64 `json:"deadband,omitempty" yaml:"deadband,omitempty"`
+	DeadbandPercent float64 `json:"deadband_percent,omitempty" yaml:"deadband_percent,omitempty"`
+	// unit like degC
+	Unit    string `json:"unit,omitempty" yaml:"unit,omitempty"`
+	Comment string `json:"comment,omitempty" yaml:"comment,omitempty"`
+
+	// parsed device and type
+	deviceName string
+	offset     int64
+	dataType   string
+	length     int64
+}
+
+// TagDB is set of tags that can be read and written by name.
+type TagDB struct {
+	tags  map[string]*Tag
+	names []string
+	// strings of Encode and Decode are Shift-JIS
+	shiftJIS bool
+}
+
+// NewTagDB returns TagDB of tags. tag with invalid device or type and duplicated name are errors.
+func NewTagDB(tags []Tag) (*TagDB, error) {
+	db := &TagDB{tags: map[string]*Tag{}}
+	for _, t := range tags {
+		t := t
+		if t.Name == "" {
+			return nil, errors.New("tag name is empty: " + t.Device)
+		}
+		if _, ok := db.tags[t.Name]; ok {
+			return nil, errors.New("tag is duplicated: " + t.Name)
+		}
+		if err := t.parse(); err != nil {
+			return nil, errors.New("tag " + t.Name + ": " + err.Error())
+		}
+		db.tags[t.Name] = &t
+		db.names = append(db.names, t.Name)
+	}
+	return db, nil
+}
+
+// LoadTags loads tags from JSON array of Tag.
+func LoadTags(r io.Reader) (*TagDB, error) {
+	var tags []Tag
+	if err := json.NewDecoder(r).Decode(&tags); err != nil {
+		return nil, err
+	}
+	return NewTagDB(tags)
+}
+
+// LoadTagsYAML loads tags from YAML sequence of Tag.
+func LoadTagsYAML(r io.Reader) (*TagDB, error) {
+	var tags []Tag
+	if err := yaml.NewDecoder(r).Decode(&tags); err != nil {
+		return nil, err
+	}
+	return NewTagDB(tags)
+}
+
+// LoadTagFile loads tags from file. file of .yaml or .yml extension is YAML, others are JSON.
+func LoadTagFile(path string) (*TagDB, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return LoadTagsYAML(f)
+	}
+	return LoadTags(f)
+}
+
+// SetShiftJIS makes Encode and Decode of string tags use Shift-JIS like client of WithShiftJIS.
+// Read and Write use the encoding of the given client.
+func (db *TagDB) SetShiftJIS(on bool) {
+	db.shiftJIS = on
+}
+
+// Tag returns tag of name.
+func (db *TagDB) Tag(name string) (Tag, bool) {
+	t, ok := db.tags[name]
+	if !ok {
+		return Tag{}, false
+	}
+	return *t, true
+}
+
+// Tags returns all tags in the order they are given.
+func (db *TagDB) Tags() []Tag {
+	tags := make([]Tag, len(db.names))
+	for i, name := range db.names {
+		tags[i] = *db.tags[name]
+	}
+	return tags
+}
+
+// Read reads tag of name by c. numeric value is returned as float64 that is scaled,
+// bool tag returns bool and string tag returns string.
+func (db *TagDB) Read(c Client, name string) (any, error) {
+	t, ok := db.tags[name]
+	if !ok {
+		return nil, errors.New("unknown tag: " + name)
+	}
+
+	var raw float64
+	switch t.dataType {
+	case "bool":
+		values, err := c.ReadBools(t.deviceName, t.offset, 1)
+		if err != nil {
+			return nil, err
+		}
+		return values[0], nil
+	case "string":
+		return c.ReadString(t.deviceName, t.offset, t.length)
+	case "int16":
+		v, err := c.ReadInt16(t.deviceName, t.offset)
+		if err != nil {
+			return nil, err
+		}
+		raw = float64(v)
+	case "uint16":
+		v, err := c.ReadUint16(t.deviceName, t.offset)
+		if err != nil {
+			return nil, err
+		}
+		raw = float64(v)
+	case "int32":
+		v, err := c.ReadInt32(t.deviceName, t.offset)
+		if err != nil {
+			return nil, err
+		}
+		raw = float64(v)
+	case "uint32":
+		v, err := c.ReadUint32(t.deviceName, t.offset)
+		if err != nil {
+			return nil, err
+		}
+		raw = float64(v)
+	case "float32":
+		v, err := c.ReadFloat32(t.deviceName, t.offset)
+		if err != nil {
+			return nil, err
+		}
+		raw = float64(v)
+	case "float64":
+		v, err := c.ReadFloat64(t.deviceName, t.offset)
+		if err != nil {
+			return nil, err
+		}
+		raw = v
+	}
+	return t.toEng(raw), nil
+}
+
+// Request returns read request of devices of tag, like ranges of Subscribe.
+func (db *TagDB) Request(name string) (Request, error) {
+	t, ok := db.tags[name]
+	if !ok {
+		return Request{}, errors.New("unknown tag: " + name)
+	}
+
+	r := Request{Op: OpRead, DeviceName: t.deviceName, Offset: t.offset}
+	switch t.dataType {
+	case "bool":
+		r.Op, r.NumPoints = OpBitRead, 1
+	case "int16", "uint16":
+		r.NumPoints = 1
+	case "int32", "uint32", "float32":
+		r.NumPoints = 2
+	case "float64":
+		r.NumPoints = 4
+	case "string":
+		r.NumPoints = (t.length + 1) / 2
+	}
+	return r, nil
+}
+
+// Decode decodes payload of Request of tag to value like Read. order is word order of multi-word values,
+// and strings are decoded from Shift-JIS after SetShiftJIS.
+func (db *TagDB) Decode(name string, payload []byte, order WordOrder) (any, error) {
+	r, err := db.Request(name)
+	if err != nil {
+		return nil, err
+	}
+	t := db.tags[name]
+	if int64(len(payload)) != WriteDataLen(r.NumPoints, r.Op == OpBitRead) {
+		return nil, fmt.Errorf("invalid payload length of tag %v: %v byte", name, len(payload))
+	}
+
+	var raw float64
+	switch t.dataType {
+	case "bool":
+		return DecodeBits(payload, 1)[0], nil
+	case "string":
+		return decodeStringHelper(payload, t.length, db.shiftJIS)
+	case "int16":
+		raw = float64(int16(binary.LittleEndian.Uint16(payload)))
+	case "uint16":
+		raw = float64(binary.LittleEndian.Uint16(payload))
+	case "int32":
+		raw = float64(int32(binary.LittleEndian.Uint32(order.arrange(payload))))
+	case "uint32":
+		raw = float64(binary.LittleEndian.Uint32(order.arrange(payload)))
+	case "float32":
+		raw = float64(math.Float32frombits(binary.LittleEndian.Uint32(order.arrange(payload))))
+	case "float64":
+		raw = math.Float64frombits(binary.LittleEndian.Uint64(order.arrange(payload)))
+	}
+	return t.toEng(raw), nil
+}
+
+// Deadband returns SubscribeOption that sends updates of tags only when their values change over their deadbands.
+// names are tags of ranges given to Subscribe in the same order, like ranges built by Request.
+// bool and string tags, and tags without deadband are sent on any change. order is word order of multi-word values.
+func (db *TagDB) Deadband(order WordOrder, names ...string) SubscribeOption {
+	return WithChangeFilter(func(index int, last, payload []byte) bool {
+		if index >= len(names) {
+			return !bytes.Equal(last, payload)
+		}
+		t, ok := db.tags[names[index]]
+		if !ok {
+			return !bytes.Equal(last, payload)
+		}
+		lastValue, err1 := db.Decode(t.Name, last, order)
+		value, err2 := db.Decode(t.Name, payload, order)
+		lastNum, ok1 := lastValue.(float64)
+		num, ok2 := value.(float64)
+		if err1 != nil || err2 != nil || !ok1 || !ok2 {
+			return !bytes.Equal(last, payload)
+		}
+		return math.Abs(num-lastNum) > t.deadband(lastNum)
+	})
+}
+
+// Write writes value to tag of name by c. numeric value is unscaled to raw value and rounded to integer type of tag.
+// value of numeric tag can be any integer or float type, bool tag needs bool and string tag needs string.
+func (db *TagDB) Write(c Client, name string, value any) error {
+	t, ok := db.tags[name]
+	if !ok {
+		return errors.New("unknown tag: " + name)
+	}
+
+	switch t.dataType {
+	case "bool":
+		v, ok := value.(bool)
+		if !ok {
+			return fmt.Errorf("tag %v needs bool but got %T", name, value)
+		}
+		return c.WriteBools(t.deviceName, t.offset, []bool{v})
+	case "string":
+		v, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("tag %v needs string but got %T", name, value)
+		}
+		return c.WriteString(t.deviceName, t.offset, t.length, v)
+	}
+
+	v, ok := toFloat64(value)
+	if !ok {
+		return fmt.Errorf("tag %v needs number but got %T", name, value)
+	}
+	raw := t.toRaw(v)
+	switch t.dataType {
+	case "int16":
+		if raw = math.Round(raw); raw < math.MinInt16 || raw > math.MaxInt16 {
+			return fmt.Errorf("tag %v: %v overflows int16", name, value)
+		}
+		return c.WriteInt16(t.deviceName, t.offset, int16(raw))
+	case "uint16":
+		if raw = math.Round(raw); raw < 0 || raw > math.MaxUint16 {
+			return fmt.Errorf("tag %v: %v overflows uint16", name, value)
+		}
+		return c.WriteUint16(t.deviceName, t.offset, uint16(raw))
+	case "int32":
+		if raw = math.Round(raw); raw < math.MinInt32 || raw > math.MaxInt32 {
+			return fmt.Errorf("tag %v: %v overflows int32", name, value)
+		}
+		return c.WriteInt32(t.deviceName, t.offset, int32(raw))
+	case "uint32":
+		if raw = math.Round(raw); raw < 0 || raw > math.MaxUint32 {
+			return fmt.Errorf("tag %v: %v overflows uint32", name, value)
+		}
+		return c.WriteUint32(t.deviceName, t.offset, uint32(raw))
+	case "float32":
+		return c.WriteFloat32(t.deviceName, t.offset, float32(raw))
+	}
+	return c.WriteFloat64(t.deviceName, t.offset, raw)
+}
+
+// parse parses device and type of tag.
+func (t *Tag) parse() error {
+	deviceName, offset, err := ParseDevice(t.Device)
+	if err != nil {
+		return err
+	}
+	// validate device name and offset by building a request
+	if _, err := NewLocalStation().AppendReadRequest(nil, deviceName, offset, 1); err != nil {
+		return err
+	}
+	t.deviceName, t.offset = deviceName, offset
+
+	dataType := t.Type
+	if dataType == "" {
+		dataType = "int16"
+	}
+	switch {
+	case dataType == "int16" || dataType == "uint16" || dataType == "int32" || dataType == "uint32" ||
+		dataType == "float32" || dataType == "float64" || dataType == "bool":
+		t.dataType = dataType
+	case strings.HasPrefix(dataType, "string:"):
+		length, err := strconv.ParseInt(strings.TrimPrefix(dataType, "string:"), 10, 64)
+		if err != nil || length <= 0 {
+			return errors.New("invalid string length: " + dataType)
+		}
+		t.dataType, t.length = "string", length
+	default:
+		return errors.New("unsupported type: " + dataType)
+	}
+
+	if t.RawMin != t.RawMax {
+		if t.Scale != 0 || t.Offset != 0 {
+			return errors.New("scale and offset can not be used with raw range")
+		}
+		if t.EngMin == t.EngMax {
+			return errors.New("engineering range is empty")
+		}
+	} else if t.EngMin != 0 || t.EngMax != 0 {
+		return errors.New("engineering range needs raw range")
+	}
+	if t.Deadband < 0 || t.DeadbandPercent < 0 {
+		return errors.New("deadband is negative")
+	}
+	if t.Precision != nil && *t.Precision < 0 {
+		return errors.New("precision is negative: " + strconv.Itoa(*t.Precision))
+	}
+	return nil
+}
+
+func (t *Tag) scale() float64 {
+	if t.Scale == 0 {
+		return 1
+	}
+	return t.Scale
+}
+
+// deadband returns width of deadband around last value.
+func (t *Tag) deadband(last float64) float64 {
+	if t.DeadbandPercent == 0 {
+		return t.Deadband
+	}
+	span := math.Abs(last)
+	if t.RawMin != t.RawMax {
+		span = math.Abs(t.EngMax - t.EngMin)
+	}
+	return math.Max(t.Deadband, span*t.DeadbandPercent/100)
+}
+
+// toEng converts raw value to engineering value rounded to Precision.
+func (t *Tag) toEng(raw float64) float64 {
+	v := raw*t.scale() + t.Offset
+	if t.RawMin != t.RawMax {
+		v = t.EngMin + (raw-t.RawMin)*(t.EngMax-t.EngMin)/(t.RawMax-t.RawMin)
+	}
+	if t.Precision != nil {
+		p := math.Pow10(*t.Precision)
+		v = math.Round(v*p) / p
+	}
+	return v
+}
+
+// toRaw converts engineering value to raw value. it is the inverse of toEng without rounding.
+func (t *Tag) toRaw(v float64) float64 {
+	if t.RawMin != t.RawMax {
+		return t.RawMin + (v-t.EngMin)*(t.RawMax-t.RawMin)/(t.EngMax-t.EngMin)
+	}
+	return (v - t.Offset) / t.scale()
+}
+
+// toFloat64 converts number of any integer or float type to float64.
+func toFloat64(value any) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int8:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
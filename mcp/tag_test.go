@@ -0,0 +1,223 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestLoadTags(t *testing.T) {
+	jsonDB, err := LoadTags(strings.NewReader(`[
+		{"name": "FurnaceTemp", "device": "D1500", "type": "float32", "unit": "degC"},
+		{"name": "Pressure", "device": "D1502", "scale": 0.1, "offset": -10},
+		{"name": "Running", "device": "M100", "type": "bool"}
+	]`))
+	if err != nil {
+		t.Fatalf("unexpected load err: %v", err)
+	}
+
+	yamlDB, err := LoadTagsYAML(strings.NewReader(`
+- name: FurnaceTemp
+  device: D1500
+  type: float32
+  unit: degC
+- name: Pressure
+  device: D1502
+  scale: 0.1
+  offset: -10
+- name: Running
+  device: M100
+  type: bool
+`))
+	if err != nil {
+		t.Fatalf("unexpected load err: %v", err)
+	}
+
+	expected := []Tag{
+		{Name: "FurnaceTemp", Device: "D1500", Type: "float32", Unit: "degC"},
+		{Name: "Pressure", Device: "D1502", Scale: 0.1, Offset: -10},
+		{Name: "Running", Device: "M100", Type: "bool"},
+	}
+	for _, db := range []*TagDB{jsonDB, yamlDB} {
+		if diff := cmp.Diff(db.Tags(), expected, cmpopts.IgnoreUnexported(Tag{})); diff != "" {
+			t.Errorf("tags differs: (-got +want)\n%s", diff)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "tags.yml")
+	if err := os.WriteFile(path, []byte("- name: Speed\n  device: W1F\n  type: uint16\n"), 0o644); err != nil {
+		t.Fatalf("failed to write tag file: %v", err)
+	}
+	fileDB, err := LoadTagFile(path)
+	if err != nil {
+		t.Fatalf("unexpected load err: %v", err)
+	}
+	if tag, ok := fileDB.Tag("Speed"); !ok || tag.Device != "W1F" {
+		t.Fatalf("expected tag Speed but actual is %v, %v", tag, ok)
+	}
+
+	cases := []string{
+		`[{"name": "A", "device": "D1"}, {"name": "A", "device": "D2"}]`,
+		`[{"name": "A", "device": "QQ1"}]`,
+		`[{"name": "A", "device": "D1", "type": "int64"}]`,
+		`[{"name": "", "device": "D1"}]`,
+		`[{"name": "A", "device": "D1", "scale": 0.1, "raw_max": 32000, "eng_max": 300}]`,
+		`[{"name": "A", "device": "D1", "raw_max": 32000}]`,
+		`[{"name": "A", "device": "D1", "eng_max": 300}]`,
+		`[{"name": "A", "device": "D1", "precision": -1}]`,
+	}
+	for _, v := range cases {
+		if _, err := LoadTags(strings.NewReader(v)); err == nil {
+			t.Errorf("%v: expected error but actual is nil", v)
+		}
+	}
+}
+
+func TestTagDB_ReadWrite(t *testing.T) {
+	client, _ := newTestMemoryClient(t)
+	db, err := NewTagDB([]Tag{
+		{Name: "FurnaceTemp", Device: "D1500", Type: "float32"},
+		{Name: "Pressure", Device: "D1502", Scale: 0.1, Offset: -10},
+		{Name: "Running", Device: "M100", Type: "bool"},
+		{Name: "Recipe", Device: "D1600", Type: "string:6"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected tag err: %v", err)
+	}
+
+	values := map[string]any{"FurnaceTemp": 812.5, "Pressure": 2.3, "Running": true, "Recipe": "ABC"}
+	for name, v := range values {
+		if err := db.Write(client, name, v); err != nil {
+			t.Fatalf("unexpected write err of %v: %v", name, err)
+		}
+	}
+	for name, v := range values {
+		actual, err := db.Read(client, name)
+		if err != nil {
+			t.Fatalf("unexpected read err of %v: %v", name, err)
+		}
+		if diff := cmp.Diff(actual, v, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+			t.Errorf("%v differs: (-got +want)\n%s", name, diff)
+		}
+	}
+
+	// raw value of Pressure is (2.3 - -10) / 0.1
+	if raw, err := client.ReadInt16("D", 1502); err != nil || raw != 123 {
+		t.Fatalf("expected %v but actual is %v, %v", 123, raw, err)
+	}
+	if err := db.Write(client, "Pressure", 4000); err == nil {
+		t.Fatalf("expected overflow error but actual is nil")
+	}
+	if err := db.Write(client, "Running", 1); err == nil {
+		t.Fatalf("expected type error but actual is nil")
+	}
+	if _, err := db.Read(client, "Unknown"); err == nil {
+		t.Fatalf("expected unknown tag error but actual is nil")
+	}
+}
+
+func TestTagDB_Scaling(t *testing.T) {
+	client, _ := newTestMemoryClient(t)
+	precision := 1
+	db, err := NewTagDB([]Tag{
+		// 4-20 mA of 0-300 degC converted to raw 0-32000
+		{Name: "Temp", Device: "D100", RawMax: 32000, EngMax: 300, Unit: "degC", Precision: &precision},
+		{Name: "Level", Device: "D101", RawMin: 6400, RawMax: 32000, EngMin: -50, EngMax: 50},
+	})
+	if err != nil {
+		t.Fatalf("unexpected tag err: %v", err)
+	}
+
+	if err := db.Write(client, "Temp", 150); err != nil {
+		t.Fatalf("unexpected write err: %v", err)
+	}
+	if raw, err := client.ReadInt16("D", 100); err != nil || raw != 16000 {
+		t.Fatalf("expected %v but actual is %v, %v", 16000, raw, err)
+	}
+	if err := db.Write(client, "Level", 0); err != nil {
+		t.Fatalf("unexpected write err: %v", err)
+	}
+	if raw, err := client.ReadInt16("D", 101); err != nil || raw != 19200 {
+		t.Fatalf("expected %v but actual is %v, %v", 19200, raw, err)
+	}
+
+	// raw 12345 is 115.734375 degC that is rounded to 1 decimal place
+	if err := client.WriteInt16("D", 100, 12345); err != nil {
+		t.Fatalf("unexpected mcp write err: %v", err)
+	}
+	if v, err := db.Read(client, "Temp"); err != nil || v != 115.7 {
+		t.Fatalf("expected %v but actual is %v, %v", 115.7, v, err)
+	}
+	if v, err := db.Decode("Temp", []byte{0x39, 0x30}, LowWordFirst); err != nil || v != 115.7 {
+		t.Fatalf("expected %v but actual is %v, %v", 115.7, v, err)
+	}
+	if v, err := db.Read(client, "Level"); err != nil || v != 0.0 {
+		t.Fatalf("expected %v but actual is %v, %v", 0.0, v, err)
+	}
+}
+
+func TestTagDB_Deadband(t *testing.T) {
+	db, err := NewTagDB([]Tag{
+		{Name: "Temp", Device: "D100", Deadband: 5},
+		{Name: "Level", Device: "D101", RawMax: 1000, EngMax: 100, DeadbandPercent: 2},
+		{Name: "Flow", Device: "D102", DeadbandPercent: 10},
+		{Name: "Running", Device: "M10", Type: "bool"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected tag err: %v", err)
+	}
+	var o subscribeOptions
+	db.Deadband(LowWordFirst, "Temp", "Level", "Flow", "Running")(&o)
+
+	word := func(v uint16) []byte { return []byte{byte(v), byte(v >> 8)} }
+	cases := []struct {
+		index       int
+		last, value []byte
+		changed     bool
+	}{
+		{index: 0, last: word(100), value: word(105)},
+		{index: 0, last: word(100), value: word(94), changed: true},
+		// 2% of engineering range 0-100 is 2 that is raw 20
+		{index: 1, last: word(500), value: word(520)},
+		{index: 1, last: word(500), value: word(521), changed: true},
+		// 10% of last value
+		{index: 2, last: word(200), value: word(180)},
+		{index: 2, last: word(200), value: word(221), changed: true},
+		{index: 3, last: []byte{0x10}, value: []byte{0x10}},
+		{index: 3, last: []byte{0x10}, value: []byte{0x00}, changed: true},
+	}
+	for _, v := range cases {
+		if changed := o.changed(v.index, v.last, v.value); changed != v.changed {
+			t.Errorf("expected %v but actual is %v: index=%v last=%X value=%X", v.changed, changed, v.index, v.last, v.value)
+		}
+	}
+
+	// value is compared with the last sent value, so slow drift is sent when it exceeds deadband
+	client, _ := newTestMemoryClient(t)
+	r, err := db.Request("Temp")
+	if err != nil {
+		t.Fatalf("unexpected request err: %v", err)
+	}
+	s, err := Subscribe(context.Background(), client, 10*time.Millisecond, []Request{r}, db.Deadband(LowWordFirst, "Temp"))
+	if err != nil {
+		t.Fatalf("unexpected subscribe err: %v", err)
+	}
+	defer s.Close()
+	<-s.C
+	for _, v := range []int16{3, 6} {
+		if err := client.WriteInt16("D", 100, v); err != nil {
+			t.Fatalf("unexpected mcp write err: %v", err)
+		}
+		time.Sleep(30 * time.Millisecond)
+	}
+	if u := <-s.C; u.Err != nil || !bytes.Equal(u.Payload, word(6)) {
+		t.Fatalf("expected %X but actual is %X, %v", word(6), u.Payload, u.Err)
+	}
+}
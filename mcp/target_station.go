@@ -0,0 +1,25 @@
+package mcp
+
+import "context"
+
+// stationKey is context key of station given by WithTargetStation.
+type stationKey struct{}
+
+// WithTargetStation returns ctx that makes context aware methods like ReadContext and WriteContext of client
+// access stn instead of the station of the client. one connection to an Ethernet module can relay requests to
+// multiple PLCs of the network behind it, like other stations on CC-Link IE.
+// methods without context like Read and ReadRaw always access the station of the client.
+func WithTargetStation(ctx context.Context, stn *station) context.Context {
+	return context.WithValue(ctx, stationKey{}, stn)
+}
+
+// stationHelper returns station given to ctx by WithTargetStation, or station of the client.
+func (c *client3E) stationHelper(ctx context.Context) *station {
+	if stn, ok := ctx.Value(stationKey{}).(*station); ok && stn != nil {
+		if c.opts.slmp && !stn.slmp {
+			return stn.slmpHelper()
+		}
+		return stn
+	}
+	return c.stn
+}
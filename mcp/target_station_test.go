@@ -0,0 +1,102 @@
+package mcp
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestClient3E_TargetStation(t *testing.T) {
+	// relay that has device memory of own station and station of network 1
+	local, remote := newTestMemory(), newTestMemory()
+	host, port := newTestServer(t, func(req []byte) []byte {
+		if req[2] == 0x01 {
+			return remote.handle(req)
+		}
+		return local.handle(req)
+	})
+	stn, err := NewStation(1, 2, 0x03FF, 0)
+	if err != nil {
+		t.Fatalf("unexpected station err: %v", err)
+	}
+
+	for _, opts := range [][]Option{nil, {WithFrame4E()}, {WithReadCache(time.Minute)}} {
+		client, err := New3EClient(host, port, NewLocalStation(), true, opts...)
+		if err != nil {
+			t.Fatalf("unexpected connect err: %v", err)
+		}
+		defer client.ShutDown()
+
+		ctx := context.Background()
+		other := WithTargetStation(ctx, stn)
+		if _, err := client.WriteContext(ctx, "D", 100, 1, []byte{0x01, 0x00}); err != nil {
+			t.Fatalf("unexpected write err: %v", err)
+		}
+		if _, err := client.WriteContext(other, "D", 100, 1, []byte{0x02, 0x00}); err != nil {
+			t.Fatalf("unexpected write err: %v", err)
+		}
+
+		for _, v := range []struct {
+			ctx      context.Context
+			expected byte
+		}{{ctx, 0x01}, {other, 0x02}, {ctx, 0x01}, {other, 0x02}} {
+			payload, err := client.ReadContext(v.ctx, "D", 100, 1)
+			if err != nil {
+				t.Fatalf("unexpected read err: %v", err)
+			}
+			if payload[0] != v.expected {
+				t.Fatalf("expected %v but actual is %v", v.expected, payload[0])
+			}
+		}
+
+		results, err := client.BatchContext(other, []Request{{Op: OpRead, DeviceName: "D", Offset: 100, NumPoints: 1}})
+		if err != nil {
+			t.Fatalf("unexpected batch err: %v", err)
+		}
+		if results[0].Payload[0] != 0x02 {
+			t.Fatalf("expected %v but actual is %v", 0x02, results[0].Payload[0])
+		}
+	}
+}
+
+func TestClient3E_TargetStationRaw(t *testing.T) {
+	local, remote := newTestMemory(), newTestMemory()
+	host, port := newTestServer(t, func(req []byte) []byte {
+		if req[2] == 0x01 {
+			return remote.handle(req)
+		}
+		return local.handle(req)
+	})
+	stn, err := NewStation(1, 2, 0x03FF, 0)
+	if err != nil {
+		t.Fatalf("unexpected station err: %v", err)
+	}
+	client, err := New3EClient(host, port, NewLocalStation(), true)
+	if err != nil {
+		t.Fatalf("unexpected connect err: %v", err)
+	}
+	defer client.ShutDown()
+
+	other := WithTargetStation(context.Background(), stn)
+	if _, err := client.WriteContext(other, "D", 100, 1, []byte{0x02, 0x00}); err != nil {
+		t.Fatalf("unexpected write err: %v", err)
+	}
+	// raw methods have no context and access the station of the client
+	if _, err := client.WriteRaw("D", 100, 1, []byte{0x01, 0x00}); err != nil {
+		t.Fatalf("unexpected write err: %v", err)
+	}
+	raw, err := client.ReadRaw("D", 100, 1)
+	if err != nil {
+		t.Fatalf("unexpected read err: %v", err)
+	}
+	if raw[2] != 0x00 || raw[11] != 0x01 {
+		t.Fatalf("expected %v but actual is %v", []byte{0x00, 0x01}, []byte{raw[2], raw[11]})
+	}
+	payload, err := client.ReadContext(other, "D", 100, 1)
+	if err != nil {
+		t.Fatalf("unexpected read err: %v", err)
+	}
+	if payload[0] != 0x02 {
+		t.Fatalf("expected %v but actual is %v", 0x02, payload[0])
+	}
+}
@@ -0,0 +1,239 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ReadUint16 reads 1 word device as unsigned 16bit integer.
+func (c *client3E) ReadUint16(deviceName string, offset int64) (uint16, error) {
+	payload, err := c.readWordsHelper(deviceName, offset, 1)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint16(payload), nil
+}
+
+// ReadInt16 reads 1 word device as signed 16bit integer.
+func (c *client3E) ReadInt16(deviceName string, offset int64) (int16, error) {
+	v, err := c.ReadUint16(deviceName, offset)
+	return int16(v), err
+}
+
+// ReadUint32 reads 2 word devices as unsigned 32bit integer.
+// lower word is stored in offset and upper word is stored in offset+1 unless WithWordOrder is HighWordFirst.
+func (c *client3E) ReadUint32(deviceName string, offset int64) (uint32, error) {
+	payload, err := c.readWordsHelper(deviceName, offset, 2)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint32(c.opts.wordOrder.arrange(payload)), nil
+}
+
+// ReadInt32 reads 2 word devices as signed 32bit integer.
+// lower word is stored in offset and upper word is stored in offset+1 unless WithWordOrder is HighWordFirst.
+func (c *client3E) ReadInt32(deviceName string, offset int64) (int32, error) {
+	v, err := c.ReadUint32(deviceName, offset)
+	return int32(v), err
+}
+
+// WriteUint16 writes unsigned 16bit integer to 1 word device.
+func (c *client3E) WriteUint16(deviceName string, offset int64, value uint16) error {
+	writeData := make([]byte, 2)
+	binary.LittleEndian.PutUint16(writeData, value)
+	return c.writeWordsHelper(deviceName, offset, writeData)
+}
+
+// WriteInt16 writes signed 16bit integer to 1 word device.
+func (c *client3E) WriteInt16(deviceName string, offset int64, value int16) error {
+	return c.WriteUint16(deviceName, offset, uint16(value))
+}
+
+// WriteUint32 writes unsigned 32bit integer to 2 word devices.
+// lower word is stored in offset and upper word is stored in offset+1 unless WithWordOrder is HighWordFirst.
+func (c *client3E) WriteUint32(deviceName string, offset int64, value uint32) error {
+	writeData := make([]byte, 4)
+	binary.LittleEndian.PutUint32(writeData, value)
+	return c.writeWordsHelper(deviceName, offset, c.opts.wordOrder.arrange(writeData))
+}
+
+// WriteInt32 writes signed 32bit integer to 2 word devices.
+// lower word is stored in offset and upper word is stored in offset+1 unless WithWordOrder is HighWordFirst.
+func (c *client3E) WriteInt32(deviceName string, offset int64, value int32) error {
+	return c.WriteUint32(deviceName, offset, uint32(value))
+}
+
+// ReadFloat32 reads 2 word devices as IEEE754 single precision float (REAL of GX Works).
+func (c *client3E) ReadFloat32(deviceName string, offset int64) (float32, error) {
+	v, err := c.ReadUint32(deviceName, offset)
+	return math.Float32frombits(v), err
+}
+
+// WriteFloat32 writes IEEE754 single precision float (REAL of GX Works) to 2 word devices.
+func (c *client3E) WriteFloat32(deviceName string, offset int64, value float32) error {
+	return c.WriteUint32(deviceName, offset, math.Float32bits(value))
+}
+
+// ReadFloat64 reads 4 word devices as IEEE754 double precision float (LREAL of GX Works).
+// lowest word is stored in offset and highest word is stored in offset+3 unless WithWordOrder is HighWordFirst.
+func (c *client3E) ReadFloat64(deviceName string, offset int64) (float64, error) {
+	payload, err := c.readWordsHelper(deviceName, offset, 4)
+	if err != nil {
+		return 0, err
+	}
+	return math.Float64frombits(binary.LittleEndian.Uint64(c.opts.wordOrder.arrange(payload))), nil
+}
+
+// WriteFloat64 writes IEEE754 double precision float (LREAL of GX Works) to 4 word devices.
+// lowest word is stored in offset and highest word is stored in offset+3 unless WithWordOrder is HighWordFirst.
+func (c *client3E) WriteFloat64(deviceName string, offset int64, value float64) error {
+	writeData := make([]byte, 8)
+	binary.LittleEndian.PutUint64(writeData, math.Float64bits(value))
+	return c.writeWordsHelper(deviceName, offset, c.opts.wordOrder.arrange(writeData))
+}
+
+// ReadString reads string of length characters stored in word devices.
+// PLC stores 2 characters per 1 word, lower byte first. string is terminated by null character.
+func (c *client3E) ReadString(deviceName string, offset, length int64) (string, error) {
+	payload, err := c.readWordsHelper(deviceName, offset, (length+1)/2)
+	if err != nil {
+		return "", err
+	}
+	return decodeStringHelper(payload, length, c.opts.shiftJIS)
+}
+
+// WriteString writes value to word devices as string of fixed length characters.
+// value shorter than length is padded by null character. value longer than length is error.
+func (c *client3E) WriteString(deviceName string, offset, length int64, value string) error {
+	writeData, err := encodeStringHelper(value, length, c.opts.shiftJIS)
+	if err != nil {
+		return err
+	}
+	return c.writeWordsHelper(deviceName, offset, writeData)
+}
+
+// decodeStringHelper decodes string of length characters in words of data that is terminated by null character.
+// the string is decoded from Shift-JIS when sjis is set.
+func decodeStringHelper(data []byte, length int64, sjis bool) (string, error) {
+	data = data[:length]
+	if i := bytes.IndexByte(data, 0); i >= 0 {
+		data = data[:i]
+	}
+	if sjis {
+		return DecodeSJIS(data)
+	}
+	return string(data), nil
+}
+
+// encodeStringHelper encodes value to words of length characters padded by null character.
+// the string is encoded in Shift-JIS when sjis is set. value longer than length is error.
+func encodeStringHelper(value string, length int64, sjis bool) ([]byte, error) {
+	data := []byte(value)
+	if sjis {
+		encoded, err := EncodeSJIS(value)
+		if err != nil {
+			return nil, err
+		}
+		data = encoded
+	}
+	if int64(len(data)) > length {
+		return nil, errors.New("string is too long: length is " + fmt.Sprint(length) + " but string is " + fmt.Sprint(len(data)) + " byte")
+	}
+
+	writeData := make([]byte, 2*((length+1)/2)) // 2 characters per 1 word
+	copy(writeData, data)
+	return writeData, nil
+}
+
+// ReadBools reads numPoints bit devices as bool values.
+// BitRead returns 2 points per 1 byte (upper 4bit is first point), ReadBools unpacks it to 1 point per 1 bool.
+func (c *client3E) ReadBools(deviceName string, offset, numPoints int64) ([]bool, error) {
+	payload, err := c.BitRead(deviceName, offset, numPoints)
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(payload)) != (numPoints+1)/2 {
+		return nil, errors.New("invalid payload length: expected " + fmt.Sprint((numPoints+1)/2) + " byte but actual is " + fmt.Sprint(len(payload)) + " byte")
+	}
+	return DecodeBits(payload, numPoints), nil
+}
+
+// DecodeBits unpacks bit device payload that has 2 points per 1 byte. upper 4bit is first point.
+// points beyond the payload are false.
+func DecodeBits(payload []byte, numPoints int64) []bool {
+	values := make([]bool, numPoints)
+	for i := range values {
+		if i/2 < len(payload) {
+			values[i] = payload[i/2]&(0x10>>(4*(i%2))) != 0
+		}
+	}
+	return values
+}
+
+// WriteBools writes values to bit devices from offset. numPoints is length of values.
+func (c *client3E) WriteBools(deviceName string, offset int64, values []bool) error {
+	_, err := c.BitWrite(deviceName, offset, int64(len(values)), EncodeBits(values))
+	return err
+}
+
+// EncodeBits packs values to bit device payload that has 2 points per 1 byte. upper 4bit is first point.
+func EncodeBits(values []bool) []byte {
+	payload := make([]byte, (len(values)+1)/2)
+	for i, v := range values {
+		if v {
+			payload[i/2] |= 0x10 >> (4 * (i % 2))
+		}
+	}
+	return payload
+}
+
+// DecodeWords decodes word device payload that has 2 byte per 1 point in little endian.
+// odd last byte is ignored.
+func DecodeWords(payload []byte) []uint16 {
+	words := make([]uint16, len(payload)/2)
+	for i := range words {
+		words[i] = binary.LittleEndian.Uint16(payload[2*i:])
+	}
+	return words
+}
+
+// DecodeDWords decodes word device payload as 2 word values like counters and 32-bit setpoints.
+// each pair of words is combined in order, and rest of payload that is less than 2 words is ignored.
+func DecodeDWords(payload []byte, order WordOrder) []uint32 {
+	dwords := make([]uint32, len(payload)/4)
+	for i := range dwords {
+		dwords[i] = binary.LittleEndian.Uint32(order.arrange(payload[4*i : 4*i+4]))
+	}
+	return dwords
+}
+
+// DecodeInt32s decodes word device payload as signed 2 word values. see DecodeDWords.
+func DecodeInt32s(payload []byte, order WordOrder) []int32 {
+	dwords := DecodeDWords(payload, order)
+	values := make([]int32, len(dwords))
+	for i, v := range dwords {
+		values[i] = int32(v)
+	}
+	return values
+}
+
+// readWordsHelper reads numPoints word devices and checks the payload has 2 byte per 1 device point.
+func (c *client3E) readWordsHelper(deviceName string, offset, numPoints int64) ([]byte, error) {
+	payload, err := c.Read(deviceName, offset, numPoints)
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(payload)) != 2*numPoints {
+		return nil, errors.New("invalid payload length: expected " + fmt.Sprint(2*numPoints) + " byte but actual is " + fmt.Sprint(len(payload)) + " byte")
+	}
+	return payload, nil
+}
+
+// writeWordsHelper writes writeData to word devices. numPoints is computed from length of writeData.
+func (c *client3E) writeWordsHelper(deviceName string, offset int64, writeData []byte) error {
+	_, err := c.Write(deviceName, offset, int64(len(writeData)/2), writeData)
+	return err
+}
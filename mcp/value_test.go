@@ -0,0 +1,190 @@
+package mcp
+
+import (
+	"github.com/google/go-cmp/cmp"
+	"testing"
+)
+
+func TestClient3E_ReadNumeric(t *testing.T) {
+	client, mem := newTestMemoryClient(t)
+	mem.words[[2]int64{0xA8, 100}] = 0xFFFE
+	mem.words[[2]int64{0xA8, 200}] = 0x5678
+	mem.words[[2]int64{0xA8, 201}] = 0x1234
+	mem.words[[2]int64{0xA8, 300}] = 0xFFFF
+	mem.words[[2]int64{0xA8, 301}] = 0xFFFF
+
+	u16, err := client.ReadUint16("D", 100)
+	if err != nil {
+		t.Fatalf("unexpected read err: %v", err)
+	}
+	if u16 != 0xFFFE {
+		t.Fatalf("expected %v but actual is %v", 0xFFFE, u16)
+	}
+
+	i16, err := client.ReadInt16("D", 100)
+	if err != nil {
+		t.Fatalf("unexpected read err: %v", err)
+	}
+	if i16 != -2 {
+		t.Fatalf("expected %v but actual is %v", -2, i16)
+	}
+
+	u32, err := client.ReadUint32("D", 200)
+	if err != nil {
+		t.Fatalf("unexpected read err: %v", err)
+	}
+	if u32 != 0x12345678 {
+		t.Fatalf("expected %X but actual is %X", 0x12345678, u32)
+	}
+
+	i32, err := client.ReadInt32("D", 300)
+	if err != nil {
+		t.Fatalf("unexpected read err: %v", err)
+	}
+	if i32 != -1 {
+		t.Fatalf("expected %v but actual is %v", -1, i32)
+	}
+}
+
+func TestClient3E_WriteNumeric(t *testing.T) {
+	client, mem := newTestMemoryClient(t)
+
+	if err := client.WriteUint16("D", 100, 0xFFFE); err != nil {
+		t.Fatalf("unexpected write err: %v", err)
+	}
+	if err := client.WriteInt16("D", 101, -2); err != nil {
+		t.Fatalf("unexpected write err: %v", err)
+	}
+	if err := client.WriteUint32("D", 200, 0x12345678); err != nil {
+		t.Fatalf("unexpected write err: %v", err)
+	}
+	if err := client.WriteInt32("D", 300, -1); err != nil {
+		t.Fatalf("unexpected write err: %v", err)
+	}
+
+	mem.mu.Lock()
+	defer mem.mu.Unlock()
+	expected := map[int64]uint16{100: 0xFFFE, 101: 0xFFFE, 200: 0x5678, 201: 0x1234, 202: 0x0000, 300: 0xFFFF, 301: 0xFFFF}
+	for offset, word := range expected {
+		if actual := mem.words[[2]int64{0xA8, offset}]; actual != word {
+			t.Errorf("D%v: expected %X but actual is %X", offset, word, actual)
+		}
+	}
+}
+
+func TestClient3E_Float(t *testing.T) {
+	client, mem := newTestMemoryClient(t)
+
+	if err := client.WriteFloat32("D", 100, 1.5); err != nil {
+		t.Fatalf("unexpected write err: %v", err)
+	}
+	if err := client.WriteFloat64("D", 200, -2.25); err != nil {
+		t.Fatalf("unexpected write err: %v", err)
+	}
+
+	mem.mu.Lock()
+	// 1.5 is 0x3FC00000 and -2.25 is 0xC002000000000000
+	expected := map[int64]uint16{100: 0x0000, 101: 0x3FC0, 200: 0x0000, 201: 0x0000, 202: 0x0000, 203: 0xC002}
+	for offset, word := range expected {
+		if actual := mem.words[[2]int64{0xA8, offset}]; actual != word {
+			t.Errorf("D%v: expected %X but actual is %X", offset, word, actual)
+		}
+	}
+	mem.mu.Unlock()
+
+	f32, err := client.ReadFloat32("D", 100)
+	if err != nil {
+		t.Fatalf("unexpected read err: %v", err)
+	}
+	if f32 != 1.5 {
+		t.Fatalf("expected %v but actual is %v", 1.5, f32)
+	}
+
+	f64, err := client.ReadFloat64("D", 200)
+	if err != nil {
+		t.Fatalf("unexpected read err: %v", err)
+	}
+	if f64 != -2.25 {
+		t.Fatalf("expected %v but actual is %v", -2.25, f64)
+	}
+}
+
+func TestClient3E_String(t *testing.T) {
+	client, mem := newTestMemoryClient(t)
+
+	if err := client.WriteString("D", 100, 8, "ABCDE"); err != nil {
+		t.Fatalf("unexpected write err: %v", err)
+	}
+
+	mem.mu.Lock()
+	expected := map[int64]uint16{100: 0x4241, 101: 0x4443, 102: 0x0045, 103: 0x0000}
+	for offset, word := range expected {
+		if actual := mem.words[[2]int64{0xA8, offset}]; actual != word {
+			t.Errorf("D%v: expected %X but actual is %X", offset, word, actual)
+		}
+	}
+	mem.mu.Unlock()
+
+	s, err := client.ReadString("D", 100, 8)
+	if err != nil {
+		t.Fatalf("unexpected read err: %v", err)
+	}
+	if s != "ABCDE" {
+		t.Fatalf("expected %v but actual is %v", "ABCDE", s)
+	}
+
+	// odd length uses lower byte of last word
+	s2, err := client.ReadString("D", 100, 3)
+	if err != nil {
+		t.Fatalf("unexpected read err: %v", err)
+	}
+	if s2 != "ABC" {
+		t.Fatalf("expected %v but actual is %v", "ABC", s2)
+	}
+
+	if err := client.WriteString("D", 100, 4, "ABCDE"); err == nil {
+		t.Fatalf("expected error for too long string")
+	}
+}
+
+func TestClient3E_ReadBools(t *testing.T) {
+	client, mem := newTestMemoryClient(t)
+	mem.bits[[2]int64{0x90, 100}] = true
+	mem.bits[[2]int64{0x90, 103}] = true
+	mem.bits[[2]int64{0x90, 104}] = true
+
+	values, err := client.ReadBools("M", 100, 5)
+	if err != nil {
+		t.Fatalf("unexpected read err: %v", err)
+	}
+
+	expected := []bool{true, false, false, true, true}
+	if diff := cmp.Diff(values, expected); diff != "" {
+		t.Errorf("bools differs: (-got +want)\n%s", diff)
+	}
+}
+
+func TestClient3E_WriteBools(t *testing.T) {
+	client, mem := newTestMemoryClient(t)
+
+	values := []bool{true, false, false, true, true}
+	if err := client.WriteBools("M", 100, values); err != nil {
+		t.Fatalf("unexpected write err: %v", err)
+	}
+
+	mem.mu.Lock()
+	for i, v := range values {
+		if actual := mem.bits[[2]int64{0x90, 100 + int64(i)}]; actual != v {
+			t.Errorf("M%v: expected %v but actual is %v", 100+i, v, actual)
+		}
+	}
+	mem.mu.Unlock()
+
+	actual, err := client.ReadBools("M", 100, 5)
+	if err != nil {
+		t.Fatalf("unexpected read err: %v", err)
+	}
+	if diff := cmp.Diff(actual, values); diff != "" {
+		t.Errorf("bools differs: (-got +want)\n%s", diff)
+	}
+}
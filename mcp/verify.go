@@ -0,0 +1,72 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// ErrVerifyFailed is error of write whose devices read back differ from written data. see VerifyError.
+var ErrVerifyFailed = errors.New("written data is not read back")
+
+// VerifyError is returned by writes of WithVerifyWrites when devices read back differ from written data.
+type VerifyError struct {
+	// device addresses like D100 whose value differs
+	Addresses []string
+}
+
+func (e *VerifyError) Error() string {
+	return ErrVerifyFailed.Error() + ": " + strings.Join(e.Addresses, ", ")
+}
+
+func (e *VerifyError) Unwrap() error {
+	return ErrVerifyFailed
+}
+
+// WithVerifyWrites reads devices back after each write and returns *VerifyError when they differ from written data,
+// for safety-critical setpoint changes. it costs one more request per write, and the read back is never cached.
+// it is ignored with WithDryRun because writes are not sent.
+func WithVerifyWrites() Option {
+	return func(o *options) {
+		o.verifyWrites = true
+	}
+}
+
+// verifyMiddleware reads devices of write requests back after the write. it wraps terminal handler.
+func (c *client3E) verifyMiddleware(next Handler) Handler {
+	return func(ctx context.Context, req *Request) ([]byte, error) {
+		if req.Op != OpWrite && req.Op != OpBitWrite {
+			return next(ctx, req)
+		}
+		resp, err := next(ctx, req)
+		if _, perr := payloadHelper(resp, err); perr != nil {
+			return resp, err
+		}
+
+		bit := req.Op == OpBitWrite
+		got, err := c.readBackHelper(ctx, req, bit)
+		if err != nil {
+			return nil, err
+		}
+		var addresses []string
+		if bit {
+			written, read := DecodeBits(req.WriteData, req.NumPoints), DecodeBits(got, req.NumPoints)
+			for i := range written {
+				if written[i] != read[i] {
+					addresses = append(addresses, FormatDevice(req.DeviceName, req.Offset+int64(i)))
+				}
+			}
+		} else {
+			written, read := DecodeWords(req.WriteData), DecodeWords(got)
+			for i := 0; i < int(req.NumPoints) && i < len(written); i++ {
+				if i >= len(read) || written[i] != read[i] {
+					addresses = append(addresses, FormatDevice(req.DeviceName, req.Offset+int64(i)))
+				}
+			}
+		}
+		if len(addresses) > 0 {
+			return nil, &VerifyError{Addresses: addresses}
+		}
+		return resp, nil
+	}
+}
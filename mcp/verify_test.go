@@ -0,0 +1,47 @@
+package mcp
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWithVerifyWrites(t *testing.T) {
+	mem := newTestMemory()
+	// PLC program keeps D101 and M1 at its own value
+	host, port := newTestServer(t, func(req []byte) []byte {
+		resp := mem.handle(req)
+		mem.mu.Lock()
+		mem.words[[2]int64{0xA8, 101}] = 7
+		mem.bits[[2]int64{0x90, 1}] = false
+		mem.mu.Unlock()
+		return resp
+	})
+	client, err := New3EClient(host, port, NewLocalStation(), true, WithVerifyWrites())
+	if err != nil {
+		t.Fatalf("unexpected connect err: %v", err)
+	}
+	defer client.ShutDown()
+
+	if _, err := client.Write("D", 200, 2, []byte{0x01, 0x00, 0x02, 0x00}); err != nil {
+		t.Fatalf("unexpected mcp write err: %v", err)
+	}
+
+	_, err = client.Write("D", 100, 3, []byte{0x01, 0x00, 0x02, 0x00, 0x07, 0x00})
+	var verifyErr *VerifyError
+	if !errors.As(err, &verifyErr) || !errors.Is(err, ErrVerifyFailed) {
+		t.Fatalf("expected %v but actual is %v", ErrVerifyFailed, err)
+	}
+	if diff := cmp.Diff(verifyErr.Addresses, []string{"D101"}); diff != "" {
+		t.Errorf("addresses differs: (-got +want)\n%s", diff)
+	}
+
+	err = client.WriteBools("M", 0, []bool{true, true, true})
+	if !errors.As(err, &verifyErr) {
+		t.Fatalf("expected %v but actual is %v", ErrVerifyFailed, err)
+	}
+	if diff := cmp.Diff(verifyErr.Addresses, []string{"M1"}); diff != "" {
+		t.Errorf("addresses differs: (-got +want)\n%s", diff)
+	}
+}
@@ -0,0 +1,37 @@
+package mcp
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// rmwKey is context key of requests of read-modify-write that holds c.rmw.
+type rmwKey struct{}
+
+// rmwHelper locks c.rmw and returns context of read-modify-write and function to unlock it.
+// other writes of the client wait until it is unlocked.
+func (c *client3E) rmwHelper() (context.Context, func()) {
+	c.rmw.Lock()
+	return context.WithValue(context.Background(), rmwKey{}, true), c.rmw.Unlock
+}
+
+// WriteWordBits writes bits of value selected by mask to 1 word device and keeps other bits, like flags in a packed status word.
+// the word is read from PLC bypassing WithReadCache, modified and written back while other writes of the client wait,
+// but writes from other clients or PLC program between the read and the write are overwritten.
+func (c *client3E) WriteWordBits(deviceName string, offset int64, mask, value uint16) error {
+	ctx, unlock := c.rmwHelper()
+	defer unlock()
+
+	payload, err := c.ReadContext(withoutCache(ctx), deviceName, offset, 1)
+	if err != nil {
+		return err
+	}
+	if len(payload) != 2 {
+		return errors.New("invalid payload length: expected 2 byte but actual is " + fmt.Sprint(len(payload)) + " byte")
+	}
+	word := binary.LittleEndian.Uint16(payload)
+	_, err = c.WriteContext(ctx, deviceName, offset, 1, binary.LittleEndian.AppendUint16(nil, word&^mask|value&mask))
+	return err
+}
@@ -0,0 +1,87 @@
+package mcp
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestClient3E_WriteWordBits(t *testing.T) {
+	client, mem := newTestMemoryClient(t)
+	mem.words[[2]int64{0xA8, 100}] = 0x00F0
+
+	if err := client.WriteWordBits("D", 100, 0x0011, 0x0001); err != nil {
+		t.Fatalf("unexpected mcp write err: %v", err)
+	}
+	if got := mem.words[[2]int64{0xA8, 100}]; got != 0x00E1 {
+		t.Fatalf("expected %04X but actual is %04X", 0x00E1, got)
+	}
+
+	// concurrent writes of different bits are not lost
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(bit uint16) {
+			defer wg.Done()
+			if err := client.WriteWordBits("D", 200, 1<<bit, 0xFFFF); err != nil {
+				t.Errorf("unexpected mcp write err: %v", err)
+			}
+		}(uint16(i))
+	}
+	wg.Wait()
+	if got := mem.words[[2]int64{0xA8, 200}]; got != 0x00FF {
+		t.Fatalf("expected %04X but actual is %04X", 0x00FF, got)
+	}
+}
+
+func TestClient3E_WriteWordBitsCache(t *testing.T) {
+	client, mem := newTestMemoryClient(t, WithReadCache(time.Minute))
+
+	// cached word is stale after PLC program sets bits
+	if _, err := client.ReadUint16("D", 0); err != nil {
+		t.Fatalf("unexpected mcp read err: %v", err)
+	}
+	mem.mu.Lock()
+	mem.words[[2]int64{0xA8, 0}] = 0x00F0
+	mem.mu.Unlock()
+
+	if err := client.WriteWordBits("D", 0, 0x0001, 0x0001); err != nil {
+		t.Fatalf("unexpected mcp write err: %v", err)
+	}
+	if got := mem.words[[2]int64{0xA8, 0}]; got != 0x00F1 {
+		t.Fatalf("expected %04X but actual is %04X", 0x00F1, got)
+	}
+}
+
+func TestClient3E_WriteWordBitsRace(t *testing.T) {
+	// plain write is issued between the read and the write back
+	read := make(chan struct{})
+	pause := func(next Handler) Handler {
+		return func(ctx context.Context, req *Request) ([]byte, error) {
+			resp, err := next(ctx, req)
+			if req.Op == OpRead {
+				close(read)
+				time.Sleep(50 * time.Millisecond)
+			}
+			return resp, err
+		}
+	}
+	client, mem := newTestMemoryClient(t, WithMiddleware(pause))
+
+	done := make(chan error, 1)
+	go func() { done <- client.WriteWordBits("D", 0, 0x0001, 0x0001) }()
+	<-read
+	if err := client.WriteUint16("D", 0, 0x0100); err != nil {
+		t.Fatalf("unexpected mcp write err: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected mcp write err: %v", err)
+	}
+	// plain write waits for the write back, so it is not lost
+	mem.mu.Lock()
+	defer mem.mu.Unlock()
+	if got := mem.words[[2]int64{0xA8, 0}]; got != 0x0100 {
+		t.Fatalf("expected %04X but actual is %04X", 0x0100, got)
+	}
+}
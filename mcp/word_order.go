@@ -0,0 +1,27 @@
+package mcp
+
+// WordOrder is order of words of multi-word values.
+type WordOrder int
+
+const (
+	// LowWordFirst stores lower word in lower device number. This is the layout of GX Works.
+	LowWordFirst WordOrder = iota
+
+	// HighWordFirst stores upper word in lower device number.
+	HighWordFirst
+)
+
+// arrange converts little endian bytes of multi-word value to devices layout and vice versa.
+// bytes in each word are always little endian, only the order of words is changed.
+func (w WordOrder) arrange(data []byte) []byte {
+	if w != HighWordFirst {
+		return data
+	}
+
+	arranged := make([]byte, len(data))
+	for i := 0; i+1 < len(data); i += 2 {
+		j := len(data) - 2 - i
+		arranged[j], arranged[j+1] = data[i], data[i+1]
+	}
+	return arranged
+}
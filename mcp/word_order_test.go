@@ -0,0 +1,69 @@
+package mcp
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestWordOrder_Arrange(t *testing.T) {
+	cases := []struct {
+		order    WordOrder
+		input    string
+		expected string
+	}{
+		{order: LowWordFirst, input: "78563412", expected: "78563412"},
+		{order: HighWordFirst, input: "78563412", expected: "34127856"},
+		{order: HighWordFirst, input: "0011223344556677", expected: "6677445522330011"},
+	}
+
+	for _, v := range cases {
+		input, _ := hex.DecodeString(v.input)
+		actual := hex.EncodeToString(v.order.arrange(input))
+		if actual != v.expected {
+			t.Errorf("expected %v but actual is %v", v.expected, actual)
+		}
+	}
+}
+
+func TestClient3E_HighWordFirst(t *testing.T) {
+	client, mem := newTestMemoryClient(t, WithWordOrder(HighWordFirst))
+
+	if err := client.WriteUint32("D", 100, 0x12345678); err != nil {
+		t.Fatalf("unexpected write err: %v", err)
+	}
+
+	mem.mu.Lock()
+	if mem.words[[2]int64{0xA8, 100}] != 0x1234 || mem.words[[2]int64{0xA8, 101}] != 0x5678 {
+		t.Errorf("expected %X %X but actual is %X %X", 0x1234, 0x5678, mem.words[[2]int64{0xA8, 100}], mem.words[[2]int64{0xA8, 101}])
+	}
+	mem.mu.Unlock()
+
+	u32, err := client.ReadUint32("D", 100)
+	if err != nil {
+		t.Fatalf("unexpected read err: %v", err)
+	}
+	if u32 != 0x12345678 {
+		t.Fatalf("expected %X but actual is %X", 0x12345678, u32)
+	}
+
+	var v struct {
+		Value float64 `mcp:"D200"`
+	}
+	v.Value = -2.25
+	if err := client.WriteStruct(v); err != nil {
+		t.Fatalf("unexpected write err: %v", err)
+	}
+	mem.mu.Lock()
+	if mem.words[[2]int64{0xA8, 200}] != 0xC002 {
+		t.Errorf("expected %X but actual is %X", 0xC002, mem.words[[2]int64{0xA8, 200}])
+	}
+	mem.mu.Unlock()
+
+	v.Value = 0
+	if err := client.ReadStruct(&v); err != nil {
+		t.Fatalf("unexpected read err: %v", err)
+	}
+	if v.Value != -2.25 {
+		t.Fatalf("expected %v but actual is %v", -2.25, v.Value)
+	}
+}